@@ -2121,6 +2121,12 @@ func TestGolangBindings(t *testing.T) {
 			}
 		})
 	}
+	runBindingTests(t, gocmd, pkg)
+}
+
+// runBindingTests converts the generated binding package into a Go module using
+// the current source tree and runs its tests.
+func runBindingTests(t *testing.T, gocmd string, pkg string) {
 	// Convert the package to go modules and use the current source for go-ethereum
 	moder := exec.Command(gocmd, "mod", "init", "bindtest")
 	moder.Dir = pkg
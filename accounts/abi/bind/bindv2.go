@@ -0,0 +1,252 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// LibraryPattern returns the link placeholder id the Solidity compiler uses for
+// the library with the given fully qualified name (<source file>:<library>),
+// which is also used to identify contracts in v2 bindings.
+func LibraryPattern(name string) string {
+	return crypto.Keccak256Hash([]byte(name)).String()[2:36]
+}
+
+// BindV2 generates a v2 Go wrapper around a contract ABI. Contrary to the wrappers
+// generated by Bind, v2 wrappers are not tied to a backend: they only contain
+// stateless helpers to pack calldata and to unpack return values, events and
+// custom errors, which can be used with the generic helpers of this package or
+// any other transport, such as batch RPC requests.
+//
+// The ids hold the link pattern of each contract, which is used to identify the
+// contract as a library in the bytecode of others. The libs map every pattern
+// to the type of the library.
+func BindV2(types []string, abis []string, bytecodes []string, ids []string, pkg string, libs map[string]string, aliases map[string]string) (string, error) {
+	var (
+		// contracts is the map of each individual contract requested binding
+		contracts = make(map[string]*tmplContractV2)
+
+		// structs is the map of all redeclared structs shared by passed contracts.
+		structs = make(map[string]*tmplStruct)
+
+		// typeNames tracks the Go types generated for all contracts, to detect
+		// collisions between them.
+		typeNames = make(map[string]string)
+	)
+	declare := func(name, origin string) error {
+		if prev, ok := typeNames[name]; ok {
+			return fmt.Errorf("duplicated type %q generated for %s and %s, use --alias for renaming", name, prev, origin)
+		}
+		typeNames[name] = origin
+		return nil
+	}
+	for i := 0; i < len(types); i++ {
+		// Parse the actual ABI to generate the binding for
+		evmABI, err := abi.JSON(strings.NewReader(abis[i]))
+		if err != nil {
+			return "", err
+		}
+		// Strip any whitespace from the JSON ABI
+		strippedABI := strings.Map(func(r rune) rune {
+			if unicode.IsSpace(r) {
+				return -1
+			}
+			return r
+		}, abis[i])
+
+		contract := &tmplContractV2{
+			Type:     capitalise(types[i]),
+			InputABI: strings.ReplaceAll(strippedABI, "\"", "\\\""),
+			InputBin: strings.TrimPrefix(strings.TrimSpace(bytecodes[i]), "0x"),
+			ID:       ids[i],
+			Methods:  make(map[string]*tmplMethod),
+			Events:   make(map[string]*tmplEvent),
+			Errors:   make(map[string]*tmplError),
+		}
+		if err := declare(contract.Type, "contract "+types[i]); err != nil {
+			return "", err
+		}
+		// identifiers are used to detect collisions between the generated helper
+		// methods, which all live in the namespace of the contract type.
+		identifiers := make(map[string]bool)
+		normalize := func(name, prefix, suffix string) (string, error) {
+			normalized := methodNormalizer[LangGo](alias(aliases, name))
+			// Name shouldn't start with a digit. It will make the generated code invalid.
+			if len(normalized) > 0 && unicode.IsDigit(rune(normalized[0])) {
+				normalized = prefix + normalized
+				normalized = abi.ResolveNameConflict(normalized, func(name string) bool {
+					return identifiers[name+suffix]
+				})
+			}
+			if identifiers[normalized+suffix] {
+				return "", fmt.Errorf("duplicated identifier \"%s\"(normalized \"%s\"), use --alias for renaming", name, normalized)
+			}
+			identifiers[normalized+suffix] = true
+			return normalized, nil
+		}
+		contract.Constructor = evmABI.Constructor
+		contract.Constructor.Inputs = normalizeInputs(evmABI.Constructor.Inputs, structs)
+
+		for _, original := range evmABI.Methods {
+			// Normalize the method for capital cases and non-anonymous inputs/outputs
+			normalized := original
+			if normalized.Name, err = normalize(original.Name, "M", ""); err != nil {
+				return "", err
+			}
+			normalized.Inputs = normalizeInputs(original.Inputs, structs)
+
+			// Multiple outputs are returned in a struct, which needs proper field names
+			named := structured(original.Outputs)
+			normalized.Outputs = make([]abi.Argument, len(original.Outputs))
+			copy(normalized.Outputs, original.Outputs)
+			for j, output := range normalized.Outputs {
+				if named {
+					normalized.Outputs[j].Name = capitalise(output.Name)
+				} else {
+					normalized.Outputs[j].Name = fmt.Sprintf("Arg%d", j)
+				}
+				if hasStruct(output.Type) {
+					bindStructTypeGo(output.Type, structs)
+				}
+			}
+			if len(normalized.Outputs) > 1 {
+				if err := declare(contract.Type+normalized.Name+"Output", "method "+original.Name); err != nil {
+					return "", err
+				}
+			}
+			contract.Methods[original.Name] = &tmplMethod{Original: original, Normalized: normalized}
+		}
+		for _, original := range evmABI.Events {
+			// Skip anonymous events as they don't support explicit filtering
+			if original.Anonymous {
+				continue
+			}
+			normalized := original
+			if normalized.Name, err = normalize(original.Name, "E", "Event"); err != nil {
+				return "", err
+			}
+			if err := declare(contract.Type+normalized.Name, "event "+original.Name); err != nil {
+				return "", err
+			}
+			used := make(map[string]bool)
+			normalized.Inputs = make([]abi.Argument, len(original.Inputs))
+			copy(normalized.Inputs, original.Inputs)
+			for j, input := range normalized.Inputs {
+				if input.Name == "" || isKeyWord(input.Name) {
+					normalized.Inputs[j].Name = fmt.Sprintf("arg%d", j)
+				}
+				// Events are decoded into structs, ensure there is no camel-case-style
+				// name conflict between the fields.
+				for index := 0; ; index++ {
+					if !used[capitalise(normalized.Inputs[j].Name)] {
+						used[capitalise(normalized.Inputs[j].Name)] = true
+						break
+					}
+					normalized.Inputs[j].Name = fmt.Sprintf("%s%d", normalized.Inputs[j].Name, index)
+				}
+				if hasStruct(input.Type) {
+					bindStructTypeGo(input.Type, structs)
+				}
+			}
+			contract.Events[original.Name] = &tmplEvent{Original: original, Normalized: normalized}
+		}
+		for _, original := range evmABI.Errors {
+			normalized := original
+			if normalized.Name, err = normalize(original.Name, "E", "Error"); err != nil {
+				return "", err
+			}
+			if err := declare(contract.Type+normalized.Name, "error "+original.Name); err != nil {
+				return "", err
+			}
+			for _, input := range original.Inputs {
+				if hasStruct(input.Type) {
+					bindStructTypeGo(input.Type, structs)
+				}
+			}
+			contract.Errors[original.Name] = &tmplError{Original: original, Normalized: normalized}
+		}
+		contracts[types[i]] = contract
+	}
+	// Resolve the libraries each contract needs to be linked against
+	for _, contract := range contracts {
+		for pattern, name := range libs {
+			if !strings.Contains(contract.InputBin, "__$"+pattern+"$__") {
+				continue
+			}
+			if _, ok := contracts[name]; !ok {
+				return "", fmt.Errorf("contract %s depends on library %s, which is not bound", contract.Type, name)
+			}
+			contract.Deps = append(contract.Deps, capitalise(name))
+		}
+		sort.Strings(contract.Deps)
+	}
+	for name := range structs {
+		if err := declare(structs[name].Name, "struct "+name); err != nil {
+			return "", err
+		}
+	}
+	// Generate the contract template data content and render it
+	data := &tmplDataV2{
+		Package:   pkg,
+		Contracts: contracts,
+		Structs:   structs,
+	}
+	buffer := new(bytes.Buffer)
+
+	funcs := map[string]interface{}{
+		"bindtype":      bindTypeGo,
+		"bindtopictype": bindTopicTypeGo,
+		"capitalise":    capitalise,
+		"decapitalise":  decapitalise,
+	}
+	tmpl := template.Must(template.New("").Funcs(funcs).Parse(tmplSourceGoV2))
+	if err := tmpl.Execute(buffer, data); err != nil {
+		return "", err
+	}
+	// Pass the code through gofmt to clean it up
+	code, err := format.Source(buffer.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("%v\n%s", err, buffer)
+	}
+	return string(code), nil
+}
+
+// normalizeInputs returns a copy of the method inputs with names usable as Go
+// parameters, recording any struct types used.
+func normalizeInputs(inputs abi.Arguments, structs map[string]*tmplStruct) abi.Arguments {
+	normalized := make(abi.Arguments, len(inputs))
+	copy(normalized, inputs)
+	for j, input := range normalized {
+		if input.Name == "" || isKeyWord(input.Name) {
+			normalized[j].Name = fmt.Sprintf("arg%d", j)
+		}
+		if hasStruct(input.Type) {
+			bindStructTypeGo(input.Type, structs)
+		}
+	}
+	return normalized
+}
@@ -0,0 +1,229 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// storeABI is the interface of a hand assembled contract linking against an
+// empty library. It stores a single value set by the constructor or set(), which
+// emits Stored, returns the linked library address from lib(), and fail() always
+// reverts with the Failure custom error.
+const storeABI = `[
+	{"type":"constructor","inputs":[{"name":"initial","type":"uint256"}],"stateMutability":"nonpayable"},
+	{"type":"function","name":"value","inputs":[],"outputs":[{"name":"","type":"uint256"}],"stateMutability":"view"},
+	{"type":"function","name":"lib","inputs":[],"outputs":[{"name":"","type":"address"}],"stateMutability":"view"},
+	{"type":"function","name":"set","inputs":[{"name":"v","type":"uint256"}],"outputs":[],"stateMutability":"nonpayable"},
+	{"type":"function","name":"pair","inputs":[],"outputs":[{"name":"a","type":"uint256"},{"name":"b","type":"address"}],"stateMutability":"view"},
+	{"type":"function","name":"fail","inputs":[{"name":"code","type":"uint256"}],"outputs":[],"stateMutability":"view"},
+	{"type":"event","name":"Stored","inputs":[{"name":"from","type":"address","indexed":true},{"name":"value","type":"uint256","indexed":false}],"anonymous":false},
+	{"type":"error","name":"Failure","inputs":[{"name":"code","type":"uint256"},{"name":"who","type":"address"}]}
+]`
+
+// storeBin is the bytecode of the store contract, with the placeholder of the
+// linked library replaced by its pattern in bindV2Tests.
+const storeBin = "60206020380360003960005160005560e3601b60003960e36000f360003560e01c80633fa4f24514610042578063928012301461004e57806360fe47b11461006c578063a8aa1b311461009f578063132e4f3c146100af5760006000fd5b60005460005260206000f35b73__$PLACEHOLDER$__60005260206000f35b60043580600055600052337febfcf7c0a1b09f6499e519a8d8bb85ce33cd539ec6cbd964e116cd74943ead1a60206000a2005b6000546000523360205260406000f35b7f73f6e924000000000000000000000000000000000000000000000000000000006000526004356004523360245260446000fd"
+
+// libBin is the bytecode of an empty library.
+const libBin = "6001600c60003960016000f300"
+
+var bindV2Tests = []struct {
+	name     string
+	types    []string
+	abi      []string
+	bytecode []string
+	imports  string
+	tester   string
+}{
+	{
+		name:     "Store",
+		types:    []string{"Store", "Lib"},
+		abi:      []string{storeABI, `[]`},
+		bytecode: []string{strings.ReplaceAll(storeBin, "PLACEHOLDER", LibraryPattern("Lib")), libBin},
+		imports: `
+			"errors"
+			"math/big"
+
+			"github.com/ethereum/go-ethereum/accounts/abi/bind"
+			"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+			"github.com/ethereum/go-ethereum/core/types"
+			"github.com/ethereum/go-ethereum/crypto"
+		`,
+		tester: `
+			key, _ := crypto.GenerateKey()
+			auth, _ := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
+
+			sim := backends.NewSimulatedBackend(types.GenesisAlloc{auth.From: {Balance: big.NewInt(10000000000000000)}}, 10000000)
+			defer sim.Close()
+
+			// Deploy the contract, which needs the library deployed and linked first
+			store := NewStore()
+			res, err := bind.LinkAndDeploy(&bind.DeploymentParams{
+				Contracts: []*bind.MetaData{StoreMetaData},
+				Inputs:    map[string][]byte{StoreMetaData.ID: store.PackConstructor(big.NewInt(7))},
+			}, bind.DefaultDeployer(auth, sim))
+			if err != nil {
+				t.Fatalf("Failed to deploy contracts: %v", err)
+			}
+			if len(res.Txs) != 2 {
+				t.Fatalf("Deployment count mismatch: have %d, want 2", len(res.Txs))
+			}
+			sim.Commit()
+
+			instance := store.Instance(sim, res.Addresses[StoreMetaData.ID])
+			opts := &bind.CallOpts{From: auth.From}
+
+			if value, err := bind.Call(instance, opts, store.PackValue(), store.UnpackValue); err != nil || value.Cmp(big.NewInt(7)) != 0 {
+				t.Fatalf("Value mismatch: have %v (%v), want 7", value, err)
+			}
+			if lib, err := bind.Call(instance, opts, store.PackLib(), store.UnpackLib); err != nil || lib != res.Addresses[LibMetaData.ID] {
+				t.Fatalf("Library mismatch: have %v (%v), want %v", lib, err, res.Addresses[LibMetaData.ID])
+			}
+			if pair, err := bind.Call(instance, opts, store.PackPair(), store.UnpackPair); err != nil || pair.A.Cmp(big.NewInt(7)) != 0 || pair.B != auth.From {
+				t.Fatalf("Pair mismatch: have %+v (%v)", pair, err)
+			}
+			// Update the value and check the emitted event
+			if _, err := bind.Transact(instance, auth, store.PackSet(big.NewInt(42))); err != nil {
+				t.Fatalf("Failed to set value: %v", err)
+			}
+			sim.Commit()
+
+			it, err := bind.FilterEvents(instance, nil, store.UnpackStoredEvent, []interface{}{auth.From})
+			if err != nil {
+				t.Fatalf("Failed to filter events: %v", err)
+			}
+			defer it.Close()
+
+			if !it.Next() {
+				t.Fatalf("Event missing: %v", it.Error())
+			}
+			if it.Event.From != auth.From || it.Event.Value.Cmp(big.NewInt(42)) != 0 {
+				t.Fatalf("Event mismatch: have %+v", it.Event)
+			}
+			if it.Next() {
+				t.Fatalf("Unexpected event: %+v", it.Event)
+			}
+			// Check that custom errors are decoded into typed errors
+			_, err = instance.CallRaw(opts, store.PackFail(big.NewInt(3)))
+			data, ok := bind.RevertData(err)
+			if !ok {
+				t.Fatalf("Revert data missing: %v", err)
+			}
+			var failure *StoreFailure
+			if !errors.As(store.UnpackError(data), &failure) {
+				t.Fatalf("Failed to unpack custom error: %v", store.UnpackError(data))
+			}
+			if failure.Code.Cmp(big.NewInt(3)) != 0 || failure.Who != auth.From {
+				t.Fatalf("Custom error mismatch: have %v", failure)
+			}
+			if StoreFailureErrorID() != crypto.Keccak256Hash([]byte("Failure(uint256,address)")) {
+				t.Fatalf("Error ID mismatch: have %v", StoreFailureErrorID())
+			}
+			if err := store.UnpackError([]byte{1, 2, 3, 4}); errors.As(err, &failure) {
+				t.Fatalf("Unknown error decoded as custom error")
+			}
+		`,
+	},
+}
+
+// Tests that v2 bindings can be generated, compiled and used end to end.
+func TestGolangBindingsV2(t *testing.T) {
+	t.Parallel()
+	// Skip the test if no Go command can be found
+	gocmd := runtime.GOROOT() + "/bin/go"
+	if !common.FileExist(gocmd) {
+		t.Skip("go sdk not found for testing")
+	}
+	// Create a temporary workspace for the test suite
+	pkg := filepath.Join(t.TempDir(), "bindtest")
+	if err := os.MkdirAll(pkg, 0700); err != nil {
+		t.Fatalf("failed to create package: %v", err)
+	}
+	// Generate the test suite for all the contracts
+	for i, tt := range bindV2Tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids := make([]string, len(tt.types))
+			libs := make(map[string]string)
+			for j, typ := range tt.types {
+				ids[j] = LibraryPattern(typ)
+				libs[ids[j]] = typ
+			}
+			// Generate the binding and create a Go source file in the workspace
+			bind, err := BindV2(tt.types, tt.abi, tt.bytecode, ids, "bindtest", libs, nil)
+			if err != nil {
+				t.Fatalf("test %d: failed to generate binding: %v", i, err)
+			}
+			if err = os.WriteFile(filepath.Join(pkg, strings.ToLower(tt.name)+".go"), []byte(bind), 0600); err != nil {
+				t.Fatalf("test %d: failed to write binding: %v", i, err)
+			}
+			// Generate the test file with the injected test code
+			code := fmt.Sprintf(`
+			package bindtest
+
+			import (
+				"testing"
+				%s
+			)
+
+			func Test%s(t *testing.T) {
+				%s
+			}
+		`, tt.imports, tt.name, tt.tester)
+			if err := os.WriteFile(filepath.Join(pkg, strings.ToLower(tt.name)+"_test.go"), []byte(code), 0600); err != nil {
+				t.Fatalf("test %d: failed to write tests: %v", i, err)
+			}
+		})
+	}
+	runBindingTests(t, gocmd, pkg)
+}
+
+// Tests that colliding identifiers in v2 bindings are reported instead of
+// generating code that doesn't compile.
+func TestBindV2Collisions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		abi  string
+		fail string
+	}{
+		// Events and errors of the same name would both need the same type
+		{`[{"type":"event","name":"Paused","inputs":[]},{"type":"error","name":"Paused","inputs":[]}]`, `duplicated type "TPaused"`},
+		// A method named after the unpacker of an event
+		{`[{"type":"event","name":"Set","inputs":[]},{"type":"function","name":"setEvent","inputs":[],"outputs":[{"name":"","type":"uint256"}]}]`, `duplicated identifier`},
+		// Methods, events and errors with distinct names
+		{`[{"type":"event","name":"Set","inputs":[]},{"type":"function","name":"set","inputs":[],"outputs":[{"name":"","type":"uint256"}]},{"type":"error","name":"Unset","inputs":[]}]`, ""},
+		// Methods whose names normalize to the same identifier
+		{`[{"type":"function","name":"_get","inputs":[],"outputs":[]},{"type":"function","name":"get","inputs":[],"outputs":[]}]`, `duplicated identifier`},
+	}
+	for i, tt := range tests {
+		_, err := BindV2([]string{"T"}, []string{tt.abi}, []string{""}, []string{LibraryPattern("T")}, "bindtest", nil, nil)
+		switch {
+		case tt.fail == "" && err != nil:
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		case tt.fail != "" && (err == nil || !strings.Contains(err.Error(), tt.fail)):
+			t.Errorf("test %d: error mismatch: have %v, want %q", i, err, tt.fail)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// This file contains the runtime helpers used by v2 bindings. Contrary to the
+// original bindings, v2 bindings are stateless: they only pack calldata and
+// unpack return values, logs and errors, leaving it to these helpers (or any
+// other transport, such as batch RPC requests and multicall contracts) to talk
+// to the chain.
+
+// ContractEvent is implemented by the event types generated by v2 bindings.
+type ContractEvent interface {
+	// ContractEventName returns the name of the event in the contract ABI.
+	ContractEventName() string
+}
+
+// Call executes a call against the contract with the given calldata, decoding
+// the output with the unpack method generated by the binding.
+func Call[T any](c *BoundContract, opts *CallOpts, calldata []byte, unpack func([]byte) (T, error)) (T, error) {
+	output, err := c.CallRaw(opts, calldata)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return unpack(output)
+}
+
+// Transact initiates a transaction against the contract with the given calldata.
+func Transact(c *BoundContract, opts *TransactOpts, calldata []byte) (*types.Transaction, error) {
+	return c.RawTransact(opts, calldata)
+}
+
+// RevertData extracts the data returned by a reverted execution from the error
+// reported by the backend, to be decoded by the error unpackers of a binding.
+func RevertData(err error) ([]byte, bool) {
+	var derr rpc.DataError
+	if !errors.As(err, &derr) {
+		return nil, false
+	}
+	switch data := derr.ErrorData().(type) {
+	case string:
+		blob, err := hexutil.Decode(data)
+		if err != nil {
+			return nil, false
+		}
+		return blob, true
+	case []byte:
+		return data, true
+	default:
+		return nil, false
+	}
+}
+
+// FilterEvents filters the past logs of the contract for events of type T,
+// matching the given topic criteria of the indexed event arguments.
+func FilterEvents[T ContractEvent](c *BoundContract, opts *FilterOpts, unpack func(*types.Log) (*T, error), topics ...[]interface{}) (*EventIterator[T], error) {
+	var ev T
+	logs, sub, err := c.FilterLogs(opts, ev.ContractEventName(), topics...)
+	if err != nil {
+		return nil, err
+	}
+	return &EventIterator[T]{unpack: unpack, logs: logs, sub: sub}, nil
+}
+
+// WatchEvents subscribes to future logs of the contract for events of type T,
+// matching the given topic criteria of the indexed event arguments, and delivers
+// the decoded events into the sink.
+func WatchEvents[T ContractEvent](c *BoundContract, opts *WatchOpts, unpack func(*types.Log) (*T, error), sink chan<- *T, topics ...[]interface{}) (event.Subscription, error) {
+	var ev T
+	logs, sub, err := c.WatchLogs(opts, ev.ContractEventName(), topics...)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				ev, err := unpack(&log)
+				if err != nil {
+					return err
+				}
+				select {
+				case sink <- ev:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// EventIterator is returned from FilterEvents and is used to iterate over the
+// decoded events of the filtered logs.
+type EventIterator[T any] struct {
+	Event *T // Event containing the contract specifics and raw log
+
+	unpack func(*types.Log) (*T, error) // Unpack method of the binding to decode logs with
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *EventIterator[T]) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			return it.decode(&log)
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		return it.decode(&log)
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// decode unpacks the log into the current event of the iterator.
+func (it *EventIterator[T]) decode(log *types.Log) bool {
+	ev, err := it.unpack(log)
+	if err != nil {
+		it.fail = err
+		return false
+	}
+	it.Event = ev
+	return true
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *EventIterator[T]) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *EventIterator[T]) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// DeploymentParams contains the contracts to deploy with LinkAndDeploy.
+type DeploymentParams struct {
+	Contracts []*MetaData               // Contracts to deploy, their libraries are deployed as needed
+	Inputs    map[string][]byte         // Packed constructor inputs, keyed by contract ID (optional)
+	Overrides map[string]common.Address // Already deployed libraries to link against, keyed by ID (optional)
+}
+
+// DeploymentResult contains the outcome of LinkAndDeploy.
+type DeploymentResult struct {
+	Txs       map[string]*types.Transaction // Deployment transactions, keyed by contract ID
+	Addresses map[string]common.Address     // Addresses of the contracts and libraries, keyed by ID
+}
+
+// DeployFn deploys the given linked bytecode with the packed constructor input,
+// returning the address of the contract and the deployment transaction.
+type DeployFn func(input, bytecode []byte) (common.Address, *types.Transaction, error)
+
+// DefaultDeployer returns a DeployFn that sends the deployments with the given
+// transaction options. If the options specify a nonce, it is incremented after
+// every deployment.
+func DefaultDeployer(opts *TransactOpts, backend ContractBackend) DeployFn {
+	// Copy the options so the nonce can be tracked without modifying the caller's one
+	cpy := *opts
+	opts = &cpy
+
+	return func(input, bytecode []byte) (common.Address, *types.Transaction, error) {
+		code := make([]byte, 0, len(bytecode)+len(input))
+		code = append(append(code, bytecode...), input...)
+
+		addr, tx, _, err := DeployContract(opts, abi.ABI{}, code, backend)
+		if err != nil {
+			return common.Address{}, nil, err
+		}
+		if opts.Nonce != nil {
+			opts.Nonce = new(big.Int).SetUint64(tx.Nonce() + 1)
+		}
+		return addr, tx, nil
+	}
+}
+
+// LinkAndDeploy deploys the given contracts, along with all the libraries they
+// depend on, linking the library addresses into the bytecode of their users.
+// Every library is deployed once and only if it isn't overridden. On failure,
+// the partial result of the already sent deployments is returned as well.
+func LinkAndDeploy(params *DeploymentParams, deploy DeployFn) (*DeploymentResult, error) {
+	res := &DeploymentResult{
+		Txs:       make(map[string]*types.Transaction),
+		Addresses: make(map[string]common.Address),
+	}
+	for id, addr := range params.Overrides {
+		res.Addresses[id] = addr
+	}
+	for _, contract := range params.Contracts {
+		if _, err := res.link(contract, params.Inputs, deploy, nil); err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// link deploys a single contract after recursively deploying its dependencies,
+// returning its address. The path tracks the contracts being linked to detect
+// dependency cycles.
+func (res *DeploymentResult) link(contract *MetaData, inputs map[string][]byte, deploy DeployFn, path []string) (common.Address, error) {
+	if contract.ID == "" {
+		return common.Address{}, errors.New("contract metadata has no ID")
+	}
+	if addr, ok := res.Addresses[contract.ID]; ok {
+		return addr, nil
+	}
+	for _, id := range path {
+		if id == contract.ID {
+			return common.Address{}, fmt.Errorf("dependency cycle through %s", contract.ID)
+		}
+	}
+	path = append(path, contract.ID)
+
+	code := strings.TrimPrefix(contract.Bin, "0x")
+	for _, dep := range contract.Deps {
+		addr, err := res.link(dep, inputs, deploy, path)
+		if err != nil {
+			return common.Address{}, err
+		}
+		code = strings.ReplaceAll(code, "__$"+dep.ID+"$__", hex.EncodeToString(addr[:]))
+	}
+	if idx := strings.Index(code, "__$"); idx >= 0 {
+		return common.Address{}, fmt.Errorf("contract %s has unresolved library reference at offset %d", contract.ID, idx/2)
+	}
+	bytecode, err := hex.DecodeString(code)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("invalid bytecode of contract %s: %v", contract.ID, err)
+	}
+	addr, tx, err := deploy(inputs[contract.ID], bytecode)
+	if err != nil {
+		return common.Address{}, err
+	}
+	res.Addresses[contract.ID] = addr
+	res.Txs[contract.ID] = tx
+	return addr, nil
+}
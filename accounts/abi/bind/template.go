@@ -46,6 +46,27 @@ type tmplContract struct {
 	Library     bool                   // Indicator whether the contract is a library
 }
 
+// tmplDataV2 is the data structure required to fill the v2 binding template.
+type tmplDataV2 struct {
+	Package   string                     // Name of the package to place the generated file in
+	Contracts map[string]*tmplContractV2 // List of contracts to generate into this file
+	Structs   map[string]*tmplStruct     // Contract struct type definitions
+}
+
+// tmplContractV2 contains the data needed to generate an individual v2 contract
+// binding.
+type tmplContractV2 struct {
+	Type        string                 // Type name of the main contract binding
+	InputABI    string                 // JSON ABI used as the input to generate the binding from
+	InputBin    string                 // Optional EVM bytecode used to generate deploy code from
+	ID          string                 // Library link pattern identifying the contract
+	Deps        []string               // Types of the libraries the bytecode links against
+	Constructor abi.Method             // Contract constructor for deploy parametrization
+	Methods     map[string]*tmplMethod // Contract methods, both calls and transactions
+	Events      map[string]*tmplEvent  // Contract events accessors
+	Errors      map[string]*tmplError  // Contract custom errors
+}
+
 // tmplMethod is a wrapper around an abi.Method that contains a few preprocessed
 // and cached data fields.
 type tmplMethod struct {
@@ -61,6 +82,13 @@ type tmplEvent struct {
 	Normalized abi.Event // Normalized version of the parsed fields
 }
 
+// tmplError is a wrapper around an abi.Error that contains a few preprocessed
+// and cached data fields.
+type tmplError struct {
+	Original   abi.Error // Original error as parsed by the abi package
+	Normalized abi.Error // Normalized version of the parsed error (capitalized name)
+}
+
 // tmplField is a wrapper around a struct field with binding language
 // struct type definition and relative filed name.
 type tmplField struct {
@@ -87,3 +115,9 @@ var tmplSource = map[Lang]string{
 //
 //go:embed source.go.tpl
 var tmplSourceGo string
+
+// tmplSourceGoV2 is the Go source template that the generated v2 Go contract
+// binding is based on.
+//
+//go:embed source2.go.tpl
+var tmplSourceGoV2 string
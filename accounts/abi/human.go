@@ -0,0 +1,556 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package abi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// elementaryTypeRegex matches the elementary types of the ABI specification,
+// along with the aliases accepted by Solidity.
+var elementaryTypeRegex = regexp.MustCompile(`^(u?int[0-9]*|bytes[0-9]*|u?fixed([0-9]+x[0-9]+)?|address|bool|string|function|byte)$`)
+
+// humanField is the JSON representation of a single ABI entry, assembled from its
+// human-readable form.
+type humanField struct {
+	Type            string               `json:"type"`
+	Name            string               `json:"name,omitempty"`
+	Inputs          []ArgumentMarshaling `json:"inputs"`
+	Outputs         []ArgumentMarshaling `json:"outputs"`
+	StateMutability string               `json:"stateMutability,omitempty"`
+	Anonymous       bool                 `json:"anonymous,omitempty"`
+}
+
+// humanStruct is a struct definition, whose members are resolved when the struct
+// is first used.
+type humanStruct struct {
+	name     string
+	tokens   []string             // Tokens of the member list, resolved lazily
+	members  []ArgumentMarshaling // Resolved members
+	resolved bool
+	pending  bool // Whether the struct is being resolved, to detect recursion
+}
+
+// humanParser parses a set of human-readable signatures.
+type humanParser struct {
+	structs map[string]*humanStruct
+	tokens  []string // Tokens of the signature being parsed
+}
+
+// ParseHumanReadable parses an ABI given as a list of human-readable signatures,
+// as produced by Solidity's declarations, e.g.
+//
+//	constructor(uint256 supply)
+//	function transfer(address to, uint256 amount) returns (bool)
+//	function balanceOf(address owner) view returns (uint256)
+//	event Transfer(address indexed from, address indexed to, uint256 value)
+//	error Unauthorized(address caller)
+//	struct Point { uint256 x; uint256 y; }
+//	function move((uint256 x, uint256 y) delta, Point[] points)
+//
+// Struct definitions may be referenced by name from any signature. Signatures
+// without a leading keyword are treated as functions. Unnamed tuple components
+// are named argN after their position, like unnamed event and error arguments.
+func ParseHumanReadable(signatures []string) (ABI, error) {
+	p := &humanParser{structs: make(map[string]*humanStruct)}
+
+	// Gather all the struct definitions first, so they can be used in any order
+	var entries []int
+	tokenized := make([][]string, len(signatures))
+	for i, sig := range signatures {
+		tokens, err := tokenizeHuman(sig)
+		if err != nil {
+			return ABI{}, fmt.Errorf("invalid signature %q: %v", sig, err)
+		}
+		if len(tokens) == 0 {
+			continue
+		}
+		if tokens[0] != "struct" {
+			tokenized[i] = tokens
+			entries = append(entries, i)
+			continue
+		}
+		if err := p.parseStruct(tokens); err != nil {
+			return ABI{}, fmt.Errorf("invalid signature %q: %v", sig, err)
+		}
+	}
+	// Resolve all structs upfront, so invalid definitions are reported even if
+	// they are unused
+	for _, name := range sortedKeys(p.structs) {
+		if _, err := p.resolveStruct(p.structs[name]); err != nil {
+			return ABI{}, err
+		}
+	}
+	// Parse all other entries and feed them through the JSON parser, so they are
+	// interpreted the same way as compiler output
+	fields := make([]humanField, 0, len(entries))
+	for _, i := range entries {
+		field, err := p.parseEntry(tokenized[i])
+		if err != nil {
+			return ABI{}, fmt.Errorf("invalid signature %q: %v", signatures[i], err)
+		}
+		fields = append(fields, field)
+	}
+	blob, err := json.Marshal(fields)
+	if err != nil {
+		return ABI{}, err
+	}
+	return JSON(bytes.NewReader(blob))
+}
+
+// tokenizeHuman splits a signature into identifiers and punctuation.
+func tokenizeHuman(sig string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(sig); {
+		c := sig[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.IndexByte("()[]{},;", c) >= 0:
+			tokens = append(tokens, string(c))
+			i++
+		case isAlpha(c) || isDigit(c) || isIdentifierSymbol(c):
+			start := i
+			for i < len(sig) && (isAlpha(sig[i]) || isDigit(sig[i]) || isIdentifierSymbol(sig[i]) || sig[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, sig[start:i])
+		default:
+			return nil, fmt.Errorf("unexpected character %q", c)
+		}
+	}
+	return tokens, nil
+}
+
+// peek returns the next token, or an empty string at the end of the input.
+func (p *humanParser) peek() string {
+	if len(p.tokens) == 0 {
+		return ""
+	}
+	return p.tokens[0]
+}
+
+// next consumes the next token.
+func (p *humanParser) next() string {
+	tok := p.peek()
+	if len(p.tokens) > 0 {
+		p.tokens = p.tokens[1:]
+	}
+	return tok
+}
+
+// expect consumes the next token, failing if it's not the wanted one.
+func (p *humanParser) expect(want string) error {
+	if have := p.next(); have != want {
+		if have == "" {
+			return fmt.Errorf("expected %q, got end of input", want)
+		}
+		return fmt.Errorf("expected %q, got %q", want, have)
+	}
+	return nil
+}
+
+// parseStruct records a struct definition of the form
+// struct Name { type name; ... }.
+func (p *humanParser) parseStruct(tokens []string) error {
+	if len(tokens) < 4 || tokens[2] != "{" || tokens[len(tokens)-1] != "}" {
+		return errors.New("malformed struct definition")
+	}
+	name := tokens[1]
+	if elementaryTypeRegex.MatchString(name) || name == "tuple" {
+		return fmt.Errorf("invalid struct name %q", name)
+	}
+	if _, ok := p.structs[name]; ok {
+		return fmt.Errorf("duplicate struct %q", name)
+	}
+	p.structs[name] = &humanStruct{name: name, tokens: tokens[3 : len(tokens)-1]}
+	return nil
+}
+
+// resolveStruct parses the members of a struct definition.
+func (p *humanParser) resolveStruct(s *humanStruct) ([]ArgumentMarshaling, error) {
+	if s.resolved {
+		return s.members, nil
+	}
+	if s.pending {
+		return nil, fmt.Errorf("recursive struct %q", s.name)
+	}
+	s.pending = true
+	defer func() { s.pending = false }()
+
+	// Members are parsed with their own token stream, restore the current one after
+	saved := p.tokens
+	defer func() { p.tokens = saved }()
+
+	p.tokens = s.tokens
+	var members []ArgumentMarshaling
+	for len(p.tokens) > 0 {
+		member, err := p.parseParam(false)
+		if err != nil {
+			return nil, fmt.Errorf("struct %s: %v", s.name, err)
+		}
+		if member.Name == "" {
+			return nil, fmt.Errorf("struct %s: unnamed member", s.name)
+		}
+		if err := p.expect(";"); err != nil {
+			return nil, fmt.Errorf("struct %s: %v", s.name, err)
+		}
+		members = append(members, member)
+	}
+	s.members, s.resolved = members, true
+	return members, nil
+}
+
+// parseEntry parses a function, event, error, constructor, fallback or receive
+// signature.
+func (p *humanParser) parseEntry(tokens []string) (humanField, error) {
+	p.tokens = tokens
+
+	field := humanField{Type: "function"}
+	switch p.peek() {
+	case "function", "event", "error":
+		field.Type = p.next()
+	case "constructor", "fallback", "receive":
+		field.Type = p.next()
+		field.StateMutability = "nonpayable"
+	}
+	if field.Type == "function" || field.Type == "event" || field.Type == "error" {
+		name := p.next()
+		if name == "" || !(isAlpha(name[0]) || isIdentifierSymbol(name[0])) || strings.Contains(name, ".") {
+			return humanField{}, fmt.Errorf("invalid name %q", name)
+		}
+		field.Name = name
+	}
+	inputs, err := p.parseParams(field.Type == "event")
+	if err != nil {
+		return humanField{}, err
+	}
+	if (field.Type == "fallback" || field.Type == "receive") && len(inputs) > 0 {
+		return humanField{}, fmt.Errorf("%s cannot have inputs", field.Type)
+	}
+	field.Inputs = inputs
+	if field.Type == "function" {
+		field.StateMutability = "nonpayable"
+		field.Outputs = []ArgumentMarshaling{}
+	}
+	// Parse the trailing modifiers and return values
+	var returns bool
+	for len(p.tokens) > 0 {
+		switch tok := p.next(); tok {
+		case "anonymous":
+			if field.Type != "event" {
+				return humanField{}, fmt.Errorf("unexpected %q", tok)
+			}
+			field.Anonymous = true
+
+		case "external", "public", "virtual", "override":
+			// Visibility and inheritance don't matter for the ABI
+
+		case "view", "pure", "payable", "nonpayable", "constant":
+			if field.Type == "event" || field.Type == "error" {
+				return humanField{}, fmt.Errorf("unexpected %q", tok)
+			}
+			if tok == "constant" {
+				tok = "view"
+			}
+			field.StateMutability = tok
+
+		case "returns":
+			if field.Type != "function" || returns {
+				return humanField{}, fmt.Errorf("unexpected %q", tok)
+			}
+			returns = true
+			if field.Outputs, err = p.parseParams(false); err != nil {
+				return humanField{}, err
+			}
+		default:
+			return humanField{}, fmt.Errorf("unexpected %q", tok)
+		}
+	}
+	if field.Type == "receive" && field.StateMutability != "payable" {
+		return humanField{}, errors.New("receive must be payable")
+	}
+	return field, nil
+}
+
+// parseParams parses a parenthesized, comma separated parameter list.
+func (p *humanParser) parseParams(indexable bool) ([]ArgumentMarshaling, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	params := []ArgumentMarshaling{}
+	if p.peek() == ")" {
+		p.next()
+		return params, nil
+	}
+	for {
+		param, err := p.parseParam(indexable)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, param)
+
+		switch tok := p.next(); tok {
+		case ",":
+			continue
+		case ")":
+			return params, nil
+		case "":
+			return nil, errors.New("expected ')', got end of input")
+		default:
+			return nil, fmt.Errorf("expected ',' or ')', got %q", tok)
+		}
+	}
+}
+
+// parseParam parses a single parameter: its type, modifiers and optional name.
+func (p *humanParser) parseParam(indexable bool) (ArgumentMarshaling, error) {
+	param, err := p.parseType()
+	if err != nil {
+		return ArgumentMarshaling{}, err
+	}
+	for {
+		switch tok := p.peek(); tok {
+		case "indexed":
+			if !indexable {
+				return ArgumentMarshaling{}, errors.New("unexpected \"indexed\"")
+			}
+			param.Indexed = true
+			p.next()
+			continue
+
+		case "memory", "calldata", "storage":
+			p.next()
+			continue
+
+		case "", ",", ")", ";":
+			return param, nil
+
+		default:
+			if !(isAlpha(tok[0]) || isIdentifierSymbol(tok[0])) || strings.Contains(tok, ".") {
+				return ArgumentMarshaling{}, fmt.Errorf("invalid parameter name %q", tok)
+			}
+			param.Name = p.next()
+			return param, nil
+		}
+	}
+}
+
+// parseType parses an elementary, tuple or struct type with optional array
+// suffixes.
+func (p *humanParser) parseType() (ArgumentMarshaling, error) {
+	var arg ArgumentMarshaling
+
+	tok := p.peek()
+	switch {
+	case tok == "(" || tok == "tuple":
+		if tok == "tuple" {
+			p.next()
+		}
+		components, err := p.parseParams(false)
+		if err != nil {
+			return ArgumentMarshaling{}, err
+		}
+		// Tuple components need names to be decoded into struct fields
+		for i := range components {
+			if components[i].Name == "" {
+				components[i].Name = fmt.Sprintf("arg%d", i)
+			}
+		}
+		arg.Type, arg.Components = "tuple", components
+
+	case elementaryTypeRegex.MatchString(tok):
+		p.next()
+		switch tok {
+		case "uint", "int":
+			tok += "256"
+		case "byte":
+			tok = "bytes1"
+		case "address":
+			// Payable addresses are plain addresses in the ABI
+			if p.peek() == "payable" {
+				p.next()
+			}
+		}
+		arg.Type = tok
+
+	case tok == "":
+		return ArgumentMarshaling{}, errors.New("expected type, got end of input")
+
+	default:
+		s, ok := p.structs[tok]
+		if !ok {
+			return ArgumentMarshaling{}, fmt.Errorf("unknown type %q", tok)
+		}
+		p.next()
+		components, err := p.resolveStruct(s)
+		if err != nil {
+			return ArgumentMarshaling{}, err
+		}
+		arg.Type, arg.InternalType, arg.Components = "tuple", "struct "+s.name, components
+	}
+	// Parse any array suffixes
+	for p.peek() == "[" {
+		p.next()
+		suffix := "[]"
+		if tok := p.peek(); tok != "]" {
+			for i := 0; i < len(tok); i++ {
+				if !isDigit(tok[i]) {
+					return ArgumentMarshaling{}, fmt.Errorf("invalid array size %q", tok)
+				}
+			}
+			suffix = "[" + p.next() + "]"
+		}
+		if err := p.expect("]"); err != nil {
+			return ArgumentMarshaling{}, err
+		}
+		arg.Type += suffix
+		if arg.InternalType != "" {
+			arg.InternalType += suffix
+		}
+	}
+	return arg, nil
+}
+
+// HumanReadable formats the ABI as a list of human-readable signatures, which
+// can be parsed back by ParseHumanReadable. Named structs are emitted as struct
+// definitions ahead of the signatures using them.
+func (abi ABI) HumanReadable() []string {
+	var (
+		f    = &humanFormatter{structs: make(map[string]string)}
+		sigs []string
+	)
+	if len(abi.Constructor.Inputs) > 0 || abi.Constructor.IsPayable() {
+		sig := "constructor(" + f.formatArgs(abi.Constructor.Inputs, false) + ")"
+		if abi.Constructor.IsPayable() {
+			sig += " payable"
+		}
+		sigs = append(sigs, sig)
+	}
+	if abi.HasFallback() {
+		sig := "fallback() external"
+		if abi.Fallback.IsPayable() {
+			sig += " payable"
+		}
+		sigs = append(sigs, sig)
+	}
+	if abi.HasReceive() {
+		sigs = append(sigs, "receive() external payable")
+	}
+	for _, name := range sortedKeys(abi.Methods) {
+		method := abi.Methods[name]
+
+		sig := "function " + method.RawName + "(" + f.formatArgs(method.Inputs, false) + ")"
+		switch {
+		case method.StateMutability != "" && method.StateMutability != "nonpayable":
+			sig += " " + method.StateMutability
+		case method.StateMutability == "" && method.Constant:
+			sig += " view"
+		case method.StateMutability == "" && method.Payable:
+			sig += " payable"
+		}
+		if len(method.Outputs) > 0 {
+			sig += " returns (" + f.formatArgs(method.Outputs, false) + ")"
+		}
+		sigs = append(sigs, sig)
+	}
+	for _, name := range sortedKeys(abi.Events) {
+		event := abi.Events[name]
+
+		sig := "event " + event.RawName + "(" + f.formatArgs(event.Inputs, true) + ")"
+		if event.Anonymous {
+			sig += " anonymous"
+		}
+		sigs = append(sigs, sig)
+	}
+	for _, name := range sortedKeys(abi.Errors) {
+		sigs = append(sigs, "error "+name+"("+f.formatArgs(abi.Errors[name].Inputs, true)+")")
+	}
+	// Prepend the struct definitions used by the signatures
+	structs := make([]string, 0, len(f.structs))
+	for _, name := range sortedKeys(f.structs) {
+		structs = append(structs, f.structs[name])
+	}
+	return append(structs, sigs...)
+}
+
+// humanFormatter formats arguments into their human-readable form, collecting
+// the named structs encountered.
+type humanFormatter struct {
+	structs map[string]string // Struct definitions, keyed by name
+}
+
+// formatArgs formats a comma separated argument list. Events and errors name
+// their unnamed arguments argN when parsed, which is omitted again if autonamed
+// is set.
+func (f *humanFormatter) formatArgs(args Arguments, autonamed bool) string {
+	formatted := make([]string, len(args))
+	for i, arg := range args {
+		formatted[i] = f.formatType(arg.Type)
+		if arg.Indexed {
+			formatted[i] += " indexed"
+		}
+		if arg.Name != "" && !(autonamed && arg.Name == fmt.Sprintf("arg%d", i)) {
+			formatted[i] += " " + arg.Name
+		}
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// formatType formats a type, recording the definitions of named structs. The
+// components of anonymous tuples named argN when parsed are left unnamed.
+func (f *humanFormatter) formatType(typ Type) string {
+	switch typ.T {
+	case SliceTy:
+		return f.formatType(*typ.Elem) + "[]"
+	case ArrayTy:
+		return fmt.Sprintf("%s[%d]", f.formatType(*typ.Elem), typ.Size)
+	case TupleTy:
+		fields := make([]string, len(typ.TupleElems))
+		for i, elem := range typ.TupleElems {
+			fields[i] = f.formatType(*elem)
+			if typ.TupleRawName != "" || typ.TupleRawNames[i] != fmt.Sprintf("arg%d", i) {
+				fields[i] += " " + typ.TupleRawNames[i]
+			}
+		}
+		if typ.TupleRawName == "" {
+			return "(" + strings.Join(fields, ", ") + ")"
+		}
+		if _, ok := f.structs[typ.TupleRawName]; !ok {
+			f.structs[typ.TupleRawName] = "struct " + typ.TupleRawName + " { " + strings.Join(fields, "; ") + "; }"
+		}
+		return typ.TupleRawName
+	default:
+		return typ.String()
+	}
+}
+
+// sortedKeys returns the keys of a map in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,157 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package abi
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Tests that human-readable signatures are parsed into the same ABI as their
+// JSON equivalent.
+func TestParseHumanReadable(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		human []string
+		json  string
+	}{
+		{
+			human: []string{"function transfer(address to, uint256 amount) returns (bool)"},
+			json:  `[{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable"}]`,
+		},
+		{
+			human: []string{"function balanceOf(address) external view returns (uint)", "totalSupply() constant returns (uint256 supply)"},
+			json:  `[{"type":"function","name":"balanceOf","inputs":[{"name":"","type":"address"}],"outputs":[{"name":"","type":"uint256"}],"stateMutability":"view"},{"type":"function","name":"totalSupply","inputs":[],"outputs":[{"name":"supply","type":"uint256"}],"stateMutability":"view"}]`,
+		},
+		{
+			human: []string{"event Transfer(address indexed from, address indexed to, uint256 value)", "event Log(string) anonymous"},
+			json:  `[{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"value","type":"uint256"}]},{"type":"event","name":"Log","inputs":[{"name":"","type":"string"}],"anonymous":true}]`,
+		},
+		{
+			human: []string{"error Unauthorized(address)", "error InsufficientBalance(uint256 available, uint256 required)"},
+			json:  `[{"type":"error","name":"Unauthorized","inputs":[{"name":"","type":"address"}]},{"type":"error","name":"InsufficientBalance","inputs":[{"name":"available","type":"uint256"},{"name":"required","type":"uint256"}]}]`,
+		},
+		{
+			human: []string{"constructor(address payable owner, bytes32[2] salts) payable", "fallback() external", "receive() external payable"},
+			json:  `[{"type":"constructor","inputs":[{"name":"owner","type":"address"},{"name":"salts","type":"bytes32[2]"}],"stateMutability":"payable"},{"type":"fallback","stateMutability":"nonpayable"},{"type":"receive","stateMutability":"payable"}]`,
+		},
+		{
+			human: []string{"function move(tuple(uint256 x, uint256 y)[] memory deltas, (bool ok, bytes data) calldata result) pure"},
+			json:  `[{"type":"function","name":"move","inputs":[{"name":"deltas","type":"tuple[]","components":[{"name":"x","type":"uint256"},{"name":"y","type":"uint256"}]},{"name":"result","type":"tuple","components":[{"name":"ok","type":"bool"},{"name":"data","type":"bytes"}]}],"outputs":[],"stateMutability":"pure"}]`,
+		},
+		{
+			// Unnamed tuple components are named by their position
+			human: []string{"function f((uint256,address) x, tuple(uint256, address)) returns ((uint256, address))"},
+			json:  `[{"type":"function","name":"f","inputs":[{"name":"x","type":"tuple","components":[{"name":"arg0","type":"uint256"},{"name":"arg1","type":"address"}]},{"name":"","type":"tuple","components":[{"name":"arg0","type":"uint256"},{"name":"arg1","type":"address"}]}],"outputs":[{"name":"","type":"tuple","components":[{"name":"arg0","type":"uint256"},{"name":"arg1","type":"address"}]}],"stateMutability":"nonpayable"}]`,
+		},
+		{
+			human: []string{"event Moved((uint256, int256 dy) delta)"},
+			json:  `[{"type":"event","name":"Moved","inputs":[{"name":"delta","type":"tuple","components":[{"name":"arg0","type":"uint256"},{"name":"dy","type":"int256"}]}]}]`,
+		},
+		{
+			// Structs can be used before their definition and nest other structs
+			human: []string{
+				"function draw(Line[] lines) returns (Point end)",
+				"struct Line { Point from; Point to; }",
+				"struct Point { int x; int y; }",
+			},
+			json: `[{"type":"function","name":"draw","inputs":[{"name":"lines","type":"tuple[]","internalType":"struct Line[]","components":[{"name":"from","type":"tuple","internalType":"struct Point","components":[{"name":"x","type":"int256"},{"name":"y","type":"int256"}]},{"name":"to","type":"tuple","internalType":"struct Point","components":[{"name":"x","type":"int256"},{"name":"y","type":"int256"}]}]}],"outputs":[{"name":"end","type":"tuple","internalType":"struct Point","components":[{"name":"x","type":"int256"},{"name":"y","type":"int256"}]}],"stateMutability":"nonpayable"}]`,
+		},
+	}
+	for i, tt := range tests {
+		have, err := ParseHumanReadable(tt.human)
+		if err != nil {
+			t.Errorf("test %d: failed to parse human-readable ABI: %v", i, err)
+			continue
+		}
+		want, err := JSON(strings.NewReader(tt.json))
+		if err != nil {
+			t.Fatalf("test %d: failed to parse JSON ABI: %v", i, err)
+		}
+		if !reflect.DeepEqual(have, want) {
+			t.Errorf("test %d: ABI mismatch:\nhave %+v\nwant %+v", i, have, want)
+		}
+	}
+}
+
+func TestParseHumanReadableErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		human []string
+		err   string
+	}{
+		{[]string{"function transfer(address to, uint256 amount"}, `expected ')', got end of input`},
+		{[]string{"function transfer(address indexed to)"}, `unexpected "indexed"`},
+		{[]string{"event Transfer(address from) view"}, `unexpected "view"`},
+		{[]string{"function f(Missing m)"}, `unknown type "Missing"`},
+		{[]string{"function f(uint256[x] a)"}, `invalid array size "x"`},
+		{[]string{"function f() returns (bool) returns (bool)"}, `unexpected "returns"`},
+		{[]string{"receive() external"}, `receive must be payable`},
+		{[]string{"fallback(uint256 a)"}, `fallback cannot have inputs`},
+		{[]string{"function f(A a)", "struct A { B b; }", "struct B { A a; }"}, `recursive struct`},
+		{[]string{"struct A { uint256; }"}, `unnamed member`},
+		{[]string{"struct A { uint256 a; }", "struct A { uint256 b; }"}, `duplicate struct "A"`},
+		{[]string{"function f(uint256 a) #"}, `unexpected character '#'`},
+	}
+	for i, tt := range tests {
+		_, err := ParseHumanReadable(tt.human)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %q", i, err, tt.err)
+		}
+	}
+}
+
+// Tests that formatting an ABI into its human-readable form and parsing it back
+// results in the same ABI.
+func TestHumanReadableRoundtrip(t *testing.T) {
+	t.Parallel()
+
+	human := []string{
+		"struct Line { Point from; Point to; }",
+		"struct Point { int256 x; int256 y; }",
+		"constructor(address owner) payable",
+		"fallback() external payable",
+		"receive() external payable",
+		"function balanceOf(address owner) view returns (uint256)",
+		"function draw(Line[] lines, (bool ok, bytes data)[2] results) returns (Point end)",
+		"function f((uint256, address) x) returns ((uint256, address))",
+		"function transfer(address to, uint256 amount) returns (bool)",
+		"function transfer(address to, uint256 amount, bytes data) returns (bool)",
+		"event Log(string) anonymous",
+		"event Transfer(address indexed from, address indexed to, uint256 value)",
+		"error InsufficientBalance(uint256 available, uint256 required)",
+		"error Unauthorized(address)",
+	}
+	parsed, err := ParseHumanReadable(human)
+	if err != nil {
+		t.Fatalf("failed to parse human-readable ABI: %v", err)
+	}
+	formatted := parsed.HumanReadable()
+	if !reflect.DeepEqual(formatted, human) {
+		t.Errorf("formatted ABI mismatch:\nhave %q\nwant %q", formatted, human)
+	}
+	reparsed, err := ParseHumanReadable(formatted)
+	if err != nil {
+		t.Fatalf("failed to parse formatted ABI: %v", err)
+	}
+	if !reflect.DeepEqual(reparsed, parsed) {
+		t.Errorf("reparsed ABI mismatch:\nhave %+v\nwant %+v", reparsed, parsed)
+	}
+}
@@ -78,3 +78,11 @@ func TestParseSelector(t *testing.T) {
 		}
 	}
 }
+
+func TestParseSelectorErrors(t *testing.T) {
+	for i, input := range []string{"", "noArgs", "noClose(uint256", "trailing(uint256)x", "(uint256)"} {
+		if _, err := ParseSelector(input); err == nil {
+			t.Errorf("test %d: expected error for selector '%v'", i, input)
+		}
+	}
+}
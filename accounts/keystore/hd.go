@@ -0,0 +1,350 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package keystore
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/google/uuid"
+	"github.com/tyler-smith/go-bip39"
+)
+
+const (
+	// hdDirName is the subdirectory of the key directory holding the seed files
+	// of hierarchical deterministic wallets.
+	hdDirName = "hd"
+
+	hdVersion  = 1
+	hdSeedType = "bip39"
+)
+
+var (
+	errInvalidSeed  = errors.New("invalid master seed")
+	errInvalidChild = errors.New("invalid child key")
+)
+
+// hdWalletJSON is the on-disk representation of a hierarchical deterministic
+// wallet. The mnemonic and seed are encrypted, the accounts pinned to the wallet
+// are kept in the clear so they can be listed without unlocking.
+type hdWalletJSON struct {
+	ID       string          `json:"id"`
+	Version  int             `json:"version"`
+	Type     string          `json:"type"`
+	Crypto   CryptoJSON      `json:"crypto"`
+	Accounts []hdAccountJSON `json:"accounts"`
+}
+
+type hdAccountJSON struct {
+	Address common.Address          `json:"address"`
+	Path    accounts.DerivationPath `json:"path"`
+}
+
+// hdSecretJSON is the plaintext content of the encrypted part of a seed file.
+type hdSecretJSON struct {
+	Mnemonic string        `json:"mnemonic"`
+	Seed     hexutil.Bytes `json:"seed"`
+}
+
+// loadHDWallet reads the seed file at the given path, without decrypting it.
+func loadHDWallet(path string) (*hdWalletJSON, error) {
+	blob, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file := new(hdWalletJSON)
+	if err := json.Unmarshal(blob, file); err != nil {
+		return nil, err
+	}
+	if file.Version != hdVersion {
+		return nil, fmt.Errorf("unsupported seed file version %d", file.Version)
+	}
+	if file.Type != hdSeedType {
+		return nil, fmt.Errorf("unsupported seed type %q", file.Type)
+	}
+	return file, nil
+}
+
+// storeHDWallet atomically writes the seed file to the given path.
+func storeHDWallet(path string, file *hdWalletJSON) error {
+	blob, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+	return writeKeyFile(path, blob)
+}
+
+// decryptHDSecret decrypts the mnemonic and seed of a seed file.
+func decryptHDSecret(file *hdWalletJSON, auth string) (*hdSecretJSON, error) {
+	blob, err := DecryptDataV3(file.Crypto, auth)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(blob)
+
+	secret := new(hdSecretJSON)
+	if err := json.Unmarshal(blob, secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// extendedKey is a BIP-32 extended private key.
+type extendedKey struct {
+	key       []byte // 32 byte private key
+	chainCode []byte // 32 byte chain code
+}
+
+// newMasterKey derives the BIP-32 master key from a seed.
+func newMasterKey(seed []byte) (*extendedKey, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	k := new(big.Int).SetBytes(sum[:32])
+	if k.Sign() == 0 || k.Cmp(crypto.S256().Params().N) >= 0 {
+		return nil, errInvalidSeed
+	}
+	return &extendedKey{key: sum[:32], chainCode: sum[32:]}, nil
+}
+
+// child derives the private child key at the given index, which is hardened if
+// the index is at least 2^31.
+func (k *extendedKey) child(index uint32) (*extendedKey, error) {
+	mac := hmac.New(sha512.New, k.chainCode)
+	if index >= 0x80000000 {
+		mac.Write([]byte{0x00})
+		mac.Write(k.key)
+	} else {
+		priv, err := crypto.ToECDSA(k.key)
+		if err != nil {
+			return nil, err
+		}
+		mac.Write(crypto.CompressPubkey(&priv.PublicKey))
+	}
+	var enc [4]byte
+	binary.BigEndian.PutUint32(enc[:], index)
+	mac.Write(enc[:])
+	sum := mac.Sum(nil)
+
+	n := crypto.S256().Params().N
+	il := new(big.Int).SetBytes(sum[:32])
+	if il.Cmp(n) >= 0 {
+		return nil, errInvalidChild
+	}
+	il.Add(il, new(big.Int).SetBytes(k.key))
+	il.Mod(il, n)
+	if il.Sign() == 0 {
+		return nil, errInvalidChild
+	}
+	return &extendedKey{key: math.PaddedBigBytes(il, 32), chainCode: sum[32:]}, nil
+}
+
+// deriveHDKey derives the private key at the given path from a BIP-39 seed.
+func deriveHDKey(seed []byte, path accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	key, err := newMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	for _, index := range path {
+		child, err := key.child(index)
+		clear(key.key)
+		if err != nil {
+			return nil, err
+		}
+		key = child
+	}
+	defer clear(key.key)
+	return crypto.ToECDSA(key.key)
+}
+
+// deriveHDAddress derives the address at the given path from a BIP-39 seed.
+func deriveHDAddress(seed []byte, path accounts.DerivationPath) (common.Address, error) {
+	key, err := deriveHDKey(seed, path)
+	if err != nil {
+		return common.Address{}, err
+	}
+	defer zeroKey(key)
+	return crypto.PubkeyToAddress(key.PublicKey), nil
+}
+
+// ImportMnemonic stores the seed of a BIP-39 mnemonic into the key directory as
+// a hierarchical deterministic wallet, encrypting it with the passphrase. The
+// optional BIP-39 passphrase extends the mnemonic, it is not stored.
+//
+// The account at the default derivation path m/44'/60'/0'/0/0 is pinned to the
+// new wallet and returned.
+func (ks *KeyStore) ImportMnemonic(mnemonic, bip39Passphrase, passphrase string) (accounts.Account, error) {
+	if err := ks.policy.Check(passphrase); err != nil {
+		return accounts.Account{}, err
+	}
+	mnemonic = strings.Join(strings.Fields(mnemonic), " ")
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, bip39Passphrase)
+	if err != nil {
+		return accounts.Account{}, fmt.Errorf("invalid mnemonic: %v", err)
+	}
+	defer clear(seed)
+
+	path := accounts.DefaultBaseDerivationPath
+	address, err := deriveHDAddress(seed, path)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	ks.importMu.Lock()
+	defer ks.importMu.Unlock()
+
+	ks.refreshWallets()
+	ks.mu.RLock()
+	for _, wallet := range ks.hdWallets {
+		if wallet.Contains(accounts.Account{Address: address}) {
+			ks.mu.RUnlock()
+			return accounts.Account{Address: address}, ErrAccountAlreadyExists
+		}
+	}
+	ks.mu.RUnlock()
+
+	// Encrypt the mnemonic along with the seed and store them with the account
+	secret, err := json.Marshal(&hdSecretJSON{Mnemonic: mnemonic, Seed: seed})
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	defer clear(secret)
+
+	cryptoStruct, err := EncryptDataV3WithKDF(secret, []byte(passphrase), ks.kdfConfig())
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	file := &hdWalletJSON{
+		ID:       id.String(),
+		Version:  hdVersion,
+		Type:     hdSeedType,
+		Crypto:   cryptoStruct,
+		Accounts: []hdAccountJSON{{Address: address, Path: path}},
+	}
+	filename := filepath.Join(ks.storage.JoinPath(hdDirName), keyFileName(address))
+	if err := storeHDWallet(filename, file); err != nil {
+		return accounts.Account{}, err
+	}
+	ks.refreshWallets()
+
+	url := accounts.URL{Scheme: KeyStoreScheme, Path: filename}
+	return hdAccount(url, address, path), nil
+}
+
+// ExportMnemonic decrypts and returns the BIP-39 mnemonic of the hierarchical
+// deterministic wallet containing the given account. Note, a BIP-39 passphrase
+// used on import is not part of the mnemonic.
+func (ks *KeyStore) ExportMnemonic(a accounts.Account, passphrase string) (string, error) {
+	ks.refreshWallets()
+
+	ks.mu.RLock()
+	var wallet *hdWallet
+	for _, w := range ks.hdWallets {
+		if w.Contains(a) {
+			wallet = w
+			break
+		}
+	}
+	ks.mu.RUnlock()
+
+	if wallet == nil {
+		return "", ErrNoMatch
+	}
+	file, err := loadHDWallet(wallet.url.Path)
+	if err != nil {
+		return "", err
+	}
+	secret, err := decryptHDSecret(file, passphrase)
+	if err != nil {
+		return "", err
+	}
+	clear(secret.Seed)
+	return secret.Mnemonic, nil
+}
+
+// refreshHDWallets synchronizes the tracked hierarchical deterministic wallets
+// with the seed files on disk, returning the events to fire. The keystore lock
+// must be held by the caller.
+func (ks *KeyStore) refreshHDWallets() []accounts.WalletEvent {
+	dir := ks.storage.JoinPath(hdDirName)
+	files, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		log.Debug("Failed to list HD wallets", "dir", dir, "err", err)
+		return nil
+	}
+	var (
+		wallets = make([]*hdWallet, 0, len(files))
+		known   = make(map[string]*hdWallet, len(ks.hdWallets))
+		events  []accounts.WalletEvent
+	)
+	for _, wallet := range ks.hdWallets {
+		known[wallet.url.Path] = wallet
+	}
+	// Files are listed sorted by name, so the wallets will be sorted by URL
+	for _, fi := range files {
+		if nonKeyFile(fi) {
+			continue
+		}
+		path := filepath.Join(dir, fi.Name())
+		if wallet, ok := known[path]; ok {
+			wallets = append(wallets, wallet)
+			delete(known, path)
+			continue
+		}
+		file, err := loadHDWallet(path)
+		if err != nil {
+			log.Debug("Failed to load HD wallet", "path", path, "err", err)
+			continue
+		}
+		wallet := newHDWallet(ks, accounts.URL{Scheme: KeyStoreScheme, Path: path}, file)
+		wallets = append(wallets, wallet)
+		events = append(events, accounts.WalletEvent{Wallet: wallet, Kind: accounts.WalletArrived})
+	}
+	for _, wallet := range known {
+		wallet.Close()
+		events = append(events, accounts.WalletEvent{Wallet: wallet, Kind: accounts.WalletDropped})
+	}
+	ks.hdWallets = wallets
+	return events
+}
+
+// kdfConfig returns the key derivation new keys are encrypted with.
+func (ks *KeyStore) kdfConfig() KDFConfig {
+	if store, ok := ks.storage.(*keyStorePassphrase); ok {
+		return store.kdf
+	}
+	return ScryptKDF(StandardScryptN, StandardScryptP)
+}
@@ -0,0 +1,197 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package keystore
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+var (
+	testHDAddr0 = common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94") // m/44'/60'/0'/0/0
+	testHDAddr1 = common.HexToAddress("0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0") // m/44'/60'/0'/0/1
+)
+
+// Tests key derivation against test vector 1 of BIP-32.
+func TestBIP32Derivation(t *testing.T) {
+	t.Parallel()
+
+	master, err := newMasterKey(common.FromHex("000102030405060708090a0b0c0d0e0f"))
+	if err != nil {
+		t.Fatalf("failed to derive master key: %v", err)
+	}
+	if have, want := common.Bytes2Hex(master.key), "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35"; have != want {
+		t.Errorf("master key mismatch: have %s, want %s", have, want)
+	}
+	if have, want := common.Bytes2Hex(master.chainCode), "873dff81c02f525623fd1fe5167eac3a55a049de3d314bb42ee227ffed37d508"; have != want {
+		t.Errorf("master chain code mismatch: have %s, want %s", have, want)
+	}
+	child, err := master.child(0x80000000)
+	if err != nil {
+		t.Fatalf("failed to derive child key: %v", err)
+	}
+	if have, want := common.Bytes2Hex(child.key), "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea"; have != want {
+		t.Errorf("child key mismatch: have %s, want %s", have, want)
+	}
+	if have, want := common.Bytes2Hex(child.chainCode), "47fdacbd0f1097043b78c63c20c34ef4ed9a111d980047ad16282c7ae6236141"; have != want {
+		t.Errorf("child chain code mismatch: have %s, want %s", have, want)
+	}
+}
+
+func TestHDWalletImportExport(t *testing.T) {
+	t.Parallel()
+	dir, ks := tmpKeyStore(t)
+
+	account, err := ks.ImportMnemonic("  "+testMnemonic+"\n", "", "foo")
+	if err != nil {
+		t.Fatalf("failed to import mnemonic: %v", err)
+	}
+	if account.Address != testHDAddr0 {
+		t.Fatalf("imported account mismatch: have %v, want %v", account.Address, testHDAddr0)
+	}
+	if _, err := ks.ImportMnemonic(testMnemonic, "", "bar"); err != ErrAccountAlreadyExists {
+		t.Errorf("duplicate import error mismatch: have %v, want %v", err, ErrAccountAlreadyExists)
+	}
+	if _, err := ks.ImportMnemonic("abandon abandon about", "", "foo"); err == nil {
+		t.Error("imported invalid mnemonic")
+	}
+	if _, err := ks.ExportMnemonic(account, "bar"); err != ErrDecrypt {
+		t.Errorf("export with wrong passphrase error mismatch: have %v, want %v", err, ErrDecrypt)
+	}
+	mnemonic, err := ks.ExportMnemonic(account, "foo")
+	if err != nil {
+		t.Fatalf("failed to export mnemonic: %v", err)
+	}
+	if mnemonic != testMnemonic {
+		t.Errorf("exported mnemonic mismatch: have %q, want %q", mnemonic, testMnemonic)
+	}
+	// The wallet must be listed, and derive accounts only when open
+	wallets := ks.Wallets()
+	if len(wallets) != 1 {
+		t.Fatalf("wallet count mismatch: have %d, want 1", len(wallets))
+	}
+	wallet := wallets[0]
+	if !wallet.Contains(account) {
+		t.Fatalf("wallet doesn't contain imported account")
+	}
+	if _, err := wallet.Derive(accounts.DefaultIterator(accounts.DefaultBaseDerivationPath)(), true); err != accounts.ErrWalletClosed {
+		t.Errorf("derivation on closed wallet error mismatch: have %v, want %v", err, accounts.ErrWalletClosed)
+	}
+	if _, err := wallet.SignText(account, []byte("hello")); err != ErrLocked {
+		t.Errorf("signing on closed wallet error mismatch: have %v, want %v", err, ErrLocked)
+	}
+	if err := wallet.Open("bar"); err != ErrDecrypt {
+		t.Errorf("open with wrong passphrase error mismatch: have %v, want %v", err, ErrDecrypt)
+	}
+	if err := wallet.Open("foo"); err != nil {
+		t.Fatalf("failed to open wallet: %v", err)
+	}
+	path, _ := accounts.ParseDerivationPath("m/44'/60'/0'/0/1")
+	derived, err := wallet.Derive(path, true)
+	if err != nil {
+		t.Fatalf("failed to derive account: %v", err)
+	}
+	if derived.Address != testHDAddr1 {
+		t.Errorf("derived account mismatch: have %v, want %v", derived.Address, testHDAddr1)
+	}
+	if _, err := wallet.SignText(derived, []byte("hello")); err != nil {
+		t.Errorf("failed to sign with open wallet: %v", err)
+	}
+	wallet.Close()
+
+	// Pinned accounts must survive a restart and be usable with a passphrase
+	ks = NewKeyStore(dir, veryLightScryptN, veryLightScryptP)
+	wallets = ks.Wallets()
+	if len(wallets) != 1 {
+		t.Fatalf("reloaded wallet count mismatch: have %d, want 1", len(wallets))
+	}
+	if accs := wallets[0].Accounts(); len(accs) != 2 || accs[0] != account || accs[1] != derived {
+		t.Fatalf("reloaded accounts mismatch: have %v, want %v", accs, []accounts.Account{account, derived})
+	}
+	tx := types.NewTransaction(0, common.Address{}, big.NewInt(1), 21000, big.NewInt(1), nil)
+	signed, err := wallets[0].SignTxWithPassphrase(derived, "foo", tx, big.NewInt(1))
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	if from, _ := types.Sender(types.LatestSignerForChainID(big.NewInt(1)), signed); from != testHDAddr1 {
+		t.Errorf("transaction sender mismatch: have %v, want %v", from, testHDAddr1)
+	}
+}
+
+// testChain is a chain state reader reporting a nonce for a set of accounts.
+type testChain map[common.Address]uint64
+
+func (c testChain) BalanceAt(context.Context, common.Address, *big.Int) (*big.Int, error) {
+	return new(big.Int), nil
+}
+
+func (c testChain) StorageAt(context.Context, common.Address, common.Hash, *big.Int) ([]byte, error) {
+	return nil, nil
+}
+
+func (c testChain) CodeAt(context.Context, common.Address, *big.Int) ([]byte, error) {
+	return nil, nil
+}
+
+func (c testChain) NonceAt(_ context.Context, addr common.Address, _ *big.Int) (uint64, error) {
+	return c[addr], nil
+}
+
+// Tests that account discovery finds accounts used on the default and the legacy
+// Ledger derivation paths, the same way hardware wallets do.
+func TestHDWalletSelfDerive(t *testing.T) {
+	t.Parallel()
+	_, ks := tmpKeyStore(t)
+
+	if _, err := ks.ImportMnemonic(testMnemonic, "", "foo"); err != nil {
+		t.Fatalf("failed to import mnemonic: %v", err)
+	}
+	wallet := ks.Wallets()[0]
+	if err := wallet.Open("foo"); err != nil {
+		t.Fatalf("failed to open wallet: %v", err)
+	}
+	legacy, err := wallet.Derive(accounts.LegacyLedgerBaseDerivationPath, false)
+	if err != nil {
+		t.Fatalf("failed to derive legacy account: %v", err)
+	}
+	chain := testChain{testHDAddr0: 1, testHDAddr1: 3, legacy.Address: 2}
+	wallet.SelfDerive([]accounts.DerivationPath{accounts.LegacyLedgerBaseDerivationPath, accounts.DefaultBaseDerivationPath}, chain)
+
+	// Discovered are both used accounts, the first unused one on the default path
+	// and the legacy account
+	accs := wallet.Accounts()
+	if len(accs) != 4 {
+		t.Fatalf("discovered account count mismatch: have %d, want 4: %v", len(accs), accs)
+	}
+	for _, addr := range []common.Address{testHDAddr0, testHDAddr1, legacy.Address} {
+		if !wallet.Contains(accounts.Account{Address: addr}) {
+			t.Errorf("account %v not discovered", addr)
+		}
+	}
+	// Discovered accounts are dropped on close, pinned ones stay
+	wallet.Close()
+	if accs := wallet.Accounts(); len(accs) != 1 || accs[0].Address != testHDAddr0 {
+		t.Errorf("accounts after close mismatch: have %v", accs)
+	}
+}
@@ -0,0 +1,421 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package keystore
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// Minimum time between account discovery rounds of an HD wallet.
+const selfDeriveThrottling = time.Second
+
+// hdWallet implements the accounts.Wallet interface for a hierarchical
+// deterministic wallet backed by an encrypted BIP-39 seed in the keystore.
+//
+// Opening the wallet decrypts the seed and keeps it in memory until closed, which
+// is needed for deriving new accounts and for signing without a passphrase.
+type hdWallet struct {
+	url      accounts.URL // Location of the seed file within the keystore
+	keystore *KeyStore    // Keystore where the wallet originates from
+
+	seed     []byte                                     // Decrypted seed while the wallet is open
+	accounts []accounts.Account                         // Pinned and discovered accounts
+	paths    map[common.Address]accounts.DerivationPath // Derivation paths of the tracked accounts
+	pinned   []hdAccountJSON                            // Accounts persisted in the seed file
+
+	deriveNextPaths []accounts.DerivationPath // Next derivation paths for account auto-discovery (multiple bases supported)
+	deriveNextAddrs []common.Address          // Next derived account addresses for auto-discovery (multiple bases supported)
+	deriveChain     ethereum.ChainStateReader // Blockchain state reader to discover used account with
+	deriveBusy      bool                      // Whether an account discovery round is running
+	deriveLast      time.Time                 // Time of the last account discovery round
+
+	stateLock sync.RWMutex // Protects read and write access to the wallet struct fields
+}
+
+// newHDWallet creates a wallet around the given seed file, tracking the accounts
+// pinned within.
+func newHDWallet(ks *KeyStore, url accounts.URL, file *hdWalletJSON) *hdWallet {
+	w := &hdWallet{url: url, keystore: ks, pinned: file.Accounts}
+	w.resetAccounts()
+	return w
+}
+
+// hdAccount creates the account at the given derivation path of a wallet.
+func hdAccount(url accounts.URL, address common.Address, path accounts.DerivationPath) accounts.Account {
+	return accounts.Account{
+		Address: address,
+		URL:     accounts.URL{Scheme: url.Scheme, Path: fmt.Sprintf("%s/%s", url.Path, path)},
+	}
+}
+
+// resetAccounts drops any discovered account, only tracking the pinned ones. The
+// state lock must be held by the caller.
+func (w *hdWallet) resetAccounts() {
+	w.accounts = make([]accounts.Account, 0, len(w.pinned))
+	w.paths = make(map[common.Address]accounts.DerivationPath, len(w.pinned))
+	for _, pinned := range w.pinned {
+		if _, ok := w.paths[pinned.Address]; ok {
+			continue
+		}
+		w.accounts = append(w.accounts, hdAccount(w.url, pinned.Address, pinned.Path))
+		w.paths[pinned.Address] = pinned.Path
+	}
+}
+
+// URL implements accounts.Wallet, returning the URL of the seed file.
+func (w *hdWallet) URL() accounts.URL {
+	return w.url
+}
+
+// Status implements accounts.Wallet, returning whether the seed of the wallet is
+// unlocked or not.
+func (w *hdWallet) Status() (string, error) {
+	w.stateLock.RLock()
+	defer w.stateLock.RUnlock()
+
+	if w.seed != nil {
+		return "Unlocked", nil
+	}
+	return "Locked", nil
+}
+
+// Open implements accounts.Wallet, decrypting the seed of the wallet with the
+// given passphrase and keeping it in memory until the wallet is closed.
+func (w *hdWallet) Open(passphrase string) error {
+	w.stateLock.Lock()
+	if w.seed != nil {
+		w.stateLock.Unlock()
+		return accounts.ErrWalletAlreadyOpen
+	}
+	file, err := loadHDWallet(w.url.Path)
+	if err != nil {
+		w.stateLock.Unlock()
+		return err
+	}
+	secret, err := decryptHDSecret(file, passphrase)
+	if err != nil {
+		w.stateLock.Unlock()
+		if err == ErrDecrypt && passphrase == "" {
+			return ErrLocked
+		}
+		return err
+	}
+	w.seed = secret.Seed
+	w.stateLock.Unlock()
+
+	// Notify anyone listening for wallet events that the seed is accessible
+	go w.keystore.updateFeed.Send(accounts.WalletEvent{Wallet: w, Kind: accounts.WalletOpened})
+	return nil
+}
+
+// Close implements accounts.Wallet, dropping the decrypted seed and any account
+// not pinned to the wallet.
+func (w *hdWallet) Close() error {
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+
+	clear(w.seed)
+	w.seed = nil
+	w.deriveChain = nil
+	w.resetAccounts()
+	return nil
+}
+
+// Accounts implements accounts.Wallet, returning the list of accounts pinned to
+// the wallet. If self-derivation was enabled, the account list is periodically
+// expanded based on current chain state.
+func (w *hdWallet) Accounts() []accounts.Account {
+	w.selfDerive()
+
+	w.stateLock.RLock()
+	defer w.stateLock.RUnlock()
+
+	cpy := make([]accounts.Account, len(w.accounts))
+	copy(cpy, w.accounts)
+	return cpy
+}
+
+// selfDerive runs a round of account discovery if the wallet is open, discovery
+// was requested and it's not being throttled, tracking every used account and
+// the first unused one of the last base path.
+func (w *hdWallet) selfDerive() {
+	w.stateLock.Lock()
+	if w.seed == nil || w.deriveChain == nil || w.deriveBusy || time.Since(w.deriveLast) < selfDeriveThrottling {
+		w.stateLock.Unlock()
+		return
+	}
+	w.deriveBusy = true
+
+	var (
+		accs  []accounts.Account
+		paths []accounts.DerivationPath
+
+		seed      = common.CopyBytes(w.seed)
+		chain     = w.deriveChain
+		nextPaths = make([]accounts.DerivationPath, len(w.deriveNextPaths))
+		nextAddrs = append([]common.Address{}, w.deriveNextAddrs...)
+
+		ctx = context.Background()
+		err error
+	)
+	for i, path := range w.deriveNextPaths {
+		nextPaths[i] = append(accounts.DerivationPath{}, path...)
+	}
+	w.stateLock.Unlock()
+	defer clear(seed)
+
+	for i := 0; i < len(nextAddrs) && err == nil; i++ {
+		for empty := false; !empty; {
+			// Retrieve the next derived Ethereum account
+			if nextAddrs[i] == (common.Address{}) {
+				if nextAddrs[i], err = deriveHDAddress(seed, nextPaths[i]); err != nil {
+					log.Warn("HD wallet account derivation failed", "err", err)
+					break
+				}
+			}
+			// Check the account's status against the current chain state
+			var (
+				balance *big.Int
+				nonce   uint64
+			)
+			balance, err = chain.BalanceAt(ctx, nextAddrs[i], nil)
+			if err != nil {
+				log.Warn("HD wallet balance retrieval failed", "err", err)
+				break
+			}
+			nonce, err = chain.NonceAt(ctx, nextAddrs[i], nil)
+			if err != nil {
+				log.Warn("HD wallet nonce retrieval failed", "err", err)
+				break
+			}
+			// Only the first empty account of the last base path is tracked, as
+			// the others are legacy paths no new accounts should be created on
+			path := append(accounts.DerivationPath{}, nextPaths[i]...)
+			if balance.Sign() == 0 && nonce == 0 {
+				empty = true
+				if i < len(nextAddrs)-1 {
+					break
+				}
+			}
+			paths = append(paths, path)
+			accs = append(accs, hdAccount(w.url, nextAddrs[i], path))
+
+			// Fetch the next potential account
+			if !empty {
+				log.Info("HD wallet discovered account", "address", nextAddrs[i], "path", path, "balance", balance, "nonce", nonce)
+				nextAddrs[i] = common.Address{}
+				nextPaths[i][len(nextPaths[i])-1]++
+			}
+		}
+	}
+	// Insert any accounts successfully derived and shift the discovery forward
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+
+	if w.seed != nil {
+		for i := 0; i < len(accs); i++ {
+			if _, ok := w.paths[accs[i].Address]; !ok {
+				w.accounts = append(w.accounts, accs[i])
+				w.paths[accs[i].Address] = paths[i]
+			}
+		}
+		w.deriveNextAddrs = nextAddrs
+		w.deriveNextPaths = nextPaths
+	}
+	w.deriveBusy = false
+	w.deriveLast = time.Now()
+}
+
+// Contains implements accounts.Wallet, returning whether a particular account is
+// or is not tracked by this wallet instance.
+func (w *hdWallet) Contains(account accounts.Account) bool {
+	w.stateLock.RLock()
+	defer w.stateLock.RUnlock()
+
+	path, ok := w.paths[account.Address]
+	if !ok {
+		return false
+	}
+	return account.URL == (accounts.URL{}) || account.URL == hdAccount(w.url, account.Address, path).URL
+}
+
+// Derive implements accounts.Wallet, deriving a new account at the specific
+// derivation path. If pin is set to true, the account will be added to the list
+// of tracked accounts and persisted in the seed file.
+func (w *hdWallet) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+
+	if w.seed == nil {
+		return accounts.Account{}, accounts.ErrWalletClosed
+	}
+	address, err := deriveHDAddress(w.seed, path)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	account := hdAccount(w.url, address, path)
+	if !pin {
+		return account, nil
+	}
+	for _, pinned := range w.pinned {
+		if pinned.Address == address {
+			return account, nil
+		}
+	}
+	// Persist the account into the seed file, keeping the encrypted part intact
+	file, err := loadHDWallet(w.url.Path)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	file.Accounts = append(file.Accounts, hdAccountJSON{Address: address, Path: append(accounts.DerivationPath{}, path...)})
+	if err := storeHDWallet(w.url.Path, file); err != nil {
+		return accounts.Account{}, err
+	}
+	w.pinned = file.Accounts
+
+	if _, ok := w.paths[address]; !ok {
+		w.accounts = append(w.accounts, account)
+		w.paths[address] = append(accounts.DerivationPath{}, path...)
+	}
+	return account, nil
+}
+
+// SelfDerive sets a base account derivation path from which the wallet attempts
+// to discover non zero accounts and automatically add them to list of tracked
+// accounts.
+//
+// Note, self derivation will increment the last component of the specified path
+// opposed to descending into a child path to allow discovering accounts starting
+// from non zero components.
+//
+// Hardware wallets switched derivation paths through their evolution, so this
+// method supports providing multiple bases to discover accounts created on any
+// of them. Only the last base will be used to derive the next empty account.
+//
+// You can disable automatic account discovery by calling SelfDerive with a nil
+// chain state reader.
+func (w *hdWallet) SelfDerive(bases []accounts.DerivationPath, chain ethereum.ChainStateReader) {
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+
+	w.deriveNextPaths = make([]accounts.DerivationPath, len(bases))
+	for i, base := range bases {
+		w.deriveNextPaths[i] = make(accounts.DerivationPath, len(base))
+		copy(w.deriveNextPaths[i][:], base[:])
+	}
+	w.deriveNextAddrs = make([]common.Address, len(bases))
+	w.deriveChain = chain
+	w.deriveLast = time.Time{}
+}
+
+// signHash derives the key of the given account and signs the hash with it,
+// using the open seed or the one decrypted with the passphrase if given.
+func (w *hdWallet) signHash(account accounts.Account, passphrase *string, hash []byte) ([]byte, error) {
+	key, err := w.deriveKey(account, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key)
+	return crypto.Sign(hash, key)
+}
+
+// deriveKey derives the private key of the given account, using the open seed or
+// the one decrypted with the passphrase if given.
+func (w *hdWallet) deriveKey(account accounts.Account, passphrase *string) (*ecdsa.PrivateKey, error) {
+	w.stateLock.RLock()
+	path, ok := w.paths[account.Address]
+	seed := common.CopyBytes(w.seed)
+	w.stateLock.RUnlock()
+
+	if !ok || !w.Contains(account) {
+		return nil, accounts.ErrUnknownAccount
+	}
+	if passphrase != nil {
+		file, err := loadHDWallet(w.url.Path)
+		if err != nil {
+			return nil, err
+		}
+		secret, err := decryptHDSecret(file, *passphrase)
+		if err != nil {
+			return nil, err
+		}
+		clear(seed)
+		seed = secret.Seed
+	}
+	if seed == nil {
+		return nil, ErrLocked
+	}
+	defer clear(seed)
+	return deriveHDKey(seed, path)
+}
+
+// SignData signs keccak256(data). The mimetype parameter describes the type of data being signed.
+func (w *hdWallet) SignData(account accounts.Account, mimeType string, data []byte) ([]byte, error) {
+	return w.signHash(account, nil, crypto.Keccak256(data))
+}
+
+// SignDataWithPassphrase signs keccak256(data). The mimetype parameter describes the type of data being signed.
+func (w *hdWallet) SignDataWithPassphrase(account accounts.Account, passphrase, mimeType string, data []byte) ([]byte, error) {
+	return w.signHash(account, &passphrase, crypto.Keccak256(data))
+}
+
+// SignText implements accounts.Wallet, attempting to sign the hash of
+// the given text with the given account.
+func (w *hdWallet) SignText(account accounts.Account, text []byte) ([]byte, error) {
+	return w.signHash(account, nil, accounts.TextHash(text))
+}
+
+// SignTextWithPassphrase implements accounts.Wallet, attempting to sign the
+// hash of the given text with the given account using passphrase as extra authentication.
+func (w *hdWallet) SignTextWithPassphrase(account accounts.Account, passphrase string, text []byte) ([]byte, error) {
+	return w.signHash(account, &passphrase, accounts.TextHash(text))
+}
+
+// SignTx implements accounts.Wallet, attempting to sign the given transaction
+// with the given account, deriving its key from the open seed.
+func (w *hdWallet) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return w.signTx(account, nil, tx, chainID)
+}
+
+// SignTxWithPassphrase implements accounts.Wallet, attempting to sign the given
+// transaction with the given account using passphrase as extra authentication.
+func (w *hdWallet) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return w.signTx(account, &passphrase, tx, chainID)
+}
+
+func (w *hdWallet) signTx(account accounts.Account, passphrase *string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	key, err := w.deriveKey(account, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key)
+
+	signer := types.LatestSignerForChainID(chainID)
+	return types.SignTx(tx, signer, key)
+}
@@ -29,6 +29,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"slices"
 	"sync"
 	"time"
 
@@ -61,11 +62,13 @@ const walletRefreshCycle = 3 * time.Second
 // KeyStore manages a key storage directory on disk.
 type KeyStore struct {
 	storage  keyStore                     // Storage backend, might be cleartext or encrypted
+	policy   *PasswordPolicy              // Strength policy of new passwords, nil if any is accepted
 	cache    *accountCache                // In-memory account cache over the filesystem storage
 	changes  chan struct{}                // Channel receiving change notifications from the cache
 	unlocked map[common.Address]*unlocked // Currently unlocked account (decrypted private keys)
 
 	wallets     []accounts.Wallet       // Wallet wrappers around the individual key files
+	hdWallets   []*hdWallet             // Hierarchical deterministic wallets around the seed files
 	updateFeed  event.Feed              // Event feed to notify wallet additions/removals
 	updateScope event.SubscriptionScope // Subscription scope tracking current live listeners
 	updating    bool                    // Whether the event notification loop is running
@@ -79,10 +82,22 @@ type unlocked struct {
 	abort chan struct{}
 }
 
+// Config contains the settings of an encrypted keystore.
+type Config struct {
+	KDF    KDFConfig       // Key derivation new and updated keys are encrypted with
+	Policy *PasswordPolicy // Strength policy of new passwords, nil to accept any
+}
+
 // NewKeyStore creates a keystore for the given directory.
 func NewKeyStore(keydir string, scryptN, scryptP int) *KeyStore {
+	return NewKeyStoreWithConfig(keydir, Config{KDF: ScryptKDF(scryptN, scryptP)})
+}
+
+// NewKeyStoreWithConfig creates a keystore for the given directory, encrypting
+// keys with the configured KDF and enforcing the password policy.
+func NewKeyStoreWithConfig(keydir string, config Config) *KeyStore {
 	keydir, _ = filepath.Abs(keydir)
-	ks := &KeyStore{storage: &keyStorePassphrase{keydir, scryptN, scryptP, false}}
+	ks := &KeyStore{storage: &keyStorePassphrase{keydir, config.KDF, false}, policy: config.Policy}
 	ks.init(keydir)
 	return ks
 }
@@ -108,10 +123,11 @@ func (ks *KeyStore) init(keydir string) {
 	for i := 0; i < len(accs); i++ {
 		ks.wallets[i] = &keystoreWallet{account: accs[i], keystore: ks}
 	}
+	ks.refreshHDWallets()
 }
 
-// Wallets implements accounts.Backend, returning all single-key wallets from the
-// keystore directory.
+// Wallets implements accounts.Backend, returning all single-key and hierarchical
+// deterministic wallets from the keystore directory.
 func (ks *KeyStore) Wallets() []accounts.Wallet {
 	// Make sure the list of wallets is in sync with the account cache
 	ks.refreshWallets()
@@ -119,8 +135,14 @@ func (ks *KeyStore) Wallets() []accounts.Wallet {
 	ks.mu.RLock()
 	defer ks.mu.RUnlock()
 
-	cpy := make([]accounts.Wallet, len(ks.wallets))
-	copy(cpy, ks.wallets)
+	cpy := make([]accounts.Wallet, 0, len(ks.wallets)+len(ks.hdWallets))
+	cpy = append(cpy, ks.wallets...)
+	for _, wallet := range ks.hdWallets {
+		cpy = append(cpy, wallet)
+	}
+	slices.SortFunc(cpy, func(a, b accounts.Wallet) int {
+		return a.URL().Cmp(b.URL())
+	})
 	return cpy
 }
 
@@ -163,6 +185,7 @@ func (ks *KeyStore) refreshWallets() {
 		events = append(events, accounts.WalletEvent{Wallet: wallet, Kind: accounts.WalletDropped})
 	}
 	ks.wallets = wallets
+	events = append(events, ks.refreshHDWallets()...)
 	ks.mu.Unlock()
 
 	// Fire all wallet events and return
@@ -395,8 +418,12 @@ func (ks *KeyStore) expire(addr common.Address, u *unlocked, timeout time.Durati
 }
 
 // NewAccount generates a new key and stores it into the key directory,
-// encrypting it with the passphrase.
+// encrypting it with the passphrase. The passphrase must satisfy the password
+// policy of the keystore.
 func (ks *KeyStore) NewAccount(passphrase string) (accounts.Account, error) {
+	if err := ks.policy.Check(passphrase); err != nil {
+		return accounts.Account{}, err
+	}
 	_, account, err := storeNewKey(ks.storage, crand.Reader, passphrase)
 	if err != nil {
 		return accounts.Account{}, err
@@ -414,17 +441,14 @@ func (ks *KeyStore) Export(a accounts.Account, passphrase, newPassphrase string)
 	if err != nil {
 		return nil, err
 	}
-	var N, P int
-	if store, ok := ks.storage.(*keyStorePassphrase); ok {
-		N, P = store.scryptN, store.scryptP
-	} else {
-		N, P = StandardScryptN, StandardScryptP
-	}
-	return EncryptKey(key, newPassphrase, N, P)
+	return EncryptKeyWithKDF(key, newPassphrase, ks.kdfConfig())
 }
 
 // Import stores the given encrypted JSON key into the key directory.
 func (ks *KeyStore) Import(keyJSON []byte, passphrase, newPassphrase string) (accounts.Account, error) {
+	if err := ks.policy.Check(newPassphrase); err != nil {
+		return accounts.Account{}, err
+	}
 	key, err := DecryptKey(keyJSON, passphrase)
 	if key != nil && key.PrivateKey != nil {
 		defer zeroKey(key.PrivateKey)
@@ -445,6 +469,9 @@ func (ks *KeyStore) Import(keyJSON []byte, passphrase, newPassphrase string) (ac
 
 // ImportECDSA stores the given key into the key directory, encrypting it with the passphrase.
 func (ks *KeyStore) ImportECDSA(priv *ecdsa.PrivateKey, passphrase string) (accounts.Account, error) {
+	if err := ks.policy.Check(passphrase); err != nil {
+		return accounts.Account{}, err
+	}
 	ks.importMu.Lock()
 	defer ks.importMu.Unlock()
 
@@ -467,8 +494,12 @@ func (ks *KeyStore) importKey(key *Key, passphrase string) (accounts.Account, er
 	return a, nil
 }
 
-// Update changes the passphrase of an existing account.
+// Update changes the passphrase of an existing account. The new passphrase must
+// satisfy the password policy of the keystore.
 func (ks *KeyStore) Update(a accounts.Account, passphrase, newPassphrase string) error {
+	if err := ks.policy.Check(newPassphrase); err != nil {
+		return err
+	}
 	a, key, err := ks.getDecryptedKey(a, passphrase)
 	if err != nil {
 		return err
@@ -32,6 +32,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -42,6 +43,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/google/uuid"
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/pbkdf2"
 	"golang.org/x/crypto/scrypt"
 )
@@ -67,12 +69,92 @@ const (
 
 	scryptR     = 8
 	scryptDKLen = 32
+
+	argon2idKDF = "argon2id"
+
+	// StandardArgon2idTime, StandardArgon2idMemory and StandardArgon2idThreads
+	// are the parameters of the Argon2id encryption algorithm, using 256MB memory
+	// and taking approximately 1s CPU time on a modern processor.
+	StandardArgon2idTime    = 3
+	StandardArgon2idMemory  = 256 * 1024
+	StandardArgon2idThreads = 4
+
+	// LightArgon2idTime, LightArgon2idMemory and LightArgon2idThreads are the
+	// parameters of the Argon2id encryption algorithm, using 4MB memory and
+	// taking approximately 20ms CPU time on a modern processor.
+	LightArgon2idTime    = 3
+	LightArgon2idMemory  = 4 * 1024
+	LightArgon2idThreads = 4
+
+	// maxArgon2idMemory caps the memory demanded by key files, so a malicious
+	// file can't exhaust the memory of the process decrypting it.
+	maxArgon2idMemory = 4 * 1024 * 1024
 )
 
+// KDFConfig selects the key derivation function, and its parameters, that keys
+// are encrypted with.
+type KDFConfig struct {
+	Name string // Key derivation function, "scrypt" or "argon2id"
+
+	ScryptN int // CPU/memory cost of scrypt
+	ScryptP int // Parallelization of scrypt
+
+	Argon2Time    uint32 // Number of passes of argon2id
+	Argon2Memory  uint32 // Memory of argon2id in KiB
+	Argon2Threads uint8  // Parallelism of argon2id
+}
+
+// ScryptKDF returns the key derivation config of scrypt with the given parameters.
+func ScryptKDF(scryptN, scryptP int) KDFConfig {
+	return KDFConfig{Name: keyHeaderKDF, ScryptN: scryptN, ScryptP: scryptP}
+}
+
+// Argon2idKDF returns the key derivation config of argon2id with the given
+// parameters, the memory being in KiB.
+func Argon2idKDF(time, memory uint32, threads uint8) KDFConfig {
+	return KDFConfig{Name: argon2idKDF, Argon2Time: time, Argon2Memory: memory, Argon2Threads: threads}
+}
+
+// String implements fmt.Stringer.
+func (c KDFConfig) String() string {
+	switch c.Name {
+	case keyHeaderKDF:
+		return fmt.Sprintf("scrypt(n=%d, r=%d, p=%d)", c.ScryptN, scryptR, c.ScryptP)
+	case argon2idKDF:
+		return fmt.Sprintf("argon2id(t=%d, m=%dKiB, p=%d)", c.Argon2Time, c.Argon2Memory, c.Argon2Threads)
+	}
+	return c.Name
+}
+
+// validate checks whether keys can be encrypted with the config.
+func (c KDFConfig) validate() error {
+	switch c.Name {
+	case keyHeaderKDF:
+		return nil // scrypt checks its own parameters
+	case argon2idKDF:
+		return checkArgon2idParams(c.Argon2Time, c.Argon2Memory, c.Argon2Threads)
+	}
+	return fmt.Errorf("unsupported KDF: %s", c.Name)
+}
+
+// checkArgon2idParams checks the argon2id parameters, which would otherwise
+// make the key derivation panic or allocate unbounded memory.
+func checkArgon2idParams(time, memory uint32, threads uint8) error {
+	if time == 0 {
+		return errors.New("argon2id time must be positive")
+	}
+	if threads == 0 {
+		return errors.New("argon2id threads must be positive")
+	}
+	if memory < 8*uint32(threads) || memory > maxArgon2idMemory {
+		return fmt.Errorf("argon2id memory must be between %d and %d KiB", 8*uint32(threads), maxArgon2idMemory)
+	}
+	return nil
+}
+
 type keyStorePassphrase struct {
 	keysDirPath string
-	scryptN     int
-	scryptP     int
+	kdf         KDFConfig
 	// skipKeyFileVerification disables the security-feature which does
 	// reads and decrypts any newly created keyfiles. This should be 'false' in all
 	// cases except tests -- setting this to 'true' is not recommended.
@@ -98,12 +180,12 @@ func (ks keyStorePassphrase) GetKey(addr common.Address, filename, auth string)
 
 // StoreKey generates a key, encrypts with 'auth' and stores in the given directory
 func StoreKey(dir, auth string, scryptN, scryptP int) (accounts.Account, error) {
-	_, a, err := storeNewKey(&keyStorePassphrase{dir, scryptN, scryptP, false}, rand.Reader, auth)
+	_, a, err := storeNewKey(&keyStorePassphrase{dir, ScryptKDF(scryptN, scryptP), false}, rand.Reader, auth)
 	return a, err
 }
 
 func (ks keyStorePassphrase) StoreKey(filename string, key *Key, auth string) error {
-	keyjson, err := EncryptKey(key, auth, ks.scryptN, ks.scryptP)
+	keyjson, err := EncryptKeyWithKDF(key, auth, ks.kdf)
 	if err != nil {
 		return err
 	}
@@ -138,14 +220,42 @@ func (ks keyStorePassphrase) JoinPath(filename string) string {
 
 // EncryptDataV3 encrypts the data given as 'data' with the password 'auth'.
 func EncryptDataV3(data, auth []byte, scryptN, scryptP int) (CryptoJSON, error) {
+	return EncryptDataV3WithKDF(data, auth, ScryptKDF(scryptN, scryptP))
+}
+
+// EncryptDataV3WithKDF encrypts the data given as 'data' with the password 'auth',
+// deriving the encryption key with the given KDF.
+func EncryptDataV3WithKDF(data, auth []byte, kdf KDFConfig) (CryptoJSON, error) {
+	if err := kdf.validate(); err != nil {
+		return CryptoJSON{}, err
+	}
 	salt := make([]byte, 32)
 	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
 		panic("reading from crypto/rand failed: " + err.Error())
 	}
-	derivedKey, err := scrypt.Key(auth, salt, scryptN, scryptR, scryptP, scryptDKLen)
-	if err != nil {
-		return CryptoJSON{}, err
-	}
+	var (
+		derivedKey []byte
+		kdfParams  = make(map[string]interface{}, 5)
+	)
+	switch kdf.Name {
+	case keyHeaderKDF:
+		var err error
+		derivedKey, err = scrypt.Key(auth, salt, kdf.ScryptN, scryptR, kdf.ScryptP, scryptDKLen)
+		if err != nil {
+			return CryptoJSON{}, err
+		}
+		kdfParams["n"] = kdf.ScryptN
+		kdfParams["r"] = scryptR
+		kdfParams["p"] = kdf.ScryptP
+	case argon2idKDF:
+		derivedKey = argon2.IDKey(auth, salt, kdf.Argon2Time, kdf.Argon2Memory, kdf.Argon2Threads, scryptDKLen)
+		kdfParams["t"] = kdf.Argon2Time
+		kdfParams["m"] = kdf.Argon2Memory
+		kdfParams["p"] = kdf.Argon2Threads
+	}
+	kdfParams["dklen"] = scryptDKLen
+	kdfParams["salt"] = hex.EncodeToString(salt)
+
 	encryptKey := derivedKey[:16]
 
 	iv := make([]byte, aes.BlockSize) // 16
@@ -158,12 +268,6 @@ func EncryptDataV3(data, auth []byte, scryptN, scryptP int) (CryptoJSON, error)
 	}
 	mac := crypto.Keccak256(derivedKey[16:32], cipherText)
 
-	scryptParamsJSON := make(map[string]interface{}, 5)
-	scryptParamsJSON["n"] = scryptN
-	scryptParamsJSON["r"] = scryptR
-	scryptParamsJSON["p"] = scryptP
-	scryptParamsJSON["dklen"] = scryptDKLen
-	scryptParamsJSON["salt"] = hex.EncodeToString(salt)
 	cipherParamsJSON := cipherparamsJSON{
 		IV: hex.EncodeToString(iv),
 	}
@@ -172,8 +276,8 @@ func EncryptDataV3(data, auth []byte, scryptN, scryptP int) (CryptoJSON, error)
 		Cipher:       "aes-128-ctr",
 		CipherText:   hex.EncodeToString(cipherText),
 		CipherParams: cipherParamsJSON,
-		KDF:          keyHeaderKDF,
-		KDFParams:    scryptParamsJSON,
+		KDF:          kdf.Name,
+		KDFParams:    kdfParams,
 		MAC:          hex.EncodeToString(mac),
 	}
 	return cryptoStruct, nil
@@ -182,8 +286,14 @@ func EncryptDataV3(data, auth []byte, scryptN, scryptP int) (CryptoJSON, error)
 // EncryptKey encrypts a key using the specified scrypt parameters into a json
 // blob that can be decrypted later on.
 func EncryptKey(key *Key, auth string, scryptN, scryptP int) ([]byte, error) {
+	return EncryptKeyWithKDF(key, auth, ScryptKDF(scryptN, scryptP))
+}
+
+// EncryptKeyWithKDF encrypts a key using the specified KDF into a json blob that
+// can be decrypted later on.
+func EncryptKeyWithKDF(key *Key, auth string, kdf KDFConfig) ([]byte, error) {
 	keyBytes := math.PaddedBigBytes(key.PrivateKey.D, 32)
-	cryptoStruct, err := EncryptDataV3(keyBytes, []byte(auth), scryptN, scryptP)
+	cryptoStruct, err := EncryptDataV3WithKDF(keyBytes, []byte(auth), kdf)
 	if err != nil {
 		return nil, err
 	}
@@ -196,6 +306,59 @@ func EncryptKey(key *Key, auth string, scryptN, scryptP int) ([]byte, error) {
 	return json.Marshal(encryptedKeyJSONV3)
 }
 
+// KeyKDF returns the key derivation config a json key is encrypted with, without
+// decrypting the key.
+func KeyKDF(keyjson []byte) (KDFConfig, error) {
+	k := new(encryptedKeyJSONV3)
+	if err := json.Unmarshal(keyjson, k); err != nil {
+		return KDFConfig{}, err
+	}
+	if k.Version != version {
+		return KDFConfig{}, fmt.Errorf("version not supported: %v", k.Version)
+	}
+	return cryptoKDF(k.Crypto)
+}
+
+// cryptoKDF returns the key derivation config of encrypted data.
+func cryptoKDF(cryptoJSON CryptoJSON) (KDFConfig, error) {
+	param := func(name string) (int, error) {
+		if x, ok := cryptoJSON.KDFParams[name].(float64); ok {
+			return int(x), nil
+		}
+		if x, ok := cryptoJSON.KDFParams[name].(int); ok {
+			return x, nil
+		}
+		return 0, fmt.Errorf("missing %s parameter %q", cryptoJSON.KDF, name)
+	}
+	switch cryptoJSON.KDF {
+	case keyHeaderKDF:
+		n, err := param("n")
+		if err != nil {
+			return KDFConfig{}, err
+		}
+		p, err := param("p")
+		if err != nil {
+			return KDFConfig{}, err
+		}
+		return ScryptKDF(n, p), nil
+	case argon2idKDF:
+		t, err := param("t")
+		if err != nil {
+			return KDFConfig{}, err
+		}
+		m, err := param("m")
+		if err != nil {
+			return KDFConfig{}, err
+		}
+		p, err := param("p")
+		if err != nil {
+			return KDFConfig{}, err
+		}
+		return Argon2idKDF(uint32(t), uint32(m), uint8(p)), nil
+	}
+	return KDFConfig{Name: cryptoJSON.KDF}, nil
+}
+
 // DecryptKey decrypts a key from a json blob, returning the private key itself.
 func DecryptKey(keyjson []byte, auth string) (*Key, error) {
 	// Parse the json into a simple map to fetch the key version
@@ -351,6 +514,20 @@ func getKDFKey(cryptoJSON CryptoJSON, auth string) ([]byte, error) {
 		}
 		key := pbkdf2.Key(authArray, salt, c, dkLen, sha256.New)
 		return key, nil
+	} else if cryptoJSON.KDF == argon2idKDF {
+		t := ensureInt(cryptoJSON.KDFParams["t"])
+		m := ensureInt(cryptoJSON.KDFParams["m"])
+		p := ensureInt(cryptoJSON.KDFParams["p"])
+		if int64(t) < 0 || int64(t) >= 1<<32 || int64(m) < 0 || int64(m) >= 1<<32 || p < 0 || p > 255 {
+			return nil, errors.New("invalid argon2id parameters")
+		}
+		if err := checkArgon2idParams(uint32(t), uint32(m), uint8(p)); err != nil {
+			return nil, err
+		}
+		if dkLen < 32 {
+			return nil, errors.New("argon2id key length too short")
+		}
+		return argon2.IDKey(authArray, salt, uint32(t), uint32(m), uint8(p), uint32(dkLen)), nil
 	}
 
 	return nil, fmt.Errorf("unsupported KDF: %s", cryptoJSON.KDF)
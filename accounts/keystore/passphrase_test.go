@@ -17,6 +17,7 @@
 package keystore
 
 import (
+	"crypto/rand"
 	"os"
 	"testing"
 
@@ -59,3 +60,37 @@ func TestKeyEncryptDecrypt(t *testing.T) {
 		}
 	}
 }
+
+// Tests that keys can be encrypted with argon2id and the KDF parameters are
+// recoverable from the json key file.
+func TestKeyEncryptDecryptArgon2id(t *testing.T) {
+	t.Parallel()
+	key, err := newKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kdf := Argon2idKDF(1, 64, 2)
+	keyjson, err := EncryptKeyWithKDF(key, "foo", kdf)
+	if err != nil {
+		t.Fatalf("failed to encrypt key: %v", err)
+	}
+	if _, err := DecryptKey(keyjson, "bar"); err != ErrDecrypt {
+		t.Errorf("decryption with bad password error mismatch: have %v, want %v", err, ErrDecrypt)
+	}
+	decrypted, err := DecryptKey(keyjson, "foo")
+	if err != nil {
+		t.Fatalf("failed to decrypt key: %v", err)
+	}
+	if decrypted.Address != key.Address {
+		t.Errorf("key address mismatch: have %x, want %x", decrypted.Address, key.Address)
+	}
+	if have, err := KeyKDF(keyjson); err != nil || have != kdf {
+		t.Errorf("KDF mismatch: have %v (%v), want %v", have, err, kdf)
+	}
+	// Invalid parameters must be rejected rather than make argon2 panic
+	for _, kdf := range []KDFConfig{Argon2idKDF(0, 64, 1), Argon2idKDF(1, 64, 0), Argon2idKDF(1, 4, 1), {Name: "pbkdf2"}} {
+		if _, err := EncryptKeyWithKDF(key, "foo", kdf); err == nil {
+			t.Errorf("encrypted key with invalid KDF %v", kdf)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package keystore
+
+import (
+	"errors"
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrWeakPassword is returned if a new password doesn't satisfy the password
+// policy of the key store.
+var ErrWeakPassword = errors.New("password too weak")
+
+// PasswordPolicy is the strength requirement passwords protecting new keys must
+// satisfy. Zero fields disable the respective check.
+type PasswordPolicy struct {
+	MinLength  int // Minimum number of characters
+	MinClasses int // Minimum number of character classes: lowercase, uppercase, digits and symbols
+}
+
+// Check returns an error wrapping ErrWeakPassword if the password doesn't
+// satisfy the policy. A nil policy accepts any password.
+func (p *PasswordPolicy) Check(password string) error {
+	if p == nil {
+		return nil
+	}
+	if n := utf8.RuneCountInString(password); n < p.MinLength {
+		return fmt.Errorf("%w: %d characters, at least %d required", ErrWeakPassword, n, p.MinLength)
+	}
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	var classes int
+	for _, present := range []bool{lower, upper, digit, symbol} {
+		if present {
+			classes++
+		}
+	}
+	if classes < p.MinClasses {
+		return fmt.Errorf("%w: %d character classes, at least %d of lowercase, uppercase, digits and symbols required", ErrWeakPassword, classes, p.MinClasses)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package keystore
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/accounts"
+)
+
+// errNotEncrypted is returned when rekeying a keystore storing plain keys.
+var errNotEncrypted = errors.New("keystore is not encrypted")
+
+// WalletKDF returns the key derivation the key file, or the seed file of an HD
+// wallet, backing the given wallet is encrypted with.
+func (ks *KeyStore) WalletKDF(wallet accounts.Wallet) (KDFConfig, error) {
+	switch w := wallet.(type) {
+	case *keystoreWallet:
+		keyjson, err := os.ReadFile(w.account.URL.Path)
+		if err != nil {
+			return KDFConfig{}, err
+		}
+		return KeyKDF(keyjson)
+
+	case *hdWallet:
+		file, err := loadHDWallet(w.url.Path)
+		if err != nil {
+			return KDFConfig{}, err
+		}
+		return cryptoKDF(file.Crypto)
+	}
+	return KDFConfig{}, accounts.ErrUnknownWallet
+}
+
+// RekeyWallet re-encrypts the key file, or the seed file of an HD wallet, backing
+// the given wallet with the key derivation of the keystore, keeping the password.
+// This is used to migrate keys to stronger KDF parameters.
+//
+// The new file is written next to the old one and decrypted again, it replaces
+// the old file only if it yields the same secret.
+func (ks *KeyStore) RekeyWallet(wallet accounts.Wallet, passphrase string) error {
+	store, ok := ks.storage.(*keyStorePassphrase)
+	if !ok {
+		return errNotEncrypted
+	}
+	switch w := wallet.(type) {
+	case *keystoreWallet:
+		return ks.rekeyKey(store, w.account, passphrase)
+	case *hdWallet:
+		return rekeyHDWallet(store, w.url.Path, passphrase)
+	}
+	return accounts.ErrUnknownWallet
+}
+
+// rekeyKey re-encrypts the key file of an account.
+func (ks *KeyStore) rekeyKey(store *keyStorePassphrase, a accounts.Account, passphrase string) error {
+	a, key, err := ks.getDecryptedKey(a, passphrase)
+	if err != nil {
+		return err
+	}
+	defer zeroKey(key.PrivateKey)
+
+	keyjson, err := EncryptKeyWithKDF(key, passphrase, store.kdf)
+	if err != nil {
+		return err
+	}
+	return replaceKeyFile(a.URL.Path, keyjson, func(tmpName string) error {
+		// GetKey rejects files holding a different key than the address
+		rekeyed, err := store.GetKey(key.Address, tmpName, passphrase)
+		if err != nil {
+			return err
+		}
+		zeroKey(rekeyed.PrivateKey)
+		return nil
+	})
+}
+
+// rekeyHDWallet re-encrypts the seed file at the given path.
+func rekeyHDWallet(store *keyStorePassphrase, path string, passphrase string) error {
+	file, err := loadHDWallet(path)
+	if err != nil {
+		return err
+	}
+	secret, err := DecryptDataV3(file.Crypto, passphrase)
+	if err != nil {
+		return err
+	}
+	defer clear(secret)
+
+	if file.Crypto, err = EncryptDataV3WithKDF(secret, []byte(passphrase), store.kdf); err != nil {
+		return err
+	}
+	blob, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+	return replaceKeyFile(path, blob, func(tmpName string) error {
+		rekeyed, err := loadHDWallet(tmpName)
+		if err != nil {
+			return err
+		}
+		decrypted, err := DecryptDataV3(rekeyed.Crypto, passphrase)
+		if err != nil {
+			return err
+		}
+		defer clear(decrypted)
+
+		if !bytes.Equal(decrypted, secret) {
+			return errors.New("secret mismatch")
+		}
+		return nil
+	})
+}
+
+// replaceKeyFile atomically replaces a key file with the given content, after
+// the verifier accepted the new file written to a temporary location.
+func replaceKeyFile(path string, content []byte, verify func(tmpName string) error) error {
+	tmpName, err := writeTemporaryKeyFile(path, content)
+	if err != nil {
+		return err
+	}
+	if err := verify(tmpName); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to verify re-encrypted key file, %s left unchanged: %v", path, err)
+	}
+	return os.Rename(tmpName, path)
+}
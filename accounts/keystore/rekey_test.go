@@ -0,0 +1,122 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package keystore
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+)
+
+// Tests that key and seed files are re-encrypted with the KDF of the keystore,
+// and left untouched if they can't be decrypted.
+func TestRekeyWallet(t *testing.T) {
+	t.Parallel()
+	dir, ks := tmpKeyStore(t)
+
+	account, err := ks.NewAccount("foo")
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	if _, err := ks.ImportMnemonic(testMnemonic, "", "foo"); err != nil {
+		t.Fatalf("failed to import mnemonic: %v", err)
+	}
+	kdf := Argon2idKDF(1, 64, 1)
+	rekeyed := NewKeyStoreWithConfig(dir, Config{KDF: kdf})
+
+	wallets := rekeyed.Wallets()
+	if len(wallets) != 2 {
+		t.Fatalf("wallet count mismatch: have %d, want 2", len(wallets))
+	}
+	for _, wallet := range wallets {
+		url := wallet.URL()
+		if have, err := rekeyed.WalletKDF(wallet); err != nil || have != ScryptKDF(veryLightScryptN, veryLightScryptP) {
+			t.Fatalf("%s: KDF mismatch before rekey: have %v (%v)", url, have, err)
+		}
+		old, err := os.ReadFile(url.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := rekeyed.RekeyWallet(wallet, "bar"); !errors.Is(err, ErrDecrypt) {
+			t.Errorf("%s: rekey with wrong password error mismatch: have %v, want %v", url, err, ErrDecrypt)
+		}
+		if blob, _ := os.ReadFile(url.Path); !bytes.Equal(blob, old) {
+			t.Errorf("%s: file modified by failed rekey", url)
+		}
+		if err := rekeyed.RekeyWallet(wallet, "foo"); err != nil {
+			t.Fatalf("%s: failed to rekey: %v", url, err)
+		}
+		if have, err := rekeyed.WalletKDF(wallet); err != nil || have != kdf {
+			t.Errorf("%s: KDF mismatch after rekey: have %v (%v), want %v", url, have, err, kdf)
+		}
+	}
+	// The keys must still be usable with the same password
+	if _, err := rekeyed.SignHashWithPassphrase(account, "foo", make([]byte, 32)); err != nil {
+		t.Errorf("failed to sign with rekeyed account: %v", err)
+	}
+	mnemonic, err := rekeyed.ExportMnemonic(accounts.Account{Address: testHDAddr0}, "foo")
+	if err != nil {
+		t.Errorf("failed to export rekeyed mnemonic: %v", err)
+	} else if mnemonic != testMnemonic {
+		t.Errorf("mnemonic mismatch: have %q, want %q", mnemonic, testMnemonic)
+	}
+}
+
+// Tests that new passwords are checked against the password policy.
+func TestPasswordPolicy(t *testing.T) {
+	t.Parallel()
+
+	policy := &PasswordPolicy{MinLength: 10, MinClasses: 3}
+	tests := []struct {
+		password string
+		ok       bool
+	}{
+		{"", false},
+		{"Sh0rt!", false},
+		{"alllowercaseletters", false},
+		{"lowerUPPERonly", false},
+		{"lowerUPPER123", true},
+		{"lower symbols 123", true},
+		{"ÄÖÜäöü1234", true},
+	}
+	for _, tt := range tests {
+		err := policy.Check(tt.password)
+		if tt.ok && err != nil {
+			t.Errorf("password %q rejected: %v", tt.password, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrWeakPassword) {
+			t.Errorf("password %q error mismatch: have %v, want %v", tt.password, err, ErrWeakPassword)
+		}
+	}
+	ks := NewKeyStoreWithConfig(t.TempDir(), Config{KDF: ScryptKDF(veryLightScryptN, veryLightScryptP), Policy: policy})
+	if _, err := ks.NewAccount("foo"); !errors.Is(err, ErrWeakPassword) {
+		t.Errorf("weak password error mismatch: have %v, want %v", err, ErrWeakPassword)
+	}
+	if len(ks.Accounts()) != 0 {
+		t.Errorf("account created with weak password")
+	}
+	account, err := ks.NewAccount("lowerUPPER123")
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	if err := ks.Update(account, "lowerUPPER123", "foo"); !errors.Is(err, ErrWeakPassword) {
+		t.Errorf("weak password update error mismatch: have %v, want %v", err, ErrWeakPassword)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package pkcs11 implements support for hardware security modules and other
+// tokens accessible via PKCS#11 that can hold secp256k1 keys.
+//
+// Every initialized token of the configured PKCS#11 module is exposed as a
+// wallet, its secp256k1 key pairs as accounts. Key pairs are identified by the
+// CKA_ID shared by their public and private key objects, which have to be
+// generated or imported with the tools of the token vendor, e.g.
+//
+//	pkcs11-tool --module <module> --login --keypairgen --key-type EC:secp256k1 --id 01
+//
+// Opening a wallet logs into its token with the given PIN. Signing with a
+// passphrase on a closed wallet uses the passphrase as the PIN for the duration
+// of the request.
+package pkcs11
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// Scheme is the URI prefix for PKCS#11 wallets.
+const Scheme = "pkcs11"
+
+// refreshCycle is the maximum time between wallet refreshes, tokens may be
+// inserted or removed at any time.
+const refreshCycle = 3 * time.Second
+
+// refreshThrottling is the minimum time between wallet refreshes to avoid thrashing.
+const refreshThrottling = 500 * time.Millisecond
+
+// Hub is an accounts.Backend that exposes the tokens of a PKCS#11 module.
+type Hub struct {
+	module module // PKCS#11 library the tokens are accessed through
+
+	refreshed   time.Time               // Time instance when the list of wallets was last refreshed
+	wallets     []*wallet               // List of tokens currently tracked, sorted by URL
+	updateFeed  event.Feed              // Event feed to notify wallet additions/removals
+	updateScope event.SubscriptionScope // Subscription scope tracking current live listeners
+	updating    bool                    // Whether the event notification loop is running
+
+	stateLock sync.RWMutex // Protects the internals of the hub from racey access
+}
+
+// NewHub loads the PKCS#11 module at the given path and creates a hub for the
+// tokens accessible through it.
+func NewHub(path string) (*Hub, error) {
+	mod, err := loadModule(path)
+	if err != nil {
+		return nil, err
+	}
+	return newHub(mod), nil
+}
+
+// newHub creates a hub for the tokens of an already loaded module.
+func newHub(mod module) *Hub {
+	hub := &Hub{module: mod}
+	hub.refreshWallets()
+	return hub
+}
+
+// Wallets implements accounts.Backend, returning all the currently tracked
+// tokens as wallets.
+func (hub *Hub) Wallets() []accounts.Wallet {
+	// Make sure the list of wallets is up to date
+	hub.refreshWallets()
+
+	hub.stateLock.RLock()
+	defer hub.stateLock.RUnlock()
+
+	cpy := make([]accounts.Wallet, len(hub.wallets))
+	for i, wallet := range hub.wallets {
+		cpy[i] = wallet
+	}
+	return cpy
+}
+
+// refreshWallets scans the slots of the module and updates the list of wallets
+// based on the tokens found.
+func (hub *Hub) refreshWallets() {
+	// Don't scan the slots too frequently
+	hub.stateLock.RLock()
+	elapsed := time.Since(hub.refreshed)
+	hub.stateLock.RUnlock()
+
+	if elapsed < refreshThrottling {
+		return
+	}
+	tokens, err := hub.module.tokens()
+	if err != nil {
+		log.Error("Failed to enumerate PKCS#11 tokens", "err", err)
+		return
+	}
+	hub.stateLock.Lock()
+
+	var (
+		wallets = make([]*wallet, 0, len(tokens))
+		known   = make(map[string]*wallet, len(hub.wallets))
+		events  []accounts.WalletEvent
+	)
+	for _, wallet := range hub.wallets {
+		known[wallet.url.Path] = wallet
+	}
+	for _, tok := range tokens {
+		if tok.flags&ckfTokenInitialized == 0 {
+			continue
+		}
+		url := tokenURL(tok)
+		if wallet, ok := known[url.Path]; ok && wallet.token.slot == tok.slot {
+			wallets = append(wallets, wallet)
+			delete(known, url.Path)
+			continue
+		}
+		wallet := newWallet(hub, url, tok)
+		events = append(events, accounts.WalletEvent{Wallet: wallet, Kind: accounts.WalletArrived})
+		wallets = append(wallets, wallet)
+	}
+	for _, wallet := range known {
+		events = append(events, accounts.WalletEvent{Wallet: wallet, Kind: accounts.WalletDropped})
+		wallet.Close()
+	}
+	sort.Slice(wallets, func(i, j int) bool {
+		return wallets[i].url.Cmp(wallets[j].url) < 0
+	})
+	hub.refreshed = time.Now()
+	hub.wallets = wallets
+	hub.stateLock.Unlock()
+
+	// Fire all wallet events and return
+	for _, event := range events {
+		hub.updateFeed.Send(event)
+	}
+}
+
+// tokenURL returns the URL identifying a token, preferring its serial number.
+func tokenURL(tok token) accounts.URL {
+	if tok.serial != "" {
+		return accounts.URL{Scheme: Scheme, Path: tok.serial}
+	}
+	return accounts.URL{Scheme: Scheme, Path: fmt.Sprintf("slot-%d", tok.slot)}
+}
+
+// Subscribe implements accounts.Backend, creating an async subscription to
+// receive notifications on the addition or removal of PKCS#11 tokens.
+func (hub *Hub) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
+	// We need the mutex to reliably start/stop the update loop
+	hub.stateLock.Lock()
+	defer hub.stateLock.Unlock()
+
+	sub := hub.updateScope.Track(hub.updateFeed.Subscribe(sink))
+
+	// Subscribers require an active notification loop, start it
+	if !hub.updating {
+		hub.updating = true
+		go hub.updater()
+	}
+	return sub
+}
+
+// updater is responsible for maintaining an up-to-date list of tokens managed
+// by the PKCS#11 hub, and for firing wallet addition/removal events.
+func (hub *Hub) updater() {
+	for {
+		// Wait for the refresh cycle, PKCS#11 has no portable insertion events
+		time.Sleep(refreshCycle)
+
+		// Run the wallet refresher
+		hub.refreshWallets()
+
+		// If all our subscribers left, stop the updater
+		hub.stateLock.Lock()
+		if hub.updateScope.Count() == 0 {
+			hub.updating = false
+			hub.stateLock.Unlock()
+			return
+		}
+		hub.stateLock.Unlock()
+	}
+}
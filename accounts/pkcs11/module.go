@@ -0,0 +1,107 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pkcs11
+
+import "fmt"
+
+// PKCS#11 constants used by the backend.
+const (
+	ckrOK                          = 0x000
+	ckrPinIncorrect                = 0x0a0
+	ckrPinLocked                   = 0x0a4
+	ckrUserAlreadyLoggedIn         = 0x100
+	ckrUserNotLoggedIn             = 0x101
+	ckrCryptokiAlreadyInitialized  = 0x191
+	ckfTokenInitialized            = 0x400
+	ckfProtectedAuthenticationPath = 0x100
+)
+
+// token describes a PKCS#11 token present in a slot of the module.
+type token struct {
+	slot   uint   // Slot the token is inserted into
+	label  string // Label assigned to the token on initialization
+	model  string // Model of the token
+	serial string // Serial number of the token
+	flags  uint   // Token capability flags (CKF_*)
+}
+
+// keyObject is the raw representation of an elliptic curve public key object
+// stored on a token.
+type keyObject struct {
+	id     []byte // CKA_ID shared by the public and private key objects
+	label  []byte // CKA_LABEL of the public key object
+	params []byte // CKA_EC_PARAMS, the DER encoded curve
+	point  []byte // CKA_EC_POINT, the (DER wrapped) uncompressed curve point
+}
+
+// module is a loaded PKCS#11 library.
+type module interface {
+	// tokens lists the initialized tokens present in the slots of the module.
+	tokens() ([]token, error)
+
+	// open starts a new session with the token in the given slot.
+	open(slot uint) (session, error)
+}
+
+// session is a session with a PKCS#11 token. Sessions are not safe for
+// concurrent use.
+type session interface {
+	// login authenticates the session as the normal user. An empty PIN requests
+	// authentication via the protected authentication path of the token.
+	login(pin string) error
+
+	// logout terminates the authentication of the session.
+	logout() error
+
+	// publicKeys lists the elliptic curve public key objects visible to the session.
+	publicKeys() ([]keyObject, error)
+
+	// sign signs the hash with the elliptic curve private key of the given ID
+	// using the raw CKM_ECDSA mechanism.
+	sign(id []byte, hash []byte) ([]byte, error)
+
+	// close terminates the session.
+	close() error
+}
+
+// ckError is a PKCS#11 return value other than CKR_OK.
+type ckError uint
+
+var ckErrorNames = map[ckError]string{
+	0x003: "CKR_GENERAL_ERROR",
+	0x006: "CKR_FUNCTION_FAILED",
+	0x007: "CKR_ARGUMENTS_BAD",
+	0x030: "CKR_DEVICE_ERROR",
+	0x032: "CKR_DEVICE_REMOVED",
+	0x060: "CKR_KEY_HANDLE_INVALID",
+	0x068: "CKR_KEY_FUNCTION_NOT_PERMITTED",
+	0x070: "CKR_MECHANISM_INVALID",
+	0x0a0: "CKR_PIN_INCORRECT",
+	0x0a4: "CKR_PIN_LOCKED",
+	0x0b3: "CKR_SESSION_HANDLE_INVALID",
+	0x0e0: "CKR_TOKEN_NOT_PRESENT",
+	0x101: "CKR_USER_NOT_LOGGED_IN",
+	0x150: "CKR_BUFFER_TOO_SMALL",
+	0x190: "CKR_CRYPTOKI_NOT_INITIALIZED",
+}
+
+func (err ckError) Error() string {
+	if name, ok := ckErrorNames[err]; ok {
+		return fmt.Sprintf("pkcs11: %s", name)
+	}
+	return fmt.Sprintf("pkcs11: error 0x%x", uint(err))
+}
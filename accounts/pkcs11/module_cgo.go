@@ -0,0 +1,434 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+//go:build cgo && !windows
+
+package pkcs11
+
+/*
+#cgo linux LDFLAGS: -ldl
+
+#include <dlfcn.h>
+#include <stdlib.h>
+#include <string.h>
+
+// The subset of the PKCS#11 types used, matching the layout of the OASIS
+// headers on platforms without structure packing.
+typedef unsigned long ck_ulong;
+typedef ck_ulong ck_rv;
+
+typedef struct {
+	unsigned char major;
+	unsigned char minor;
+} ck_version;
+
+typedef struct {
+	ck_ulong type;
+	void    *value;
+	ck_ulong len;
+} ck_attribute;
+
+typedef struct {
+	ck_ulong mechanism;
+	void    *parameter;
+	ck_ulong len;
+} ck_mechanism;
+
+typedef struct {
+	void    *create_mutex;
+	void    *destroy_mutex;
+	void    *lock_mutex;
+	void    *unlock_mutex;
+	ck_ulong flags;
+	void    *reserved;
+} ck_initialize_args;
+
+typedef struct {
+	unsigned char label[32];
+	unsigned char manufacturer[32];
+	unsigned char model[16];
+	unsigned char serial[16];
+	ck_ulong      flags;
+	ck_ulong      max_sessions;
+	ck_ulong      sessions;
+	ck_ulong      max_rw_sessions;
+	ck_ulong      rw_sessions;
+	ck_ulong      max_pin_len;
+	ck_ulong      min_pin_len;
+	ck_ulong      total_public_memory;
+	ck_ulong      free_public_memory;
+	ck_ulong      total_private_memory;
+	ck_ulong      free_private_memory;
+	ck_version    hardware_version;
+	ck_version    firmware_version;
+	unsigned char utc_time[16];
+} ck_token_info;
+
+typedef struct {
+	void *handle;
+	ck_rv (*initialize)(void *);
+	ck_rv (*get_slot_list)(unsigned char, ck_ulong *, ck_ulong *);
+	ck_rv (*get_token_info)(ck_ulong, ck_token_info *);
+	ck_rv (*open_session)(ck_ulong, ck_ulong, void *, void *, ck_ulong *);
+	ck_rv (*close_session)(ck_ulong);
+	ck_rv (*login)(ck_ulong, ck_ulong, unsigned char *, ck_ulong);
+	ck_rv (*logout)(ck_ulong);
+	ck_rv (*find_objects_init)(ck_ulong, ck_attribute *, ck_ulong);
+	ck_rv (*find_objects)(ck_ulong, ck_ulong *, ck_ulong, ck_ulong *);
+	ck_rv (*find_objects_final)(ck_ulong);
+	ck_rv (*get_attribute_value)(ck_ulong, ck_ulong, ck_attribute *, ck_ulong);
+	ck_rv (*sign_init)(ck_ulong, ck_mechanism *, ck_ulong);
+	ck_rv (*sign)(ck_ulong, unsigned char *, ck_ulong, unsigned char *, ck_ulong *);
+} ck_module;
+
+#define CK_RESOLVE(field, name)                           \
+	*(void **)(&m->field) = dlsym(m->handle, name);       \
+	if (m->field == NULL) {                               \
+		dlclose(m->handle);                               \
+		*err = name;                                      \
+		return 2;                                         \
+	}
+
+// ck_load opens the module and resolves the functions used. On failure 1 is
+// returned if the library could not be opened, 2 if a function is missing.
+static int ck_load(const char *path, ck_module *m, const char **err) {
+	m->handle = dlopen(path, RTLD_NOW | RTLD_LOCAL);
+	if (m->handle == NULL) {
+		*err = dlerror();
+		return 1;
+	}
+	CK_RESOLVE(initialize, "C_Initialize");
+	CK_RESOLVE(get_slot_list, "C_GetSlotList");
+	CK_RESOLVE(get_token_info, "C_GetTokenInfo");
+	CK_RESOLVE(open_session, "C_OpenSession");
+	CK_RESOLVE(close_session, "C_CloseSession");
+	CK_RESOLVE(login, "C_Login");
+	CK_RESOLVE(logout, "C_Logout");
+	CK_RESOLVE(find_objects_init, "C_FindObjectsInit");
+	CK_RESOLVE(find_objects, "C_FindObjects");
+	CK_RESOLVE(find_objects_final, "C_FindObjectsFinal");
+	CK_RESOLVE(get_attribute_value, "C_GetAttributeValue");
+	CK_RESOLVE(sign_init, "C_SignInit");
+	CK_RESOLVE(sign, "C_Sign");
+	return 0;
+}
+
+static ck_rv ck_initialize(ck_module *m) {
+	ck_initialize_args args;
+	memset(&args, 0, sizeof(args));
+	args.flags = 0x2; // CKF_OS_LOCKING_OK, Go calls in from many threads
+	return m->initialize(&args);
+}
+
+static ck_rv ck_get_slot_list(ck_module *m, ck_ulong *slots, ck_ulong *count) {
+	return m->get_slot_list(1, slots, count);
+}
+
+static ck_rv ck_get_token_info(ck_module *m, ck_ulong slot, ck_token_info *info) {
+	return m->get_token_info(slot, info);
+}
+
+static ck_rv ck_open_session(ck_module *m, ck_ulong slot, ck_ulong *session) {
+	return m->open_session(slot, 0x4, NULL, NULL, session); // CKF_SERIAL_SESSION
+}
+
+static ck_rv ck_close_session(ck_module *m, ck_ulong session) {
+	return m->close_session(session);
+}
+
+static ck_rv ck_login(ck_module *m, ck_ulong session, unsigned char *pin, ck_ulong len) {
+	return m->login(session, 1, pin, len); // CKU_USER
+}
+
+static ck_rv ck_logout(ck_module *m, ck_ulong session) {
+	return m->logout(session);
+}
+
+static ck_rv ck_find_objects_init(ck_module *m, ck_ulong session, ck_attribute *tmpl, ck_ulong count) {
+	return m->find_objects_init(session, tmpl, count);
+}
+
+static ck_rv ck_find_objects(ck_module *m, ck_ulong session, ck_ulong *objects, ck_ulong max, ck_ulong *count) {
+	return m->find_objects(session, objects, max, count);
+}
+
+static ck_rv ck_find_objects_final(ck_module *m, ck_ulong session) {
+	return m->find_objects_final(session);
+}
+
+static ck_rv ck_get_attribute_value(ck_module *m, ck_ulong session, ck_ulong object, ck_attribute *attr) {
+	return m->get_attribute_value(session, object, attr, 1);
+}
+
+static ck_rv ck_sign(ck_module *m, ck_ulong session, ck_ulong key, unsigned char *data, ck_ulong len, unsigned char *sig, ck_ulong *siglen) {
+	ck_mechanism mech;
+	memset(&mech, 0, sizeof(mech));
+	mech.mechanism = 0x1041; // CKM_ECDSA
+
+	ck_rv rv = m->sign_init(session, &mech, key);
+	if (rv != 0) {
+		return rv;
+	}
+	return m->sign(session, data, len, sig, siglen);
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unsafe"
+)
+
+// PKCS#11 attribute and object constants used in search templates.
+const (
+	ckaClass    = 0x000
+	ckaLabel    = 0x003
+	ckaKeyType  = 0x100
+	ckaID       = 0x102
+	ckaECParams = 0x180
+	ckaECPoint  = 0x181
+
+	ckoPublicKey  = 2
+	ckoPrivateKey = 3
+	ckkEC         = 3
+
+	ckUnavailableInformation = ^C.ck_ulong(0)
+)
+
+// cgoModule is a PKCS#11 library loaded with dlopen.
+type cgoModule struct {
+	m *C.ck_module
+}
+
+// loadModule loads and initializes the PKCS#11 library at the given path.
+func loadModule(path string) (module, error) {
+	cpath := C.CString(path)
+	defer C.free(unsafe.Pointer(cpath))
+
+	var (
+		m    = (*C.ck_module)(C.calloc(1, C.sizeof_ck_module))
+		cerr *C.char
+	)
+	switch C.ck_load(cpath, m, &cerr) {
+	case 1:
+		C.free(unsafe.Pointer(m))
+		return nil, fmt.Errorf("failed to load PKCS#11 module: %s", C.GoString(cerr))
+	case 2:
+		C.free(unsafe.Pointer(m))
+		return nil, fmt.Errorf("invalid PKCS#11 module: missing %s", C.GoString(cerr))
+	}
+	if rv := C.ck_initialize(m); rv != ckrOK && rv != ckrCryptokiAlreadyInitialized {
+		C.free(unsafe.Pointer(m))
+		return nil, ckError(rv)
+	}
+	return &cgoModule{m: m}, nil
+}
+
+func (mod *cgoModule) tokens() ([]token, error) {
+	var count C.ck_ulong
+	if rv := C.ck_get_slot_list(mod.m, nil, &count); rv != ckrOK {
+		return nil, ckError(rv)
+	}
+	if count == 0 {
+		return nil, nil
+	}
+	slots := make([]C.ck_ulong, count)
+	if rv := C.ck_get_slot_list(mod.m, &slots[0], &count); rv != ckrOK {
+		return nil, ckError(rv)
+	}
+	tokens := make([]token, 0, count)
+	for _, slot := range slots[:count] {
+		var info C.ck_token_info
+		if rv := C.ck_get_token_info(mod.m, slot, &info); rv != ckrOK {
+			continue // Token removed in the meantime
+		}
+		tokens = append(tokens, token{
+			slot:   uint(slot),
+			label:  padded(unsafe.Pointer(&info.label[0]), len(info.label)),
+			model:  padded(unsafe.Pointer(&info.model[0]), len(info.model)),
+			serial: padded(unsafe.Pointer(&info.serial[0]), len(info.serial)),
+			flags:  uint(info.flags),
+		})
+	}
+	return tokens, nil
+}
+
+func (mod *cgoModule) open(slot uint) (session, error) {
+	var handle C.ck_ulong
+	if rv := C.ck_open_session(mod.m, C.ck_ulong(slot), &handle); rv != ckrOK {
+		return nil, ckError(rv)
+	}
+	return &cgoSession{m: mod.m, handle: handle}, nil
+}
+
+// padded converts a blank padded PKCS#11 string to a Go one.
+func padded(ptr unsafe.Pointer, size int) string {
+	return strings.TrimRight(string(C.GoBytes(ptr, C.int(size))), " \x00")
+}
+
+// cgoSession is a session opened via a dlopen-ed PKCS#11 library.
+type cgoSession struct {
+	m      *C.ck_module
+	handle C.ck_ulong
+}
+
+func (s *cgoSession) login(pin string) error {
+	var rv C.ck_rv
+	if pin == "" {
+		rv = C.ck_login(s.m, s.handle, nil, 0)
+	} else {
+		cpin := C.CBytes([]byte(pin))
+		defer C.free(cpin)
+		rv = C.ck_login(s.m, s.handle, (*C.uchar)(cpin), C.ck_ulong(len(pin)))
+	}
+	if rv != ckrOK && rv != ckrUserAlreadyLoggedIn {
+		return ckError(rv)
+	}
+	return nil
+}
+
+func (s *cgoSession) logout() error {
+	if rv := C.ck_logout(s.m, s.handle); rv != ckrOK && rv != ckrUserNotLoggedIn {
+		return ckError(rv)
+	}
+	return nil
+}
+
+func (s *cgoSession) close() error {
+	if rv := C.ck_close_session(s.m, s.handle); rv != ckrOK {
+		return ckError(rv)
+	}
+	return nil
+}
+
+func (s *cgoSession) publicKeys() ([]keyObject, error) {
+	objects, err := s.find(ckoPublicKey, nil)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]keyObject, 0, len(objects))
+	for _, object := range objects {
+		var key keyObject
+		if key.id, err = s.attribute(object, ckaID); err != nil {
+			return nil, err
+		}
+		if key.label, err = s.attribute(object, ckaLabel); err != nil {
+			return nil, err
+		}
+		if key.params, err = s.attribute(object, ckaECParams); err != nil {
+			return nil, err
+		}
+		if key.point, err = s.attribute(object, ckaECPoint); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (s *cgoSession) sign(id []byte, hash []byte) ([]byte, error) {
+	objects, err := s.find(ckoPrivateKey, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 {
+		return nil, errors.New("private key not found")
+	}
+	var (
+		data   = C.CBytes(hash)
+		sig    = (*C.uchar)(C.malloc(128))
+		siglen = C.ck_ulong(128)
+	)
+	defer C.free(data)
+	defer C.free(unsafe.Pointer(sig))
+
+	if rv := C.ck_sign(s.m, s.handle, objects[0], (*C.uchar)(data), C.ck_ulong(len(hash)), sig, &siglen); rv != ckrOK {
+		return nil, ckError(rv)
+	}
+	return C.GoBytes(unsafe.Pointer(sig), C.int(siglen)), nil
+}
+
+// find searches for the elliptic curve key objects of the given class, with the
+// given ID if set.
+func (s *cgoSession) find(class uint, id []byte) ([]C.ck_ulong, error) {
+	var tmpl template
+	defer tmpl.free()
+
+	tmpl.addUlong(ckaClass, class)
+	tmpl.addUlong(ckaKeyType, ckkEC)
+	if id != nil {
+		tmpl.addBytes(ckaID, id)
+	}
+	if rv := C.ck_find_objects_init(s.m, s.handle, &tmpl[0], C.ck_ulong(len(tmpl))); rv != ckrOK {
+		return nil, ckError(rv)
+	}
+	defer C.ck_find_objects_final(s.m, s.handle)
+
+	var (
+		objects []C.ck_ulong
+		batch   [16]C.ck_ulong
+	)
+	for {
+		var count C.ck_ulong
+		if rv := C.ck_find_objects(s.m, s.handle, &batch[0], C.ck_ulong(len(batch)), &count); rv != ckrOK {
+			return nil, ckError(rv)
+		}
+		if count == 0 {
+			return objects, nil
+		}
+		objects = append(objects, batch[:count]...)
+	}
+}
+
+// attribute retrieves the value of an attribute of an object, returning nil if
+// the attribute is not available.
+func (s *cgoSession) attribute(object C.ck_ulong, typ uint) ([]byte, error) {
+	attr := C.ck_attribute{_type: C.ck_ulong(typ)}
+	if rv := C.ck_get_attribute_value(s.m, s.handle, object, &attr); rv != ckrOK {
+		return nil, nil // Sensitive or invalid attribute
+	}
+	if attr.len == ckUnavailableInformation || attr.len == 0 {
+		return nil, nil
+	}
+	attr.value = C.malloc(C.size_t(attr.len))
+	defer C.free(attr.value)
+
+	if rv := C.ck_get_attribute_value(s.m, s.handle, object, &attr); rv != ckrOK {
+		return nil, ckError(rv)
+	}
+	return C.GoBytes(attr.value, C.int(attr.len)), nil
+}
+
+// template is a search template whose attribute values are allocated in C memory.
+type template []C.ck_attribute
+
+func (t *template) addUlong(typ uint, value uint) {
+	ptr := (*C.ck_ulong)(C.malloc(C.sizeof_ck_ulong))
+	*ptr = C.ck_ulong(value)
+	*t = append(*t, C.ck_attribute{_type: C.ck_ulong(typ), value: unsafe.Pointer(ptr), len: C.sizeof_ck_ulong})
+}
+
+func (t *template) addBytes(typ uint, value []byte) {
+	*t = append(*t, C.ck_attribute{_type: C.ck_ulong(typ), value: C.CBytes(value), len: C.ck_ulong(len(value))})
+}
+
+func (t *template) free() {
+	for _, attr := range *t {
+		C.free(attr.value)
+	}
+}
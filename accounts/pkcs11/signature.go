@@ -0,0 +1,100 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pkcs11
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	// ecParamsSecp256k1 is the DER encoding of the secp256k1 curve OID 1.3.132.0.10,
+	// as stored in the CKA_EC_PARAMS attribute of keys on the curve.
+	ecParamsSecp256k1 = []byte{0x06, 0x05, 0x2b, 0x81, 0x04, 0x00, 0x0a}
+
+	secp256k1N     = crypto.S256().Params().N
+	secp256k1HalfN = new(big.Int).Rsh(secp256k1N, 1)
+
+	errInvalidSignature = errors.New("invalid signature from token")
+)
+
+// parseECPoint decodes the CKA_EC_POINT attribute of a secp256k1 public key. The
+// specification mandates a DER encoded octet string, but some tokens return the
+// raw uncompressed point instead.
+func parseECPoint(point []byte) (*ecdsa.PublicKey, error) {
+	if len(point) != 65 || point[0] != 0x04 {
+		var raw []byte
+		if rest, err := asn1.Unmarshal(point, &raw); err != nil {
+			return nil, fmt.Errorf("invalid EC point: %v", err)
+		} else if len(rest) > 0 {
+			return nil, errors.New("invalid EC point: trailing data")
+		}
+		point = raw
+	}
+	return crypto.UnmarshalPubkey(point)
+}
+
+// parseSignature decodes the ECDSA signature produced by a token, either in the
+// raw r || s format mandated by the specification or DER encoded.
+func parseSignature(sig []byte) (*big.Int, *big.Int, error) {
+	if len(sig) == 64 {
+		return new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:]), nil
+	}
+	var der struct {
+		R, S *big.Int
+	}
+	if rest, err := asn1.Unmarshal(sig, &der); err != nil || len(rest) > 0 {
+		return nil, nil, errInvalidSignature
+	}
+	return der.R, der.S, nil
+}
+
+// recoverableSignature converts the ECDSA signature of the hash made by the
+// token into the [R || S || V] format used by Ethereum. The S value is normalized
+// into the lower half of the curve order, as required since Homestead, and the
+// recovery id is found by trial recovery against the public key.
+func recoverableSignature(hash []byte, sig []byte, pubkey *ecdsa.PublicKey) ([]byte, error) {
+	r, s, err := parseSignature(sig)
+	if err != nil {
+		return nil, err
+	}
+	if r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(secp256k1N) >= 0 || s.Cmp(secp256k1N) >= 0 {
+		return nil, errInvalidSignature
+	}
+	if s.Cmp(secp256k1HalfN) > 0 {
+		s = new(big.Int).Sub(secp256k1N, s)
+	}
+	signature := make([]byte, crypto.SignatureLength)
+	math.ReadBits(r, signature[:32])
+	math.ReadBits(s, signature[32:64])
+
+	want := crypto.FromECDSAPub(pubkey)
+	for v := byte(0); v < 2; v++ {
+		signature[crypto.RecoveryIDOffset] = v
+		if have, err := crypto.Ecrecover(hash, signature); err == nil && bytes.Equal(have, want) {
+			return signature, nil
+		}
+	}
+	return nil, errInvalidSignature
+}
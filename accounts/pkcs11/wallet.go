@@ -0,0 +1,297 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pkcs11
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ErrPINNeeded is returned if opening the wallet requires a PIN.
+var ErrPINNeeded = accounts.NewAuthNeededError("token PIN")
+
+// key is a secp256k1 key pair stored on a token.
+type key struct {
+	id     []byte           // CKA_ID of the key objects
+	pubkey *ecdsa.PublicKey // Public key of the pair
+}
+
+// wallet represents a PKCS#11 token, exposing its secp256k1 keys as accounts.
+type wallet struct {
+	hub   *Hub         // PKCS#11 hub the token was found through
+	url   accounts.URL // Textual URL uniquely identifying this wallet
+	token token        // Description of the token in its slot
+
+	session  session                 // Authenticated session while the wallet is open
+	accounts []accounts.Account      // List of accounts of the keys on the token
+	keys     map[common.Address]*key // Key pairs backing the accounts
+	log      log.Logger              // Contextual logger to tag the token with its URL
+
+	// lock serializes access to the token, as PKCS#11 sessions are not safe for
+	// concurrent use, and protects the fields above.
+	lock sync.Mutex
+}
+
+// newWallet creates a wallet for the given token, listing the keys visible
+// without authentication.
+func newWallet(hub *Hub, url accounts.URL, tok token) *wallet {
+	w := &wallet{
+		hub:   hub,
+		url:   url,
+		token: tok,
+		keys:  make(map[common.Address]*key),
+		log:   log.New("url", url),
+	}
+	sess, err := hub.module.open(tok.slot)
+	if err != nil {
+		w.log.Debug("Failed to open PKCS#11 session", "err", err)
+		return w
+	}
+	defer sess.close()
+
+	if err := w.loadKeys(sess); err != nil {
+		w.log.Debug("Failed to list public PKCS#11 keys", "err", err)
+	}
+	return w
+}
+
+// loadKeys lists the secp256k1 keys visible to the session, tracking them as the
+// accounts of the wallet. The lock must be held by the caller, unless the wallet
+// is not yet shared.
+func (w *wallet) loadKeys(sess session) error {
+	objects, err := sess.publicKeys()
+	if err != nil {
+		return err
+	}
+	for _, object := range objects {
+		if !bytes.Equal(object.params, ecParamsSecp256k1) {
+			continue // Not a secp256k1 key
+		}
+		if len(object.id) == 0 {
+			w.log.Warn("Ignoring PKCS#11 key without ID", "label", string(object.label))
+			continue
+		}
+		pubkey, err := parseECPoint(object.point)
+		if err != nil {
+			w.log.Warn("Ignoring invalid PKCS#11 key", "label", string(object.label), "err", err)
+			continue
+		}
+		address := crypto.PubkeyToAddress(*pubkey)
+		if _, ok := w.keys[address]; ok {
+			continue
+		}
+		w.keys[address] = &key{id: object.id, pubkey: pubkey}
+		w.accounts = append(w.accounts, accounts.Account{
+			Address: address,
+			URL:     accounts.URL{Scheme: w.url.Scheme, Path: fmt.Sprintf("%s/%x", w.url.Path, object.id)},
+		})
+	}
+	return nil
+}
+
+// URL implements accounts.Wallet, returning the URL of the PKCS#11 token.
+func (w *wallet) URL() accounts.URL {
+	return w.url
+}
+
+// Status implements accounts.Wallet, returning whether the wallet is logged into
+// the token.
+func (w *wallet) Status() (string, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.session != nil {
+		return fmt.Sprintf("Logged in (%s)", w.token.model), nil
+	}
+	return fmt.Sprintf("Locked (%s)", w.token.model), nil
+}
+
+// Open implements accounts.Wallet, logging into the token with the given PIN and
+// keeping the session open until the wallet is closed. An empty PIN is only
+// accepted by tokens with a protected authentication path, such as a PIN pad.
+func (w *wallet) Open(pin string) error {
+	w.lock.Lock()
+	if w.session != nil {
+		w.lock.Unlock()
+		return accounts.ErrWalletAlreadyOpen
+	}
+	// Don't waste login attempts, tokens lock up after a few failures
+	if pin == "" && w.token.flags&ckfProtectedAuthenticationPath == 0 {
+		w.lock.Unlock()
+		return ErrPINNeeded
+	}
+	sess, err := w.login(pin)
+	if err != nil {
+		w.lock.Unlock()
+		return err
+	}
+	if err := w.loadKeys(sess); err != nil {
+		w.log.Warn("Failed to list PKCS#11 keys", "err", err)
+	}
+	w.session = sess
+	w.lock.Unlock()
+
+	// Notify anyone listening for wallet events that the token is accessible
+	go w.hub.updateFeed.Send(accounts.WalletEvent{Wallet: w, Kind: accounts.WalletOpened})
+	return nil
+}
+
+// login opens a new session with the token and authenticates it with the PIN.
+func (w *wallet) login(pin string) (session, error) {
+	sess, err := w.hub.module.open(w.token.slot)
+	if err != nil {
+		return nil, err
+	}
+	if err := sess.login(pin); err != nil {
+		sess.close()
+		return nil, err
+	}
+	return sess, nil
+}
+
+// Close implements accounts.Wallet, logging out of the token.
+func (w *wallet) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.session == nil {
+		return nil
+	}
+	w.session.logout()
+	err := w.session.close()
+	w.session = nil
+	return err
+}
+
+// Accounts implements accounts.Wallet, returning the list of accounts of the
+// secp256k1 keys on the token.
+func (w *wallet) Accounts() []accounts.Account {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	cpy := make([]accounts.Account, len(w.accounts))
+	copy(cpy, w.accounts)
+	return cpy
+}
+
+// Contains implements accounts.Wallet, returning whether a particular account is
+// or is not backed by a key on this token.
+func (w *wallet) Contains(account accounts.Account) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	_, ok := w.keys[account.Address]
+	return ok
+}
+
+// Derive implements accounts.Wallet, but is a noop for PKCS#11 tokens since keys
+// are generated and managed on the token itself.
+func (w *wallet) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	return accounts.Account{}, accounts.ErrNotSupported
+}
+
+// SelfDerive implements accounts.Wallet, but is a noop for PKCS#11 tokens since
+// there is no notion of hierarchical account derivation for them.
+func (w *wallet) SelfDerive(bases []accounts.DerivationPath, chain ethereum.ChainStateReader) {
+}
+
+// signHash signs the hash with the key of the given account. If the wallet is
+// open, its session is used, otherwise a temporary one is authenticated with the
+// PIN if given.
+func (w *wallet) signHash(account accounts.Account, pin *string, hash []byte) ([]byte, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	key, ok := w.keys[account.Address]
+	if !ok {
+		return nil, accounts.ErrUnknownAccount
+	}
+	sess := w.session
+	if sess == nil {
+		if pin == nil {
+			return nil, accounts.ErrWalletClosed
+		}
+		var err error
+		if sess, err = w.login(*pin); err != nil {
+			return nil, err
+		}
+		defer sess.close()
+		defer sess.logout()
+	}
+	sig, err := sess.sign(key.id, hash)
+	if err != nil {
+		return nil, err
+	}
+	return recoverableSignature(hash, sig, key.pubkey)
+}
+
+// SignData signs keccak256(data). The mimetype parameter describes the type of
+// data being signed, for EIP-712 typed data the data is the encoded message.
+func (w *wallet) SignData(account accounts.Account, mimeType string, data []byte) ([]byte, error) {
+	return w.signHash(account, nil, crypto.Keccak256(data))
+}
+
+// SignDataWithPassphrase signs keccak256(data), using the passphrase as the PIN
+// of the token if the wallet is not open.
+func (w *wallet) SignDataWithPassphrase(account accounts.Account, passphrase, mimeType string, data []byte) ([]byte, error) {
+	return w.signHash(account, &passphrase, crypto.Keccak256(data))
+}
+
+// SignText implements accounts.Wallet, attempting to sign the hash of the given
+// text with the given account.
+func (w *wallet) SignText(account accounts.Account, text []byte) ([]byte, error) {
+	return w.signHash(account, nil, accounts.TextHash(text))
+}
+
+// SignTextWithPassphrase implements accounts.Wallet, attempting to sign the hash
+// of the given text with the given account, using the passphrase as the PIN of
+// the token if the wallet is not open.
+func (w *wallet) SignTextWithPassphrase(account accounts.Account, passphrase string, text []byte) ([]byte, error) {
+	return w.signHash(account, &passphrase, accounts.TextHash(text))
+}
+
+// SignTx implements accounts.Wallet, attempting to sign the given transaction
+// with the given account.
+func (w *wallet) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return w.signTx(account, nil, tx, chainID)
+}
+
+// SignTxWithPassphrase implements accounts.Wallet, attempting to sign the given
+// transaction with the given account, using the passphrase as the PIN of the
+// token if the wallet is not open.
+func (w *wallet) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return w.signTx(account, &passphrase, tx, chainID)
+}
+
+func (w *wallet) signTx(account accounts.Account, pin *string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	signer := types.LatestSignerForChainID(chainID)
+	sig, err := w.signHash(account, pin, signer.Hash(tx).Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithSignature(signer, sig)
+}
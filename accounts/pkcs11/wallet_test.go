@@ -0,0 +1,249 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pkcs11
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// fakeModule is an in-memory PKCS#11 module with a single token.
+type fakeModule struct {
+	tok    token
+	pin    string
+	keys   []*fakeKey
+	logins int // Number of login attempts made
+}
+
+type fakeKey struct {
+	object  keyObject
+	private *ecdsa.PrivateKey
+	der     bool // Whether to return DER encoded signatures
+}
+
+func (m *fakeModule) tokens() ([]token, error) {
+	return []token{m.tok}, nil
+}
+
+func (m *fakeModule) open(slot uint) (session, error) {
+	return &fakeSession{module: m}, nil
+}
+
+type fakeSession struct {
+	module   *fakeModule
+	loggedIn bool
+}
+
+func (s *fakeSession) login(pin string) error {
+	s.module.logins++
+	if pin != s.module.pin {
+		return ckError(ckrPinIncorrect)
+	}
+	s.loggedIn = true
+	return nil
+}
+
+func (s *fakeSession) logout() error {
+	s.loggedIn = false
+	return nil
+}
+
+func (s *fakeSession) publicKeys() ([]keyObject, error) {
+	objects := make([]keyObject, len(s.module.keys))
+	for i, key := range s.module.keys {
+		objects[i] = key.object
+	}
+	return objects, nil
+}
+
+// sign signs the hash with the key of the given ID, always returning the high-S
+// variant of the signature, as is common for HSMs.
+func (s *fakeSession) sign(id []byte, hash []byte) ([]byte, error) {
+	if !s.loggedIn {
+		return nil, ckError(ckrUserNotLoggedIn)
+	}
+	for _, key := range s.module.keys {
+		if !bytes.Equal(key.object.id, id) {
+			continue
+		}
+		sig, err := crypto.Sign(hash, key.private)
+		if err != nil {
+			return nil, err
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:64])
+		if s.Cmp(secp256k1HalfN) <= 0 {
+			s.Sub(secp256k1N, s)
+		}
+		if key.der {
+			return asn1.Marshal(struct{ R, S *big.Int }{r, s})
+		}
+		raw := make([]byte, 64)
+		r.FillBytes(raw[:32])
+		s.FillBytes(raw[32:])
+		return raw, nil
+	}
+	return nil, ckError(0x60)
+}
+
+func (s *fakeSession) close() error {
+	return nil
+}
+
+// newFakeModule creates a module with a token holding two secp256k1 keys, one
+// encoding its point as DER and signing in DER, and a P-256 key to be ignored.
+func newFakeModule(t *testing.T) *fakeModule {
+	m := &fakeModule{
+		tok: token{slot: 3, label: "test", model: "fake", serial: "0123456789", flags: ckfTokenInitialized},
+		pin: "1234",
+	}
+	for i := 0; i < 2; i++ {
+		priv, _ := crypto.GenerateKey()
+		point := crypto.FromECDSAPub(&priv.PublicKey)
+		if i == 1 {
+			point, _ = asn1.Marshal(point)
+		}
+		m.keys = append(m.keys, &fakeKey{
+			object:  keyObject{id: []byte{byte(i + 1)}, params: ecParamsSecp256k1, point: point},
+			private: priv,
+			der:     i == 1,
+		})
+	}
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.keys = append(m.keys, &fakeKey{
+		object: keyObject{
+			id:     []byte{3},
+			params: []byte{0x06, 0x08, 0x2a, 0x86, 0x48, 0xce, 0x3d, 0x03, 0x01, 0x07},
+			point:  elliptic.Marshal(elliptic.P256(), p256.X, p256.Y),
+		},
+	})
+	return m
+}
+
+func TestWalletAccounts(t *testing.T) {
+	mod := newFakeModule(t)
+	hub := newHub(mod)
+
+	wallets := hub.Wallets()
+	if len(wallets) != 1 {
+		t.Fatalf("wallet count mismatch: have %d, want 1", len(wallets))
+	}
+	wallet := wallets[0]
+	if want := "pkcs11://0123456789"; wallet.URL().String() != want {
+		t.Errorf("wallet URL mismatch: have %v, want %v", wallet.URL(), want)
+	}
+	accs := wallet.Accounts()
+	if len(accs) != 2 {
+		t.Fatalf("account count mismatch: have %d, want 2", len(accs))
+	}
+	for i, acc := range accs {
+		if want := crypto.PubkeyToAddress(mod.keys[i].private.PublicKey); acc.Address != want {
+			t.Errorf("account %d mismatch: have %v, want %v", i, acc.Address, want)
+		}
+	}
+	// Opening without a PIN must not waste a login attempt
+	if err := wallet.Open(""); err != ErrPINNeeded {
+		t.Errorf("open without PIN error mismatch: have %v, want %v", err, ErrPINNeeded)
+	}
+	if err := wallet.Open("0000"); err == nil {
+		t.Error("opened wallet with wrong PIN")
+	}
+	if mod.logins != 1 {
+		t.Errorf("login attempt count mismatch: have %d, want 1", mod.logins)
+	}
+}
+
+func TestWalletSign(t *testing.T) {
+	var (
+		mod     = newFakeModule(t)
+		wallet  = newHub(mod).Wallets()[0]
+		accs    = wallet.Accounts()
+		chainID = big.NewInt(1337)
+		tx      = types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Nonce: 1, Gas: 21000, GasFeeCap: big.NewInt(1), GasTipCap: big.NewInt(1), To: &common.Address{}})
+	)
+	// Signing on a closed wallet requires a PIN
+	if _, err := wallet.SignTx(accs[0], tx, chainID); err != accounts.ErrWalletClosed {
+		t.Errorf("closed wallet signing error mismatch: have %v, want %v", err, accounts.ErrWalletClosed)
+	}
+	if _, err := wallet.SignTxWithPassphrase(accs[0], "0000", tx, chainID); err == nil {
+		t.Error("signed with wrong PIN")
+	}
+	for i, acc := range accs {
+		signed, err := wallet.SignTxWithPassphrase(acc, "1234", tx, chainID)
+		if err != nil {
+			t.Fatalf("account %d: failed to sign transaction: %v", i, err)
+		}
+		if from, err := types.Sender(types.LatestSignerForChainID(chainID), signed); err != nil || from != acc.Address {
+			t.Errorf("account %d: sender mismatch: have %v (%v), want %v", i, from, err, acc.Address)
+		}
+		if _, _, s := signed.RawSignatureValues(); s.Cmp(secp256k1HalfN) > 0 {
+			t.Errorf("account %d: signature not normalized to low S", i)
+		}
+	}
+	// Once open, signing must work without a PIN
+	if err := wallet.Open("1234"); err != nil {
+		t.Fatalf("failed to open wallet: %v", err)
+	}
+	defer wallet.Close()
+
+	typed := append([]byte{0x19, 0x01}, make([]byte, 64)...)
+	for i, acc := range accs {
+		sig, err := wallet.SignData(acc, accounts.MimetypeTypedData, typed)
+		if err != nil {
+			t.Fatalf("account %d: failed to sign typed data: %v", i, err)
+		}
+		pubkey, err := crypto.SigToPub(crypto.Keccak256(typed), sig)
+		if err != nil || crypto.PubkeyToAddress(*pubkey) != acc.Address {
+			t.Errorf("account %d: typed data signer mismatch", i)
+		}
+		if !crypto.ValidateSignatureValues(sig[64], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64]), true) {
+			t.Errorf("account %d: invalid typed data signature values", i)
+		}
+	}
+}
+
+func TestParseECPoint(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	raw := crypto.FromECDSAPub(&key.PublicKey)
+	der, _ := asn1.Marshal(raw)
+
+	for _, point := range [][]byte{raw, der} {
+		pubkey, err := parseECPoint(point)
+		if err != nil {
+			t.Fatalf("failed to parse point %x: %v", point, err)
+		}
+		if !pubkey.Equal(&key.PublicKey) {
+			t.Errorf("parsed point mismatch for %x", point)
+		}
+	}
+	if _, err := parseECPoint(append(der, 0x00)); err == nil {
+		t.Error("parsed point with trailing data")
+	}
+}
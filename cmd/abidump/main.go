@@ -17,22 +17,40 @@
 package main
 
 import (
+	"context"
 	"encoding/hex"
 	"flag"
 	"fmt"
 	"os"
 	"strings"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/ethclient/decoder"
 	"github.com/ethereum/go-ethereum/signer/core/apitypes"
 	"github.com/ethereum/go-ethereum/signer/fourbyte"
 )
 
+var (
+	rpcFlag  = flag.String("rpc", "", "RPC endpoint to retrieve transactions from, enables decoding transaction hashes")
+	abiFlags []string
+)
+
 func init() {
+	flag.Func("abi", "ABI file or directory of ABI files to decode with, named by contract address to bind them to it (may be repeated)", func(path string) error {
+		abiFlags = append(abiFlags, path)
+		return nil
+	})
 	flag.Usage = func() {
-		fmt.Fprintln(os.Stderr, "Usage:", os.Args[0], "<hexdata>")
+		fmt.Fprintln(os.Stderr, "Usage:", os.Args[0], "[options] <hexdata|txhash>")
 		flag.PrintDefaults()
 		fmt.Fprintln(os.Stderr, `
-Parses the given ABI data and tries to interpret it from the fourbyte database.`)
+Parses the given ABI data and tries to interpret it from the fourbyte database.
+
+If an RPC endpoint is given, a transaction hash can be provided instead, in which
+case the transaction is retrieved and its call, events and revert reason are
+decoded using the given ABIs and the fourbyte database.`)
 	}
 }
 
@@ -48,12 +66,88 @@ func parse(data []byte) {
 	}
 }
 
+// dumpTransaction retrieves a transaction via RPC and prints it decoded.
+func dumpTransaction(url string, hash common.Hash) {
+	registry := decoder.NewRegistry()
+	for _, path := range abiFlags {
+		info, err := os.Stat(path)
+		if err != nil {
+			die(err)
+		}
+		if info.IsDir() {
+			err = registry.LoadDir(path)
+		} else {
+			err = registry.LoadFile(path)
+		}
+		if err != nil {
+			die(err)
+		}
+	}
+	db, err := fourbyte.New()
+	if err != nil {
+		die(err)
+	}
+	registry.SetSelectorDatabase(db)
+
+	client, err := ethclient.Dial(url)
+	if err != nil {
+		die(err)
+	}
+	defer client.Close()
+
+	res, err := decoder.New(client, registry).Transaction(context.Background(), hash)
+	if err != nil {
+		die(err)
+	}
+	fmt.Printf("Transaction %v\n", res.Tx.Hash())
+	fmt.Printf("  from:   %v\n", res.From)
+	if to := res.Tx.To(); to != nil {
+		fmt.Printf("  to:     %v\n", *to)
+	} else {
+		fmt.Printf("  to:     <contract creation>\n")
+	}
+	fmt.Printf("  value:  %v wei\n", res.Tx.Value())
+	switch {
+	case res.Call != nil && res.Call.Guessed:
+		fmt.Printf("  call:   %v (guessed from selector)\n", res.Call)
+	case res.Call != nil:
+		fmt.Printf("  call:   %v\n", res.Call)
+	case res.CallErr != nil && len(res.Tx.Data()) > 0:
+		fmt.Printf("  call:   <%v>\n", res.CallErr)
+	}
+	if res.Receipt == nil {
+		fmt.Printf("  status: pending\n")
+		return
+	}
+	if res.Receipt.Status == types.ReceiptStatusSuccessful {
+		fmt.Printf("  status: success (block %v)\n", res.Receipt.BlockNumber)
+	} else {
+		fmt.Printf("  status: failed (block %v)\n", res.Receipt.BlockNumber)
+		if res.Revert != nil {
+			fmt.Printf("  revert: %v\n", res.Revert)
+		}
+	}
+	if len(res.Events) > 0 {
+		fmt.Printf("  logs:\n")
+	}
+	for i, event := range res.Events {
+		if event != nil {
+			fmt.Printf("    %d. %v %v\n", i, res.Receipt.Logs[i].Address, event)
+		} else {
+			fmt.Printf("    %d. %v <%v>\n", i, res.Receipt.Logs[i].Address, res.EventErrs[i])
+		}
+	}
+}
+
 // Example
 // ./abidump a9059cbb000000000000000000000000ea0e2dc7d65a50e77fc7e84bff3fd2a9e781ff5c0000000000000000000000000000000000000000000000015af1d78b58c40000
+// ./abidump --rpc http://localhost:8545 --abi ./abis 0x5c504ed432cb51138bcf09aa5e8a410dd4a1e204ef84bfed1be16dfba1b22060
 func main() {
 	flag.Parse()
 
 	switch {
+	case flag.NArg() == 1 && *rpcFlag != "" && len(strings.TrimPrefix(flag.Arg(0), "0x")) == 2*common.HashLength:
+		dumpTransaction(*rpcFlag, common.HexToHash(flag.Arg(0)))
 	case flag.NArg() == 1:
 		hexdata := flag.Arg(0)
 		data, err := hex.DecodeString(strings.TrimPrefix(hexdata, "0x"))
@@ -27,7 +27,6 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/common/compiler"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/internal/flags"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/urfave/cli/v2"
@@ -72,6 +71,10 @@ var (
 		Name:  "alias",
 		Usage: "Comma separated aliases for function and event renaming, e.g. original1=alias1, original2=alias2",
 	}
+	v2Flag = &cli.BoolFlag{
+		Name:  "v2",
+		Usage: "Generate v2 bindings with stateless pack/unpack helpers",
+	}
 )
 
 var app = flags.NewApp("Ethereum ABI wrapper code generator")
@@ -88,6 +91,7 @@ func init() {
 		outFlag,
 		langFlag,
 		aliasFlag,
+		v2Flag,
 	}
 	app.Action = abigen
 }
@@ -111,6 +115,7 @@ func abigen(c *cli.Context) error {
 		bins    []string
 		types   []string
 		sigs    []map[string]string
+		ids     []string
 		libs    = make(map[string]string)
 		aliases = make(map[string]string)
 	)
@@ -147,6 +152,7 @@ func abigen(c *cli.Context) error {
 			kind = c.String(pkgFlag.Name)
 		}
 		types = append(types, kind)
+		ids = append(ids, bind.LibraryPattern(kind))
 	} else {
 		// Generate the list of types to exclude from binding
 		var exclude *nameFilter
@@ -199,8 +205,9 @@ func abigen(c *cli.Context) error {
 			// hex encoding of the keccak256 hash of the fully qualified library name.
 			// Note that the fully qualified library name is the path of its source
 			// file and the library name separated by ":".
-			libPattern := crypto.Keccak256Hash([]byte(name)).String()[2:36] // the first 2 chars are 0x
+			libPattern := bind.LibraryPattern(name)
 			libs[libPattern] = typeName
+			ids = append(ids, libPattern)
 		}
 	}
 	// Extract all aliases from the flags
@@ -216,7 +223,15 @@ func abigen(c *cli.Context) error {
 		}
 	}
 	// Generate the contract binding
-	code, err := bind.Bind(types, abis, bins, sigs, c.String(pkgFlag.Name), lang, libs, aliases)
+	var (
+		code string
+		err  error
+	)
+	if c.Bool(v2Flag.Name) {
+		code, err = bind.BindV2(types, abis, bins, ids, c.String(pkgFlag.Name), libs, aliases)
+	} else {
+		code, err = bind.Bind(types, abis, bins, sigs, c.String(pkgFlag.Name), lang, libs, aliases)
+	}
 	if err != nil {
 		utils.Fatalf("Failed to generate ABI binding: %v", err)
 	}
@@ -0,0 +1,151 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/signer/core"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	auditSignerFlag = &cli.StringFlag{
+		Name:  "signer",
+		Usage: "Address of the key expected to sign every session of the audit log",
+	}
+	auditCommand = &cli.Command{
+		Name:  "audit",
+		Usage: "Inspect the tamper-evident audit log",
+		Subcommands: []*cli.Command{
+			auditVerifyCommand,
+		},
+	}
+	auditVerifyCommand = &cli.Command{
+		Action:    verifyAudit,
+		Name:      "verify",
+		Usage:     "Verify the integrity of an audit log and print its history",
+		ArgsUsage: "<audit log>",
+		Flags: []cli.Flag{
+			logLevelFlag,
+			auditSignerFlag,
+		},
+		Description: `
+The audit verify command checks that the entries of a Clef audit log form an
+unbroken hash chain and that the signatures within were made by the key announced
+at the start of each session, printing the verified history.
+
+The signing key of the audit log is derived from the master seed, and its address
+is printed by Clef on startup. Pass it via --signer to reject sessions signed by
+any other key, or not signed at all.
+
+Note, entries appended after the last signature can be removed or rewritten
+without detection.`,
+	}
+)
+
+func verifyAudit(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("expected audit log, got %d arguments", c.NArg())
+	}
+	var signer *common.Address
+	if hex := c.String(auditSignerFlag.Name); hex != "" {
+		if !common.IsHexAddress(hex) {
+			return fmt.Errorf("invalid signer address %q", hex)
+		}
+		addr := common.HexToAddress(hex)
+		signer = &addr
+	}
+	f, err := os.Open(c.Args().First())
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	entries, verr := core.VerifyAuditLog(f, signer)
+
+	var (
+		sessions int
+		signed   = -1
+	)
+	for i, entry := range entries {
+		if entry.Method == core.AuditStartup {
+			sessions++
+		}
+		mark := " "
+		if entry.Signature != nil {
+			mark, signed = "✓", i
+		}
+		fmt.Printf("%s %6d  %s  %-16s %-8s %s\n", mark, entry.Seq, entry.Time, entry.Method, entry.Decision, summarizeAuditEntry(entry))
+	}
+	fmt.Println()
+	if verr != nil {
+		return fmt.Errorf("audit log verification failed at %v", verr)
+	}
+	fmt.Printf("Verified %d entries in %d sessions\n", len(entries), sessions)
+	if unsigned := len(entries) - signed - 1; unsigned > 0 {
+		fmt.Printf("WARNING: the last %d entries are not covered by a signature\n", unsigned)
+	}
+	return nil
+}
+
+// summarizeAuditEntry returns a one-line description of the request recorded
+// in the audit log entry.
+func summarizeAuditEntry(entry *core.AuditEntry) string {
+	if entry.Error != "" {
+		return "error: " + entry.Error
+	}
+	switch entry.Method {
+	case core.AuditStartup:
+		if entry.Signer != nil {
+			return fmt.Sprintf("signer %v", *entry.Signer)
+		}
+		return "unsigned session"
+
+	case "SignTransaction":
+		var req struct {
+			Transaction apitypes.SendTxArgs `json:"transaction"`
+		}
+		if err := json.Unmarshal(entry.Request, &req); err != nil {
+			return "malformed request"
+		}
+		tx := req.Transaction
+		to := "contract creation"
+		if tx.To != nil {
+			to = tx.To.Address().Hex()
+		}
+		return fmt.Sprintf("%v -> %s value %v nonce %d", tx.From.Address(), to, tx.Value.ToInt(), tx.Nonce)
+
+	case "SignData", "SignTypedData", "SignGnosisSafeTx":
+		var req struct {
+			Address     common.MixedcaseAddress `json:"address"`
+			ContentType string                  `json:"contentType"`
+		}
+		if err := json.Unmarshal(entry.Request, &req); err != nil {
+			return "malformed request"
+		}
+		if req.ContentType != "" {
+			return fmt.Sprintf("%v %s", req.Address.Address(), req.ContentType)
+		}
+		return req.Address.Address().Hex()
+	}
+	return ""
+}
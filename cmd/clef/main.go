@@ -18,7 +18,9 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"crypto/ecdsa"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
@@ -52,6 +54,7 @@ import (
 	"github.com/ethereum/go-ethereum/signer/core"
 	"github.com/ethereum/go-ethereum/signer/core/apitypes"
 	"github.com/ethereum/go-ethereum/signer/fourbyte"
+	"github.com/ethereum/go-ethereum/signer/policy"
 	"github.com/ethereum/go-ethereum/signer/rules"
 	"github.com/ethereum/go-ethereum/signer/storage"
 	"github.com/mattn/go-colorable"
@@ -126,6 +129,10 @@ var (
 		Name:  "rules",
 		Usage: "Path to the rule file to auto-authorize requests with",
 	}
+	policyFlag = &cli.StringFlag{
+		Name:  "policy",
+		Usage: "Path to the declarative policy file (YAML or JSON) to auto-authorize requests with",
+	}
 	stdiouiFlag = &cli.BoolFlag{
 		Name: "stdio-ui",
 		Usage: "Use STDIN/STDOUT as a channel for an external UI. " +
@@ -160,8 +167,8 @@ the rule-engine to work.`,
 			signerSecretFlag,
 		},
 		Description: `
-The attest command stores the sha256 of the rule.js-file that you want to use for automatic processing of
-incoming requests.
+The attest command stores the sha256 of the rule.js-file (or policy file) that you want to use for
+automatic processing of incoming requests.
 
 Whenever you make an edit to the rule file, you need to use attestation to tell
 Clef that the file is 'safe' to execute.`,
@@ -256,6 +263,38 @@ Imports an unencrypted private key from <keyfile> and creates a new account.
 Prints the address.
 The keyfile is assumed to contain an unencrypted private key in hexadecimal format.
 The account is saved in encrypted format, you are prompted for a password.
+`}
+	importMnemonicCommand = &cli.Command{
+		Action: importMnemonic,
+		Name:   "import-mnemonic",
+		Usage:  "Import a BIP-39 mnemonic into a new HD wallet.",
+		Flags: []cli.Flag{
+			logLevelFlag,
+			keystoreFlag,
+			utils.LightKDFFlag,
+			acceptFlag,
+		},
+		Description: `
+Imports a BIP-39 mnemonic as a hierarchical deterministic wallet and prints the
+address of its first account, derived at m/44'/60'/0'/0/0.
+The seed is saved in encrypted format, you are prompted for the mnemonic, an
+optional BIP-39 passphrase and a password. Opening the wallet derives further
+accounts with the same paths hardware wallets use.
+`}
+	exportMnemonicCommand = &cli.Command{
+		Action:    exportMnemonic,
+		Name:      "export-mnemonic",
+		Usage:     "Print the BIP-39 mnemonic of an HD wallet.",
+		ArgsUsage: "<address>",
+		Flags: []cli.Flag{
+			logLevelFlag,
+			keystoreFlag,
+			acceptFlag,
+		},
+		Description: `
+Decrypts and prints the mnemonic of the HD wallet containing the given account,
+after asking for confirmation. Anyone who learns the mnemonic controls every
+account of the wallet.
 `}
 )
 
@@ -271,6 +310,7 @@ func init() {
 		utils.LightKDFFlag,
 		utils.NoUSBFlag,
 		utils.SmartCardDaemonPathFlag,
+		utils.PKCS11ModuleFlag,
 		utils.HTTPListenAddrFlag,
 		utils.HTTPVirtualHostsFlag,
 		utils.IPCDisabledFlag,
@@ -281,6 +321,8 @@ func init() {
 		customDBFlag,
 		auditLogFlag,
 		ruleFlag,
+		policyFlag,
+		safeRPCFlag,
 		stdiouiFlag,
 		testFlag,
 		advancedMode,
@@ -293,9 +335,13 @@ func init() {
 		delCredentialCommand,
 		newAccountCommand,
 		importRawCommand,
+		importMnemonicCommand,
+		exportMnemonicCommand,
 		gendocCommand,
 		listAccountsCommand,
 		listWalletsCommand,
+		policyCommand,
+		auditCommand,
 	}
 }
 
@@ -408,7 +454,7 @@ func initInternalApi(c *cli.Context) (*core.UIServerAPI, core.UIClientAPI, error
 		ksLoc                     = c.String(keystoreFlag.Name)
 		lightKdf                  = c.Bool(utils.LightKDFFlag.Name)
 	)
-	am := core.StartClefAccountManager(ksLoc, true, lightKdf, "")
+	am := core.StartClefAccountManager(ksLoc, true, lightKdf, "", "")
 	api := core.NewSignerAPI(am, 0, true, ui, nil, false, pwStorage)
 	internalApi := core.NewUIServerAPI(api)
 	return internalApi, ui, nil
@@ -601,6 +647,92 @@ Make sure to backup keystore and passwords in a safe location.`,
 	return nil
 }
 
+// importMnemonic imports a BIP-39 mnemonic as an HD wallet via CLI.
+func importMnemonic(c *cli.Context) error {
+	internalApi, ui, err := initInternalApi(c)
+	if err != nil {
+		return err
+	}
+	readInput := func(title, prompt string) (string, error) {
+		resp, err := ui.OnInputRequired(core.UserInputRequest{
+			Title:      title,
+			Prompt:     prompt,
+			IsPassword: true,
+		})
+		if err != nil {
+			return "", err
+		}
+		return resp.Text, nil
+	}
+	mnemonic, err := readInput("Mnemonic", "Please enter the mnemonic to import")
+	if err != nil {
+		return err
+	}
+	bip39Passphrase, err := readInput("BIP-39 passphrase", "Please enter the BIP-39 passphrase of the mnemonic, if any")
+	if err != nil {
+		return err
+	}
+	first, err := readInput("Password", "Please enter a password for the imported wallet")
+	if err != nil {
+		return err
+	}
+	second, err := readInput("Password", "Please repeat the password you just entered")
+	if err != nil {
+		return err
+	}
+	if first != second {
+		//lint:ignore ST1005 This is a message for the user
+		return errors.New("Passwords do not match")
+	}
+	acc, err := internalApi.ImportMnemonic(mnemonic, bip39Passphrase, first)
+	if err != nil {
+		return err
+	}
+	ui.ShowInfo(fmt.Sprintf(`Mnemonic imported:
+  Address %v
+  Seed file: %v
+
+The seed is now encrypted; losing both the password and the mnemonic will result
+in permanently losing access to all accounts of the wallet!
+
+Make sure to backup the mnemonic in a safe location.`,
+		acc.Address, strings.TrimSuffix(acc.URL.Path, "/"+accounts.DefaultBaseDerivationPath.String())))
+	return nil
+}
+
+// exportMnemonic prints the BIP-39 mnemonic of an HD wallet via CLI.
+func exportMnemonic(c *cli.Context) error {
+	if c.Args().Len() != 1 {
+		return errors.New("<address> must be given as first argument")
+	}
+	if !common.IsHexAddress(c.Args().First()) {
+		return fmt.Errorf("invalid address: %s", c.Args().First())
+	}
+	addr := common.HexToAddress(c.Args().First())
+
+	internalApi, ui, err := initInternalApi(c)
+	if err != nil {
+		return err
+	}
+	if !confirm(fmt.Sprintf("The mnemonic of the wallet containing %v is about to be printed.\nAnyone who learns it controls every account of the wallet.\n", addr)) {
+		return errors.New("aborted by user")
+	}
+	resp, err := ui.OnInputRequired(core.UserInputRequest{
+		Title:      "Password",
+		Prompt:     "Please enter the password of the wallet",
+		IsPassword: true,
+	})
+	if err != nil {
+		return err
+	}
+	mnemonic, err := internalApi.ExportMnemonic(addr, resp.Text)
+	if err != nil {
+		return err
+	}
+	fmt.Println(mnemonic)
+	return nil
+}
+
 // ipcEndpoint resolves an IPC endpoint based on a configured value, taking into
 // account the set data folders as well as the designated platform we're currently
 // running on.
@@ -652,11 +784,16 @@ func signer(c *cli.Context) error {
 	var (
 		api       core.ExternalAPI
 		pwStorage storage.Storage = &storage.NoStorage{}
+		auditKey  *ecdsa.PrivateKey
 	)
 	configDir := c.String(configdirFlag.Name)
 	if stretchedKey, err := readMasterKey(c, ui); err != nil {
 		log.Warn("Failed to open master, rules disabled", "err", err)
 	} else {
+		// The audit log is signed with a key derived from the master seed
+		if auditKey, err = crypto.ToECDSA(crypto.Keccak256([]byte("auditlog"), stretchedKey)); err != nil {
+			log.Warn("Failed to derive audit log key, signing disabled", "err", err)
+		}
 		vaultLocation := filepath.Join(configDir, common.Bytes2Hex(crypto.Keccak256([]byte("vault"), stretchedKey)[:10]))
 
 		// Generate domain specific keys
@@ -669,6 +806,35 @@ func signer(c *cli.Context) error {
 		jsStorage := storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "jsstorage.json"), jskey)
 		configStorage := storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "config.json"), confkey)
 
+		// Rules and policies both auto-authorize requests, only one may be in charge
+		if c.IsSet(ruleFlag.Name) && c.IsSet(policyFlag.Name) {
+			utils.Fatalf("Flags --%s and --%s are mutually exclusive", ruleFlag.Name, policyFlag.Name)
+		}
+		// Do we have a policy-file?
+		if policyFile := c.String(policyFlag.Name); policyFile != "" {
+			policyBlob, err := os.ReadFile(policyFile)
+			if err != nil {
+				log.Warn("Could not load policy, disabling", "file", policyFile, "err", err)
+			} else {
+				shasum := sha256.Sum256(policyBlob)
+				foundShaSum := hex.EncodeToString(shasum[:])
+				storedShasum, _ := configStorage.Get("ruleset_sha256")
+				if storedShasum != foundShaSum {
+					log.Warn("Policy hash not attested, disabling", "hash", foundShaSum, "attested", storedShasum)
+				} else {
+					spec, err := policy.Parse(bytes.NewReader(policyBlob))
+					if err != nil {
+						utils.Fatalf("Invalid policy: %v", err)
+					}
+					// Daily spend is tracked in its own encrypted storage
+					policykey := crypto.Keccak256([]byte("policystorage"), stretchedKey)
+					policyStorage := storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "policystorage.json"), policykey)
+
+					ui = policy.NewPolicyUI(ui, policy.NewEvaluator(spec, db, policyStorage))
+					log.Info("Policy engine configured", "file", policyFile, "accounts", len(spec.Accounts))
+				}
+			}
+		}
 		// Do we have a rule-file?
 		if ruleFile := c.String(ruleFlag.Name); ruleFile != "" {
 			ruleJS, err := os.ReadFile(ruleFile)
@@ -700,10 +866,11 @@ func signer(c *cli.Context) error {
 		advanced = c.Bool(advancedMode.Name)
 		nousb    = c.Bool(utils.NoUSBFlag.Name)
 		scpath   = c.String(utils.SmartCardDaemonPathFlag.Name)
+		p11path  = c.String(utils.PKCS11ModuleFlag.Name)
 	)
 	log.Info("Starting signer", "chainid", chainId, "keystore", ksLoc,
 		"light-kdf", lightKdf, "advanced", advanced)
-	am := core.StartClefAccountManager(ksLoc, nousb, lightKdf, scpath)
+	am := core.StartClefAccountManager(ksLoc, nousb, lightKdf, scpath, p11path)
 	defer am.Close()
 	apiImpl := core.NewSignerAPI(am, chainId, nousb, ui, db, advanced, pwStorage)
 
@@ -714,11 +881,15 @@ func signer(c *cli.Context) error {
 
 	// Audit logging
 	if logfile := c.String(auditLogFlag.Name); logfile != "" {
-		api, err = core.NewAuditLogger(logfile, api)
+		api, err = core.NewAuditLogger(logfile, api, auditKey)
 		if err != nil {
 			utils.Fatalf(err.Error())
 		}
-		log.Info("Audit logs configured", "file", logfile)
+		if auditKey != nil {
+			log.Info("Audit logs configured", "file", logfile, "signer", crypto.PubkeyToAddress(auditKey.PublicKey))
+		} else {
+			log.Warn("Audit logs configured without signing key", "file", logfile)
+		}
 	}
 	// register signer API with server
 	var (
@@ -731,13 +902,27 @@ func signer(c *cli.Context) error {
 			Service:   api,
 		},
 	}
+	namespaces := []string{"account"}
+
+	// Safe multisig workflow, signing through the regular (audited) API
+	if c.IsSet(safeRPCFlag.Name) {
+		safeAPI, closeSafe, err := newSafeAPI(c, api, configDir)
+		if err != nil {
+			utils.Fatalf("Could not start Safe API: %v", err)
+		}
+		defer closeSafe()
+
+		rpcAPI = append(rpcAPI, rpc.API{Namespace: "safe", Service: safeAPI})
+		namespaces = append(namespaces, "safe")
+		log.Info("Safe API enabled", "rpc", c.String(safeRPCFlag.Name))
+	}
 	if c.Bool(utils.HTTPEnabledFlag.Name) {
 		vhosts := utils.SplitAndTrim(c.String(utils.HTTPVirtualHostsFlag.Name))
 		cors := utils.SplitAndTrim(c.String(utils.HTTPCORSDomainFlag.Name))
 
 		srv := rpc.NewServer()
 		srv.SetBatchLimits(node.DefaultConfig.BatchRequestLimit, node.DefaultConfig.BatchResponseMaxSize)
-		err := node.RegisterApis(rpcAPI, []string{"account"}, srv)
+		err := node.RegisterApis(rpcAPI, namespaces, srv)
 		if err != nil {
 			utils.Fatalf("Could not register API: %w", err)
 		}
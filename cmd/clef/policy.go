@@ -0,0 +1,94 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/signer/fourbyte"
+	"github.com/ethereum/go-ethereum/signer/policy"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	policyCommand = &cli.Command{
+		Name:  "policy",
+		Usage: "Manage declarative approval policies",
+		Subcommands: []*cli.Command{
+			policyTestCommand,
+		},
+	}
+	policyTestCommand = &cli.Command{
+		Action:    testPolicy,
+		Name:      "test",
+		Usage:     "Evaluate a policy against requests recorded in an audit log",
+		ArgsUsage: "<policy file> <audit log>",
+		Flags: []cli.Flag{
+			logLevelFlag,
+		},
+		Description: `
+The policy test command replays the signing requests recorded in a Clef audit log
+against a policy, and prints the decision the policy would have made for each of
+them. Requests are evaluated at the time they were recorded, with daily limits
+accumulating over the transactions approved during the replay.`,
+	}
+)
+
+func testPolicy(c *cli.Context) error {
+	if c.NArg() != 2 {
+		return fmt.Errorf("expected policy file and audit log, got %d arguments", c.NArg())
+	}
+	spec, err := policy.Load(c.Args().Get(0))
+	if err != nil {
+		return fmt.Errorf("invalid policy: %v", err)
+	}
+	f, err := os.Open(c.Args().Get(1))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	records, err := policy.ReadAuditLog(f)
+	if err != nil {
+		return fmt.Errorf("invalid audit log: %v", err)
+	}
+	db, err := fourbyte.New()
+	if err != nil {
+		return err
+	}
+	var (
+		decisions = policy.Replay(spec, db, records)
+		counts    = make(map[policy.Action]int)
+	)
+	for i, record := range records {
+		decision := decisions[i]
+		counts[decision.Action]++
+
+		var from string
+		if record.Tx != nil {
+			from = record.Tx.Transaction.From.Address().Hex()
+		} else if record.Data != nil {
+			from = record.Data.Address.Address().Hex()
+		}
+		fmt.Printf("line %d\t%s\t%s\t%s\t%-7s %s\n", record.Line, record.Time.UTC().Format("2006-01-02 15:04:05"),
+			record.Method, from, decision.Action, decision.Reason)
+	}
+	fmt.Printf("\n%d requests: %d approved, %d rejected, %d manual\n", len(records),
+		counts[policy.Approve], counts[policy.Reject], counts[policy.Manual])
+	return nil
+}
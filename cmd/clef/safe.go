@@ -0,0 +1,60 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/signer/core"
+	"github.com/ethereum/go-ethereum/signer/safe"
+	"github.com/urfave/cli/v2"
+)
+
+var safeRPCFlag = &cli.StringFlag{
+	Name: "safe.rpc",
+	Usage: "Ethereum node to read Safe multisig contracts from, enabling the 'safe' API to " +
+		"propose, sign and assemble Safe transactions",
+}
+
+// newSafeAPI creates the Safe multisig API, reading the Safes from the node
+// given by --safe.rpc and persisting proposals in the config directory.
+func newSafeAPI(c *cli.Context, signer core.ExternalAPI, configDir string) (*safe.API, func(), error) {
+	url := c.String(safeRPCFlag.Name)
+	client, err := ethclient.Dial(url)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to %s: %v", url, err)
+	}
+	// Signatures are only valid on a single chain, make sure it's clef's one
+	chainID, err := client.ChainID(context.Background())
+	if err != nil {
+		client.Close()
+		return nil, nil, fmt.Errorf("failed to retrieve chain id from %s: %v", url, err)
+	}
+	if want := c.Int64(chainIdFlag.Name); !chainID.IsInt64() || chainID.Int64() != want {
+		client.Close()
+		return nil, nil, fmt.Errorf("chain id mismatch: %s is on chain %v, clef on chain %d", url, chainID, want)
+	}
+	store, err := safe.NewStore(filepath.Join(configDir, "safe"))
+	if err != nil {
+		client.Close()
+		return nil, nil, err
+	}
+	return safe.NewAPI(signer, client, store), client.Close, nil
+}
@@ -0,0 +1,543 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"regexp"
+	"text/tabwriter"
+	"time"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fp"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/bn256"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	BenchRunFlag = &cli.StringFlag{
+		Name:  "run",
+		Usage: "Regular expression selecting the workloads to run",
+	}
+	BenchTimeFlag = &cli.DurationFlag{
+		Name:  "benchtime",
+		Usage: "Minimum run time of each workload",
+		Value: 500 * time.Millisecond,
+	}
+	BenchGasFlag = &cli.Uint64Flag{
+		Name:  "benchgas",
+		Usage: "Gas limit of each run of a workload",
+		Value: 10_000_000,
+	}
+	BenchBaselineFlag = &cli.StringFlag{
+		Name:  "baseline",
+		Usage: "JSON results of a previous run to compare against",
+	}
+	BenchThresholdFlag = &cli.Float64Flag{
+		Name:  "threshold",
+		Usage: "Fail if the throughput of a workload drops by more than this percentage from the baseline (0 = disabled)",
+	}
+)
+
+var benchCommand = &cli.Command{
+	Action: benchCmd,
+	Name:   "bench",
+	Usage:  "Benchmarks the opcodes and precompiles of the interpreter",
+	Description: `
+The bench command runs a curated set of typical and worst case workloads for the
+opcodes and precompiles of the latest fork, reporting the execution time per gas
+and the throughput in Mgas/s of each. Every workload loops over its operation until
+running out of gas.
+
+The results can be stored with --json and compared against in a later run with
+--baseline, failing if a workload regressed by more than --threshold percent.`,
+	Flags: []cli.Flag{
+		BenchRunFlag,
+		BenchTimeFlag,
+		BenchGasFlag,
+		BenchBaselineFlag,
+		BenchThresholdFlag,
+		MachineFlag,
+	},
+}
+
+// benchResult is the measured performance of a workload.
+type benchResult struct {
+	Name       string  `json:"name"`
+	Kind       string  `json:"kind"`
+	Runs       int     `json:"runs"`
+	Gas        uint64  `json:"gas"`
+	Nanos      int64   `json:"ns"`
+	NsPerGas   float64 `json:"nsPerGas"`
+	MGasPerSec float64 `json:"mgasPerSec"`
+
+	// Comparison against a baseline, if any
+	Baseline *float64 `json:"baselineMgasPerSec,omitempty"`
+	Change   *float64 `json:"change,omitempty"` // Throughput change in percent
+}
+
+func benchCmd(ctx *cli.Context) error {
+	var filter *regexp.Regexp
+	if ctx.IsSet(BenchRunFlag.Name) {
+		var err error
+		if filter, err = regexp.Compile(ctx.String(BenchRunFlag.Name)); err != nil {
+			return fmt.Errorf("invalid --%s: %v", BenchRunFlag.Name, err)
+		}
+	}
+	var baseline []*benchResult
+	if file := ctx.String(BenchBaselineFlag.Name); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &baseline); err != nil {
+			return fmt.Errorf("invalid baseline %s: %v", file, err)
+		}
+	}
+	workloads, err := benchWorkloads()
+	if err != nil {
+		return err
+	}
+	var (
+		machine = ctx.Bool(MachineFlag.Name)
+		results []*benchResult
+	)
+	for _, w := range workloads {
+		if filter != nil && !filter.MatchString(w.name) {
+			continue
+		}
+		res, err := w.run(ctx.Duration(BenchTimeFlag.Name), ctx.Uint64(BenchGasFlag.Name))
+		if err != nil {
+			return fmt.Errorf("workload %s: %v", w.name, err)
+		}
+		if !machine {
+			fmt.Fprintf(os.Stderr, "%-28s %8.2f Mgas/s\n", res.Name, res.MGasPerSec)
+		}
+		results = append(results, res)
+	}
+	regressions := compareBenchResults(results, baseline, ctx.Float64(BenchThresholdFlag.Name))
+
+	if machine {
+		out, _ := json.MarshalIndent(results, "", "  ")
+		fmt.Println(string(out))
+	} else {
+		printBenchResults(results)
+	}
+	if len(regressions) > 0 {
+		return fmt.Errorf("%d workloads regressed by more than %v%%: %v", len(regressions), ctx.Float64(BenchThresholdFlag.Name), regressions)
+	}
+	return nil
+}
+
+// compareBenchResults annotates the results with their change from the baseline,
+// returning the workloads whose throughput dropped by more than the threshold.
+func compareBenchResults(results, baseline []*benchResult, threshold float64) []string {
+	previous := make(map[string]*benchResult)
+	for _, res := range baseline {
+		previous[res.Name] = res
+	}
+	var regressions []string
+	for _, res := range results {
+		prev := previous[res.Name]
+		if prev == nil || prev.MGasPerSec == 0 {
+			continue
+		}
+		change := (res.MGasPerSec - prev.MGasPerSec) / prev.MGasPerSec * 100
+		res.Baseline, res.Change = &prev.MGasPerSec, &change
+		if threshold > 0 && change < -threshold {
+			regressions = append(regressions, res.Name)
+		}
+	}
+	return regressions
+}
+
+func printBenchResults(results []*benchResult) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "Workload\tKind\tRuns\tns/gas\tMgas/s\tBaseline\tChange\t")
+	for _, res := range results {
+		baseline, change := "-", "-"
+		if res.Change != nil {
+			baseline, change = fmt.Sprintf("%.2f", *res.Baseline), fmt.Sprintf("%+.1f%%", *res.Change)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%.3f\t%.2f\t%s\t%s\t\n", res.Name, res.Kind, res.Runs, res.NsPerGas, res.MGasPerSec, baseline, change)
+	}
+	w.Flush()
+}
+
+// benchWorkload is a contract looping over an operation until it runs out of gas.
+type benchWorkload struct {
+	name  string
+	kind  string
+	code  []byte
+	input []byte
+
+	precompile *common.Address // Precompile called by the workload, checked before running it
+}
+
+// benchChainConfig enables all the forks, so that all the opcodes and precompiles
+// are available.
+func benchChainConfig() *params.ChainConfig {
+	config := *params.MergedTestChainConfig
+	config.PragueTime = new(uint64)
+	return &config
+}
+
+// run executes the workload for at least the given duration.
+func (w *benchWorkload) run(duration time.Duration, gas uint64) (*benchResult, error) {
+	var (
+		address = common.BytesToAddress([]byte("bench"))
+		cfg     = &runtime.Config{
+			ChainConfig: benchChainConfig(),
+			GasLimit:    gas,
+			Random:      new(common.Hash),
+		}
+	)
+	cfg.State, _ = state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	cfg.State.SetCode(address, w.code)
+
+	// Make sure the precompile accepts the input, as failures go unnoticed
+	// within the loop.
+	if w.precompile != nil {
+		if _, _, err := runtime.Call(*w.precompile, w.input, cfg); err != nil {
+			return nil, fmt.Errorf("invalid precompile input: %v", err)
+		}
+	}
+	res := &benchResult{Name: w.name, Kind: w.kind}
+	start := time.Now()
+	for res.Runs == 0 || time.Since(start) < duration {
+		_, left, err := runtime.Call(address, w.input, cfg)
+		if err != nil && !errors.Is(err, vm.ErrOutOfGas) {
+			return nil, err
+		}
+		res.Runs++
+		res.Gas += gas - left
+	}
+	res.Nanos = time.Since(start).Nanoseconds()
+	if res.Gas > 0 {
+		res.NsPerGas = float64(res.Nanos) / float64(res.Gas)
+		res.MGasPerSec = float64(res.Gas) * 1000 / float64(res.Nanos)
+	}
+	return res, nil
+}
+
+// benchUnroll is the number of times the body of opcode workloads is repeated
+// within their loop, to amortize its cost.
+const benchUnroll = 64
+
+// loopCode returns code running the prelude once, then the body endlessly.
+func loopCode(prelude []byte, body []byte, unroll int) []byte {
+	code := append([]byte{}, prelude...)
+	start := len(code)
+	code = append(code, byte(vm.JUMPDEST))
+	for i := 0; i < unroll; i++ {
+		code = append(code, body...)
+	}
+	return append(code, byte(vm.PUSH4), byte(start>>24), byte(start>>16), byte(start>>8), byte(start), byte(vm.JUMP))
+}
+
+// push returns the instruction pushing the value.
+func push(value []byte) []byte {
+	if len(value) == 0 {
+		return []byte{byte(vm.PUSH0)}
+	}
+	return append([]byte{byte(vm.PUSH1) + byte(len(value)-1)}, value...)
+}
+
+// pushes returns the instructions pushing the values, the last one ending up on
+// top of the stack.
+func pushes(values ...[]byte) []byte {
+	var code []byte
+	for _, v := range values {
+		code = append(code, push(v)...)
+	}
+	return code
+}
+
+func ops(codes ...vm.OpCode) []byte {
+	out := make([]byte, len(codes))
+	for i, op := range codes {
+		out[i] = byte(op)
+	}
+	return out
+}
+
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+var (
+	benchWord64  = common.FromHex("0x0123456789abcdef")
+	benchWord128 = common.FromHex("0xfedcba98765432100123456789abcdef")
+	benchWord256 = common.FromHex("0xf0e1d2c3b4a5968778695a4b3c2d1e0f0123456789abcdeffedcba9876543210")
+	benchOther   = common.FromHex("0x8899aabbccddeeff00112233445566778899aabbccddeeff0011223344556677")
+)
+
+// opcodeWorkloads returns the workloads of the opcodes. Operands are kept on the
+// stack and duplicated for every execution, results are popped.
+func opcodeWorkloads() []*benchWorkload {
+	var workloads []*benchWorkload
+	add := func(name string, prelude, body []byte) {
+		workloads = append(workloads, &benchWorkload{name: name, kind: "opcode", code: loopCode(prelude, body, benchUnroll)})
+	}
+	// Operations of two words, the first operand being on top of the stack
+	binary := func(name string, op vm.OpCode, x, y []byte) {
+		add(name, pushes(y, x), ops(vm.DUP2, vm.DUP2, op, vm.POP))
+	}
+	for _, op := range []vm.OpCode{vm.ADD, vm.SUB, vm.MUL, vm.LT, vm.GT, vm.SLT, vm.SGT, vm.EQ, vm.AND, vm.OR, vm.XOR} {
+		binary(op.String(), op, benchWord256, benchOther)
+	}
+	for _, op := range []vm.OpCode{vm.DIV, vm.SDIV, vm.MOD, vm.SMOD} {
+		binary(op.String()+"/64bit", op, benchWord64, []byte{0x12, 0x34, 0x56})
+		binary(op.String()+"/256bit", op, benchWord256, benchWord128)
+	}
+	binary("EXP/small", vm.EXP, benchWord256, []byte{0x02})
+	binary("EXP/256bit", vm.EXP, benchWord256, benchOther)
+	for _, op := range []vm.OpCode{vm.SIGNEXTEND, vm.BYTE} {
+		binary(op.String(), op, []byte{0x0f}, benchWord256)
+	}
+	for _, op := range []vm.OpCode{vm.SHL, vm.SHR, vm.SAR} {
+		binary(op.String(), op, []byte{0x41}, benchWord256)
+	}
+	for _, op := range []vm.OpCode{vm.ADDMOD, vm.MULMOD} {
+		add(op.String(), pushes(benchWord128, benchOther, benchWord256), ops(vm.DUP3, vm.DUP3, vm.DUP3, op, vm.POP))
+	}
+	for _, op := range []vm.OpCode{vm.NOT, vm.ISZERO} {
+		add(op.String(), push(benchWord256), ops(vm.DUP1, op, vm.POP))
+	}
+	// Stack manipulation
+	add("PUSH0", nil, ops(vm.PUSH0, vm.POP))
+	add("PUSH1", nil, concat(push([]byte{0x01}), ops(vm.POP)))
+	add("PUSH32", nil, concat(push(benchWord256), ops(vm.POP)))
+	add("DUP1", push(benchWord256), ops(vm.DUP1, vm.POP))
+	add("DUP16", bytes.Repeat(push(benchWord256), 16), ops(vm.DUP16, vm.POP))
+	add("SWAP1", bytes.Repeat(push(benchWord256), 2), ops(vm.SWAP1))
+	add("SWAP16", bytes.Repeat(push(benchWord256), 17), ops(vm.SWAP16))
+
+	// Execution context
+	for _, op := range []vm.OpCode{
+		vm.ADDRESS, vm.ORIGIN, vm.CALLER, vm.CALLVALUE, vm.CALLDATASIZE, vm.CODESIZE,
+		vm.GASPRICE, vm.RETURNDATASIZE, vm.COINBASE, vm.TIMESTAMP, vm.NUMBER,
+		vm.PREVRANDAO, vm.GASLIMIT, vm.CHAINID, vm.SELFBALANCE, vm.BASEFEE,
+		vm.BLOBBASEFEE, vm.PC, vm.MSIZE, vm.GAS,
+	} {
+		name := op.String()
+		if op == vm.PREVRANDAO {
+			name = "PREVRANDAO"
+		}
+		add(name, nil, ops(op, vm.POP))
+	}
+	add("CALLDATALOAD", nil, concat(push([]byte{0x00}), ops(vm.CALLDATALOAD, vm.POP)))
+	add("CALLDATACOPY/32", nil, concat(pushes([]byte{0x20}, nil, nil), ops(vm.CALLDATACOPY)))
+	add("CODECOPY/32", nil, concat(pushes([]byte{0x20}, nil, nil), ops(vm.CODECOPY)))
+	add("BLOCKHASH", nil, concat(push(nil), ops(vm.BLOCKHASH, vm.POP)))
+	add("BLOBHASH", nil, concat(push(nil), ops(vm.BLOBHASH, vm.POP)))
+
+	// Memory
+	add("MLOAD", nil, concat(push(nil), ops(vm.MLOAD, vm.POP)))
+	add("MSTORE", nil, concat(pushes(benchWord256, nil), ops(vm.MSTORE)))
+	add("MSTORE8", nil, concat(pushes(benchWord256, nil), ops(vm.MSTORE8)))
+	add("MCOPY/1024", nil, concat(pushes([]byte{0x04, 0x00}, nil, []byte{0x04, 0x00}), ops(vm.MCOPY)))
+	add("KECCAK256/32", nil, concat(pushes([]byte{0x20}, nil), ops(vm.KECCAK256, vm.POP)))
+	add("KECCAK256/1024", nil, concat(pushes([]byte{0x04, 0x00}, nil), ops(vm.KECCAK256, vm.POP)))
+
+	// Storage and accounts, warm and cold ones being iterated over
+	add("SLOAD/warm", nil, concat(push(nil), ops(vm.SLOAD, vm.POP)))
+	add("SLOAD/cold", push(nil), concat(ops(vm.DUP1, vm.SLOAD, vm.POP), push([]byte{0x01}), ops(vm.ADD)))
+	add("SSTORE/noop", nil, concat(pushes(nil, nil), ops(vm.SSTORE)))
+	add("SSTORE/set", push([]byte{0x01}), concat(push([]byte{0x01}), ops(vm.DUP2, vm.SSTORE), push([]byte{0x01}), ops(vm.ADD)))
+	add("TLOAD", nil, concat(push(nil), ops(vm.TLOAD, vm.POP)))
+	add("TSTORE", nil, concat(pushes(benchWord256, nil), ops(vm.TSTORE)))
+	for _, op := range []vm.OpCode{vm.BALANCE, vm.EXTCODESIZE, vm.EXTCODEHASH} {
+		add(op.String()+"/warm", nil, ops(vm.ADDRESS, op, vm.POP))
+		add(op.String()+"/cold", push([]byte{0x10, 0x00}), concat(ops(vm.DUP1, op, vm.POP), push([]byte{0x01}), ops(vm.ADD)))
+	}
+	// Logs of one word
+	add("LOG0/32", nil, concat(pushes([]byte{0x20}, nil), ops(vm.LOG0)))
+	add("LOG4/32", nil, concat(pushes(benchWord256, benchWord256, benchWord256, benchWord256, []byte{0x20}, nil), ops(vm.LOG4)))
+
+	// Calls and creations
+	empty := []byte{0xe0}
+	add("CALL/empty", nil, concat(pushes(nil, nil, nil, nil, nil, empty), ops(vm.GAS, vm.CALL, vm.POP)))
+	add("STATICCALL/empty", nil, concat(pushes(nil, nil, nil, nil, empty), ops(vm.GAS, vm.STATICCALL, vm.POP)))
+	add("CREATE/empty", nil, concat(pushes(nil, nil, nil), ops(vm.CREATE, vm.POP)))
+
+	return workloads
+}
+
+// precompileWorkloads returns the workloads of the precompiles, calling them with
+// their input copied from the calldata.
+func precompileWorkloads() ([]*benchWorkload, error) {
+	inputs, err := precompileInputs()
+	if err != nil {
+		return nil, err
+	}
+	var workloads []*benchWorkload
+	for _, in := range inputs {
+		addr := common.BytesToAddress([]byte{in.addr})
+		body := concat(pushes(nil, nil), ops(vm.CALLDATASIZE), pushes(nil, []byte{in.addr}), ops(vm.GAS, vm.STATICCALL, vm.POP))
+		workloads = append(workloads, &benchWorkload{
+			name:       in.name,
+			kind:       "precompile",
+			code:       loopCode(concat(ops(vm.CALLDATASIZE), pushes(nil, nil), ops(vm.CALLDATACOPY)), body, 1),
+			input:      in.input,
+			precompile: &addr,
+		})
+	}
+	return workloads, nil
+}
+
+type precompileInput struct {
+	name  string
+	addr  byte
+	input []byte
+}
+
+// precompileInputs generates valid inputs for the precompiles of the latest fork.
+func precompileInputs() ([]precompileInput, error) {
+	var inputs []precompileInput
+	add := func(name string, addr byte, input []byte) {
+		inputs = append(inputs, precompileInput{name, addr, input})
+	}
+	// Signature recovery
+	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	hash := crypto.Keccak256([]byte("bench"))
+	sig, err := crypto.Sign(hash, key)
+	if err != nil {
+		return nil, err
+	}
+	add("ecrecover", 0x01, concat(hash, common.LeftPadBytes([]byte{sig[64] + 27}, 32), sig[:64]))
+
+	// Hashing and copying
+	for _, size := range []int{32, 1024} {
+		data := bytes.Repeat([]byte{0xa5}, size)
+		add(fmt.Sprintf("sha256/%d", size), 0x02, data)
+		add(fmt.Sprintf("ripemd160/%d", size), 0x03, data)
+		add(fmt.Sprintf("identity/%d", size), 0x04, data)
+	}
+	// Modular exponentiation
+	modexp := func(base, exp, mod []byte) []byte {
+		return concat(
+			common.LeftPadBytes(big.NewInt(int64(len(base))).Bytes(), 32),
+			common.LeftPadBytes(big.NewInt(int64(len(exp))).Bytes(), 32),
+			common.LeftPadBytes(big.NewInt(int64(len(mod))).Bytes(), 32),
+			base, exp, mod,
+		)
+	}
+	add("modexp/256bit", 0x05, modexp(benchWord256, benchOther, benchWord256))
+	add("modexp/rsa2048", 0x05, modexp(bytes.Repeat(benchWord256, 8), []byte{0x01, 0x00, 0x01}, bytes.Repeat(benchOther, 8)))
+	add("modexp/1024bit", 0x05, modexp(bytes.Repeat(benchWord256, 4), bytes.Repeat([]byte{0xff}, 128), bytes.Repeat(benchOther, 4)))
+
+	// BN254 curve operations
+	var (
+		scalar = new(big.Int).SetBytes(benchWord256)
+		g1     = new(bn256.G1).ScalarBaseMult(big.NewInt(7)).Marshal()
+		g2     = new(bn256.G2).ScalarBaseMult(big.NewInt(11)).Marshal()
+	)
+	add("bn256Add", 0x06, concat(g1, g1))
+	add("bn256ScalarMul", 0x07, concat(g1, common.LeftPadBytes(scalar.Bytes(), 32)))
+	add("bn256Pairing/1", 0x08, concat(g1, g2))
+	add("bn256Pairing/8", 0x08, bytes.Repeat(concat(g1, g2), 8))
+
+	// Blake2 compression rounds
+	blake2F := func(rounds uint32) []byte {
+		input := make([]byte, 213)
+		binary.BigEndian.PutUint32(input, rounds)
+		copy(input[4:], bytes.Repeat(benchWord256, 6))
+		input[212] = 1
+		return input
+	}
+	add("blake2F/12", 0x09, blake2F(12))
+	add("blake2F/1024", 0x09, blake2F(1024))
+
+	// KZG point evaluation, proving an empty blob
+	var blob kzg4844.Blob
+	commitment, err := kzg4844.BlobToCommitment(&blob)
+	if err != nil {
+		return nil, err
+	}
+	var point kzg4844.Point
+	point[31] = 0x2a
+	proof, claim, err := kzg4844.ComputeProof(&blob, point)
+	if err != nil {
+		return nil, err
+	}
+	versioned := kzg4844.CalcBlobHashV1(sha256.New(), &commitment)
+	add("kzgPointEvaluation", 0x0a, concat(versioned[:], point[:], claim[:], commitment[:], proof[:]))
+
+	// BLS12-381 curve operations
+	var (
+		_, _, p, q = bls12381.Generators()
+		blsG1      = blsEncodeG1(&p)
+		blsG2      = blsEncodeG2(&q)
+		blsScalar  = benchWord256
+	)
+	add("bls12381G1Add", 0x0b, concat(blsG1, blsG1))
+	add("bls12381G1Mul", 0x0c, concat(blsG1, blsScalar))
+	add("bls12381G1MultiExp/16", 0x0d, bytes.Repeat(concat(blsG1, blsScalar), 16))
+	add("bls12381G2Add", 0x0e, concat(blsG2, blsG2))
+	add("bls12381G2Mul", 0x0f, concat(blsG2, blsScalar))
+	add("bls12381G2MultiExp/16", 0x10, bytes.Repeat(concat(blsG2, blsScalar), 16))
+	add("bls12381Pairing/1", 0x11, concat(blsG1, blsG2))
+	add("bls12381Pairing/4", 0x11, bytes.Repeat(concat(blsG1, blsG2), 4))
+	add("bls12381MapG1", 0x12, common.LeftPadBytes(benchWord256, 64))
+	add("bls12381MapG2", 0x13, concat(common.LeftPadBytes(benchWord256, 64), common.LeftPadBytes(benchOther, 64)))
+
+	return inputs, nil
+}
+
+// blsEncodeG1 encodes a BLS12-381 G1 point as expected by the precompiles.
+func blsEncodeG1(p *bls12381.G1Affine) []byte {
+	out := make([]byte, 128)
+	fp.BigEndian.PutElement((*[fp.Bytes]byte)(out[16:64]), p.X)
+	fp.BigEndian.PutElement((*[fp.Bytes]byte)(out[80:128]), p.Y)
+	return out
+}
+
+// blsEncodeG2 encodes a BLS12-381 G2 point as expected by the precompiles.
+func blsEncodeG2(p *bls12381.G2Affine) []byte {
+	out := make([]byte, 256)
+	fp.BigEndian.PutElement((*[fp.Bytes]byte)(out[16:64]), p.X.A0)
+	fp.BigEndian.PutElement((*[fp.Bytes]byte)(out[80:128]), p.X.A1)
+	fp.BigEndian.PutElement((*[fp.Bytes]byte)(out[144:192]), p.Y.A0)
+	fp.BigEndian.PutElement((*[fp.Bytes]byte)(out[208:256]), p.Y.A1)
+	return out
+}
+
+// benchWorkloads returns all the workloads, opcodes first.
+func benchWorkloads() ([]*benchWorkload, error) {
+	precompiles, err := precompileWorkloads()
+	if err != nil {
+		return nil, err
+	}
+	return append(opcodeWorkloads(), precompiles...), nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestBenchWorkloads(t *testing.T) {
+	workloads, err := benchWorkloads()
+	if err != nil {
+		t.Fatalf("failed to create workloads: %v", err)
+	}
+	names := make(map[string]bool)
+	for _, w := range workloads {
+		if names[w.name] {
+			t.Errorf("duplicate workload %s", w.name)
+		}
+		names[w.name] = true
+
+		res, err := w.run(0, 1_000_000)
+		if err != nil {
+			t.Errorf("workload %s failed: %v", w.name, err)
+			continue
+		}
+		if res.Runs != 1 || res.Gas == 0 || res.MGasPerSec == 0 {
+			t.Errorf("workload %s: unexpected result %+v", w.name, res)
+		}
+	}
+}
+
+func TestCompareBenchResults(t *testing.T) {
+	var (
+		baseline = []*benchResult{
+			{Name: "ADD", MGasPerSec: 100},
+			{Name: "MUL", MGasPerSec: 100},
+			{Name: "removed", MGasPerSec: 100},
+		}
+		results = []*benchResult{
+			{Name: "ADD", MGasPerSec: 120},
+			{Name: "MUL", MGasPerSec: 80},
+			{Name: "added", MGasPerSec: 100},
+		}
+	)
+	regressions := compareBenchResults(results, baseline, 10)
+	if !slices.Equal(regressions, []string{"MUL"}) {
+		t.Errorf("regressions mismatch: have %v, want [MUL]", regressions)
+	}
+	for i, want := range []float64{20, -20} {
+		if res := results[i]; res.Change == nil || *res.Change != want || *res.Baseline != 100 {
+			t.Errorf("%s: change mismatch: have %v, want %v", res.Name, res.Change, want)
+		}
+	}
+	if results[2].Change != nil {
+		t.Errorf("change reported without baseline")
+	}
+	if regressions := compareBenchResults(results, baseline, 0); len(regressions) != 0 {
+		t.Errorf("regressions reported without threshold: %v", regressions)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	EOFInitcodeFlag = &cli.BoolFlag{
+		Name:  "initcode",
+		Usage: "Validate the containers as initcode instead of runtime code",
+	}
+	EOFVerboseFlag = &cli.BoolFlag{
+		Name:  "verbose",
+		Usage: "Print the sections of valid containers",
+	}
+)
+
+var eofParseCommand = &cli.Command{
+	Action:    eofParseCmd,
+	Name:      "eofparse",
+	Usage:     "Parses and validates EOF containers",
+	ArgsUsage: "[<hex>]",
+	Description: `
+The eofparse command validates the given hex encoded EOF container. If none is
+given, containers are read from stdin, one per line, and the result of each is
+printed as "OK" or "err: <reason>".`,
+	Flags: []cli.Flag{
+		EOFInitcodeFlag,
+		EOFVerboseFlag,
+	},
+}
+
+func eofParseCmd(ctx *cli.Context) error {
+	var (
+		initcode = ctx.Bool(EOFInitcodeFlag.Name)
+		verbose  = ctx.Bool(EOFVerboseFlag.Name)
+	)
+	if ctx.Args().Len() > 0 {
+		container, err := parseEOF(ctx.Args().First(), initcode)
+		if err != nil {
+			return err
+		}
+		fmt.Println("OK")
+		if verbose {
+			fmt.Print(container)
+		}
+		return nil
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 1024*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		container, err := parseEOF(line, initcode)
+		if err != nil {
+			fmt.Printf("err: %v\n", err)
+			continue
+		}
+		fmt.Println("OK")
+		if verbose {
+			fmt.Print(container)
+		}
+	}
+	return scanner.Err()
+}
+
+// parseEOF decodes and validates a hex encoded EOF container.
+func parseEOF(input string, initcode bool) (*vm.Container, error) {
+	code := common.FromHex(input)
+	if len(code) == 0 {
+		return nil, fmt.Errorf("invalid hex input %q", input)
+	}
+	return vm.ParseAndValidate(code, initcode)
+}
@@ -0,0 +1,522 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+// Package debugger implements an interactive step debugger for the EVM, driven
+// by the tracing hooks of the execution.
+package debugger
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// runMode determines where the execution pauses next.
+type runMode int
+
+const (
+	modeStep     runMode = iota // pause at the next instruction
+	modeNext                    // pause at the next instruction in the current or a parent frame
+	modeFinish                  // pause once the current frame returned
+	modeContinue                // pause only at breakpoints
+	modeDetached                // never pause again
+)
+
+// breakpointKind is the condition type of a breakpoint.
+type breakpointKind int
+
+const (
+	breakPC breakpointKind = iota
+	breakOp
+	breakDepth
+	breakStorage
+)
+
+// breakpoint pauses the execution when its condition is met.
+type breakpoint struct {
+	id      int
+	kind    breakpointKind
+	pc      uint64
+	op      vm.OpCode
+	depth   int
+	address *common.Address // Restricts the breakpoint to a contract, if set
+}
+
+func (b *breakpoint) String() string {
+	var cond string
+	switch b.kind {
+	case breakPC:
+		cond = fmt.Sprintf("pc %d", b.pc)
+	case breakOp:
+		cond = fmt.Sprintf("op %v", b.op)
+	case breakDepth:
+		cond = fmt.Sprintf("depth %d", b.depth)
+	case breakStorage:
+		cond = "sstore"
+	}
+	if b.address != nil {
+		cond += fmt.Sprintf(" at %#x", *b.address)
+	}
+	return fmt.Sprintf("#%d: %s", b.id, cond)
+}
+
+// frame is an entry of the call stack.
+type frame struct {
+	typ  vm.OpCode
+	from common.Address
+	to   common.Address
+}
+
+// Debugger is an interactive EVM debugger. It pauses the execution on the
+// tracing hooks and reads commands from its input until told to resume.
+type Debugger struct {
+	in  *bufio.Scanner
+	out io.Writer
+
+	env    *tracing.VMContext
+	frames []frame
+
+	mode        runMode
+	targetDepth int // depth the step-over and step-out modes refer to
+
+	breakpoints []*breakpoint
+	nextID      int
+
+	// The state of the paused instruction
+	pc         uint64
+	op         vm.OpCode
+	gas, cost  uint64
+	depth      int
+	scope      tracing.OpContext
+	returnData []byte
+}
+
+// New creates a debugger reading commands from in and writing to out. The
+// debugger starts paused at the first instruction.
+func New(in io.Reader, out io.Writer) *Debugger {
+	return &Debugger{
+		in:     bufio.NewScanner(in),
+		out:    out,
+		mode:   modeStep,
+		nextID: 1,
+	}
+}
+
+// Hooks returns the tracing hooks driving the debugger.
+func (d *Debugger) Hooks() *tracing.Hooks {
+	return &tracing.Hooks{
+		OnTxStart: d.OnTxStart,
+		OnTxEnd:   d.OnTxEnd,
+		OnEnter:   d.OnEnter,
+		OnExit:    d.OnExit,
+		OnOpcode:  d.OnOpcode,
+		OnFault:   d.OnFault,
+	}
+}
+
+// OnTxStart records the execution environment, providing access to the state.
+func (d *Debugger) OnTxStart(env *tracing.VMContext, tx *types.Transaction, from common.Address) {
+	d.env = env
+	d.frames = d.frames[:0]
+}
+
+// OnTxEnd reports the outcome of the transaction.
+func (d *Debugger) OnTxEnd(receipt *types.Receipt, err error) {
+	if d.mode == modeDetached {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(d.out, "transaction failed: %v\n", err)
+		return
+	}
+	if receipt != nil {
+		fmt.Fprintf(d.out, "transaction done, gas used %d, status %d\n", receipt.GasUsed, receipt.Status)
+	}
+}
+
+// OnEnter tracks the call stack on entering a new frame.
+func (d *Debugger) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	d.frames = append(d.frames, frame{typ: vm.OpCode(typ), from: from, to: to})
+	if d.mode == modeStep {
+		fmt.Fprintf(d.out, "-> %v %#x => %#x, gas %d, input %#x\n", vm.OpCode(typ), from, to, gas, input)
+	}
+}
+
+// OnExit tracks the call stack on leaving a frame.
+func (d *Debugger) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	if len(d.frames) > 0 {
+		d.frames = d.frames[:len(d.frames)-1]
+	}
+	if d.mode == modeStep || d.mode == modeNext || d.mode == modeFinish {
+		status := "ok"
+		if err != nil {
+			status = err.Error()
+		}
+		// Frames report the depth of the caller, instructions their own
+		fmt.Fprintf(d.out, "<- return from depth %d (%s), gas used %d, output %#x\n", depth+1, status, gasUsed, output)
+	}
+}
+
+// OnOpcode pauses the execution if stepping or on a matching breakpoint.
+func (d *Debugger) OnOpcode(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	if d.mode == modeDetached {
+		return
+	}
+	d.pc, d.op, d.gas, d.cost, d.depth, d.scope, d.returnData = pc, vm.OpCode(op), gas, cost, depth, scope, rData
+
+	var pause bool
+	switch d.mode {
+	case modeStep:
+		pause = true
+	case modeNext:
+		pause = depth <= d.targetDepth
+	case modeFinish:
+		pause = depth < d.targetDepth
+	}
+	if bp := d.matchBreakpoint(); bp != nil {
+		fmt.Fprintf(d.out, "breakpoint %v\n", bp)
+		pause = true
+	}
+	if pause {
+		d.prompt()
+	}
+}
+
+// OnFault pauses the execution when an instruction fails.
+func (d *Debugger) OnFault(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, depth int, err error) {
+	if d.mode == modeDetached {
+		return
+	}
+	d.pc, d.op, d.gas, d.cost, d.depth, d.scope = pc, vm.OpCode(op), gas, cost, depth, scope
+	fmt.Fprintf(d.out, "fault: %v\n", err)
+	d.prompt()
+}
+
+// matchBreakpoint returns the first breakpoint matching the paused instruction.
+func (d *Debugger) matchBreakpoint() *breakpoint {
+	for _, bp := range d.breakpoints {
+		if bp.address != nil && (d.scope == nil || d.scope.Address() != *bp.address) {
+			continue
+		}
+		switch {
+		case bp.kind == breakPC && bp.pc == d.pc:
+			return bp
+		case bp.kind == breakOp && bp.op == d.op:
+			return bp
+		case bp.kind == breakDepth && bp.depth == d.depth && d.pc == 0:
+			return bp
+		case bp.kind == breakStorage && d.op == vm.SSTORE:
+			return bp
+		}
+	}
+	return nil
+}
+
+// prompt prints the paused instruction and executes commands until one resumes
+// the execution. The debugger detaches if the input is exhausted.
+func (d *Debugger) prompt() {
+	d.printLocation()
+	for {
+		fmt.Fprint(d.out, "> ")
+		if !d.in.Scan() {
+			fmt.Fprintln(d.out)
+			d.mode = modeDetached
+			return
+		}
+		resume, err := d.execute(strings.Fields(d.in.Text()))
+		if err != nil {
+			fmt.Fprintf(d.out, "error: %v\n", err)
+		}
+		if resume {
+			return
+		}
+	}
+}
+
+// printLocation prints a summary of the paused instruction.
+func (d *Debugger) printLocation() {
+	var addr common.Address
+	if d.scope != nil {
+		addr = d.scope.Address()
+	}
+	fmt.Fprintf(d.out, "[%d] %#x pc=%d %v gas=%d cost=%d\n", d.depth, addr, d.pc, d.op, d.gas, d.cost)
+}
+
+const helpText = `Commands:
+  s, step                 execute the next instruction, stepping into calls
+  n, next                 execute the next instruction, stepping over calls
+  f, finish               run until the current call returns
+  c, continue             run until the next breakpoint
+  q, quit                 detach the debugger and run to completion
+  b, break pc <n> [addr]  pause at the program counter
+  b, break op <op> [addr] pause at the opcode
+  b, break depth <n>      pause on entering a call at the depth
+  b, break sstore [addr]  pause at storage writes
+  bl, breakpoints         list the breakpoints
+  d, delete <id>          delete a breakpoint
+  where                   print the paused instruction
+  bt, calls               print the call stack
+  stack                   print the stack
+  mem [offset [length]]   print the memory
+  storage <slot> [addr]   print a storage slot
+  tstorage <slot> [addr]  print a transient storage slot
+  ret                     print the return data of the last call
+  h, help                 print this help`
+
+// execute runs a single debugger command, returning whether the execution
+// should resume.
+func (d *Debugger) execute(args []string) (bool, error) {
+	if len(args) == 0 {
+		return false, nil
+	}
+	switch args[0] {
+	case "s", "step":
+		d.mode = modeStep
+		return true, nil
+
+	case "n", "next":
+		d.mode, d.targetDepth = modeNext, d.depth
+		return true, nil
+
+	case "f", "finish":
+		d.mode, d.targetDepth = modeFinish, d.depth
+		return true, nil
+
+	case "c", "continue":
+		d.mode = modeContinue
+		return true, nil
+
+	case "q", "quit":
+		d.mode = modeDetached
+		return true, nil
+
+	case "b", "break":
+		bp, err := d.parseBreakpoint(args[1:])
+		if err != nil {
+			return false, err
+		}
+		d.breakpoints = append(d.breakpoints, bp)
+		fmt.Fprintf(d.out, "breakpoint %v\n", bp)
+
+	case "bl", "breakpoints":
+		for _, bp := range d.breakpoints {
+			fmt.Fprintln(d.out, bp)
+		}
+
+	case "d", "delete":
+		if len(args) != 2 {
+			return false, errors.New("usage: delete <id>")
+		}
+		id, err := strconv.Atoi(strings.TrimPrefix(args[1], "#"))
+		if err != nil {
+			return false, err
+		}
+		for i, bp := range d.breakpoints {
+			if bp.id == id {
+				d.breakpoints = append(d.breakpoints[:i], d.breakpoints[i+1:]...)
+				return false, nil
+			}
+		}
+		return false, fmt.Errorf("no breakpoint #%d", id)
+
+	case "where":
+		d.printLocation()
+
+	case "bt", "calls":
+		for i := len(d.frames) - 1; i >= 0; i-- {
+			f := d.frames[i]
+			fmt.Fprintf(d.out, "%d: %v %#x => %#x\n", i+1, f.typ, f.from, f.to)
+		}
+
+	case "stack":
+		if d.scope == nil {
+			return false, errors.New("no active frame")
+		}
+		stack := d.scope.StackData()
+		for i := len(stack) - 1; i >= 0; i-- {
+			fmt.Fprintf(d.out, "%4d: %#x\n", len(stack)-1-i, stack[i].Bytes32())
+		}
+
+	case "mem", "memory":
+		if d.scope == nil {
+			return false, errors.New("no active frame")
+		}
+		return false, d.printMemory(d.scope.MemoryData(), args[1:])
+
+	case "storage", "tstorage":
+		if len(args) < 2 || len(args) > 3 {
+			return false, fmt.Errorf("usage: %s <slot> [addr]", args[0])
+		}
+		slot, err := parseHash(args[1])
+		if err != nil {
+			return false, err
+		}
+		addr, err := d.parseAddress(args[2:])
+		if err != nil {
+			return false, err
+		}
+		value, err := d.readStorage(args[0] == "tstorage", addr, slot)
+		if err != nil {
+			return false, err
+		}
+		fmt.Fprintf(d.out, "%#x\n", value)
+
+	case "ret", "returndata":
+		fmt.Fprintf(d.out, "%#x\n", d.returnData)
+
+	case "h", "help":
+		fmt.Fprintln(d.out, helpText)
+
+	default:
+		return false, fmt.Errorf("unknown command %q, try help", args[0])
+	}
+	return false, nil
+}
+
+// parseBreakpoint parses the arguments of the break command.
+func (d *Debugger) parseBreakpoint(args []string) (*breakpoint, error) {
+	if len(args) == 0 {
+		return nil, errors.New("usage: break pc|op|depth|sstore ...")
+	}
+	bp := &breakpoint{id: d.nextID}
+	var (
+		rest []string
+		err  error
+	)
+	switch args[0] {
+	case "pc":
+		if len(args) < 2 {
+			return nil, errors.New("usage: break pc <n> [addr]")
+		}
+		bp.kind = breakPC
+		if bp.pc, err = strconv.ParseUint(args[1], 0, 64); err != nil {
+			return nil, err
+		}
+		rest = args[2:]
+	case "op":
+		if len(args) < 2 {
+			return nil, errors.New("usage: break op <op> [addr]")
+		}
+		name := strings.ToUpper(args[1])
+		bp.kind, bp.op = breakOp, vm.StringToOp(name)
+		if bp.op == vm.STOP && name != "STOP" {
+			return nil, fmt.Errorf("unknown opcode %q", args[1])
+		}
+		rest = args[2:]
+	case "depth":
+		if len(args) != 2 {
+			return nil, errors.New("usage: break depth <n>")
+		}
+		bp.kind = breakDepth
+		if bp.depth, err = strconv.Atoi(args[1]); err != nil {
+			return nil, err
+		}
+	case "sstore":
+		bp.kind = breakStorage
+		rest = args[1:]
+	default:
+		return nil, fmt.Errorf("unknown breakpoint type %q", args[0])
+	}
+	if len(rest) > 1 {
+		return nil, errors.New("too many arguments")
+	}
+	if len(rest) == 1 {
+		if !common.IsHexAddress(rest[0]) {
+			return nil, fmt.Errorf("invalid address %q", rest[0])
+		}
+		addr := common.HexToAddress(rest[0])
+		bp.address = &addr
+	}
+	d.nextID++
+	return bp, nil
+}
+
+// parseAddress returns the address given in args, or that of the current frame.
+func (d *Debugger) parseAddress(args []string) (common.Address, error) {
+	if len(args) > 0 {
+		if !common.IsHexAddress(args[0]) {
+			return common.Address{}, fmt.Errorf("invalid address %q", args[0])
+		}
+		return common.HexToAddress(args[0]), nil
+	}
+	if d.scope == nil {
+		return common.Address{}, errors.New("no active frame, address required")
+	}
+	return d.scope.Address(), nil
+}
+
+// readStorage reads a persistent or transient storage slot from the state.
+func (d *Debugger) readStorage(transient bool, addr common.Address, slot common.Hash) (common.Hash, error) {
+	if d.env == nil || d.env.StateDB == nil {
+		return common.Hash{}, errors.New("state not available")
+	}
+	if !transient {
+		return d.env.StateDB.GetState(addr, slot), nil
+	}
+	tstate, ok := d.env.StateDB.(interface {
+		GetTransientState(common.Address, common.Hash) common.Hash
+	})
+	if !ok {
+		return common.Hash{}, errors.New("transient storage not available")
+	}
+	return tstate.GetTransientState(addr, slot), nil
+}
+
+// printMemory prints the requested memory range in rows of 32 bytes.
+func (d *Debugger) printMemory(mem []byte, args []string) error {
+	var (
+		offset uint64
+		length = uint64(len(mem))
+		err    error
+	)
+	if len(args) > 0 {
+		if offset, err = strconv.ParseUint(args[0], 0, 64); err != nil {
+			return err
+		}
+		length = 32
+	}
+	if len(args) > 1 {
+		if length, err = strconv.ParseUint(args[1], 0, 64); err != nil {
+			return err
+		}
+	}
+	if offset > uint64(len(mem)) {
+		offset = uint64(len(mem))
+	}
+	end := min(offset+length, uint64(len(mem)))
+	for i := offset; i < end; i += 32 {
+		fmt.Fprintf(d.out, "%#06x: %x\n", i, mem[i:min(i+32, end)])
+	}
+	return nil
+}
+
+// parseHash parses a storage slot given as a hex or decimal number.
+func parseHash(s string) (common.Hash, error) {
+	n, ok := new(big.Int).SetString(s, 0)
+	if !ok || n.Sign() < 0 || n.BitLen() > 256 {
+		return common.Hash{}, fmt.Errorf("invalid slot %q", s)
+	}
+	return common.BigToHash(n), nil
+}
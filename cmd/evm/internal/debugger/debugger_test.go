@@ -0,0 +1,76 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package debugger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+)
+
+// Tests that breakpoints pause the execution and the state can be inspected
+// while paused.
+func TestDebuggerSession(t *testing.T) {
+	code := []byte{
+		byte(vm.PUSH1), 0x01, byte(vm.PUSH1), 0x00, byte(vm.SSTORE),
+		byte(vm.PUSH1), 0x02, byte(vm.PUSH1), 0x00, byte(vm.TSTORE),
+		byte(vm.STOP),
+	}
+	script := strings.Join([]string{
+		"break sstore",
+		"break op stop",
+		"bl",
+		"continue",
+		"stack",
+		"storage 0",
+		"step",
+		"storage 0",
+		"continue",
+		"tstorage 0x0",
+		"quit",
+	}, "\n")
+
+	var (
+		out bytes.Buffer
+		dbg = New(strings.NewReader(script), &out)
+	)
+	if _, _, err := runtime.Execute(code, nil, &runtime.Config{EVMConfig: vm.Config{Tracer: dbg.Hooks()}}); err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+	want := []string{
+		"pc=0 PUSH1 gas=",
+		"#1: sstore\n#2: op STOP\n",
+		"breakpoint #1: sstore\n",
+		"   0: 0x0000000000000000000000000000000000000000000000000000000000000000\n   1: 0x0000000000000000000000000000000000000000000000000000000000000001\n",
+		"> 0x0000000000000000000000000000000000000000000000000000000000000000\n",
+		"pc=5 PUSH1",
+		"> 0x0000000000000000000000000000000000000000000000000000000000000001\n",
+		"breakpoint #2: op STOP\n",
+		"> 0x0000000000000000000000000000000000000000000000000000000000000002\n",
+	}
+	output := out.String()
+	for _, w := range want {
+		idx := strings.Index(output, w)
+		if idx < 0 {
+			t.Fatalf("missing output %q in:\n%s", w, output)
+		}
+		output = output[idx+len(w):]
+	}
+}
@@ -425,6 +425,9 @@ func calcDifficulty(config *params.ChainConfig, number, currentTime, parentTime
 }
 
 func writeTraceResult(tracer *tracers.Tracer, f io.WriteCloser) error {
+	if f == nil {
+		return nil // tracer without output, e.g. the interactive debugger
+	}
 	defer f.Close()
 	result, err := tracer.GetResult()
 	if err != nil || result == nil {
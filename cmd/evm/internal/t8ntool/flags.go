@@ -38,6 +38,10 @@ var (
 		Name:  "trace.jsonconfig",
 		Usage: "The configurations for the custom tracer specified by --trace.tracer. If provided, must be in JSON format",
 	}
+	TraceDebuggerFlag = &cli.BoolFlag{
+		Name:  "trace.debugger",
+		Usage: "Step through the transactions interactively, reading commands from stdin. Requires file inputs",
+	}
 	TraceEnableMemoryFlag = &cli.BoolFlag{
 		Name:  "trace.memory",
 		Usage: "Enable full memory dump in traces",
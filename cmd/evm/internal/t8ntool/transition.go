@@ -25,6 +25,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/ethereum/go-ethereum/cmd/evm/internal/debugger"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
@@ -116,6 +117,21 @@ func Transition(ctx *cli.Context) error {
 			}
 			return tracer, traceFile, nil
 		}
+	} else if ctx.Bool(TraceDebuggerFlag.Name) { // Interactive debugging
+		if ctx.String(InputAllocFlag.Name) == stdinSelector || ctx.String(InputEnvFlag.Name) == stdinSelector || ctx.String(InputTxsFlag.Name) == stdinSelector {
+			return NewError(ErrorConfig, errors.New("the debugger cannot be used with stdin inputs"))
+		}
+		// Share the debugger across transactions to retain the breakpoints
+		dbg := debugger.New(os.Stdin, os.Stderr)
+		getTracer = func(txIndex int, txHash common.Hash) (*tracers.Tracer, io.WriteCloser, error) {
+			fmt.Fprintf(os.Stderr, "transaction %d (%v)\n", txIndex, txHash)
+			tracer := &tracers.Tracer{
+				Hooks:     dbg.Hooks(),
+				GetResult: func() (json.RawMessage, error) { return nil, nil },
+				Stop:      func(err error) {},
+			}
+			return tracer, nil, nil
+		}
 	} else if ctx.IsSet(TraceTracerFlag.Name) {
 		var config json.RawMessage
 		if ctx.IsSet(TraceTracerConfigFlag.Name) {
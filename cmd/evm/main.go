@@ -38,6 +38,16 @@ var (
 		Usage:    "output full trace logs",
 		Category: flags.VMCategory,
 	}
+	DebuggerFlag = &cli.BoolFlag{
+		Name:     "debugger",
+		Usage:    "step through the execution interactively, reading commands from stdin",
+		Category: flags.VMCategory,
+	}
+	GasProfileFlag = &cli.StringFlag{
+		Name:     "gasprofile",
+		Usage:    "write a pprof gas profile of the execution to the given file",
+		Category: flags.VMCategory,
+	}
 	StatDumpFlag = &cli.BoolFlag{
 		Name:     "statdump",
 		Usage:    "displays stack and heap memory information",
@@ -149,6 +159,7 @@ var stateTransitionCommand = &cli.Command{
 		t8ntool.TraceFlag,
 		t8ntool.TraceTracerFlag,
 		t8ntool.TraceTracerConfigFlag,
+		t8ntool.TraceDebuggerFlag,
 		t8ntool.TraceEnableMemoryFlag,
 		t8ntool.TraceDisableStackFlag,
 		t8ntool.TraceEnableReturnDataFlag,
@@ -213,7 +224,9 @@ var vmFlags = []cli.Flag{
 var traceFlags = []cli.Flag{
 	BenchFlag,
 	DebugFlag,
+	DebuggerFlag,
 	DumpFlag,
+	GasProfileFlag,
 	MachineFlag,
 	StatDumpFlag,
 	DisableMemoryFlag,
@@ -227,8 +240,10 @@ var app = flags.NewApp("the evm command line interface")
 func init() {
 	app.Flags = flags.Merge(vmFlags, traceFlags, debug.Flags)
 	app.Commands = []*cli.Command{
+		benchCommand,
 		compileCommand,
 		disasmCommand,
+		eofParseCommand,
 		runCommand,
 		blockTestCommand,
 		stateTestCommand,
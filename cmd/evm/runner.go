@@ -28,6 +28,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/cmd/evm/internal/compiler"
+	"github.com/ethereum/go-ethereum/cmd/evm/internal/debugger"
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
@@ -36,6 +37,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/tracing"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/eth/tracers"
 	"github.com/ethereum/go-ethereum/eth/tracers/logger"
 	"github.com/ethereum/go-ethereum/internal/flags"
 	"github.com/ethereum/go-ethereum/params"
@@ -119,6 +121,7 @@ func runCmd(ctx *cli.Context) error {
 	var (
 		tracer      *tracing.Hooks
 		debugLogger *logger.StructLogger
+		profiler    *tracers.Tracer
 		statedb     *state.StateDB
 		chainConfig *params.ChainConfig
 		sender      = common.BytesToAddress([]byte("sender"))
@@ -127,7 +130,16 @@ func runCmd(ctx *cli.Context) error {
 		blobHashes  []common.Hash  // TODO (MariusVanDerWijden) implement blob hashes in state tests
 		blobBaseFee = new(big.Int) // TODO (MariusVanDerWijden) implement blob fee in state tests
 	)
-	if ctx.Bool(MachineFlag.Name) {
+	if ctx.String(GasProfileFlag.Name) != "" {
+		var err error
+		profiler, err = tracers.DefaultDirectory.New("gasProfiler", new(tracers.Context), json.RawMessage(`{"format":"pprof"}`))
+		if err != nil {
+			return err
+		}
+		tracer = profiler.Hooks
+	} else if ctx.Bool(DebuggerFlag.Name) {
+		tracer = debugger.New(os.Stdin, os.Stderr).Hooks()
+	} else if ctx.Bool(MachineFlag.Name) {
 		tracer = logger.NewJSONLogger(logconfig, os.Stdout)
 	} else if ctx.Bool(DebugFlag.Name) {
 		debugLogger = logger.NewStructLogger(logconfig)
@@ -285,6 +297,12 @@ func runCmd(ctx *cli.Context) error {
 		fmt.Println(string(dumpdb.Dump(nil)))
 	}
 
+	if profiler != nil {
+		if err := writeGasProfile(profiler, ctx.String(GasProfileFlag.Name)); err != nil {
+			return err
+		}
+	}
+
 	if ctx.Bool(DebugFlag.Name) {
 		if debugLogger != nil {
 			fmt.Fprintln(os.Stderr, "#### TRACE ####")
@@ -301,7 +319,7 @@ allocations:     %d
 allocated bytes: %d
 `, initialGas-leftOverGas, stats.time, stats.allocs, stats.bytesAllocated)
 	}
-	if tracer == nil {
+	if tracer == nil || profiler != nil || ctx.Bool(DebuggerFlag.Name) {
 		fmt.Printf("%#x\n", output)
 		if err != nil {
 			fmt.Printf(" error: %v\n", err)
@@ -310,3 +328,17 @@ allocated bytes: %d
 
 	return nil
 }
+
+// writeGasProfile writes the pprof profile collected by the gas profiler to
+// the given file.
+func writeGasProfile(profiler *tracers.Tracer, path string) error {
+	res, err := profiler.GetResult()
+	if err != nil {
+		return fmt.Errorf("failed to collect gas profile: %v", err)
+	}
+	var blob []byte
+	if err := json.Unmarshal(res, &blob); err != nil {
+		return fmt.Errorf("invalid gas profile: %v", err)
+	}
+	return os.WriteFile(path, blob, 0644)
+}
@@ -19,9 +19,11 @@ package main
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 
+	"github.com/ethereum/go-ethereum/cmd/evm/internal/debugger"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
@@ -59,6 +61,13 @@ func stateTestCmd(ctx *cli.Context) error {
 	}
 	var cfg vm.Config
 	switch {
+	case ctx.Bool(DebuggerFlag.Name):
+		// The debugger reads its commands from stdin, so batch mode is unavailable
+		if len(ctx.Args().First()) == 0 {
+			return errors.New("the debugger requires the test file as an argument")
+		}
+		cfg.Tracer = debugger.New(os.Stdin, os.Stderr).Hooks()
+
 	case ctx.Bool(MachineFlag.Name):
 		cfg.Tracer = logger.NewJSONLogger(config, os.Stderr)
 
@@ -19,10 +19,13 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/console/prompt"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/urfave/cli/v2"
@@ -106,6 +109,9 @@ Print a short summary of all accounts`,
 					utils.KeyStoreDirFlag,
 					utils.PasswordFileFlag,
 					utils.LightKDFFlag,
+					utils.KeyStoreKDFFlag,
+					utils.PasswordMinLengthFlag,
+					utils.PasswordMinClassesFlag,
 				},
 				Description: `
     geth account new
@@ -131,6 +137,9 @@ password to file or expose in any other way.
 					utils.DataDirFlag,
 					utils.KeyStoreDirFlag,
 					utils.LightKDFFlag,
+					utils.KeyStoreKDFFlag,
+					utils.PasswordMinLengthFlag,
+					utils.PasswordMinClassesFlag,
 				},
 				Description: `
     geth account update <address>
@@ -149,6 +158,37 @@ For non-interactive use the password can be specified with the --password flag:
 
 Since only one password can be given, only format update can be performed,
 changing your password is only possible interactively.
+`,
+			},
+			{
+				Name:   "rekey",
+				Usage:  "Re-encrypt all keys with the configured key derivation",
+				Action: accountRekey,
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.KeyStoreDirFlag,
+					utils.PasswordFileFlag,
+					utils.LightKDFFlag,
+					utils.KeyStoreKDFFlag,
+					utils.PasswordMinLengthFlag,
+					utils.PasswordMinClassesFlag,
+				},
+				Description: `
+    geth account rekey --keystore.kdf argon2id
+
+Re-encrypts every key file and HD wallet seed file of the keystore with the key
+derivation function selected by --keystore.kdf and --lightkdf, keeping their
+passwords. Files already encrypted with the selected parameters are skipped.
+
+Every file is written next to the original and decrypted again before it
+atomically replaces the original, so a failure leaves the original untouched.
+
+You are prompted for the password of every file. For non-interactive use the
+--password file lists the passwords one per line, in the order the files are
+printed by 'geth account list'. The last password is used for all remaining files.
+
+Passwords violating the policy set by --password.minlength and --password.minclasses
+are reported, they can be changed with 'geth account update'.
 `,
 			},
 			{
@@ -160,6 +200,9 @@ changing your password is only possible interactively.
 					utils.KeyStoreDirFlag,
 					utils.PasswordFileFlag,
 					utils.LightKDFFlag,
+					utils.KeyStoreKDFFlag,
+					utils.PasswordMinLengthFlag,
+					utils.PasswordMinClassesFlag,
 				},
 				ArgsUsage: "<keyFile>",
 				Description: `
@@ -182,6 +225,53 @@ Note:
 As you can directly copy your encrypted accounts to another ethereum instance,
 this import mechanism is not needed when you transfer an account between
 nodes.
+`,
+			},
+			{
+				Name:   "import-mnemonic",
+				Usage:  "Import a BIP-39 mnemonic into a new HD wallet",
+				Action: accountImportMnemonic,
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.KeyStoreDirFlag,
+					utils.PasswordFileFlag,
+					utils.LightKDFFlag,
+					utils.KeyStoreKDFFlag,
+					utils.PasswordMinLengthFlag,
+					utils.PasswordMinClassesFlag,
+				},
+				Description: `
+    geth account import-mnemonic
+
+Imports a BIP-39 mnemonic as a hierarchical deterministic wallet and prints the
+address of its first account, derived at m/44'/60'/0'/0/0. You are prompted for
+the mnemonic, an optional BIP-39 passphrase and a password.
+
+The seed is saved in encrypted format under <KEYSTORE>/hd. Further accounts are
+derived with the same paths hardware wallets use, either discovered from the
+chain when the wallet is opened, or derived explicitly.
+
+You must remember the password to unlock the wallet in the future. The BIP-39
+passphrase is not stored, it is only needed to import the mnemonic again.
+`,
+			},
+			{
+				Name:      "export-mnemonic",
+				Usage:     "Print the BIP-39 mnemonic of an HD wallet",
+				Action:    accountExportMnemonic,
+				ArgsUsage: "<address>",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.KeyStoreDirFlag,
+					utils.PasswordFileFlag,
+				},
+				Description: `
+    geth account export-mnemonic <address>
+
+Decrypts and prints the mnemonic of the HD wallet containing the given account,
+after asking for confirmation.
+
+Anyone who learns the mnemonic controls every account of the wallet.
 `,
 			},
 		},
@@ -285,16 +375,13 @@ func accountCreate(ctx *cli.Context) error {
 	if isEphemeral {
 		utils.Fatalf("Can't use ephemeral directory as keystore path")
 	}
-	scryptN := keystore.StandardScryptN
-	scryptP := keystore.StandardScryptP
-	if cfg.Node.UseLightweightKDF {
-		scryptN = keystore.LightScryptN
-		scryptP = keystore.LightScryptP
+	ksConfig, err := makeKeyStoreConfig(&cfg.Node)
+	if err != nil {
+		utils.Fatalf("Failed to configure the keystore: %v", err)
 	}
-
 	password := utils.GetPassPhraseWithList("Your new account is locked with a password. Please give a password. Do not forget this password.", true, 0, utils.MakePasswordList(ctx))
 
-	account, err := keystore.StoreKey(keydir, password, scryptN, scryptP)
+	account, err := keystore.NewKeyStoreWithConfig(keydir, ksConfig).NewAccount(password)
 
 	if err != nil {
 		utils.Fatalf("Failed to create account: %v", err)
@@ -332,6 +419,51 @@ func accountUpdate(ctx *cli.Context) error {
 	return nil
 }
 
+// accountRekey re-encrypts all keys of the keystore with the configured key
+// derivation function.
+func accountRekey(ctx *cli.Context) error {
+	cfg := loadBaseConfig(ctx)
+	keydir, isEphemeral, err := cfg.Node.GetKeyStoreDir()
+	if err != nil {
+		utils.Fatalf("Failed to get the keystore directory: %v", err)
+	}
+	if isEphemeral {
+		utils.Fatalf("Can't use ephemeral directory as keystore path")
+	}
+	ksConfig, err := makeKeyStoreConfig(&cfg.Node)
+	if err != nil {
+		utils.Fatalf("Failed to configure the keystore: %v", err)
+	}
+	var (
+		ks        = keystore.NewKeyStoreWithConfig(keydir, ksConfig)
+		wallets   = ks.Wallets()
+		passwords = utils.MakePasswordList(ctx)
+		failed    int
+	)
+	for i, wallet := range wallets {
+		path := wallet.URL().Path
+		kdf, err := ks.WalletKDF(wallet)
+		if err == nil && kdf == ksConfig.KDF {
+			fmt.Printf("Skipping %s, already encrypted with %v\n", path, kdf)
+			continue
+		}
+		password := utils.GetPassPhraseWithList(fmt.Sprintf("Please enter the password of %s", path), false, i, passwords)
+		if err := ks.RekeyWallet(wallet, password); err != nil {
+			log.Error("Failed to rekey", "path", path, "err", err)
+			failed++
+			continue
+		}
+		fmt.Printf("Re-encrypted %s with %v\n", path, ksConfig.KDF)
+		if err := ksConfig.Policy.Check(password); err != nil {
+			log.Warn("Password violates the password policy, change it with 'geth account update'", "path", path, "err", err)
+		}
+	}
+	if failed > 0 {
+		utils.Fatalf("Failed to rekey %d of %d files", failed, len(wallets))
+	}
+	return nil
+}
+
 func importWallet(ctx *cli.Context) error {
 	if ctx.Args().Len() != 1 {
 		utils.Fatalf("keyfile must be given as the only argument")
@@ -382,3 +514,60 @@ func accountImport(ctx *cli.Context) error {
 	fmt.Printf("Address: {%x}\n", acct.Address)
 	return nil
 }
+
+func accountImportMnemonic(ctx *cli.Context) error {
+	mnemonic, err := prompt.Stdin.PromptPassword("Mnemonic: ")
+	if err != nil {
+		utils.Fatalf("Failed to read mnemonic: %v", err)
+	}
+	bip39Passphrase, err := prompt.Stdin.PromptPassword("BIP-39 passphrase (empty for none): ")
+	if err != nil {
+		utils.Fatalf("Failed to read BIP-39 passphrase: %v", err)
+	}
+	ks := keystoreBackend(ctx)
+	passphrase := utils.GetPassPhraseWithList("Your new wallet is locked with a password. Please give a password. Do not forget this password.", true, 0, utils.MakePasswordList(ctx))
+
+	acct, err := ks.ImportMnemonic(mnemonic, bip39Passphrase, passphrase)
+	if err != nil {
+		utils.Fatalf("Could not create the wallet: %v", err)
+	}
+	fmt.Printf("Address: {%x}\n", acct.Address)
+	fmt.Printf("Path of the seed file: %s\n", strings.TrimSuffix(acct.URL.Path, "/"+accounts.DefaultBaseDerivationPath.String()))
+	return nil
+}
+
+func accountExportMnemonic(ctx *cli.Context) error {
+	if ctx.Args().Len() != 1 {
+		utils.Fatalf("address must be given as the only argument")
+	}
+	if !common.IsHexAddress(ctx.Args().First()) {
+		utils.Fatalf("Invalid address: %s", ctx.Args().First())
+	}
+	account := accounts.Account{Address: common.HexToAddress(ctx.Args().First())}
+
+	ks := keystoreBackend(ctx)
+	passphrase := utils.GetPassPhraseWithList("", false, 0, utils.MakePasswordList(ctx))
+
+	fmt.Println("Anyone who learns the mnemonic controls every account of the wallet.")
+	confirmed, err := prompt.Stdin.PromptConfirm("Print the mnemonic?")
+	if err != nil || !confirmed {
+		utils.Fatalf("Export aborted")
+	}
+	mnemonic, err := ks.ExportMnemonic(account, passphrase)
+	if err != nil {
+		utils.Fatalf("Could not export the mnemonic: %v", err)
+	}
+	fmt.Println(mnemonic)
+	return nil
+}
+
+// keystoreBackend returns the keystore of the account manager defined by the
+// CLI flags.
+func keystoreBackend(ctx *cli.Context) *keystore.KeyStore {
+	am := makeAccountManager(ctx)
+	backends := am.Backends(keystore.KeyStoreType)
+	if len(backends) == 0 {
+		utils.Fatalf("Keystore is not available")
+	}
+	return backends[0].(*keystore.KeyStore)
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/external"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/accounts/pkcs11"
 	"github.com/ethereum/go-ethereum/accounts/scwallet"
 	"github.com/ethereum/go-ethereum/accounts/usbwallet"
 	"github.com/ethereum/go-ethereum/beacon/blsync"
@@ -329,14 +330,34 @@ func deprecated(field string) bool {
 	}
 }
 
-func setAccountManagerBackends(conf *node.Config, am *accounts.Manager, keydir string) error {
-	scryptN := keystore.StandardScryptN
-	scryptP := keystore.StandardScryptP
-	if conf.UseLightweightKDF {
-		scryptN = keystore.LightScryptN
-		scryptP = keystore.LightScryptP
+// makeKeyStoreConfig assembles the key derivation and password policy of the
+// key store from the node config.
+func makeKeyStoreConfig(conf *node.Config) (keystore.Config, error) {
+	var config keystore.Config
+	switch conf.KeyStoreKDF {
+	case "", "scrypt":
+		config.KDF = keystore.ScryptKDF(keystore.StandardScryptN, keystore.StandardScryptP)
+		if conf.UseLightweightKDF {
+			config.KDF = keystore.ScryptKDF(keystore.LightScryptN, keystore.LightScryptP)
+		}
+	case "argon2id":
+		config.KDF = keystore.Argon2idKDF(keystore.StandardArgon2idTime, keystore.StandardArgon2idMemory, keystore.StandardArgon2idThreads)
+		if conf.UseLightweightKDF {
+			config.KDF = keystore.Argon2idKDF(keystore.LightArgon2idTime, keystore.LightArgon2idMemory, keystore.LightArgon2idThreads)
+		}
+	default:
+		return config, fmt.Errorf("unsupported keystore KDF %q", conf.KeyStoreKDF)
 	}
+	if conf.PasswordMinLength > 0 || conf.PasswordMinClasses > 0 {
+		config.Policy = &keystore.PasswordPolicy{
+			MinLength:  conf.PasswordMinLength,
+			MinClasses: conf.PasswordMinClasses,
+		}
+	}
+	return config, nil
+}
 
+func setAccountManagerBackends(conf *node.Config, am *accounts.Manager, keydir string) error {
 	// Assemble the supported backends
 	if len(conf.ExternalSigner) > 0 {
 		log.Info("Using external signer", "url", conf.ExternalSigner)
@@ -352,7 +373,11 @@ func setAccountManagerBackends(conf *node.Config, am *accounts.Manager, keydir s
 	// If/when we implement some form of lockfile for USB and keystore wallets,
 	// we can have both, but it's very confusing for the user to see the same
 	// accounts in both externally and locally, plus very racey.
-	am.AddBackend(keystore.NewKeyStore(keydir, scryptN, scryptP))
+	ksConfig, err := makeKeyStoreConfig(conf)
+	if err != nil {
+		return err
+	}
+	am.AddBackend(keystore.NewKeyStoreWithConfig(keydir, ksConfig))
 	if conf.USB {
 		// Start a USB hub for Ledger hardware wallets
 		if ledgerhub, err := usbwallet.NewLedgerHub(); err != nil {
@@ -381,6 +406,14 @@ func setAccountManagerBackends(conf *node.Config, am *accounts.Manager, keydir s
 			am.AddBackend(schub)
 		}
 	}
+	if len(conf.PKCS11Module) > 0 {
+		// Start a PKCS#11 hub for hardware security modules
+		if p11hub, err := pkcs11.NewHub(conf.PKCS11Module); err != nil {
+			log.Warn(fmt.Sprintf("Failed to start PKCS#11 hub, disabling: %v", err))
+		} else {
+			am.AddBackend(p11hub)
+		}
+	}
 
 	return nil
 }
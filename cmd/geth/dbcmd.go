@@ -18,6 +18,7 @@ package main
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -31,6 +32,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/console/prompt"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state/snapshot"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -54,6 +56,10 @@ var (
 		Name:  "remove.chain",
 		Usage: "If set, selects the state data for removal",
 	}
+	dbMigrateDryRunFlag = &cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "List the pending migrations without applying them",
+	}
 
 	removedbCommand = &cli.Command{
 		Action:    removeDB,
@@ -83,6 +89,7 @@ Remove blockchain and state databases`,
 			dbMetadataCmd,
 			dbCheckStateContentCmd,
 			dbInspectHistoryCmd,
+			dbMigrateCmd,
 		},
 	}
 	dbInspectCmd = &cli.Command{
@@ -125,6 +132,19 @@ a data corruption.`,
 		Description: `This command performs a database compaction.
 WARNING: This operation may take a very long time to finish, and may cause database
 corruption if it is aborted during execution'!`,
+	}
+	dbMigrateCmd = &cli.Command{
+		Action: dbMigrate,
+		Name:   "migrate",
+		Usage:  "Upgrade the database schema to the latest supported version",
+		Flags: flags.Merge([]cli.Flag{
+			utils.SyncModeFlag,
+			dbMigrateDryRunFlag,
+		}, utils.NetworkFlags, utils.DatabaseFlags),
+		Description: `This command applies all pending schema migrations to the database. The
+same migrations are otherwise run automatically on startup. Migrations are
+checkpointed, so an interrupted run will resume where it left off. Use --dry-run
+to list the pending migrations without modifying the database.`,
 	}
 	dbGetCmd = &cli.Command{
 		Action:    dbGet,
@@ -448,6 +468,33 @@ func dbCompact(ctx *cli.Context) error {
 	return nil
 }
 
+// dbMigrate applies the pending schema migrations to the database.
+func dbMigrate(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	dryRun := ctx.Bool(dbMigrateDryRunFlag.Name)
+	db := utils.MakeChainDatabase(ctx, stack, dryRun)
+	defer db.Close()
+
+	version := rawdb.ReadDatabaseVersion(db)
+	if version == nil {
+		return errors.New("database is not initialized")
+	}
+	pending, err := rawdb.PendingMigrations(db, core.BlockChainVersion)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Database version: v%d, supported: v%d\n", *version, core.BlockChainVersion)
+	for _, m := range pending {
+		fmt.Printf("Pending migration to v%d: %s\n", m.Version, m.Description)
+	}
+	if dryRun || *version == core.BlockChainVersion {
+		return nil
+	}
+	return rawdb.MigrateDatabase(db, core.BlockChainVersion)
+}
+
 // dbGet shows the value of a given database key
 func dbGet(ctx *cli.Context) error {
 	if ctx.NArg() != 1 {
@@ -64,6 +64,7 @@ var (
 		utils.NoUSBFlag, // deprecated
 		utils.USBFlag,
 		utils.SmartCardDaemonPathFlag,
+		utils.PKCS11ModuleFlag,
 		utils.OverrideCancun,
 		utils.OverrideVerkle,
 		utils.EnablePersonal,
@@ -95,6 +96,9 @@ var (
 		utils.LightMaxPeersFlag, // deprecated
 		utils.LightNoPruneFlag,  // deprecated
 		utils.LightKDFFlag,
+		utils.KeyStoreKDFFlag,
+		utils.PasswordMinLengthFlag,
+		utils.PasswordMinClassesFlag,
 		utils.LightNoSyncServeFlag, // deprecated
 		utils.EthRequiredBlocksFlag,
 		utils.LegacyWhitelistFlag, // deprecated
@@ -185,7 +189,10 @@ var (
 		utils.InsecureUnlockAllowedFlag,
 		utils.RPCGlobalGasCapFlag,
 		utils.RPCGlobalEVMTimeoutFlag,
+		utils.RPCGlobalTraceSizeCapFlag,
 		utils.RPCGlobalTxFeeCapFlag,
+		utils.TraceIndexFlag,
+		utils.RPCDatabaseWritesFlag,
 		utils.AllowUnprotectedTxs,
 		utils.BatchRequestLimit,
 		utils.BatchResponseMaxSize,
@@ -246,6 +253,8 @@ func init() {
 		snapshotCommand,
 		// See verkle.go
 		verkleCommand,
+		// See stateless.go
+		statelessCommand,
 	}
 	if logTestCommand != nil {
 		app.Commands = append(app.Commands, logTestCommand)
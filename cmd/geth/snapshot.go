@@ -22,6 +22,9 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
 	"time"
 
 	"github.com/ethereum/go-ethereum/cmd/utils"
@@ -159,6 +162,47 @@ block is used.
 				Description: `
 The export-preimages command exports hash preimages to a flat file, in exactly
 the expected order for the overlay tree migration.
+`,
+			},
+			{
+				Action:    snapshotExportState,
+				Name:      "export",
+				Usage:     "Export the state of a block into a portable binary file",
+				ArgsUsage: "<dumpfile> [? <blockHash> | <blockNum>]",
+				Flags:     flags.Merge(utils.NetworkFlags, utils.DatabaseFlags),
+				Description: `
+geth snapshot export <dumpfile> [? <blockHash> | <blockNum>]
+will export all accounts, storage slots and contract codes of the given block,
+enumerated via the snapshot, into a compact binary file. The block itself and
+the headers of its 256 most recent ancestors are included, so the file can be
+used to bootstrap another node with 'geth snapshot import'. If the file name
+ends with .gz, the output is gzip-compressed.
+
+The argument is interpreted as block number or hash. If none is provided, the latest
+block is used.
+`,
+			},
+			{
+				Action:    snapshotImportState,
+				Name:      "import",
+				Usage:     "Import the state of a block from a portable binary file",
+				ArgsUsage: "<dumpfile>",
+				Flags:     flags.Merge(utils.NetworkFlags, utils.DatabaseFlags),
+				Description: `
+geth snapshot import <dumpfile>
+will import a state file produced by 'geth snapshot export'. The state trie is
+regenerated from the flat data and its root is verified against the header of
+the exported block, which then becomes the head of the local chain. The file is
+fully verified before anything is written, so a corrupt file leaves the database
+untouched. The node can continue syncing from that block afterwards.
+
+The database must be initialized with the genesis of the same network ('geth init')
+and must not contain any other blocks. The snapshot is regenerated in the background
+once the node starts.
+
+Only the imported block and the headers of its 256 most recent ancestors are
+written. Older headers, as well as the bodies and receipts of all earlier blocks,
+are not available in the resulting chain.
 `,
 			},
 		},
@@ -692,3 +736,99 @@ func checkAccount(ctx *cli.Context) error {
 	log.Info("Checked the snapshot journalled storage", "time", common.PrettyDuration(time.Since(start)))
 	return nil
 }
+
+// snapshotExportState exports the state of a block into a portable binary file.
+func snapshotExportState(ctx *cli.Context) error {
+	if ctx.NArg() < 1 || ctx.NArg() > 2 {
+		return fmt.Errorf("required arguments: %v", ctx.Command.ArgsUsage)
+	}
+	var (
+		stack, _  = makeConfigNode(ctx)
+		interrupt = make(chan os.Signal, 1)
+		stop      = make(chan struct{})
+	)
+	defer stack.Close()
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	defer close(interrupt)
+	go func() {
+		if _, ok := <-interrupt; ok {
+			log.Info("Interrupted during state export, stopping at next batch")
+		}
+		close(stop)
+	}()
+	chaindb := utils.MakeChainDatabase(ctx, stack, true)
+	defer chaindb.Close()
+
+	var block *types.Block
+	if ctx.NArg() == 2 {
+		arg := ctx.Args().Get(1)
+		if hashish(arg) {
+			hash := common.HexToHash(arg)
+			if number := rawdb.ReadHeaderNumber(chaindb, hash); number != nil {
+				block = rawdb.ReadBlock(chaindb, hash, *number)
+			}
+		} else {
+			number, err := strconv.ParseUint(arg, 10, 64)
+			if err != nil {
+				return err
+			}
+			if hash := rawdb.ReadCanonicalHash(chaindb, number); hash != (common.Hash{}) {
+				block = rawdb.ReadBlock(chaindb, hash, number)
+			}
+		}
+		if block == nil {
+			return fmt.Errorf("block %s not found", arg)
+		}
+	} else {
+		block = rawdb.ReadHeadBlock(chaindb)
+		if block == nil {
+			log.Error("Failed to load head block")
+			return errors.New("no head block")
+		}
+	}
+	td := rawdb.ReadTd(chaindb, block.Hash(), block.NumberU64())
+	if td == nil {
+		return fmt.Errorf("total difficulty of block %d not found", block.NumberU64())
+	}
+	triedb := utils.MakeTrieDatabase(ctx, chaindb, false, true, false)
+	defer triedb.Close()
+
+	snapConfig := snapshot.Config{
+		CacheSize:  256,
+		Recovery:   false,
+		NoBuild:    true,
+		AsyncBuild: false,
+	}
+	snaptree, err := snapshot.New(snapConfig, chaindb, triedb, block.Root())
+	if err != nil {
+		log.Error("Failed to open snapshot tree", "err", err)
+		return err
+	}
+	return utils.ExportSnapshotState(chaindb, snaptree, block, td, ctx.Args().First(), stop)
+}
+
+// snapshotImportState imports the state of a block from a portable binary file
+// and sets the block as the head of the local chain.
+func snapshotImportState(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("required arguments: %v", ctx.Command.ArgsUsage)
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chaindb := utils.MakeChainDatabase(ctx, stack, false)
+	defer chaindb.Close()
+
+	triedb := utils.MakeTrieDatabase(ctx, chaindb, false, false, false)
+	defer triedb.Close()
+
+	start := time.Now()
+	block, err := utils.ImportSnapshotState(chaindb, triedb, ctx.Args().First())
+	if err != nil {
+		log.Error("Failed to import state", "err", err)
+		return err
+	}
+	fmt.Printf("Imported state of block %d (%x) in %v\n", block.NumberU64(), block.Hash(), time.Since(start))
+	return nil
+}
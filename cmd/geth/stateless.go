@@ -0,0 +1,112 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/stateless"
+	"github.com/ethereum/go-ethereum/internal/flags"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	statelessCommand = &cli.Command{
+		Name:        "stateless",
+		Usage:       "A set of commands for stateless block execution",
+		Description: "",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "verify",
+				Usage:     "Execute a block purely from its execution witness",
+				ArgsUsage: "<witness-file>",
+				Action:    verifyWitness,
+				Flags:     flags.Merge(utils.NetworkFlags, utils.DatabaseFlags),
+				Description: `
+geth stateless verify <witness-file>
+will execute the block contained in the witness file without accessing any
+local state, and report the resulting state and receipt roots. The witness can
+be either JSON or RLP encoded, as returned by debug_executionWitness.
+
+The chain configuration is taken from the local database if it's initialized,
+otherwise from the selected network. If the local chain contains the block,
+the roots are also compared against the canonical header.
+`,
+			},
+		},
+	}
+)
+
+// verifyWitness executes a block statelessly from an execution witness file.
+func verifyWitness(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("required arguments: %v", ctx.Command.ArgsUsage)
+	}
+	blob, err := os.ReadFile(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	witness := new(stateless.Witness)
+	if trimmed := bytes.TrimSpace(blob); len(trimmed) > 0 && trimmed[0] == '{' {
+		err = json.Unmarshal(trimmed, witness)
+	} else {
+		err = rlp.DecodeBytes(blob, witness)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to decode witness: %v", err)
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chaindb := utils.MakeChainDatabase(ctx, stack, true)
+	defer chaindb.Close()
+
+	config, err := core.LoadChainConfig(chaindb, utils.MakeGenesis(ctx))
+	if err != nil {
+		return err
+	}
+	log.Info("Executing block statelessly", "number", witness.Block.Number(), "txs", len(witness.Block.Transactions()),
+		"headers", len(witness.Headers), "codes", len(witness.Codes), "nodes", len(witness.State))
+
+	receiptRoot, stateRoot, err := core.ExecuteStateless(config, witness)
+	if err != nil {
+		log.Error("Stateless execution failed", "number", witness.Block.Number(), "err", err)
+		return err
+	}
+	fmt.Printf("Block:        %d\n", witness.Block.NumberU64())
+	fmt.Printf("State root:   %#x\n", stateRoot)
+	fmt.Printf("Receipt root: %#x\n", receiptRoot)
+
+	// If the block is known locally, cross-check the computed roots
+	number := witness.Block.NumberU64()
+	if header := rawdb.ReadHeader(chaindb, rawdb.ReadCanonicalHash(chaindb, number), number); header != nil && header.ParentHash == witness.Block.ParentHash() {
+		if header.Root != stateRoot || header.ReceiptHash != receiptRoot {
+			return fmt.Errorf("root mismatch with local block %d: state %x (local %x), receipts %x (local %x)",
+				number, stateRoot, header.Root, receiptRoot, header.ReceiptHash)
+		}
+		fmt.Printf("Roots match local block %#x\n", header.Hash())
+	}
+	return nil
+}
@@ -53,6 +53,7 @@ import (
 	"github.com/ethereum/go-ethereum/eth/filters"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
 	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/eth/tracers/parity"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/ethdb/remotedb"
 	"github.com/ethereum/go-ethereum/ethstats"
@@ -131,6 +132,11 @@ var (
 		Value:    pcsclite.PCSCDSockName,
 		Category: flags.AccountCategory,
 	}
+	PKCS11ModuleFlag = &cli.StringFlag{
+		Name:     "pkcs11.module",
+		Usage:    "Path to a PKCS#11 module (shared library) to access secp256k1 keys on hardware security modules",
+		Category: flags.AccountCategory,
+	}
 	NetworkIdFlag = &cli.Uint64Flag{
 		Name:     "networkid",
 		Usage:    "Explicitly set network id (integer)(For testnets: use --goerli, --sepolia, --holesky instead)",
@@ -233,6 +239,22 @@ var (
 		Usage:    "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
 		Category: flags.AccountCategory,
 	}
+	KeyStoreKDFFlag = &cli.StringFlag{
+		Name:     "keystore.kdf",
+		Usage:    "Key derivation function of new and updated keys (scrypt, argon2id)",
+		Value:    "scrypt",
+		Category: flags.AccountCategory,
+	}
+	PasswordMinLengthFlag = &cli.IntFlag{
+		Name:     "password.minlength",
+		Usage:    "Minimum length of the passwords of new keys (0 = no minimum)",
+		Category: flags.AccountCategory,
+	}
+	PasswordMinClassesFlag = &cli.IntFlag{
+		Name:     "password.minclasses",
+		Usage:    "Minimum number of character classes (lowercase, uppercase, digits, symbols) in the passwords of new keys",
+		Category: flags.AccountCategory,
+	}
 	EthRequiredBlocksFlag = &cli.StringFlag{
 		Name:     "eth.requiredblocks",
 		Usage:    "Comma separated block number-to-hash mappings to require for peering (<number>=<hash>)",
@@ -562,12 +584,28 @@ var (
 		Value:    ethconfig.Defaults.RPCEVMTimeout,
 		Category: flags.APICategory,
 	}
+	RPCGlobalTraceSizeCapFlag = &cli.Uint64Flag{
+		Name:     "rpc.tracesizecap",
+		Usage:    "Sets a cap on the total size (in bytes) of the opcode traces kept in memory for a single debug tracing request, or a single block of debug_traceChain (0=infinite). Traces are only streamed without buffering over subscriptions (debug_traceTransactionStream), HTTP requests buffer the entire trace",
+		Value:    ethconfig.Defaults.RPCTraceSizeCap,
+		Category: flags.APICategory,
+	}
 	RPCGlobalTxFeeCapFlag = &cli.Float64Flag{
 		Name:     "rpc.txfeecap",
 		Usage:    "Sets a cap on transaction fee (in ether) that can be sent via the RPC APIs (0 = no cap)",
 		Value:    ethconfig.Defaults.RPCTxFeeCap,
 		Category: flags.APICategory,
 	}
+	TraceIndexFlag = &cli.BoolFlag{
+		Name:     "trace.index",
+		Usage:    "Index the call trace addresses of imported blocks to speed up trace_filter (traces every block)",
+		Category: flags.APICategory,
+	}
+	RPCDatabaseWritesFlag = &cli.BoolFlag{
+		Name:     "rpc.dbwrite",
+		Usage:    "Allow modifying the raw chain database via the debug_db* RPC APIs (dangerous)",
+		Category: flags.APICategory,
+	}
 	// Authenticated RPC HTTP settings
 	AuthListenFlag = &cli.StringFlag{
 		Name:     "authrpc.addr",
@@ -1409,6 +1447,9 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	setNodeUserIdent(ctx, cfg)
 	SetDataDir(ctx, cfg)
 	setSmartCard(ctx, cfg)
+	if ctx.IsSet(PKCS11ModuleFlag.Name) {
+		cfg.PKCS11Module = ctx.String(PKCS11ModuleFlag.Name)
+	}
 
 	if ctx.IsSet(JWTSecretFlag.Name) {
 		cfg.JWTSecret = ctx.String(JWTSecretFlag.Name)
@@ -1431,6 +1472,15 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	if ctx.IsSet(LightKDFFlag.Name) {
 		cfg.UseLightweightKDF = ctx.Bool(LightKDFFlag.Name)
 	}
+	if ctx.IsSet(KeyStoreKDFFlag.Name) {
+		cfg.KeyStoreKDF = ctx.String(KeyStoreKDFFlag.Name)
+	}
+	if ctx.IsSet(PasswordMinLengthFlag.Name) {
+		cfg.PasswordMinLength = ctx.Int(PasswordMinLengthFlag.Name)
+	}
+	if ctx.IsSet(PasswordMinClassesFlag.Name) {
+		cfg.PasswordMinClasses = ctx.Int(PasswordMinClassesFlag.Name)
+	}
 	if ctx.IsSet(NoUSBFlag.Name) || cfg.NoUSB {
 		log.Warn("Option nousb is deprecated and USB is deactivated by default. Use --usb to enable")
 	}
@@ -1780,9 +1830,19 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(RPCGlobalEVMTimeoutFlag.Name) {
 		cfg.RPCEVMTimeout = ctx.Duration(RPCGlobalEVMTimeoutFlag.Name)
 	}
+	if ctx.IsSet(RPCGlobalTraceSizeCapFlag.Name) {
+		cfg.RPCTraceSizeCap = ctx.Uint64(RPCGlobalTraceSizeCapFlag.Name)
+	}
 	if ctx.IsSet(RPCGlobalTxFeeCapFlag.Name) {
 		cfg.RPCTxFeeCap = ctx.Float64(RPCGlobalTxFeeCapFlag.Name)
 	}
+	if ctx.IsSet(TraceIndexFlag.Name) {
+		cfg.TraceIndex = ctx.Bool(TraceIndexFlag.Name)
+	}
+	if ctx.IsSet(RPCDatabaseWritesFlag.Name) {
+		cfg.RPCDatabaseWrites = ctx.Bool(RPCDatabaseWritesFlag.Name)
+		log.Warn("Database writes enabled over the debug RPC APIs")
+	}
 	if ctx.IsSet(NoDiscoverFlag.Name) {
 		cfg.EthDiscoveryURLs, cfg.SnapDiscoveryURLs = []string{}, []string{}
 	} else if ctx.IsSet(DNSDiscoveryFlag.Name) {
@@ -1944,6 +2004,10 @@ func RegisterEthService(stack *node.Node, cfg *ethconfig.Config) (ethapi.Backend
 		Fatalf("Failed to register the Ethereum service: %v", err)
 	}
 	stack.RegisterAPIs(tracers.APIs(backend.APIBackend))
+	stack.RegisterAPIs(parity.APIs(backend.APIBackend))
+	if cfg.TraceIndex {
+		stack.RegisterLifecycle(parity.NewIndexer(backend.APIBackend, backend.BlockChain()))
+	}
 	return backend.APIBackend, backend
 }
 
@@ -0,0 +1,531 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb"
+)
+
+// The state export file is a stream of RLP items. It starts with a stateHeader,
+// followed by the RLP encoded block the state belongs to, its total difficulty
+// and the list of its most recent ancestor headers in ascending order. The rest
+// of the file is a sequence of stateEntry items in snapshot enumeration
+// order: every account is followed by all of its storage slots, and every piece
+// of contract code is emitted once, right before the first account using it.
+//
+// Whenever a backwards-incompatible change is made, the Version header should be
+// bumped. If the importer sees a higher version, it should reject the import.
+type stateHeader struct {
+	Magic    string // Always set to 'gethstate' for disambiguation
+	Version  uint64
+	Root     common.Hash
+	Number   uint64
+	Hash     common.Hash
+	UnixTime uint64
+}
+
+const (
+	stateMagic   = "gethstate"
+	stateVersion = 1
+)
+
+// stateHeaderHistory is the number of ancestor headers included with the state,
+// covering the range of blocks accessible to the BLOCKHASH opcode.
+const stateHeaderHistory = 256
+
+const (
+	stateEntryAccount = 0 // Hash is the account hash, Data the slim RLP account
+	stateEntryStorage = 1 // Hash is the slot hash, Data the RLP slot value
+	stateEntryCode    = 2 // Hash is the code hash, Data the contract code
+)
+
+// stateEntry is a single account, storage slot or contract code item in the
+// state export file.
+type stateEntry struct {
+	Kind byte
+	Hash common.Hash
+	Data []byte
+}
+
+// ExportSnapshotState exports the full state of the given block into the
+// specified file, iterating the accounts and storage slots through the snapshot.
+// If the suffix is 'gz', gzip compression is used.
+func ExportSnapshotState(chaindb ethdb.Database, snaptree *snapshot.Tree, block *types.Block, td *big.Int, fn string, interrupt chan struct{}) error {
+	log.Info("Exporting state", "file", fn, "number", block.NumberU64(), "hash", block.Hash(), "root", block.Root())
+
+	fh, err := os.OpenFile(fn, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	// Enable gzip compressing if file name has gz suffix.
+	var writer io.Writer = fh
+	if strings.HasSuffix(fn, ".gz") {
+		gz := gzip.NewWriter(writer)
+		defer gz.Close()
+		writer = gz
+	}
+	buf := bufio.NewWriter(writer)
+	defer buf.Flush()
+	writer = buf
+
+	root := block.Root()
+	if err := rlp.Encode(writer, &stateHeader{
+		Magic:    stateMagic,
+		Version:  stateVersion,
+		Root:     root,
+		Number:   block.NumberU64(),
+		Hash:     block.Hash(),
+		UnixTime: uint64(time.Now().Unix()),
+	}); err != nil {
+		return err
+	}
+	if err := rlp.Encode(writer, block); err != nil {
+		return err
+	}
+	if err := rlp.Encode(writer, td); err != nil {
+		return err
+	}
+	ancestors, err := readAncestors(chaindb, block.Header(), stateHeaderHistory)
+	if err != nil {
+		return err
+	}
+	if err := rlp.Encode(writer, ancestors); err != nil {
+		return err
+	}
+	accIt, err := snaptree.AccountIterator(root, common.Hash{})
+	if err != nil {
+		return err
+	}
+	defer accIt.Release()
+
+	var (
+		start    = time.Now()
+		logged   = time.Now()
+		accounts uint64
+		slots    uint64
+		codes    = make(map[common.Hash]struct{})
+	)
+	for accIt.Next() {
+		account, err := types.FullAccount(accIt.Account())
+		if err != nil {
+			return err
+		}
+		codeHash := common.BytesToHash(account.CodeHash)
+		if codeHash != types.EmptyCodeHash {
+			if _, ok := codes[codeHash]; !ok {
+				code := rawdb.ReadCode(chaindb, codeHash)
+				if len(code) == 0 {
+					return fmt.Errorf("missing code %x for account %x", codeHash, accIt.Hash())
+				}
+				if err := rlp.Encode(writer, &stateEntry{Kind: stateEntryCode, Hash: codeHash, Data: code}); err != nil {
+					return err
+				}
+				codes[codeHash] = struct{}{}
+			}
+		}
+		if err := rlp.Encode(writer, &stateEntry{Kind: stateEntryAccount, Hash: accIt.Hash(), Data: accIt.Account()}); err != nil {
+			return err
+		}
+		if account.Root != types.EmptyRootHash {
+			stIt, err := snaptree.StorageIterator(root, accIt.Hash(), common.Hash{})
+			if err != nil {
+				return err
+			}
+			for stIt.Next() {
+				if err := rlp.Encode(writer, &stateEntry{Kind: stateEntryStorage, Hash: stIt.Hash(), Data: stIt.Slot()}); err != nil {
+					stIt.Release()
+					return err
+				}
+				slots++
+			}
+			err = stIt.Error()
+			stIt.Release()
+			if err != nil {
+				return err
+			}
+		}
+		accounts++
+
+		if accounts%1000 == 0 {
+			// Check interruption emitted by ctrl+c
+			select {
+			case <-interrupt:
+				return errors.New("state export interrupted")
+			default:
+			}
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Exporting state", "at", accIt.Hash(), "accounts", accounts, "slots", slots,
+				"codes", len(codes), "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if err := accIt.Error(); err != nil {
+		return err
+	}
+	log.Info("Exported state", "file", fn, "accounts", accounts, "slots", slots, "codes", len(codes),
+		"elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// readAncestors retrieves up to limit ancestors of the given header, excluding
+// the genesis, in ascending order.
+func readAncestors(db ethdb.Reader, header *types.Header, limit int) ([]*types.Header, error) {
+	var ancestors []*types.Header
+	for len(ancestors) < limit && header.Number.Uint64() > 1 {
+		parent := rawdb.ReadHeader(db, header.ParentHash, header.Number.Uint64()-1)
+		if parent == nil {
+			return nil, fmt.Errorf("missing header %d (%x)", header.Number.Uint64()-1, header.ParentHash)
+		}
+		ancestors = append(ancestors, parent)
+		header = parent
+	}
+	for i, j := 0, len(ancestors)-1; i < j; i, j = i+1, j-1 {
+		ancestors[i], ancestors[j] = ancestors[j], ancestors[i]
+	}
+	return ancestors, nil
+}
+
+// ImportSnapshotState imports a state export file into the database. The state
+// trie is regenerated from the flat data and its root is checked against the
+// header of the exported block before the block is marked as the chain head.
+//
+// The file is read twice: the first pass verifies the state root without
+// touching the database, only the second one writes the state. This way a
+// corrupt file can't leave the database without a usable state.
+//
+// The database must already be initialized with the genesis of the same network
+// and must not contain any other blocks. Besides the state, only the imported
+// block and its most recent ancestor headers are written: older headers and all
+// earlier block bodies and receipts are not available in the resulting chain.
+func ImportSnapshotState(db ethdb.Database, tdb *triedb.Database, fn string) (*types.Block, error) {
+	log.Info("Importing state", "file", fn)
+	if _, err := importSnapshotState(db, tdb, fn, false); err != nil {
+		return nil, err
+	}
+	return importSnapshotState(db, tdb, fn, true)
+}
+
+// importSnapshotState reads a state export file, regenerating and verifying the
+// state trie. If commit is set, the state and the chain segment are written into
+// the database, otherwise the file is only verified.
+func importSnapshotState(db ethdb.Database, tdb *triedb.Database, fn string, commit bool) (*types.Block, error) {
+	fh, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	var reader io.Reader = bufio.NewReader(fh)
+	if strings.HasSuffix(fn, ".gz") {
+		if reader, err = gzip.NewReader(reader); err != nil {
+			return nil, err
+		}
+	}
+	stream := rlp.NewStream(reader, 0)
+
+	// Read the header and the chain segment the state belongs to
+	var header stateHeader
+	if err := stream.Decode(&header); err != nil {
+		return nil, fmt.Errorf("could not decode header: %v", err)
+	}
+	if header.Magic != stateMagic {
+		return nil, errors.New("incompatible data, wrong magic")
+	}
+	if header.Version != stateVersion {
+		return nil, fmt.Errorf("incompatible version %d, (support only %d)", header.Version, stateVersion)
+	}
+	var (
+		block     = new(types.Block)
+		td        = new(big.Int)
+		ancestors []*types.Header
+	)
+	if err := stream.Decode(block); err != nil {
+		return nil, fmt.Errorf("could not decode block: %v", err)
+	}
+	if err := stream.Decode(td); err != nil {
+		return nil, fmt.Errorf("could not decode total difficulty: %v", err)
+	}
+	if err := stream.Decode(&ancestors); err != nil {
+		return nil, fmt.Errorf("could not decode ancestor headers: %v", err)
+	}
+	if block.Hash() != header.Hash || block.NumberU64() != header.Number || block.Root() != header.Root {
+		return nil, errors.New("block does not match state header")
+	}
+	// Ensure the target database is a freshly initialized one of the same network
+	genesis := rawdb.ReadCanonicalHash(db, 0)
+	if genesis == (common.Hash{}) {
+		return nil, errors.New("database is not initialized, run 'geth init' first")
+	}
+	if head := rawdb.ReadHeadHeader(db); head != nil && head.Number.Sign() > 0 {
+		return nil, fmt.Errorf("database already contains chain data up to block %d", head.Number)
+	}
+	if err := verifyAncestors(block.Header(), ancestors, genesis); err != nil {
+		return nil, err
+	}
+	progress := "Verifying state"
+	if commit {
+		progress = "Importing state"
+	}
+	log.Info(progress, "number", header.Number, "hash", header.Hash, "root", header.Root,
+		"data age", common.PrettyDuration(time.Since(time.Unix(int64(header.UnixTime), 0))))
+	// Path-based trie nodes are keyed by their location, drop the genesis
+	// state to not leave stale nodes behind the imported ones. This is only
+	// done once the file is known to hold the expected state.
+	scheme := tdb.Scheme()
+	if commit && scheme == rawdb.PathScheme {
+		if err := deletePathTrieNodes(db); err != nil {
+			return nil, err
+		}
+	}
+	var (
+		batch                       = db.NewBatch()
+		writer ethdb.KeyValueWriter = batch
+
+		start    = time.Now()
+		logged   = time.Now()
+		accounts uint64
+		slots    uint64
+		codes    uint64
+
+		accTrie  *trie.StackTrie
+		stTrie   *trie.StackTrie
+		accHash  common.Hash
+		accData  []byte
+		accRoot  common.Hash
+		accValid bool
+	)
+	if !commit {
+		writer = discardWriter{}
+	}
+	accTrie = trie.NewStackTrie(func(path []byte, hash common.Hash, blob []byte) {
+		rawdb.WriteTrieNode(writer, common.Hash{}, path, hash, blob, scheme)
+	})
+	flush := func() error {
+		if batch.ValueSize() > ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+		return nil
+	}
+	// finishAccount verifies the storage of the pending account and inserts it
+	// into the account trie.
+	finishAccount := func() error {
+		if !accValid {
+			return nil
+		}
+		root := types.EmptyRootHash
+		if stTrie != nil {
+			root = stTrie.Hash()
+			stTrie = nil
+		}
+		if root != accRoot {
+			return fmt.Errorf("storage root mismatch for account %x: have %x, want %x", accHash, root, accRoot)
+		}
+		full, err := types.FullAccountRLP(accData)
+		if err != nil {
+			return err
+		}
+		accValid = false
+		return accTrie.Update(accHash[:], full)
+	}
+	for {
+		var entry stateEntry
+		if err := stream.Decode(&entry); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		switch entry.Kind {
+		case stateEntryCode:
+			if crypto.Keccak256Hash(entry.Data) != entry.Hash {
+				return nil, fmt.Errorf("invalid code %x", entry.Hash)
+			}
+			rawdb.WriteCode(writer, entry.Hash, entry.Data)
+			codes++
+
+		case stateEntryAccount:
+			if err := finishAccount(); err != nil {
+				return nil, err
+			}
+			account, err := types.FullAccount(entry.Data)
+			if err != nil {
+				return nil, err
+			}
+			accHash, accData, accRoot, accValid = entry.Hash, entry.Data, account.Root, true
+			accounts++
+
+		case stateEntryStorage:
+			if !accValid {
+				return nil, fmt.Errorf("storage slot %x without account", entry.Hash)
+			}
+			if stTrie == nil {
+				owner := accHash
+				stTrie = trie.NewStackTrie(func(path []byte, hash common.Hash, blob []byte) {
+					rawdb.WriteTrieNode(writer, owner, path, hash, blob, scheme)
+				})
+			}
+			if err := stTrie.Update(entry.Hash[:], entry.Data); err != nil {
+				return nil, err
+			}
+			slots++
+
+		default:
+			return nil, fmt.Errorf("unknown entry kind %d", entry.Kind)
+		}
+		if err := flush(); err != nil {
+			return nil, err
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info(progress, "accounts", accounts, "slots", slots, "codes", codes,
+				"elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if err := finishAccount(); err != nil {
+		return nil, err
+	}
+	if root := accTrie.Hash(); root != header.Root {
+		return nil, fmt.Errorf("state root mismatch: have %x, want %x", root, header.Root)
+	}
+	if !commit {
+		log.Info("Verified state", "file", fn, "number", block.NumberU64(), "hash", block.Hash(),
+			"accounts", accounts, "slots", slots, "codes", codes, "elapsed", common.PrettyDuration(time.Since(start)))
+		return block, nil
+	}
+	if err := batch.Write(); err != nil {
+		return nil, err
+	}
+	batch.Reset()
+
+	// Activate the freshly written state in the path-based trie database,
+	// the hash-based one reads the nodes straight from disk.
+	if scheme == rawdb.PathScheme {
+		if err := tdb.Enable(header.Root); err != nil {
+			return nil, err
+		}
+	}
+	// Write the ancestor headers, deriving their total difficulties backwards
+	// from the one of the imported block
+	parentTd := new(big.Int).Sub(td, block.Difficulty())
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		ancestor := ancestors[i]
+		rawdb.WriteHeader(batch, ancestor)
+		rawdb.WriteTd(batch, ancestor.Hash(), ancestor.Number.Uint64(), parentTd)
+		rawdb.WriteCanonicalHash(batch, ancestor.Hash(), ancestor.Number.Uint64())
+		parentTd = new(big.Int).Sub(parentTd, ancestor.Difficulty)
+	}
+	// Mark the imported block as the head of the chain
+	rawdb.WriteTd(batch, block.Hash(), block.NumberU64(), td)
+	rawdb.WriteBlock(batch, block)
+	rawdb.WriteCanonicalHash(batch, block.Hash(), block.NumberU64())
+	rawdb.WriteHeadHeaderHash(batch, block.Hash())
+	rawdb.WriteHeadFastBlockHash(batch, block.Hash())
+	rawdb.WriteHeadBlockHash(batch, block.Hash())
+	if err := batch.Write(); err != nil {
+		return nil, err
+	}
+	log.Info("Imported state", "file", fn, "number", block.NumberU64(), "hash", block.Hash(), "headers", len(ancestors),
+		"accounts", accounts, "slots", slots, "codes", codes, "elapsed", common.PrettyDuration(time.Since(start)))
+	return block, nil
+}
+
+// verifyAncestors checks that the ancestor headers form a contiguous chain up
+// to the imported block, of the expected length. If the chain reaches down to
+// the first block, it must also link to the local genesis.
+func verifyAncestors(header *types.Header, ancestors []*types.Header, genesis common.Hash) error {
+	want := stateHeaderHistory
+	if number := header.Number.Uint64(); number <= stateHeaderHistory {
+		want = max(int(number)-1, 0)
+	}
+	if len(ancestors) != want {
+		return fmt.Errorf("ancestor header count mismatch: have %d, want %d", len(ancestors), want)
+	}
+	child := header
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		if ancestors[i].Hash() != child.ParentHash || ancestors[i].Number.Uint64()+1 != child.Number.Uint64() {
+			return fmt.Errorf("ancestor header %d does not link to its child", ancestors[i].Number)
+		}
+		child = ancestors[i]
+	}
+	if child.Number.Uint64() == 1 && child.ParentHash != genesis {
+		return fmt.Errorf("genesis mismatch: have %x, want %x", genesis, child.ParentHash)
+	}
+	return nil
+}
+
+// discardWriter is a key-value writer dropping all data, used to verify a state
+// export file without writing it.
+type discardWriter struct{}
+
+func (discardWriter) Put(key []byte, value []byte) error { return nil }
+func (discardWriter) Delete(key []byte) error            { return nil }
+
+// deletePathTrieNodes removes all account and storage trie nodes stored in the
+// path-based scheme from the database.
+func deletePathTrieNodes(db ethdb.Database) error {
+	batch := db.NewBatch()
+	for _, prefix := range [][]byte{rawdb.TrieNodeAccountPrefix, rawdb.TrieNodeStoragePrefix} {
+		it := db.NewIterator(prefix, nil)
+		for it.Next() {
+			if !rawdb.IsAccountTrieNode(it.Key()) && !rawdb.IsStorageTrieNode(it.Key()) {
+				continue
+			}
+			if err := batch.Delete(it.Key()); err != nil {
+				it.Release()
+				return err
+			}
+			if batch.ValueSize() > ethdb.IdealBatchSize {
+				if err := batch.Write(); err != nil {
+					it.Release()
+					return err
+				}
+				batch.Reset()
+			}
+		}
+		err := it.Error()
+		it.Release()
+		if err != nil {
+			return err
+		}
+	}
+	return batch.Write()
+}
@@ -0,0 +1,162 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/hashdb"
+	"github.com/ethereum/go-ethereum/triedb/pathdb"
+)
+
+func TestStateImportAndExport(t *testing.T) {
+	t.Run("hash", func(t *testing.T) { testStateImportAndExport(t, rawdb.HashScheme, "state") })
+	t.Run("path", func(t *testing.T) { testStateImportAndExport(t, rawdb.PathScheme, "state") })
+	t.Run("gzip", func(t *testing.T) { testStateImportAndExport(t, rawdb.HashScheme, "state.gz") })
+}
+
+func testStateImportAndExport(t *testing.T, scheme string, name string) {
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address  = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.HexToAddress("0xaaaa")
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				address: {Balance: big.NewInt(1000000000000000000)},
+				// SSTORE(NUMBER, CALLVALUE)
+				contract: {Code: []byte{0x34, 0x43, 0x55}, Storage: map[common.Hash]common.Hash{{0x01}: {0x01}}},
+			},
+		}
+		signer = types.LatestSigner(genesis.Config)
+	)
+	db, blocks, _ := core.GenerateChainWithGenesis(genesis, ethash.NewFaker(), 16, func(i int, g *core.BlockGen) {
+		tx, err := types.SignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   genesis.Config.ChainID,
+			Nonce:     uint64(i),
+			GasTipCap: common.Big0,
+			GasFeeCap: g.PrevBlock(-1).BaseFee(),
+			Gas:       50000,
+			To:        &contract,
+			Value:     big.NewInt(int64(i + 1)),
+		})
+		if err != nil {
+			t.Fatalf("error creating tx: %v", err)
+		}
+		g.AddTx(tx)
+	})
+	chain, err := core.NewBlockChain(db, nil, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("unable to initialize chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("error inserting chain: %v", err)
+	}
+	head := chain.CurrentBlock()
+	block := chain.GetBlock(head.Hash(), head.Number.Uint64())
+
+	// Export the head state and import it into a database holding only the genesis.
+	fn := fmt.Sprintf("%v/%s", t.TempDir(), name)
+	if err := ExportSnapshotState(db, chain.Snapshots(), block, chain.GetTd(block.Hash(), block.NumberU64()), fn, make(chan struct{})); err != nil {
+		t.Fatalf("error exporting state: %v", err)
+	}
+	config := &triedb.Config{HashDB: hashdb.Defaults}
+	if scheme == rawdb.PathScheme {
+		config = &triedb.Config{PathDB: pathdb.Defaults}
+	}
+	db2 := rawdb.NewMemoryDatabase()
+	tdb := triedb.NewDatabase(db2, config)
+	gblock := genesis.MustCommit(db2, tdb)
+
+	// A corrupt file must be rejected without touching the existing state.
+	if name == "state" {
+		blob, err := os.ReadFile(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		corrupt := fmt.Sprintf("%v/corrupt", t.TempDir())
+		blob[len(blob)-1]++
+		if err := os.WriteFile(corrupt, blob, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ImportSnapshotState(db2, tdb, corrupt); err == nil {
+			t.Fatal("expected error importing corrupt state")
+		}
+		if hash := rawdb.ReadHeadBlockHash(db2); hash != gblock.Hash() {
+			t.Fatalf("head block changed by failed import: have %x, want %x", hash, gblock.Hash())
+		}
+		if _, err := state.New(gblock.Root(), state.NewDatabaseWithNodeDB(db2, tdb), nil); err != nil {
+			t.Fatalf("genesis state lost by failed import: %v", err)
+		}
+	}
+	imported, err := ImportSnapshotState(db2, tdb, fn)
+	if err != nil {
+		t.Fatalf("error importing state: %v", err)
+	}
+	if imported.Hash() != block.Hash() {
+		t.Fatalf("imported block mismatch: have %x, want %x", imported.Hash(), block.Hash())
+	}
+	if hash := rawdb.ReadHeadBlockHash(db2); hash != block.Hash() {
+		t.Fatalf("head block mismatch: have %x, want %x", hash, block.Hash())
+	}
+	// The ancestor headers must be available with their total difficulties.
+	for _, ancestor := range blocks[:len(blocks)-1] {
+		number := ancestor.NumberU64()
+		if hash := rawdb.ReadCanonicalHash(db2, number); hash != ancestor.Hash() {
+			t.Fatalf("canonical hash %d mismatch: have %x, want %x", number, hash, ancestor.Hash())
+		}
+		if have, want := rawdb.ReadTd(db2, ancestor.Hash(), number), chain.GetTd(ancestor.Hash(), number); have == nil || have.Cmp(want) != 0 {
+			t.Fatalf("total difficulty %d mismatch: have %v, want %v", number, have, want)
+		}
+	}
+	statedb, err := state.New(block.Root(), state.NewDatabaseWithNodeDB(db2, tdb), nil)
+	if err != nil {
+		t.Fatalf("failed to open imported state: %v", err)
+	}
+	origin, _ := chain.State()
+	if have, want := statedb.GetBalance(address), origin.GetBalance(address); have.Cmp(want) != 0 {
+		t.Fatalf("balance mismatch: have %v, want %v", have, want)
+	}
+	if have := statedb.GetCode(contract); len(have) != 3 {
+		t.Fatalf("code mismatch: have %x", have)
+	}
+	for i := 0; i <= len(blocks); i++ {
+		slot := common.BigToHash(big.NewInt(int64(i)))
+		if have, want := statedb.GetState(contract, slot), origin.GetState(contract, slot); have != want {
+			t.Fatalf("storage slot %d mismatch: have %x, want %x", i, have, want)
+		}
+	}
+	// A second import into the same database must be rejected.
+	if _, err := ImportSnapshotState(db2, tdb, fn); err == nil {
+		t.Fatal("expected error importing into a non-empty database")
+	}
+}
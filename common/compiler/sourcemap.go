@@ -0,0 +1,93 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package compiler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Jump types of a source map entry.
+const (
+	JumpRegular  = '-' // Regular jump or no jump at all
+	JumpInto     = 'i' // Jump into a function
+	JumpOutOf    = 'o' // Return from a function
+	noSourceFile = -1  // File index of instructions without source
+)
+
+// SourceMapEntry is the source range of a single instruction, as described at
+// https://docs.soliditylang.org/en/latest/internals/source_mappings.html.
+type SourceMapEntry struct {
+	Start         int  // Byte offset of the range in the source file
+	Length        int  // Byte length of the range
+	File          int  // Source file identifier, -1 if the instruction has no source
+	Jump          byte // Jump type of the instruction
+	ModifierDepth int  // Depth of the modifier the instruction belongs to
+}
+
+// HasSource returns whether the instruction maps to a source range.
+func (e *SourceMapEntry) HasSource() bool {
+	return e.File != noSourceFile
+}
+
+// ParseSourceMap decompresses a solc source map into one entry per instruction.
+// Empty fields of an entry take the value of the previous entry.
+func ParseSourceMap(srcmap string) ([]SourceMapEntry, error) {
+	if srcmap == "" {
+		return nil, nil
+	}
+	var (
+		items   = strings.Split(srcmap, ";")
+		entries = make([]SourceMapEntry, 0, len(items))
+		last    = SourceMapEntry{File: noSourceFile, Jump: JumpRegular}
+	)
+	for i, item := range items {
+		entry := last
+		for j, field := range strings.Split(item, ":") {
+			if field == "" {
+				continue
+			}
+			if j == 3 {
+				if len(field) != 1 || (field[0] != JumpRegular && field[0] != JumpInto && field[0] != JumpOutOf) {
+					return nil, fmt.Errorf("source map entry %d: invalid jump type %q", i, field)
+				}
+				entry.Jump = field[0]
+				continue
+			}
+			n, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("source map entry %d: %v", i, err)
+			}
+			switch j {
+			case 0:
+				entry.Start = n
+			case 1:
+				entry.Length = n
+			case 2:
+				entry.File = n
+			case 4:
+				entry.ModifierDepth = n
+			default:
+				return nil, fmt.Errorf("source map entry %d: too many fields", i)
+			}
+		}
+		entries = append(entries, entry)
+		last = entry
+	}
+	return entries, nil
+}
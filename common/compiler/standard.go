@@ -0,0 +1,179 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package compiler
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Build is the result of a solc compilation using the standard JSON interface.
+type Build struct {
+	Sources   map[int]*Source // Source files by identifier
+	Contracts []*Artifact     // Compiled contracts, sorted by name
+}
+
+// Source is a source file of a compilation.
+type Source struct {
+	ID      int
+	Path    string
+	Content string // Empty if the input only referenced the file by URL
+}
+
+// Artifact is a compiled contract of a compilation.
+type Artifact struct {
+	Name             string // Fully qualified name, i.e. path:Contract
+	Abi              json.RawMessage
+	Bytecode         *Bytecode // Creation code
+	DeployedBytecode *Bytecode // Runtime code
+}
+
+// Bytecode is the creation or runtime code of a contract, along with its
+// source map.
+type Bytecode struct {
+	Object              string                            `json:"object"` // Hex code, possibly containing library placeholders
+	SourceMap           string                            `json:"sourceMap"`
+	LinkReferences      map[string]map[string][]CodeRange `json:"linkReferences"`
+	ImmutableReferences map[string][]CodeRange            `json:"immutableReferences"`
+}
+
+// CodeRange is a range of bytes within a contract code.
+type CodeRange struct {
+	Start  int `json:"start"`
+	Length int `json:"length"`
+}
+
+// Code decodes the bytecode, zeroing the placeholders of unlinked libraries.
+// The returned mask reports the bytes which may differ in the deployed code,
+// namely library addresses and immutable values.
+func (b *Bytecode) Code() ([]byte, []bool, error) {
+	object := strings.TrimPrefix(b.Object, "0x")
+	if len(object)%2 != 0 {
+		return nil, nil, fmt.Errorf("odd length bytecode")
+	}
+	var (
+		code = make([]byte, len(object)/2)
+		mask = make([]bool, len(code))
+	)
+	for i := range code {
+		chunk := object[2*i : 2*i+2]
+		if strings.Contains(chunk, "_") {
+			// Placeholders are 40 characters long and are byte aligned
+			mask[i] = true
+			continue
+		}
+		if _, err := hex.Decode(code[i:i+1], []byte(chunk)); err != nil {
+			return nil, nil, err
+		}
+	}
+	masked := func(ranges []CodeRange) error {
+		for _, r := range ranges {
+			if r.Start < 0 || r.Length < 0 || r.Start+r.Length > len(code) {
+				return fmt.Errorf("code reference [%d, +%d) out of bounds", r.Start, r.Length)
+			}
+			for i := r.Start; i < r.Start+r.Length; i++ {
+				mask[i] = true
+			}
+		}
+		return nil
+	}
+	for _, libs := range b.LinkReferences {
+		for _, ranges := range libs {
+			if err := masked(ranges); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	for _, ranges := range b.ImmutableReferences {
+		if err := masked(ranges); err != nil {
+			return nil, nil, err
+		}
+	}
+	return code, mask, nil
+}
+
+// standardInput is the subset of the solc standard JSON input needed to recover
+// the sources of a compilation.
+type standardInput struct {
+	Sources map[string]struct {
+		Content string `json:"content"`
+	} `json:"sources"`
+}
+
+// standardOutput is the subset of the solc standard JSON output describing the
+// compiled contracts.
+type standardOutput struct {
+	Errors []struct {
+		Severity         string `json:"severity"`
+		FormattedMessage string `json:"formattedMessage"`
+	} `json:"errors"`
+	Sources map[string]struct {
+		ID int `json:"id"`
+	} `json:"sources"`
+	Contracts map[string]map[string]struct {
+		Abi json.RawMessage `json:"abi"`
+		Evm struct {
+			Bytecode         *Bytecode `json:"bytecode"`
+			DeployedBytecode *Bytecode `json:"deployedBytecode"`
+		} `json:"evm"`
+	} `json:"contracts"`
+}
+
+// ParseStandardJSON parses the output of a solc --standard-json run. The input
+// of the compilation is optional and provides the contents of the sources. The
+// output is expected to contain the bytecode and source maps of the contracts.
+func ParseStandardJSON(input, output []byte) (*Build, error) {
+	var in standardInput
+	if len(input) > 0 {
+		if err := json.Unmarshal(input, &in); err != nil {
+			return nil, fmt.Errorf("solc: error reading standard input (%v)", err)
+		}
+	}
+	var out standardOutput
+	if err := json.Unmarshal(output, &out); err != nil {
+		return nil, fmt.Errorf("solc: error reading standard output (%v)", err)
+	}
+	for _, e := range out.Errors {
+		if e.Severity == "error" {
+			return nil, fmt.Errorf("solc: compilation failed: %s", e.FormattedMessage)
+		}
+	}
+	build := &Build{Sources: make(map[int]*Source)}
+	for path, src := range out.Sources {
+		build.Sources[src.ID] = &Source{ID: src.ID, Path: path, Content: in.Sources[path].Content}
+	}
+	for path, contracts := range out.Contracts {
+		for name, contract := range contracts {
+			if contract.Evm.Bytecode == nil || contract.Evm.DeployedBytecode == nil {
+				return nil, fmt.Errorf("solc: missing bytecode of %s:%s", path, name)
+			}
+			build.Contracts = append(build.Contracts, &Artifact{
+				Name:             path + ":" + name,
+				Abi:              contract.Abi,
+				Bytecode:         contract.Evm.Bytecode,
+				DeployedBytecode: contract.Evm.DeployedBytecode,
+			})
+		}
+	}
+	slices.SortFunc(build.Contracts, func(a, b *Artifact) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return build, nil
+}
@@ -18,13 +18,16 @@ package core
 
 import (
 	"crypto/ecdsa"
+	"fmt"
 	"math/big"
+	"runtime"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -204,6 +207,87 @@ func benchInsertChain(b *testing.B, disk bool, gen func(int, *BlockGen)) {
 	}
 }
 
+// BenchmarkIntermediateRoot measures the state root derivation of a block which
+// modifies the storage of many contracts, with a varying number of threads.
+func BenchmarkIntermediateRoot(b *testing.B) {
+	benchStateRoot(b, false)
+}
+
+// BenchmarkStateCommit measures the state commit of a block which modifies the
+// storage of many contracts, with a varying number of threads.
+func BenchmarkStateCommit(b *testing.B) {
+	benchStateRoot(b, true)
+}
+
+func benchStateRoot(b *testing.B, commit bool) {
+	const (
+		contracts = 500
+		slots     = 100
+	)
+	// Create a base state with the storage of all the contracts populated
+	db := state.NewDatabase(rawdb.NewMemoryDatabase())
+	statedb, _ := state.New(types.EmptyRootHash, db, nil)
+	for i := 0; i < contracts; i++ {
+		addr := common.BigToAddress(big.NewInt(int64(i + 1)))
+		statedb.SetNonce(addr, 1)
+		for j := 0; j < slots; j++ {
+			statedb.SetState(addr, common.BigToHash(big.NewInt(int64(j))), common.BigToHash(big.NewInt(int64(j+1))))
+		}
+	}
+	base, err := statedb.Commit(0, true)
+	if err != nil {
+		b.Fatalf("failed to commit base state: %v", err)
+	}
+	// Every iteration modifies half of the slots of the given number of contracts
+	// on top of the base state. The resulting root must not depend on the thread
+	// count.
+	wants := make(map[int]common.Hash)
+	run := func(b *testing.B, procs int, modified int) {
+		defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(procs))
+
+		b.ReportAllocs()
+		b.StopTimer()
+		for i := 0; i < b.N; i++ {
+			statedb, _ := state.New(base, db, nil)
+			for i := 0; i < modified; i++ {
+				addr := common.BigToAddress(big.NewInt(int64(i + 1)))
+				for j := 0; j < slots; j += 2 {
+					statedb.SetState(addr, common.BigToHash(big.NewInt(int64(j))), common.BigToHash(big.NewInt(int64(j*2+1))))
+				}
+			}
+			var have common.Hash
+			b.StartTimer()
+			if commit {
+				have, err = statedb.Commit(1, true)
+			} else {
+				have = statedb.IntermediateRoot(true)
+			}
+			b.StopTimer()
+
+			if err != nil {
+				b.Fatalf("failed to commit state: %v", err)
+			}
+			if want, ok := wants[modified]; !ok {
+				wants[modified] = have
+			} else if have != want {
+				b.Fatalf("state root mismatch: have %x, want %x", have, want)
+			}
+		}
+		// Report the time per contract, as the sequential run modifies fewer
+		b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*modified), "ns/contract")
+	}
+	// Tries with at most 100 changes are hashed and committed by a single thread,
+	// so modifying fewer contracts on one thread measures the sequential path.
+	b.Run("sequential", func(b *testing.B) {
+		run(b, 1, 50)
+	})
+	for procs := 1; procs <= runtime.NumCPU(); procs *= 2 {
+		b.Run(fmt.Sprintf("procs-%d", procs), func(b *testing.B) {
+			run(b, procs, contracts)
+		})
+	}
+}
+
 func BenchmarkChainRead_header_10k(b *testing.B) {
 	benchReadChain(b, false, 10000)
 }
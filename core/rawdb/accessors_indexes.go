@@ -18,6 +18,7 @@ package rawdb
 
 import (
 	"bytes"
+	"encoding/binary"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -179,3 +180,74 @@ func DeleteBloombits(db ethdb.Database, bit uint, from uint64, to uint64) {
 		log.Crit("Failed to delete bloom bits", "err", it.Error())
 	}
 }
+
+// The roles an address can have in the call traces of a block, as recorded in
+// the trace address index.
+const (
+	TraceAddressFrom byte = 1 << iota // Address is the sender of a call
+	TraceAddressTo                    // Address is the recipient of a call
+)
+
+// ReadTraceAddressEntry retrieves the roles of an address within the call
+// traces of the given block, zero if the address is not indexed.
+func ReadTraceAddressEntry(db ethdb.KeyValueReader, address common.Address, number uint64) byte {
+	data, _ := db.Get(traceAddressKey(address, number))
+	if len(data) != 1 {
+		return 0
+	}
+	return data[0]
+}
+
+// WriteTraceAddressEntry stores the roles of an address within the call traces
+// of the given block.
+func WriteTraceAddressEntry(db ethdb.KeyValueWriter, address common.Address, number uint64, roles byte) {
+	if err := db.Put(traceAddressKey(address, number), []byte{roles}); err != nil {
+		log.Crit("Failed to store trace address entry", "err", err)
+	}
+}
+
+// ReadTraceAddressBlocks returns the numbers of the blocks within [from, to]
+// in which the address had any of the requested roles, in ascending order.
+func ReadTraceAddressBlocks(db ethdb.Iteratee, address common.Address, from uint64, to uint64, roles byte) []uint64 {
+	prefix := append(traceAddressPrefix, address.Bytes()...)
+	it := db.NewIterator(prefix, encodeBlockNumber(from))
+	defer it.Release()
+
+	var numbers []uint64
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(prefix)+8 {
+			continue
+		}
+		number := binary.BigEndian.Uint64(key[len(prefix):])
+		if number > to {
+			break
+		}
+		if value := it.Value(); len(value) == 1 && value[0]&roles != 0 {
+			numbers = append(numbers, number)
+		}
+	}
+	return numbers
+}
+
+// ReadTraceIndexRange retrieves the range of blocks whose call traces have been
+// indexed. The boolean is false if no block has been indexed yet.
+func ReadTraceIndexRange(db ethdb.KeyValueReader) (uint64, uint64, bool) {
+	tail, _ := db.Get(traceIndexTailKey)
+	head, _ := db.Get(traceIndexHeadKey)
+	if len(tail) != 8 || len(head) != 8 {
+		return 0, 0, false
+	}
+	return binary.BigEndian.Uint64(tail), binary.BigEndian.Uint64(head), true
+}
+
+// WriteTraceIndexRange stores the range of blocks whose call traces have been
+// indexed.
+func WriteTraceIndexRange(db ethdb.KeyValueWriter, tail uint64, head uint64) {
+	if err := db.Put(traceIndexTailKey, encodeBlockNumber(tail)); err != nil {
+		log.Crit("Failed to store the trace index tail", "err", err)
+	}
+	if err := db.Put(traceIndexHeadKey, encodeBlockNumber(head)); err != nil {
+		log.Crit("Failed to store the trace index head", "err", err)
+	}
+}
@@ -19,6 +19,7 @@ package rawdb
 import (
 	"bytes"
 	"math/big"
+	"slices"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -154,3 +155,48 @@ func TestDeleteBloomBits(t *testing.T) {
 	check(1, 1, params.MainnetGenesisHash, true)
 	check(1, 1, params.SepoliaGenesisHash, true)
 }
+
+// Tests that the trace address index returns the blocks matching the roles.
+func TestTraceAddressIndex(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	var (
+		addr  = common.HexToAddress("0x01")
+		other = common.HexToAddress("0x02")
+	)
+	WriteTraceAddressEntry(db, addr, 1, TraceAddressFrom)
+	WriteTraceAddressEntry(db, addr, 5, TraceAddressTo)
+	WriteTraceAddressEntry(db, addr, 9, TraceAddressFrom|TraceAddressTo)
+	WriteTraceAddressEntry(db, other, 3, TraceAddressFrom)
+
+	if roles := ReadTraceAddressEntry(db, addr, 9); roles != TraceAddressFrom|TraceAddressTo {
+		t.Fatalf("roles mismatch: have %d, want %d", roles, TraceAddressFrom|TraceAddressTo)
+	}
+	if roles := ReadTraceAddressEntry(db, addr, 3); roles != 0 {
+		t.Fatalf("unexpected roles for missing entry: %d", roles)
+	}
+	tests := []struct {
+		from, to uint64
+		roles    byte
+		want     []uint64
+	}{
+		{0, 10, TraceAddressFrom | TraceAddressTo, []uint64{1, 5, 9}},
+		{0, 10, TraceAddressFrom, []uint64{1, 9}},
+		{0, 10, TraceAddressTo, []uint64{5, 9}},
+		{2, 8, TraceAddressFrom | TraceAddressTo, []uint64{5}},
+		{10, 20, TraceAddressFrom | TraceAddressTo, nil},
+	}
+	for i, tt := range tests {
+		have := ReadTraceAddressBlocks(db, addr, tt.from, tt.to, tt.roles)
+		if !slices.Equal(have, tt.want) {
+			t.Errorf("test %d: blocks mismatch: have %v, want %v", i, have, tt.want)
+		}
+	}
+	if _, _, ok := ReadTraceIndexRange(db); ok {
+		t.Fatal("unexpected trace index range")
+	}
+	WriteTraceIndexRange(db, 1, 9)
+	if tail, head, ok := ReadTraceIndexRange(db); !ok || tail != 1 || head != 9 {
+		t.Fatalf("trace index range mismatch: have [%d, %d] %v, want [1, 9]", tail, head, ok)
+	}
+}
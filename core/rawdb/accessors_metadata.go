@@ -53,6 +53,46 @@ func WriteDatabaseVersion(db ethdb.KeyValueWriter, version uint64) {
 	}
 }
 
+// databaseMigration is the progress marker of a partially applied schema
+// migration, allowing it to be resumed after an interruption.
+type databaseMigration struct {
+	Version uint64 // Database version the migration is converting to
+	Marker  []byte // Migration specific progress marker
+}
+
+// ReadDatabaseMigration retrieves the progress of an interrupted schema
+// migration. The boolean flag is false if no migration is in progress.
+func ReadDatabaseMigration(db ethdb.KeyValueReader) (uint64, []byte, bool) {
+	enc, _ := db.Get(databaseMigrationKey)
+	if len(enc) == 0 {
+		return 0, nil, false
+	}
+	var progress databaseMigration
+	if err := rlp.DecodeBytes(enc, &progress); err != nil {
+		log.Error("Invalid database migration progress", "err", err)
+		return 0, nil, false
+	}
+	return progress.Version, progress.Marker, true
+}
+
+// WriteDatabaseMigration stores the progress of a running schema migration.
+func WriteDatabaseMigration(db ethdb.KeyValueWriter, version uint64, marker []byte) {
+	enc, err := rlp.EncodeToBytes(&databaseMigration{Version: version, Marker: marker})
+	if err != nil {
+		log.Crit("Failed to encode database migration progress", "err", err)
+	}
+	if err := db.Put(databaseMigrationKey, enc); err != nil {
+		log.Crit("Failed to store database migration progress", "err", err)
+	}
+}
+
+// DeleteDatabaseMigration deletes the progress of a finished schema migration.
+func DeleteDatabaseMigration(db ethdb.KeyValueWriter) {
+	if err := db.Delete(databaseMigrationKey); err != nil {
+		log.Crit("Failed to delete database migration progress", "err", err)
+	}
+}
+
 // ReadChainConfig retrieves the consensus settings based on the given genesis hash.
 func ReadChainConfig(db ethdb.KeyValueReader, hash common.Hash) *params.ChainConfig {
 	data, _ := db.Get(configKey(hash))
@@ -0,0 +1,126 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// errDatabaseTooNew is returned if the database was written by a newer version
+// of the software, using a schema this binary does not understand.
+var errDatabaseTooNew = errors.New("database version is newer than supported")
+
+// Migration is a forward schema change, converting a database of the preceding
+// version into Version. Migrations may operate on both the key-value store and
+// the freezer.
+type Migration struct {
+	Version     uint64 // Database version after applying the migration
+	Description string // Human readable summary of the schema change
+
+	// Run applies the migration. Long running migrations should periodically
+	// persist their position via checkpoint, after flushing the data converted
+	// so far. If the process is interrupted, Run is invoked again with the last
+	// checkpointed marker (nil on the first run) and must carry on from there.
+	Run func(db ethdb.Database, marker []byte, checkpoint func(marker []byte)) error
+}
+
+// migrations is the registry of database schema migrations, ordered by version.
+// Version bumps without a registered migration need no data conversion.
+var migrations []*Migration
+
+// PendingMigrations returns the list of migrations required to bring the
+// database to the target version, or an error if the database is newer than
+// the target.
+func PendingMigrations(db ethdb.KeyValueReader, target uint64) ([]*Migration, error) {
+	version := ReadDatabaseVersion(db)
+	if version == nil {
+		return nil, nil // fresh database, nothing to convert
+	}
+	if *version > target {
+		return nil, fmt.Errorf("%w: have v%d, supported v%d", errDatabaseTooNew, *version, target)
+	}
+	var pending []*Migration
+	for _, m := range migrations {
+		if m.Version > *version && m.Version <= target {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// MigrateDatabase runs all the pending schema migrations, bringing the database
+// to the target version. Fresh databases are stamped with the target version
+// directly, whereas databases newer than the target are refused.
+func MigrateDatabase(db ethdb.Database, target uint64) error {
+	version := ReadDatabaseVersion(db)
+	if version == nil {
+		WriteDatabaseVersion(db, target)
+		return nil
+	}
+	pending, err := PendingMigrations(db, target)
+	if err != nil {
+		return err
+	}
+	if *version < target {
+		log.Warn("Upgrade blockchain database version", "from", *version, "to", target, "migrations", len(pending))
+	}
+	for _, m := range pending {
+		if err := runMigration(db, m); err != nil {
+			return fmt.Errorf("database migration to v%d failed: %w", m.Version, err)
+		}
+		WriteDatabaseVersion(db, m.Version)
+		DeleteDatabaseMigration(db)
+	}
+	if *version < target {
+		WriteDatabaseVersion(db, target)
+	}
+	return nil
+}
+
+// runMigration executes a single migration, resuming it from the last stored
+// checkpoint if it was previously interrupted.
+func runMigration(db ethdb.Database, m *Migration) error {
+	var (
+		start  = time.Now()
+		logged = time.Now()
+		marker []byte
+	)
+	if version, progress, ok := ReadDatabaseMigration(db); ok && version == m.Version {
+		marker = progress
+		log.Info("Resuming database migration", "version", m.Version, "description", m.Description, "marker", fmt.Sprintf("%x", marker))
+	} else {
+		log.Info("Migrating database", "version", m.Version, "description", m.Description)
+	}
+	checkpoint := func(marker []byte) {
+		WriteDatabaseMigration(db, m.Version, marker)
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Migrating database", "version", m.Version, "marker", fmt.Sprintf("%x", marker), "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if err := m.Run(db, marker, checkpoint); err != nil {
+		return err
+	}
+	log.Info("Database migration completed", "version", m.Version, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
@@ -0,0 +1,218 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// Tests that migrations are applied in order, can be resumed from their last
+// checkpoint after an interruption, and that newer databases are refused.
+func TestMigrateDatabase(t *testing.T) {
+	defer func(old []*Migration) { migrations = old }(migrations)
+
+	var (
+		errInterrupt = errors.New("interrupted")
+		interrupt    = true
+		resumed      []byte
+	)
+	migrations = []*Migration{
+		{
+			Version:     2,
+			Description: "Rename old- keys to new-",
+			Run: func(db ethdb.Database, marker []byte, checkpoint func([]byte)) error {
+				resumed = marker
+				it := db.NewIterator([]byte("old-"), marker)
+				defer it.Release()
+
+				for count := 0; it.Next(); count++ {
+					if count == 5 && interrupt {
+						return errInterrupt
+					}
+					key := append([]byte("new-"), it.Key()[4:]...)
+					db.Put(key, it.Value())
+					db.Delete(it.Key())
+					checkpoint(it.Key()[4:])
+				}
+				return it.Error()
+			},
+		},
+		{
+			Version:     4,
+			Description: "Add marker",
+			Run: func(db ethdb.Database, marker []byte, checkpoint func([]byte)) error {
+				return db.Put([]byte("v4"), []byte{0x01})
+			},
+		},
+	}
+	db := NewMemoryDatabase()
+
+	// Fresh databases should be stamped without running anything
+	if err := MigrateDatabase(db, 1); err != nil {
+		t.Fatalf("failed to initialize database: %v", err)
+	}
+	if version := ReadDatabaseVersion(db); version == nil || *version != 1 {
+		t.Fatalf("version mismatch: have %v, want 1", version)
+	}
+	for i := 0; i < 10; i++ {
+		db.Put([]byte(fmt.Sprintf("old-%d", i)), []byte{byte(i)})
+	}
+	pending, err := PendingMigrations(db, 4)
+	if err != nil {
+		t.Fatalf("failed to retrieve pending migrations: %v", err)
+	}
+	if len(pending) != 2 {
+		t.Fatalf("pending migration count mismatch: have %d, want 2", len(pending))
+	}
+	// Interrupt the first migration and ensure nothing is marked as done
+	if err := MigrateDatabase(db, 4); !errors.Is(err, errInterrupt) {
+		t.Fatalf("migration error mismatch: have %v, want %v", err, errInterrupt)
+	}
+	if version := ReadDatabaseVersion(db); *version != 1 {
+		t.Fatalf("version mismatch after interruption: have %d, want 1", *version)
+	}
+	if version, marker, ok := ReadDatabaseMigration(db); !ok || version != 2 || !bytes.Equal(marker, []byte("4")) {
+		t.Fatalf("migration progress mismatch: have v%d %q (%v)", version, marker, ok)
+	}
+	// Resume the migrations and check the final database
+	interrupt = false
+	if err := MigrateDatabase(db, 4); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	if !bytes.Equal(resumed, []byte("4")) {
+		t.Fatalf("migration resumed from wrong marker: %q", resumed)
+	}
+	if version := ReadDatabaseVersion(db); *version != 4 {
+		t.Fatalf("version mismatch: have %d, want 4", *version)
+	}
+	if _, _, ok := ReadDatabaseMigration(db); ok {
+		t.Fatal("migration progress not cleaned up")
+	}
+	for i := 0; i < 10; i++ {
+		if has, _ := db.Has([]byte(fmt.Sprintf("old-%d", i))); has {
+			t.Errorf("old key %d not migrated", i)
+		}
+		if blob, _ := db.Get([]byte(fmt.Sprintf("new-%d", i))); !bytes.Equal(blob, []byte{byte(i)}) {
+			t.Errorf("new key %d mismatch: %x", i, blob)
+		}
+	}
+	if has, _ := db.Has([]byte("v4")); !has {
+		t.Error("second migration not applied")
+	}
+	// Databases newer than supported must be refused
+	if err := MigrateDatabase(db, 3); !errors.Is(err, errDatabaseTooNew) {
+		t.Fatalf("error mismatch: have %v, want %v", err, errDatabaseTooNew)
+	}
+}
+
+// Tests that an interrupted migration of an on-disk database resumes from its
+// last checkpoint once the database is reopened.
+func TestMigrateDatabasePersistent(t *testing.T) {
+	defer func(old []*Migration) { migrations = old }(migrations)
+
+	var (
+		errInterrupt = errors.New("interrupted")
+		interrupt    = true
+		migrated     int
+	)
+	migrations = []*Migration{{
+		Version:     2,
+		Description: "Rename old- keys to new-",
+		Run: func(db ethdb.Database, marker []byte, checkpoint func([]byte)) error {
+			it := db.NewIterator([]byte("old-"), marker)
+			defer it.Release()
+
+			for count := 0; it.Next(); count++ {
+				if count == 5 && interrupt {
+					return errInterrupt
+				}
+				batch := db.NewBatch()
+				batch.Put(append([]byte("new-"), it.Key()[4:]...), it.Value())
+				batch.Delete(it.Key())
+				if err := batch.Write(); err != nil {
+					return err
+				}
+				checkpoint(it.Key()[4:])
+				migrated++
+			}
+			return it.Error()
+		},
+	}}
+	datadir := t.TempDir()
+	open := func() ethdb.Database {
+		db, err := Open(OpenOptions{
+			Directory:         datadir,
+			AncientsDirectory: filepath.Join(datadir, "ancient"),
+		})
+		if err != nil {
+			t.Fatalf("failed to open database: %v", err)
+		}
+		return db
+	}
+	// Initialize the database and interrupt the migration
+	db := open()
+	if err := MigrateDatabase(db, 1); err != nil {
+		t.Fatalf("failed to initialize database: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		db.Put([]byte(fmt.Sprintf("old-%d", i)), []byte{byte(i)})
+	}
+	if err := MigrateDatabase(db, 2); !errors.Is(err, errInterrupt) {
+		t.Fatalf("migration error mismatch: have %v, want %v", err, errInterrupt)
+	}
+	db.Close()
+
+	// Reopen the database and resume the migration
+	db = open()
+	if version, marker, ok := ReadDatabaseMigration(db); !ok || version != 2 || !bytes.Equal(marker, []byte("4")) {
+		t.Fatalf("migration progress mismatch: have v%d %q (%v)", version, marker, ok)
+	}
+	interrupt = false
+	if err := MigrateDatabase(db, 2); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	db.Close()
+
+	// Reopen the database once more and check the migrated contents
+	db = open()
+	defer db.Close()
+
+	if version := ReadDatabaseVersion(db); version == nil || *version != 2 {
+		t.Fatalf("version mismatch: have %v, want 2", version)
+	}
+	if _, _, ok := ReadDatabaseMigration(db); ok {
+		t.Fatal("migration progress not cleaned up")
+	}
+	// The checkpointed entry is visited again on resumption, moving nothing
+	if migrated != 10 {
+		t.Errorf("migrated entry count mismatch: have %d, want 10", migrated)
+	}
+	for i := 0; i < 10; i++ {
+		if has, _ := db.Has([]byte(fmt.Sprintf("old-%d", i))); has {
+			t.Errorf("old key %d not migrated", i)
+		}
+		if blob, _ := db.Get([]byte(fmt.Sprintf("new-%d", i))); !bytes.Equal(blob, []byte{byte(i)}) {
+			t.Errorf("new key %d mismatch: %x", i, blob)
+		}
+	}
+}
@@ -31,6 +31,9 @@ var (
 	// databaseVersionKey tracks the current database version.
 	databaseVersionKey = []byte("DatabaseVersion")
 
+	// databaseMigrationKey tracks the progress of an interrupted schema migration.
+	databaseMigrationKey = []byte("DatabaseMigration")
+
 	// headHeaderKey tracks the latest known header's hash.
 	headHeaderKey = []byte("LastHeader")
 
@@ -79,6 +82,12 @@ var (
 	// txIndexTailKey tracks the oldest block whose transactions have been indexed.
 	txIndexTailKey = []byte("TransactionIndexTail")
 
+	// traceIndexTailKey tracks the oldest block whose call traces have been indexed.
+	traceIndexTailKey = []byte("TraceIndexTail")
+
+	// traceIndexHeadKey tracks the latest block whose call traces have been indexed.
+	traceIndexHeadKey = []byte("TraceIndexHead")
+
 	// fastTxLookupLimitKey tracks the transaction lookup limit during fast sync.
 	// This flag is deprecated, it's kept to avoid reporting errors when inspect
 	// database.
@@ -141,6 +150,8 @@ var (
 
 	CliqueSnapshotPrefix = []byte("clique-")
 
+	traceAddressPrefix = []byte("trace-addr-") // traceAddressPrefix + address + num (uint64 big endian) -> call trace roles
+
 	BestUpdateKey         = []byte("update-")    // bigEndian64(syncPeriod) -> RLP(types.LightClientUpdate)  (nextCommittee only referenced by root hash)
 	FixedCommitteeRootKey = []byte("fixedRoot-") // bigEndian64(syncPeriod) -> committee root hash
 	SyncCommitteeKey      = []byte("committee-") // bigEndian64(syncPeriod) -> serialized committee
@@ -199,6 +210,11 @@ func blockReceiptsKey(number uint64, hash common.Hash) []byte {
 	return append(append(blockReceiptsPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
 }
 
+// traceAddressKey = traceAddressPrefix + address + num (uint64 big endian)
+func traceAddressKey(address common.Address, number uint64) []byte {
+	return append(append(traceAddressPrefix, address.Bytes()...), encodeBlockNumber(number)...)
+}
+
 // txLookupKey = txLookupPrefix + hash
 func txLookupKey(hash common.Hash) []byte {
 	return append(txLookupPrefix, hash.Bytes()...)
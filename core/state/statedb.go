@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"maps"
 	"math/big"
+	"runtime"
 	"slices"
 	"sort"
 	"sync"
@@ -853,6 +854,10 @@ func (s *StateDB) IntermediateRoot(deleteEmptyObjects bool) common.Hash {
 		// need concurrency support within the trie itself. That's a TODO for a
 		// later time.
 		workers.SetLimit(1)
+	} else {
+		// Bound the number of concurrently updated storage tries, spinning up
+		// a thread for each dirty object thrashes the scheduler on big blocks.
+		workers.SetLimit(runtime.GOMAXPROCS(0))
 	}
 	for addr, op := range s.mutations {
 		if op.applied || op.isDelete() {
@@ -1235,6 +1240,9 @@ func (s *StateDB) commit(deleteEmptyObjects bool) (*stateUpdate, error) {
 		root    common.Hash
 		workers errgroup.Group
 	)
+	// The account trie commit occupies one of the workers for its full runtime,
+	// leave room for at least one storage trie commit next to it.
+	workers.SetLimit(max(runtime.GOMAXPROCS(0), 2))
 	// Schedule the account trie first since that will be the biggest, so give
 	// it the most time to crunch.
 	//
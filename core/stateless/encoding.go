@@ -124,6 +124,32 @@ type extWitness struct {
 
 // extWitnessMarshalling defines the hex marshalling types for a witness.
 type extWitnessMarshalling struct {
+	Block *rlpBlock
 	Codes []hexutil.Bytes
 	State []hexutil.Bytes
 }
+
+// rlpBlock is a block marshalling into JSON as its hex encoded RLP, since blocks
+// have no JSON representation of their own.
+type rlpBlock types.Block
+
+// MarshalText implements encoding.TextMarshaler.
+func (b *rlpBlock) MarshalText() ([]byte, error) {
+	blob, err := rlp.EncodeToBytes((*types.Block)(b))
+	if err != nil {
+		return nil, err
+	}
+	return hexutil.Bytes(blob).MarshalText()
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (b *rlpBlock) UnmarshalText(input []byte) error {
+	var blob hexutil.Bytes
+	if err := blob.UnmarshalText(input); err != nil {
+		return err
+	}
+	if err := rlp.DecodeBytes(blob, (*types.Block)(b)); err != nil {
+		return fmt.Errorf("invalid witness block: %v", err)
+	}
+	return nil
+}
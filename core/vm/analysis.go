@@ -16,6 +16,14 @@
 
 package vm
 
+import (
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/params"
+)
+
 const (
 	set2BitsMask = uint16(0b11)
 	set3BitsMask = uint16(0b111)
@@ -116,3 +124,114 @@ func codeBitmapInternal(code, bits bitvec) bitvec {
 	}
 	return bits
 }
+
+// basicBlock holds the static requirements of a sequence of instructions which
+// is always entered at its first instruction and left after its last one, unless
+// an instruction fails.
+type basicBlock struct {
+	gas      uint64 // Total constant gas of the instructions
+	minStack int    // Minimum stack height on entry
+	maxStack int    // Maximum stack height on entry
+}
+
+// blockAnalysis holds the basic blocks of a code along with the positions they
+// start at, so that the interpreter can charge and validate them at once.
+type blockAnalysis struct {
+	starts bitvec       // Bit set at the code positions where blocks start
+	pcs    []uint32     // Code position of each block start, ascending
+	blocks []basicBlock // Static requirements of each block
+}
+
+// isStart reports whether a block starts at the given code position.
+func (a *blockAnalysis) isStart(pc uint64) bool {
+	return pc/8 < uint64(len(a.starts)) && (a.starts[pc/8]>>(pc%8))&1 == 1
+}
+
+// find returns the number of the block starting at the given code position,
+// which must be a block start. Sequential execution enters the block following
+// the previously entered one, so only jumps need to search for it.
+func (a *blockAnalysis) find(pc uint64, prev int) int {
+	if next := prev + 1; next < len(a.pcs) && uint64(a.pcs[next]) == pc {
+		return next
+	}
+	n, _ := slices.BinarySearch(a.pcs, uint32(pc))
+	return n
+}
+
+// jumpTableID identifies the contents of a jump table across EVM instances: the
+// instruction set of the fork it is derived from, and the extra EIPs enabled on
+// top of it, in activation order.
+type jumpTableID struct {
+	fork *JumpTable
+	eips string
+}
+
+// codeCacheKey identifies the analysis of a code, such as its basic blocks or
+// its validated EOF container, which depends on the jump table it is executed
+// with.
+type codeCacheKey struct {
+	hash  common.Hash
+	table jumpTableID
+}
+
+// blockCache holds the basic block analyses of the recently executed contracts.
+var blockCache = lru.NewCache[codeCacheKey, *blockAnalysis](1024)
+
+// containerCache holds the validated EOF containers of the recently executed
+// contracts. The containers are never modified once validated.
+var containerCache = lru.NewCache[codeCacheKey, *Container](1024)
+
+// endsBlock reports whether an instruction must be the last one of a basic block,
+// either because it may leave the sequential flow, or because it depends on the
+// exact amount of gas left, which the precharged gas of the following instructions
+// would alter.
+func endsBlock(op OpCode, operation *operation) bool {
+	switch op {
+	case STOP, JUMP, JUMPI, RETURN, REVERT, INVALID, SELFDESTRUCT, GAS,
+		CALL, CALLCODE, DELEGATECALL, STATICCALL, CREATE, CREATE2:
+		return true
+	}
+	return operation.dynamicGas != nil || operation.undefined
+}
+
+// codeBlocks splits legacy code into basic blocks, computing the total constant
+// gas of each along with the stack heights it may be entered with. Blocks start
+// at JUMPDESTs and after block ending instructions, so that every reachable
+// position following a jump or a block end is a block start, including the end
+// of the code.
+func codeBlocks(code []byte, table *JumpTable) *blockAnalysis {
+	var (
+		analysis = &blockAnalysis{starts: make(bitvec, len(code)/8+1+4)} // room for a truncated PUSH32 at the end
+		block    *basicBlock
+		height   int // Stack height relative to the start of the block
+	)
+	open := func(pc uint64) {
+		analysis.starts.set1(pc)
+		analysis.pcs = append(analysis.pcs, uint32(pc))
+		analysis.blocks = append(analysis.blocks, basicBlock{maxStack: int(params.StackLimit)})
+		block, height = &analysis.blocks[len(analysis.blocks)-1], 0
+	}
+	pc := uint64(0)
+	for pc < uint64(len(code)) {
+		op := OpCode(code[pc])
+		if block == nil || op == JUMPDEST {
+			open(pc)
+		}
+		operation := table[op]
+		block.gas += operation.constantGas
+		block.minStack = max(block.minStack, operation.minStack-height)
+		block.maxStack = min(block.maxStack, operation.maxStack-height)
+		height += int(params.StackLimit) - operation.maxStack
+
+		pc++
+		if op >= PUSH1 && op <= PUSH32 {
+			pc += uint64(op - PUSH0)
+		}
+		if endsBlock(op, operation) {
+			block = nil
+		}
+	}
+	// Execution falling off the code stops, which is free
+	open(pc)
+	return analysis
+}
@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 func TestJumpDestAnalysis(t *testing.T) {
@@ -105,3 +106,61 @@ func BenchmarkJumpdestOpAnalysis(bench *testing.B) {
 	op = STOP
 	bench.Run(op.String(), bencher)
 }
+
+func TestCodeBlocks(t *testing.T) {
+	code := []byte{
+		byte(PUSH1), 0x01, byte(PUSH1), 0x02, byte(ADD), // block 0
+		byte(JUMPDEST), byte(POP), byte(PUSH1), 0x05, byte(JUMP), // block 1
+		byte(STOP),        // block 2
+		byte(PUSH2), 0x01, // block 3, truncated push
+	}
+	analysis := codeBlocks(code, &cancunInstructionSet)
+
+	starts := map[int]basicBlock{
+		0:  {gas: 9, minStack: 0, maxStack: 1022},
+		5:  {gas: 14, minStack: 1, maxStack: 1024},
+		10: {gas: 0, minStack: 0, maxStack: 1024},
+		11: {gas: 3, minStack: 0, maxStack: 1023},
+		14: {gas: 0, minStack: 0, maxStack: 1024},
+	}
+	for pc := 0; pc < len(code)+33; pc++ {
+		want, ok := starts[pc]
+		if !ok {
+			if analysis.isStart(uint64(pc)) {
+				t.Errorf("pc %d: unexpected block start", pc)
+			}
+			continue
+		}
+		if !analysis.isStart(uint64(pc)) {
+			t.Errorf("pc %d: missing block start", pc)
+			continue
+		}
+		// Resolve the block both sequentially and as a jump destination
+		for _, prev := range []int{-1, len(analysis.blocks) - 1} {
+			if have := analysis.blocks[analysis.find(uint64(pc), prev)]; have != want {
+				t.Errorf("pc %d, prev %d: block mismatch: have %+v, want %+v", pc, prev, have, want)
+			}
+		}
+	}
+	if len(analysis.blocks) != len(starts) {
+		t.Errorf("block count mismatch: have %d, want %d", len(analysis.blocks), len(starts))
+	}
+}
+
+// Tests that EVMs running with the same extra EIPs share their basic block
+// analyses, even though each of them uses a private copy of the jump table.
+func TestBlockCacheKey(t *testing.T) {
+	newID := func(eips ...int) jumpTableID {
+		evm := NewEVM(BlockContext{}, TxContext{}, nil, params.TestChainConfig, Config{ExtraEips: eips})
+		return evm.interpreter.tableID
+	}
+	if a, b := newID(), newID(); a != b {
+		t.Errorf("plain table identity mismatch: %v != %v", a, b)
+	}
+	if a, b := newID(1344, 2200), newID(1344, 2200); a != b {
+		t.Errorf("extended table identity mismatch: %v != %v", a, b)
+	}
+	if a, b := newID(), newID(1344); a == b {
+		t.Errorf("extended table identity collides with plain one: %v", a)
+	}
+}
@@ -51,12 +51,19 @@ type Contract struct {
 
 	jumpdests map[common.Hash]bitvec // Aggregated result of JUMPDEST analysis.
 	analysis  bitvec                 // Locally cached result of JUMPDEST analysis
+	blocks    *blockAnalysis         // Locally cached result of basic block analysis
 
 	Code     []byte
 	CodeHash common.Hash
 	CodeAddr *common.Address
 	Input    []byte
 
+	// Container is the decoded EOF container of the code, if it is one
+	Container *Container
+
+	codeSection int              // Currently executing EOF code section
+	returnStack []*returnContext // EOF return stack of CALLF
+
 	// is the execution frame represented by this object a contract deployment
 	IsDeployment bool
 
@@ -131,6 +138,57 @@ func (c *Contract) isCode(udest uint64) bool {
 	return c.analysis.codeSegment(udest)
 }
 
+// basicBlocks returns the basic block analysis of the code under the given jump
+// table, identified across EVM instances by id. The analysis of regular contracts
+// is cached across executions by code hash, whereas the one of initcode without
+// a hash is only kept locally.
+func (c *Contract) basicBlocks(table *JumpTable, id jumpTableID) *blockAnalysis {
+	if c.blocks != nil {
+		return c.blocks
+	}
+	if c.CodeHash == (common.Hash{}) {
+		c.blocks = codeBlocks(c.Code, table)
+		return c.blocks
+	}
+	key := codeCacheKey{hash: c.CodeHash, table: id}
+	blocks, exist := blockCache.Get(key)
+	if !exist {
+		blocks = codeBlocks(c.Code, table)
+		blockCache.Add(key, blocks)
+	}
+	c.blocks = blocks
+	return blocks
+}
+
+// eofContainer returns the decoded and validated EOF container of the code under
+// the given EOF jump table, identified across EVM instances by id. Like the basic
+// block analysis, the containers of deployed contracts are cached by code hash,
+// so the validation only runs the first time a code is executed.
+func (c *Contract) eofContainer(table *JumpTable, id jumpTableID) (*Container, error) {
+	if c.Container != nil {
+		return c.Container, nil
+	}
+	key := codeCacheKey{hash: c.CodeHash, table: id}
+	if c.CodeHash != (common.Hash{}) {
+		if container, exist := containerCache.Get(key); exist {
+			c.Container = container
+			return container, nil
+		}
+	}
+	container := new(Container)
+	if err := container.UnmarshalBinary(c.Code); err != nil {
+		return nil, err
+	}
+	if err := container.ValidateCode(table, false); err != nil {
+		return nil, err
+	}
+	if c.CodeHash != (common.Hash{}) {
+		containerCache.Add(key, container)
+	}
+	c.Container = container
+	return container, nil
+}
+
 // AsDelegate sets the contract to be a delegate call and returns the current
 // contract (for chaining calls)
 func (c *Contract) AsDelegate() *Contract {
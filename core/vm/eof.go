@@ -0,0 +1,445 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	offsetVersion   = 2
+	offsetTypesKind = 3
+	offsetCodeKind  = 6
+
+	kindTypes     = 1
+	kindCode      = 2
+	kindContainer = 3
+	kindData      = 0xff
+
+	eofFormatByte = 0xef
+	eof1Version   = 1
+
+	maxInputItems        = 127
+	maxOutputItems       = 127
+	maxStackHeight       = 1023
+	maxCodeSections      = 1024
+	maxContainerSections = 256
+	maxContainerDepth    = 256 // nesting limit, implicitly bounded by max code size
+
+	nonReturningFunction = 0x80 // output marker of functions which never return
+)
+
+var eofMagic = []byte{0xef, 0x00}
+
+// EOF container format and validation errors.
+var (
+	ErrInvalidMagic             = errors.New("invalid magic")
+	ErrUndefinedInstruction     = errors.New("undefined instruction")
+	ErrTruncatedImmediate       = errors.New("truncated immediate")
+	ErrInvalidSectionArgument   = errors.New("invalid section argument")
+	ErrInvalidCallArgument      = errors.New("callf into non-returning section")
+	ErrInvalidDataloadNArgument = errors.New("invalid dataloadN argument")
+	ErrInvalidJumpDest          = errors.New("invalid jump destination")
+	ErrInvalidBackwardJump      = errors.New("invalid backward jump")
+	ErrInvalidOutputs           = errors.New("invalid number of outputs")
+	ErrInvalidMaxStackHeight    = errors.New("invalid max stack height")
+	ErrInvalidCodeTermination   = errors.New("invalid code termination")
+	ErrEOFStackUnderflow        = errors.New("stack underflow")
+	ErrEOFStackOverflow         = errors.New("stack overflow")
+	ErrUnreachableCode          = errors.New("unreachable code")
+	ErrUnreachableCodeSection   = errors.New("unreachable code section")
+	ErrInvalidNonReturning      = errors.New("invalid non-returning flag")
+	ErrInvalidVersion           = errors.New("invalid version")
+	ErrMissingTypeHeader        = errors.New("missing type header")
+	ErrInvalidTypeSize          = errors.New("invalid type section size")
+	ErrMissingCodeHeader        = errors.New("missing code header")
+	ErrInvalidCodeSize          = errors.New("invalid code size")
+	ErrInvalidContainerSize     = errors.New("invalid container size")
+	ErrMissingDataHeader        = errors.New("missing data header")
+	ErrMissingTerminator        = errors.New("missing header terminator")
+	ErrTooManyInputs            = errors.New("invalid type content, too many inputs")
+	ErrTooManyOutputs           = errors.New("invalid type content, too many outputs")
+	ErrInvalidSection0Type      = errors.New("invalid section 0 type, input and output should be zero and non-returning (0x80)")
+	ErrTooLargeMaxStackHeight   = errors.New("invalid type content, max stack height exceeds limit")
+	ErrInvalidContainerSection  = errors.New("invalid container section index")
+	ErrUnreferencedSubContainer = errors.New("unreferenced subcontainer")
+	ErrIncompatibleContainer    = errors.New("incompatible container kind")
+	ErrTruncatedContainer       = errors.New("truncated container data")
+	ErrTrailingBytes            = errors.New("trailing bytes after container")
+	ErrContainerTooDeep         = errors.New("container nesting too deep")
+)
+
+// isEOFVersion1 returns whether the code starts with the EOF magic and the
+// first supported version.
+func isEOFVersion1(code []byte) bool {
+	return hasEOFMagic(code) && len(code) > offsetVersion && code[offsetVersion] == eof1Version
+}
+
+// hasEOFMagic returns whether the code starts with the EOF container magic.
+func hasEOFMagic(code []byte) bool {
+	return len(eofMagic) <= len(code) && bytes.Equal(eofMagic, code[:len(eofMagic)])
+}
+
+// HasEOFByte returns true if code starts with the 0xEF byte, the marker shared
+// by all EOF containers and rejected as the first byte of legacy code.
+func HasEOFByte(code []byte) bool {
+	return len(code) != 0 && code[0] == eofFormatByte
+}
+
+// functionMetadata is an EOF function signature, as defined in the type section.
+type functionMetadata struct {
+	inputs         uint8
+	outputs        uint8
+	maxStackHeight uint16
+}
+
+// stackDelta returns the net stack change of calling the function.
+func (meta *functionMetadata) stackDelta() int {
+	return int(meta.outputs) - int(meta.inputs)
+}
+
+// nonReturning returns whether the function never returns to its caller.
+func (meta *functionMetadata) nonReturning() bool {
+	return meta.outputs == nonReturningFunction
+}
+
+// Container is an EOF container object: the parsed representation of EOF
+// code with its code sections, subcontainers and data section.
+type Container struct {
+	types           []*functionMetadata
+	codeSections    [][]byte
+	subContainers   []*Container
+	subContainerRaw [][]byte
+	data            []byte
+	dataSize        int // Declared data size, may exceed len(data) in deploy containers
+	dataSizeOffset  int // Position of the data size in the header
+	raw             []byte
+}
+
+// CodeSections returns the number of code sections in the container.
+func (c *Container) CodeSections() int {
+	return len(c.codeSections)
+}
+
+// Code returns the code of the given section.
+func (c *Container) Code(section int) []byte {
+	return c.codeSections[section]
+}
+
+// Data returns the data section of the container.
+func (c *Container) Data() []byte {
+	return c.data
+}
+
+// SubContainers returns the number of nested containers.
+func (c *Container) SubContainers() int {
+	return len(c.subContainers)
+}
+
+// SubContainer returns the nested container at the given index.
+func (c *Container) SubContainer(index int) *Container {
+	return c.subContainers[index]
+}
+
+// MarshalBinary returns the raw encoding of the container.
+func (c *Container) MarshalBinary() []byte {
+	return c.raw
+}
+
+// String returns a human readable summary of the container's sections.
+func (c *Container) String() string {
+	var b strings.Builder
+	for i, code := range c.codeSections {
+		fmt.Fprintf(&b, "code[%d] (in=%d out=%d max=%d): %x\n", i, c.types[i].inputs, c.types[i].outputs, c.types[i].maxStackHeight, code)
+	}
+	for i, sub := range c.subContainerRaw {
+		fmt.Fprintf(&b, "container[%d]: %x\n", i, sub)
+	}
+	fmt.Fprintf(&b, "data (declared %d): %x\n", c.dataSize, c.data)
+	return b.String()
+}
+
+// UnmarshalBinary decodes an EOF container. The encoding must be complete, with
+// no trailing bytes and no truncated data section.
+func (c *Container) UnmarshalBinary(b []byte) error {
+	size, err := c.unmarshal(b, true, 0)
+	if err != nil {
+		return err
+	}
+	if size != len(b) {
+		return ErrTrailingBytes
+	}
+	return nil
+}
+
+// UnmarshalInitcode decodes an EOF initcode container which may be followed by
+// arbitrary calldata, as is the case in contract creation transactions. The
+// trailing calldata is returned.
+func (c *Container) UnmarshalInitcode(b []byte) ([]byte, error) {
+	size, err := c.unmarshal(b, true, 0)
+	if err != nil {
+		return nil, err
+	}
+	return b[size:], nil
+}
+
+// unmarshal decodes the container from the start of b, returning the number of
+// bytes consumed. If full is not set, the data section may be truncated.
+func (c *Container) unmarshal(b []byte, full bool, depth int) (int, error) {
+	if depth > maxContainerDepth {
+		return 0, ErrContainerTooDeep
+	}
+	if !hasEOFMagic(b) {
+		return 0, fmt.Errorf("%w: want %x", ErrInvalidMagic, eofMagic)
+	}
+	if len(b) < 14 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if b[offsetVersion] != eof1Version {
+		return 0, fmt.Errorf("%w: have %d, want %d", ErrInvalidVersion, b[offsetVersion], eof1Version)
+	}
+	// Parse the type section header
+	kind, typesSize, err := parseSection(b, offsetTypesKind)
+	if err != nil {
+		return 0, err
+	}
+	if kind != kindTypes {
+		return 0, fmt.Errorf("%w: found section kind %x instead", ErrMissingTypeHeader, kind)
+	}
+	if typesSize < 4 || typesSize%4 != 0 {
+		return 0, fmt.Errorf("%w: type section size must be divisible by 4, have %d", ErrInvalidTypeSize, typesSize)
+	}
+	if typesSize/4 > maxCodeSections {
+		return 0, fmt.Errorf("%w: type section must not exceed 4*%d, have %d", ErrInvalidTypeSize, maxCodeSections, typesSize)
+	}
+	// Parse the code section header
+	kind, codeSizes, err := parseSectionList(b, offsetCodeKind, 2)
+	if err != nil {
+		return 0, err
+	}
+	if kind != kindCode {
+		return 0, fmt.Errorf("%w: found section kind %x instead", ErrMissingCodeHeader, kind)
+	}
+	if len(codeSizes) != typesSize/4 {
+		return 0, fmt.Errorf("%w: mismatch of code sections found and type signatures, types %d, code %d", ErrInvalidCodeSize, typesSize/4, len(codeSizes))
+	}
+	// Parse the optional container section header
+	offset := offsetCodeKind + 3 + 2*len(codeSizes)
+	if offset >= len(b) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	var containerSizes []int
+	if b[offset] == kindContainer {
+		if _, containerSizes, err = parseSectionList(b, offset, 4); err != nil {
+			return 0, err
+		}
+		if len(containerSizes) > maxContainerSections {
+			return 0, fmt.Errorf("%w: number of container sections may not exceed %d, have %d", ErrInvalidContainerSize, maxContainerSections, len(containerSizes))
+		}
+		offset += 3 + 4*len(containerSizes)
+	}
+	// Parse the data section header and the terminator
+	kind, dataSize, err := parseSection(b, offset)
+	if err != nil {
+		return 0, err
+	}
+	if kind != kindData {
+		return 0, fmt.Errorf("%w: found section %x instead", ErrMissingDataHeader, kind)
+	}
+	c.dataSize, c.dataSizeOffset = dataSize, offset+1
+	offset += 3
+
+	if offset >= len(b) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if b[offset] != 0 {
+		return 0, fmt.Errorf("%w: have %x", ErrMissingTerminator, b[offset])
+	}
+	offset++
+
+	// Verify that the body is large enough for all the declared sections
+	expectedSize := offset + typesSize
+	for _, size := range codeSizes {
+		expectedSize += size
+	}
+	for _, size := range containerSizes {
+		expectedSize += size
+	}
+	if len(b) < expectedSize {
+		return 0, fmt.Errorf("%w: have %d, want at least %d", io.ErrUnexpectedEOF, len(b), expectedSize)
+	}
+	// Parse the types section
+	c.types = make([]*functionMetadata, 0, typesSize/4)
+	for i := 0; i < typesSize/4; i++ {
+		sig := &functionMetadata{
+			inputs:         b[offset+i*4],
+			outputs:        b[offset+i*4+1],
+			maxStackHeight: binary.BigEndian.Uint16(b[offset+i*4+2:]),
+		}
+		if sig.inputs > maxInputItems {
+			return 0, fmt.Errorf("%w for section %d: have %d", ErrTooManyInputs, i, sig.inputs)
+		}
+		if sig.outputs > maxOutputItems && !sig.nonReturning() {
+			return 0, fmt.Errorf("%w for section %d: have %d", ErrTooManyOutputs, i, sig.outputs)
+		}
+		if sig.maxStackHeight > maxStackHeight {
+			return 0, fmt.Errorf("%w for section %d: have %d", ErrTooLargeMaxStackHeight, i, sig.maxStackHeight)
+		}
+		c.types = append(c.types, sig)
+	}
+	if c.types[0].inputs != 0 || !c.types[0].nonReturning() {
+		return 0, fmt.Errorf("%w: have %d, %d", ErrInvalidSection0Type, c.types[0].inputs, c.types[0].outputs)
+	}
+	offset += typesSize
+
+	// Parse the code sections
+	c.codeSections = make([][]byte, len(codeSizes))
+	for i, size := range codeSizes {
+		if size == 0 {
+			return 0, fmt.Errorf("%w for section %d: size must not be 0", ErrInvalidCodeSize, i)
+		}
+		c.codeSections[i] = b[offset : offset+size]
+		offset += size
+	}
+	// Parse the subcontainers
+	for i, size := range containerSizes {
+		if size == 0 {
+			return 0, fmt.Errorf("%w for container %d: size must not be 0", ErrInvalidContainerSize, i)
+		}
+		sub := new(Container)
+		consumed, err := sub.unmarshal(b[offset:offset+size], false, depth+1)
+		if err != nil {
+			return 0, err
+		}
+		if consumed != size {
+			return 0, fmt.Errorf("%w: subcontainer %d", ErrInvalidContainerSize, i)
+		}
+		c.subContainers = append(c.subContainers, sub)
+		c.subContainerRaw = append(c.subContainerRaw, b[offset:offset+size])
+		offset += size
+	}
+	// Parse the data section, which may only be truncated in deploy containers
+	end := offset + dataSize
+	if end > len(b) {
+		if full {
+			return 0, fmt.Errorf("%w: have %d, want %d", ErrTruncatedContainer, len(b)-offset, dataSize)
+		}
+		end = len(b)
+	}
+	c.data = b[offset:end]
+	c.raw = b[:end]
+	return end, nil
+}
+
+// parseSection decodes a (kind, size) pair from an EOF header.
+func parseSection(b []byte, idx int) (kind, size int, err error) {
+	if idx+3 > len(b) {
+		return 0, 0, io.ErrUnexpectedEOF
+	}
+	return int(b[idx]), int(binary.BigEndian.Uint16(b[idx+1:])), nil
+}
+
+// parseSectionList decodes a (kind, len, []size) section list from an EOF
+// header, where each size is encoded on the given number of bytes.
+func parseSectionList(b []byte, idx int, width int) (kind int, list []int, err error) {
+	if idx+3 > len(b) {
+		return 0, nil, io.ErrUnexpectedEOF
+	}
+	kind = int(b[idx])
+	count := int(binary.BigEndian.Uint16(b[idx+1:]))
+	if count == 0 {
+		return 0, nil, fmt.Errorf("%w: section %x must not be empty", ErrInvalidCodeSize, kind)
+	}
+	idx += 3
+	if idx+count*width > len(b) {
+		return 0, nil, io.ErrUnexpectedEOF
+	}
+	list = make([]int, count)
+	for i := range list {
+		if width == 2 {
+			list[i] = int(binary.BigEndian.Uint16(b[idx+i*width:]))
+		} else {
+			list[i] = int(binary.BigEndian.Uint32(b[idx+i*width:]))
+		}
+	}
+	return kind, list, nil
+}
+
+// ValidateCode validates each code section of the container against the EOF
+// rules, recursing into the subcontainers. Initcode containers may only end
+// with RETURNCONTRACT, runtime containers may not use it.
+func (c *Container) ValidateCode(jt *JumpTable, isInitcode bool) error {
+	var (
+		visited = make([]bool, len(c.codeSections))
+		queue   = []int{0}
+		refs    = make(map[int]OpCode)
+	)
+	visited[0] = true
+	for len(queue) > 0 {
+		section := queue[0]
+		queue = queue[1:]
+
+		calls, err := validateCode(c.codeSections[section], section, c, jt, isInitcode, refs)
+		if err != nil {
+			return err
+		}
+		for _, callee := range calls {
+			if !visited[callee] {
+				visited[callee] = true
+				queue = append(queue, callee)
+			}
+		}
+	}
+	for section, ok := range visited {
+		if !ok {
+			return fmt.Errorf("%w: section %d", ErrUnreachableCodeSection, section)
+		}
+	}
+	// Ensure all subcontainers are referenced and validate them in the mode
+	// implied by the referencing instruction
+	for i, sub := range c.subContainers {
+		op, ok := refs[i]
+		if !ok {
+			return fmt.Errorf("%w: container %d", ErrUnreferencedSubContainer, i)
+		}
+		if op == EOFCREATE && len(sub.data) < sub.dataSize {
+			return fmt.Errorf("%w: initcode container %d", ErrTruncatedContainer, i)
+		}
+		if err := sub.ValidateCode(jt, op == EOFCREATE); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseAndValidate parses and validates the given EOF container, returning the
+// decoded container if it's valid.
+func ParseAndValidate(code []byte, isInitcode bool) (*Container, error) {
+	var c Container
+	if err := c.UnmarshalBinary(code); err != nil {
+		return nil, err
+	}
+	if err := c.ValidateCode(&eofInstructionSet, isInitcode); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
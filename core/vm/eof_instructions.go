@@ -0,0 +1,392 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// returnContext is an entry of the EOF return stack, recording where execution
+// continues after a RETF.
+type returnContext struct {
+	section int
+	pc      uint64
+}
+
+// relativeJump returns the program counter value which, after the interpreter
+// loop's increment, lands on the relative jump target. The offset is relative
+// to the end of the instruction at pc, which is end bytes long.
+func relativeJump(pc uint64, end uint64, offset int16) uint64 {
+	return uint64(int64(pc+end)+int64(offset)) - 1
+}
+
+// opRjump implements the RJUMP opcode.
+func opRjump(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	offset := int16(binary.BigEndian.Uint16(scope.Contract.Code[*pc+1:]))
+	*pc = relativeJump(*pc, 3, offset)
+	return nil, nil
+}
+
+// opRjumpi implements the RJUMPI opcode.
+func opRjumpi(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	condition := scope.Stack.pop()
+	if condition.IsZero() {
+		*pc += 2
+		return nil, nil
+	}
+	return opRjump(pc, interpreter, scope)
+}
+
+// opRjumpv implements the RJUMPV opcode.
+func opRjumpv(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		code  = scope.Contract.Code
+		count = uint64(code[*pc+1]) + 1
+		end   = 2 + 2*count
+		index = scope.Stack.pop()
+	)
+	if !index.IsUint64() || index.Uint64() >= count {
+		*pc += end - 1
+		return nil, nil
+	}
+	offset := int16(binary.BigEndian.Uint16(code[*pc+2+2*index.Uint64():]))
+	*pc = relativeJump(*pc, end, offset)
+	return nil, nil
+}
+
+// opCallf implements the CALLF opcode.
+func opCallf(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		container = scope.Contract.Container
+		target    = int(binary.BigEndian.Uint16(scope.Contract.Code[*pc+1:]))
+		meta      = container.types[target]
+	)
+	if scope.Stack.len()+int(meta.maxStackHeight)-int(meta.inputs) > int(params.StackLimit) {
+		return nil, &ErrStackOverflow{stackLen: scope.Stack.len(), limit: int(params.StackLimit)}
+	}
+	if len(scope.Contract.returnStack) >= int(params.StackLimit) {
+		return nil, ErrReturnStackExceeded
+	}
+	scope.Contract.returnStack = append(scope.Contract.returnStack, &returnContext{
+		section: scope.Contract.codeSection,
+		pc:      *pc + 3,
+	})
+	scope.Contract.codeSection = target
+	scope.Contract.Code = container.codeSections[target]
+	*pc = math.MaxUint64 // wraps to the section start in the interpreter loop
+	return nil, nil
+}
+
+// opRetf implements the RETF opcode.
+func opRetf(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	last := len(scope.Contract.returnStack) - 1
+	ctx := scope.Contract.returnStack[last]
+	scope.Contract.returnStack = scope.Contract.returnStack[:last]
+
+	scope.Contract.codeSection = ctx.section
+	scope.Contract.Code = scope.Contract.Container.codeSections[ctx.section]
+	*pc = ctx.pc - 1
+	return nil, nil
+}
+
+// opJumpf implements the JUMPF opcode.
+func opJumpf(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		container = scope.Contract.Container
+		target    = int(binary.BigEndian.Uint16(scope.Contract.Code[*pc+1:]))
+		meta      = container.types[target]
+	)
+	if scope.Stack.len()+int(meta.maxStackHeight)-int(meta.inputs) > int(params.StackLimit) {
+		return nil, &ErrStackOverflow{stackLen: scope.Stack.len(), limit: int(params.StackLimit)}
+	}
+	scope.Contract.codeSection = target
+	scope.Contract.Code = container.codeSections[target]
+	*pc = math.MaxUint64 // wraps to the section start in the interpreter loop
+	return nil, nil
+}
+
+// opDupN implements the DUPN opcode.
+func opDupN(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	n := int(scope.Contract.Code[*pc+1]) + 1
+	scope.Stack.dup(n)
+	*pc += 1
+	return nil, nil
+}
+
+// opSwapN implements the SWAPN opcode.
+func opSwapN(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	n := int(scope.Contract.Code[*pc+1]) + 2
+	scope.Stack.swap(n)
+	*pc += 1
+	return nil, nil
+}
+
+// opExchange implements the EXCHANGE opcode.
+func opExchange(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		imm  = scope.Contract.Code[*pc+1]
+		n    = int(imm>>4) + 1
+		m    = int(imm&0x0f) + 1
+		data = scope.Stack.data
+		top  = len(data) - 1
+	)
+	data[top-n], data[top-n-m] = data[top-n-m], data[top-n]
+	*pc += 1
+	return nil, nil
+}
+
+// opDataLoad implements the DATALOAD opcode.
+func opDataLoad(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	offset := scope.Stack.peek()
+	offset64, overflow := offset.Uint64WithOverflow()
+	if overflow {
+		offset.Clear()
+		return nil, nil
+	}
+	offset.SetBytes(getData(scope.Contract.Container.data, offset64, 32))
+	return nil, nil
+}
+
+// opDataLoadN implements the DATALOADN opcode.
+func opDataLoadN(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	offset := uint64(binary.BigEndian.Uint16(scope.Contract.Code[*pc+1:]))
+	scope.Stack.push(new(uint256.Int).SetBytes(scope.Contract.Container.data[offset : offset+32]))
+	*pc += 2
+	return nil, nil
+}
+
+// opDataSize implements the DATASIZE opcode.
+func opDataSize(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	scope.Stack.push(new(uint256.Int).SetUint64(uint64(len(scope.Contract.Container.data))))
+	return nil, nil
+}
+
+// opDataCopy implements the DATACOPY opcode.
+func opDataCopy(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		memOffset = scope.Stack.pop()
+		offset    = scope.Stack.pop()
+		size      = scope.Stack.pop()
+	)
+	offset64, overflow := offset.Uint64WithOverflow()
+	if overflow {
+		offset64 = math.MaxUint64
+	}
+	// These values are checked for overflow during gas cost calculation
+	scope.Memory.Set(memOffset.Uint64(), size.Uint64(), getData(scope.Contract.Container.data, offset64, size.Uint64()))
+	return nil, nil
+}
+
+// opReturnDataLoad implements the RETURNDATALOAD opcode.
+func opReturnDataLoad(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	offset := scope.Stack.peek()
+	offset64, overflow := offset.Uint64WithOverflow()
+	if overflow {
+		offset64 = math.MaxUint64
+	}
+	offset.SetBytes(getData(interpreter.returnData, offset64, 32))
+	return nil, nil
+}
+
+// opEOFCreate implements the EOFCREATE opcode.
+func opEOFCreate(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	if interpreter.readOnly {
+		return nil, ErrWriteProtection
+	}
+	var (
+		initcode     = scope.Contract.Container.subContainers[scope.Contract.Code[*pc+1]]
+		value        = scope.Stack.pop()
+		salt         = scope.Stack.pop()
+		offset, size = scope.Stack.pop(), scope.Stack.pop()
+		input        = scope.Memory.GetCopy(int64(offset.Uint64()), int64(size.Uint64()))
+	)
+	*pc += 1
+
+	// Charge for hashing the initcode container, which is not known to the
+	// dynamic gas function
+	hashingGas := params.Keccak256WordGas * toWordSize(uint64(len(initcode.raw)))
+	if !scope.Contract.UseGas(hashingGas, interpreter.evm.Config.Tracer, tracing.GasChangeIgnored) {
+		return nil, ErrOutOfGas
+	}
+	// Apply EIP150
+	gas := scope.Contract.Gas
+	gas -= gas / 64
+	scope.Contract.UseGas(gas, interpreter.evm.Config.Tracer, tracing.GasChangeCallContractCreation2)
+
+	res, addr, returnGas, suberr := interpreter.evm.EOFCreate(scope.Contract, initcode, input, gas, &value, &salt)
+	if suberr != nil {
+		value.Clear()
+	} else {
+		value.SetBytes(addr.Bytes())
+	}
+	scope.Stack.push(&value)
+	scope.Contract.RefundGas(returnGas, interpreter.evm.Config.Tracer, tracing.GasChangeCallLeftOverRefunded)
+
+	if suberr == ErrExecutionReverted {
+		interpreter.returnData = res // set REVERT data to return data buffer
+		return res, nil
+	}
+	interpreter.returnData = nil // clear dirty return data buffer
+	return nil, nil
+}
+
+// opReturnContract implements the RETURNCONTRACT opcode, terminating the
+// initcode execution and returning the deploy container with the auxiliary
+// data appended to its data section.
+func opReturnContract(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		deploy       = scope.Contract.Container.subContainers[scope.Contract.Code[*pc+1]]
+		offset, size = scope.Stack.pop(), scope.Stack.pop()
+		aux          = scope.Memory.GetPtr(int64(offset.Uint64()), int64(size.Uint64()))
+		dataSize     = len(deploy.data) + len(aux)
+	)
+	if dataSize < deploy.dataSize || dataSize > 0xffff {
+		return nil, ErrInvalidAuxData
+	}
+	ret := make([]byte, 0, len(deploy.raw)+len(aux))
+	ret = append(append(ret, deploy.raw...), aux...)
+	binary.BigEndian.PutUint16(ret[deploy.dataSizeOffset:], uint16(dataSize))
+	return ret, errStopToken
+}
+
+// extCallGas returns the gas passed on to the callee of an EXT*CALL, or false
+// if the call would not leave the callee enough gas and should fail lightly.
+func extCallGas(available uint64) (uint64, bool) {
+	retained := max(available/64, params.ExtCallMinRetainedGas)
+	if available < retained || available-retained < params.ExtCallMinCalleeGas {
+		return 0, false
+	}
+	return available - retained, true
+}
+
+// extCall executes the EXT*CALL variant op, pushing the status code of the call
+// onto the stack: 0 for success, 1 for a revert or light failure and 2 for an
+// exceptional failure of the callee.
+func extCall(op OpCode, interpreter *EVMInterpreter, scope *ScopeContext, addr common.Address, args []byte, value *uint256.Int) ([]byte, error) {
+	var (
+		evm    = interpreter.evm
+		status = new(uint256.Int)
+	)
+	gas, ok := extCallGas(scope.Contract.Gas)
+	if !ok || evm.depth > int(params.CallCreateDepth) || (!value.IsZero() && !evm.Context.CanTransfer(evm.StateDB, scope.Contract.Address(), value)) {
+		interpreter.returnData = nil
+		scope.Stack.push(status.SetOne())
+		return nil, nil
+	}
+	if op == EXTDELEGATECALL && !isEOFVersion1(evm.StateDB.GetCode(addr)) {
+		interpreter.returnData = nil
+		scope.Stack.push(status.SetOne())
+		return nil, nil
+	}
+	scope.Contract.UseGas(gas, evm.Config.Tracer, tracing.GasChangeIgnored)
+
+	var (
+		ret       []byte
+		returnGas uint64
+		err       error
+	)
+	switch op {
+	case EXTCALL:
+		ret, returnGas, err = evm.Call(scope.Contract, addr, args, gas, value)
+	case EXTDELEGATECALL:
+		ret, returnGas, err = evm.DelegateCall(scope.Contract, addr, args, gas)
+	case EXTSTATICCALL:
+		ret, returnGas, err = evm.StaticCall(scope.Contract, addr, args, gas)
+	}
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrExecutionReverted), errors.Is(err, ErrDepth), errors.Is(err, ErrInsufficientBalance):
+		status.SetOne()
+	default:
+		status.SetUint64(2)
+	}
+	scope.Stack.push(status)
+	scope.Contract.RefundGas(returnGas, evm.Config.Tracer, tracing.GasChangeCallLeftOverRefunded)
+
+	interpreter.returnData = ret
+	return ret, nil
+}
+
+// opExtCall implements the EXTCALL opcode.
+func opExtCall(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	stack := scope.Stack
+	addr, inOffset, inSize, value := stack.pop(), stack.pop(), stack.pop(), stack.pop()
+	if interpreter.readOnly && !value.IsZero() {
+		return nil, ErrWriteProtection
+	}
+	args := scope.Memory.GetPtr(int64(inOffset.Uint64()), int64(inSize.Uint64()))
+	return extCall(EXTCALL, interpreter, scope, addr.Bytes20(), args, &value)
+}
+
+// opExtDelegateCall implements the EXTDELEGATECALL opcode.
+func opExtDelegateCall(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	stack := scope.Stack
+	addr, inOffset, inSize := stack.pop(), stack.pop(), stack.pop()
+	args := scope.Memory.GetPtr(int64(inOffset.Uint64()), int64(inSize.Uint64()))
+	return extCall(EXTDELEGATECALL, interpreter, scope, addr.Bytes20(), args, new(uint256.Int))
+}
+
+// opExtStaticCall implements the EXTSTATICCALL opcode.
+func opExtStaticCall(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	stack := scope.Stack
+	addr, inOffset, inSize := stack.pop(), stack.pop(), stack.pop()
+	args := scope.Memory.GetPtr(int64(inOffset.Uint64()), int64(inSize.Uint64()))
+	return extCall(EXTSTATICCALL, interpreter, scope, addr.Bytes20(), args, new(uint256.Int))
+}
+
+// makeGasExtCall creates the dynamic gas function of the EXT*CALL opcodes,
+// charging for memory expansion, cold account access and value transfers.
+func makeGasExtCall(transfersValue bool) gasFunc {
+	return func(evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+		// Addresses with any of the upper 12 bytes set halt the execution
+		if stack.Back(0).BitLen() > 160 {
+			return 0, ErrInvalidExtCallTarget
+		}
+		gas, err := memoryGasCost(mem, memorySize)
+		if err != nil {
+			return 0, err
+		}
+		var (
+			addr     = common.Address(stack.Back(0).Bytes20())
+			overflow bool
+		)
+		if !evm.StateDB.AddressInAccessList(addr) {
+			evm.StateDB.AddAddressToAccessList(addr)
+			if gas, overflow = math.SafeAdd(gas, params.ColdAccountAccessCostEIP2929-params.WarmStorageReadCostEIP2929); overflow {
+				return 0, ErrGasUintOverflow
+			}
+		}
+		if transfersValue && !stack.Back(3).IsZero() {
+			if gas, overflow = math.SafeAdd(gas, params.CallValueTransferGas); overflow {
+				return 0, ErrGasUintOverflow
+			}
+			if evm.StateDB.Empty(addr) {
+				if gas, overflow = math.SafeAdd(gas, params.CallNewAccountGas); overflow {
+					return 0, ErrGasUintOverflow
+				}
+			}
+		}
+		return gas, nil
+	}
+}
@@ -0,0 +1,573 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// eofSection is a code section along with its type signature.
+type eofSection struct {
+	inputs, outputs uint8
+	maxStackHeight  uint16
+	code            []byte
+}
+
+// makeContainer assembles an EOF container from its sections. The declared
+// data size is taken from dataSize if it is not negative, allowing truncated
+// data sections to be built.
+func makeContainer(sections []eofSection, containers [][]byte, data []byte, dataSize int) []byte {
+	if dataSize < 0 {
+		dataSize = len(data)
+	}
+	b := []byte{0xef, 0x00, eof1Version, kindTypes}
+	b = binary.BigEndian.AppendUint16(b, uint16(4*len(sections)))
+	b = append(b, kindCode)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(sections)))
+	for _, s := range sections {
+		b = binary.BigEndian.AppendUint16(b, uint16(len(s.code)))
+	}
+	if len(containers) > 0 {
+		b = append(b, kindContainer)
+		b = binary.BigEndian.AppendUint16(b, uint16(len(containers)))
+		for _, c := range containers {
+			b = binary.BigEndian.AppendUint32(b, uint32(len(c)))
+		}
+	}
+	b = append(b, kindData)
+	b = binary.BigEndian.AppendUint16(b, uint16(dataSize))
+	b = append(b, 0)
+	for _, s := range sections {
+		b = append(b, s.inputs, s.outputs)
+		b = binary.BigEndian.AppendUint16(b, s.maxStackHeight)
+	}
+	for _, s := range sections {
+		b = append(b, s.code...)
+	}
+	for _, c := range containers {
+		b = append(b, c...)
+	}
+	return append(b, data...)
+}
+
+// returnValueCode returns code which returns the top of the stack as a word.
+var returnValueCode = []byte{byte(PUSH0), byte(MSTORE), byte(PUSH1), 32, byte(PUSH0), byte(RETURN)}
+
+func TestEOFValidation(t *testing.T) {
+	var (
+		nonReturning = func(max uint16, code ...byte) eofSection {
+			return eofSection{outputs: nonReturningFunction, maxStackHeight: max, code: code}
+		}
+		returnContract = makeContainer([]eofSection{nonReturning(2, byte(PUSH0), byte(PUSH0), byte(RETURNCONTRACT), 0)},
+			[][]byte{makeContainer([]eofSection{nonReturning(0, byte(STOP))}, nil, nil, -1)}, nil, -1)
+	)
+	tests := []struct {
+		name      string
+		code      []byte
+		initcode  bool
+		want      error
+		wantParse error
+	}{
+		{
+			name: "minimal",
+			code: makeContainer([]eofSection{nonReturning(0, byte(STOP))}, nil, nil, -1),
+		},
+		{
+			name:      "invalid magic",
+			code:      []byte{0xef, 0x01, 0x01},
+			wantParse: ErrInvalidMagic,
+		},
+		{
+			name:      "truncated header",
+			code:      makeContainer([]eofSection{nonReturning(0, byte(STOP))}, nil, nil, -1)[:10],
+			wantParse: io.ErrUnexpectedEOF,
+		},
+		{
+			name:      "trailing bytes",
+			code:      append(makeContainer([]eofSection{nonReturning(0, byte(STOP))}, nil, nil, -1), 0x00),
+			wantParse: ErrTrailingBytes,
+		},
+		{
+			name:      "truncated data",
+			code:      makeContainer([]eofSection{nonReturning(0, byte(STOP))}, nil, []byte{0x01}, 2),
+			wantParse: ErrTruncatedContainer,
+		},
+		{
+			name:      "returning first section",
+			code:      makeContainer([]eofSection{{code: []byte{byte(RETF)}}}, nil, nil, -1),
+			wantParse: ErrInvalidSection0Type,
+		},
+		{
+			name: "undefined instruction",
+			code: makeContainer([]eofSection{nonReturning(1, byte(PUSH0), byte(JUMP))}, nil, nil, -1),
+			want: ErrUndefinedInstruction,
+		},
+		{
+			name: "truncated immediate",
+			code: makeContainer([]eofSection{nonReturning(1, byte(PUSH2), 0x00)}, nil, nil, -1),
+			want: ErrTruncatedImmediate,
+		},
+		{
+			name: "missing termination",
+			code: makeContainer([]eofSection{nonReturning(1, byte(PUSH0))}, nil, nil, -1),
+			want: ErrInvalidCodeTermination,
+		},
+		{
+			name: "jump into immediate",
+			code: makeContainer([]eofSection{nonReturning(0, byte(RJUMP), 0xff, 0xfe)}, nil, nil, -1),
+			want: ErrInvalidJumpDest,
+		},
+		{
+			name: "infinite loop",
+			code: makeContainer([]eofSection{nonReturning(0, byte(RJUMP), 0xff, 0xfd)}, nil, nil, -1),
+		},
+		{
+			name: "unbalanced loop",
+			code: makeContainer([]eofSection{nonReturning(1, byte(PUSH0), byte(RJUMP), 0xff, 0xfc)}, nil, nil, -1),
+			want: ErrInvalidBackwardJump,
+		},
+		{
+			name: "unreachable code",
+			code: makeContainer([]eofSection{nonReturning(0, byte(STOP), byte(STOP))}, nil, nil, -1),
+			want: ErrUnreachableCode,
+		},
+		{
+			name: "stack underflow",
+			code: makeContainer([]eofSection{nonReturning(0, byte(ADD), byte(STOP))}, nil, nil, -1),
+			want: ErrEOFStackUnderflow,
+		},
+		{
+			name: "wrong max stack height",
+			code: makeContainer([]eofSection{nonReturning(2, byte(PUSH0), byte(STOP))}, nil, nil, -1),
+			want: ErrInvalidMaxStackHeight,
+		},
+		{
+			name: "callf",
+			code: makeContainer([]eofSection{
+				nonReturning(1, byte(CALLF), 0x00, 0x01, byte(STOP)),
+				{outputs: 1, maxStackHeight: 1, code: []byte{byte(PUSH0), byte(RETF)}},
+			}, nil, nil, -1),
+		},
+		{
+			name: "callf wrong outputs",
+			code: makeContainer([]eofSection{
+				nonReturning(1, byte(CALLF), 0x00, 0x01, byte(STOP)),
+				{outputs: 1, maxStackHeight: 2, code: []byte{byte(PUSH0), byte(PUSH0), byte(RETF)}},
+			}, nil, nil, -1),
+			want: ErrInvalidOutputs,
+		},
+		{
+			name: "unreachable section",
+			code: makeContainer([]eofSection{nonReturning(0, byte(STOP)), nonReturning(0, byte(STOP))}, nil, nil, -1),
+			want: ErrUnreachableCodeSection,
+		},
+		{
+			name: "dataloadn",
+			code: makeContainer([]eofSection{nonReturning(1, byte(DATALOADN), 0x00, 0x00, byte(STOP))}, nil, make([]byte, 32), -1),
+		},
+		{
+			name: "dataloadn out of bounds",
+			code: makeContainer([]eofSection{nonReturning(1, byte(DATALOADN), 0x00, 0x01, byte(STOP))}, nil, make([]byte, 32), -1),
+			want: ErrInvalidDataloadNArgument,
+		},
+		{
+			name:     "returncontract",
+			code:     returnContract,
+			initcode: true,
+		},
+		{
+			name: "returncontract in runtime code",
+			code: returnContract,
+			want: ErrIncompatibleContainer,
+		},
+		{
+			name:     "return in initcode",
+			code:     makeContainer([]eofSection{nonReturning(2, byte(PUSH0), byte(PUSH0), byte(RETURN))}, nil, nil, -1),
+			initcode: true,
+			want:     ErrIncompatibleContainer,
+		},
+		{
+			name: "unreferenced subcontainer",
+			code: makeContainer([]eofSection{nonReturning(0, byte(STOP))},
+				[][]byte{makeContainer([]eofSection{nonReturning(0, byte(STOP))}, nil, nil, -1)}, nil, -1),
+			want: ErrUnreferencedSubContainer,
+		},
+	}
+	for _, tt := range tests {
+		var c Container
+		err := c.UnmarshalBinary(tt.code)
+		if !errors.Is(err, tt.wantParse) {
+			t.Errorf("%s: parse error mismatch: have %v, want %v", tt.name, err, tt.wantParse)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if !bytes.Equal(c.MarshalBinary(), tt.code) {
+			t.Errorf("%s: encoding mismatch: have %x, want %x", tt.name, c.MarshalBinary(), tt.code)
+		}
+		if err := c.ValidateCode(&eofInstructionSet, tt.initcode); !errors.Is(err, tt.want) {
+			t.Errorf("%s: validation error mismatch: have %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+// newOsakaEVM creates an EVM with the Osaka rules active on an empty state.
+func newOsakaEVM(t *testing.T) *EVM {
+	t.Helper()
+
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	config := *params.MergedTestChainConfig
+	config.PragueTime = new(uint64)
+	config.OsakaTime = new(uint64)
+
+	vmctx := BlockContext{
+		CanTransfer: func(StateDB, common.Address, *uint256.Int) bool { return true },
+		Transfer:    func(StateDB, common.Address, common.Address, *uint256.Int) {},
+		BlockNumber: big.NewInt(0),
+		Random:      &common.Hash{},
+	}
+	return NewEVM(vmctx, TxContext{}, statedb, &config, Config{})
+}
+
+// Tests execution of EOF code, including function calls, and the deployment of
+// EOF contracts from both creation transactions and EOFCREATE.
+func TestEOFExecution(t *testing.T) {
+	var (
+		evm    = newOsakaEVM(t)
+		sender = AccountRef(common.HexToAddress("0x1000"))
+
+		// Returns the output of section 1, which returns the word 42
+		runtime = makeContainer([]eofSection{
+			{outputs: nonReturningFunction, maxStackHeight: 2, code: append([]byte{byte(CALLF), 0x00, 0x01}, returnValueCode...)},
+			{outputs: 1, maxStackHeight: 1, code: []byte{byte(PUSH1), 42, byte(RETF)}},
+		}, nil, nil, -1)
+		// Deploys the runtime container
+		initcode = makeContainer([]eofSection{
+			{outputs: nonReturningFunction, maxStackHeight: 2, code: []byte{byte(PUSH0), byte(PUSH0), byte(RETURNCONTRACT), 0}},
+		}, [][]byte{runtime}, nil, -1)
+		// Creates a contract from the initcode and returns its address
+		factory = makeContainer([]eofSection{
+			{outputs: nonReturningFunction, maxStackHeight: 4, code: append([]byte{byte(PUSH0), byte(PUSH0), byte(PUSH0), byte(PUSH0), byte(EOFCREATE), 0}, returnValueCode...)},
+		}, [][]byte{initcode}, nil, -1)
+	)
+	if _, err := ParseAndValidate(runtime, false); err != nil {
+		t.Fatalf("invalid runtime container: %v", err)
+	}
+	// Deploy the runtime code with a creation transaction and call it
+	_, addr, _, err := evm.Create(sender, initcode, 1000000, new(uint256.Int))
+	if err != nil {
+		t.Fatalf("failed to deploy initcode: %v", err)
+	}
+	if code := evm.StateDB.GetCode(addr); !bytes.Equal(code, runtime) {
+		t.Fatalf("deployed code mismatch: have %x, want %x", code, runtime)
+	}
+	ret, _, err := evm.Call(sender, addr, nil, 100000, new(uint256.Int))
+	if err != nil {
+		t.Fatalf("failed to call contract: %v", err)
+	}
+	if want := common.LeftPadBytes([]byte{42}, 32); !bytes.Equal(ret, want) {
+		t.Fatalf("return value mismatch: have %x, want %x", ret, want)
+	}
+	// Legacy create instructions may not deploy EOF initcode
+	evm.depth++
+	if _, _, _, err := evm.Create(sender, initcode, 1000000, new(uint256.Int)); !errors.Is(err, ErrInvalidEOFInitcode) {
+		t.Fatalf("legacy create error mismatch: have %v, want %v", err, ErrInvalidEOFInitcode)
+	}
+	evm.depth--
+
+	// Deploy the factory and have it create the runtime contract via EOFCREATE
+	factoryAddr := common.HexToAddress("0xfac7")
+	evm.StateDB.CreateAccount(factoryAddr)
+	evm.StateDB.SetCode(factoryAddr, factory)
+
+	ret, _, err = evm.Call(sender, factoryAddr, nil, 1000000, new(uint256.Int))
+	if err != nil {
+		t.Fatalf("failed to call factory: %v", err)
+	}
+	created := crypto.CreateAddress2(factoryAddr, common.Hash{}, crypto.Keccak256(initcode))
+	if have := common.BytesToAddress(ret); have != created {
+		t.Fatalf("created address mismatch: have %x, want %x", have, created)
+	}
+	if code := evm.StateDB.GetCode(created); !bytes.Equal(code, runtime) {
+		t.Fatalf("created code mismatch: have %x, want %x", code, runtime)
+	}
+}
+
+// Tests that EOF code in the state which doesn't pass validation, and thus
+// could never have been deployed, fails the call instead of being executed.
+func TestEOFInvalidDeployedCode(t *testing.T) {
+	var (
+		evm    = newOsakaEVM(t)
+		sender = AccountRef(common.HexToAddress("0x1000"))
+		addr   = common.HexToAddress("0xbad")
+	)
+	// RETF in the non-returning first section, with an empty return stack
+	code := common.FromHex("ef00010100040200010001ff00000000800000e4")
+	evm.StateDB.CreateAccount(addr)
+	evm.StateDB.SetCode(addr, code)
+
+	if _, _, err := evm.Call(sender, addr, nil, 100000, new(uint256.Int)); !errors.Is(err, ErrInvalidEOFCode) {
+		t.Fatalf("call error mismatch: have %v, want %v", err, ErrInvalidEOFCode)
+	}
+}
+
+// word returns b left padded to a 32 byte word.
+func word(b ...byte) []byte {
+	return common.LeftPadBytes(b, 32)
+}
+
+// Tests the execution of the EOF control flow and data section instructions, and
+// that the validated containers of deployed code are cached.
+func TestEOFInstructions(t *testing.T) {
+	// The data section holds the bytes 1..34
+	data := make([]byte, 34)
+	for i := range data {
+		data[i] = byte(i + 1)
+	}
+	// rjumps returns 9 if cond is set and 7 otherwise
+	rjumps := func(cond byte) []eofSection {
+		return []eofSection{{outputs: nonReturningFunction, maxStackHeight: 2, code: append([]byte{
+			byte(PUSH1), cond,
+			byte(RJUMPI), 0x00, 0x05,
+			byte(PUSH1), 7,
+			byte(RJUMP), 0x00, 0x02,
+			byte(PUSH1), 9,
+		}, returnValueCode...)}}
+	}
+	// rjumpv returns 6 for index 0, 7 for index 1 and 5 otherwise
+	rjumpv := func(index byte) []eofSection {
+		return []eofSection{{outputs: nonReturningFunction, maxStackHeight: 2, code: append([]byte{
+			byte(PUSH1), index,
+			byte(RJUMPV), 0x01, 0x00, 0x05, 0x00, 0x0a,
+			byte(PUSH1), 5,
+			byte(RJUMP), 0x00, 0x07,
+			byte(PUSH1), 6,
+			byte(RJUMP), 0x00, 0x02,
+			byte(PUSH1), 7,
+		}, returnValueCode...)}}
+	}
+	tests := []struct {
+		name     string
+		sections []eofSection
+		want     []byte
+	}{
+		{name: "rjumpi taken", sections: rjumps(1), want: word(9)},
+		{name: "rjumpi not taken", sections: rjumps(0), want: word(7)},
+		{name: "rjumpv first", sections: rjumpv(0), want: word(6)},
+		{name: "rjumpv second", sections: rjumpv(1), want: word(7)},
+		{name: "rjumpv out of range", sections: rjumpv(5), want: word(5)},
+		{
+			name: "jumpf non-returning",
+			sections: []eofSection{
+				{outputs: nonReturningFunction, code: []byte{byte(JUMPF), 0x00, 0x01}},
+				{outputs: nonReturningFunction, maxStackHeight: 2, code: append([]byte{byte(PUSH1), 8}, returnValueCode...)},
+			},
+			want: word(8),
+		},
+		{
+			name: "jumpf returning",
+			sections: []eofSection{
+				{outputs: nonReturningFunction, maxStackHeight: 2, code: append([]byte{byte(CALLF), 0x00, 0x01}, returnValueCode...)},
+				{outputs: 1, code: []byte{byte(JUMPF), 0x00, 0x02}},
+				{outputs: 1, maxStackHeight: 1, code: []byte{byte(PUSH1), 11, byte(RETF)}},
+			},
+			want: word(11),
+		},
+		{
+			name:     "dataloadn",
+			sections: []eofSection{{outputs: nonReturningFunction, maxStackHeight: 2, code: append([]byte{byte(DATALOADN), 0x00, 0x02}, returnValueCode...)}},
+			want:     data[2:34],
+		},
+		{
+			name:     "dataload",
+			sections: []eofSection{{outputs: nonReturningFunction, maxStackHeight: 2, code: append([]byte{byte(PUSH1), 1, byte(DATALOAD)}, returnValueCode...)}},
+			want:     data[1:33],
+		},
+		{
+			name:     "dataload past end",
+			sections: []eofSection{{outputs: nonReturningFunction, maxStackHeight: 2, code: append([]byte{byte(PUSH1), 33, byte(DATALOAD)}, returnValueCode...)}},
+			want:     common.RightPadBytes([]byte{34}, 32),
+		},
+		{
+			name:     "datasize",
+			sections: []eofSection{{outputs: nonReturningFunction, maxStackHeight: 2, code: append([]byte{byte(DATASIZE)}, returnValueCode...)}},
+			want:     word(34),
+		},
+		{
+			name: "datacopy",
+			sections: []eofSection{{outputs: nonReturningFunction, maxStackHeight: 3, code: []byte{
+				byte(PUSH1), 6, byte(PUSH1), 30, byte(PUSH0), byte(DATACOPY),
+				byte(PUSH1), 32, byte(PUSH0), byte(RETURN),
+			}}},
+			want: common.RightPadBytes([]byte{31, 32, 33, 34}, 32),
+		},
+	}
+	var (
+		evm    = newOsakaEVM(t)
+		sender = AccountRef(common.HexToAddress("0x1000"))
+	)
+	for i, tt := range tests {
+		code := makeContainer(tt.sections, nil, data, -1)
+		if _, err := ParseAndValidate(code, false); err != nil {
+			t.Fatalf("%s: invalid container: %v", tt.name, err)
+		}
+		addr := common.BigToAddress(big.NewInt(int64(0x100 + i)))
+		evm.StateDB.CreateAccount(addr)
+		evm.StateDB.SetCode(addr, code)
+
+		ret, _, err := evm.Call(sender, addr, nil, 100000, new(uint256.Int))
+		if err != nil {
+			t.Fatalf("%s: call failed: %v", tt.name, err)
+		}
+		if !bytes.Equal(ret, tt.want) {
+			t.Errorf("%s: return value mismatch: have %x, want %x", tt.name, ret, tt.want)
+		}
+		key := codeCacheKey{hash: crypto.Keccak256Hash(code), table: evm.interpreter.tableID}
+		if !containerCache.Contains(key) {
+			t.Errorf("%s: validated container not cached", tt.name)
+		}
+	}
+}
+
+// Tests that RETURNCONTRACT appends the auxiliary data to a deploy container
+// with a truncated data section.
+func TestEOFReturnContractAuxData(t *testing.T) {
+	var (
+		evm    = newOsakaEVM(t)
+		sender = AccountRef(common.HexToAddress("0x1000"))
+
+		// Returns the first word of its data section, supplied at deployment
+		runtime = makeContainer([]eofSection{
+			{outputs: nonReturningFunction, maxStackHeight: 2, code: append([]byte{byte(DATALOADN), 0x00, 0x00}, returnValueCode...)},
+		}, nil, nil, 32)
+		deployed = append(bytes.Clone(runtime), word(0x77)...)
+		// Deploys the runtime container with the word 0x77 as auxiliary data
+		initcode = makeContainer([]eofSection{
+			{outputs: nonReturningFunction, maxStackHeight: 2, code: []byte{
+				byte(PUSH1), 0x77, byte(PUSH0), byte(MSTORE),
+				byte(PUSH1), 32, byte(PUSH0), byte(RETURNCONTRACT), 0,
+			}},
+		}, [][]byte{runtime}, nil, -1)
+	)
+	_, addr, _, err := evm.Create(sender, initcode, 1000000, new(uint256.Int))
+	if err != nil {
+		t.Fatalf("failed to deploy initcode: %v", err)
+	}
+	if code := evm.StateDB.GetCode(addr); !bytes.Equal(code, deployed) {
+		t.Fatalf("deployed code mismatch: have %x, want %x", code, deployed)
+	}
+	ret, _, err := evm.Call(sender, addr, nil, 100000, new(uint256.Int))
+	if err != nil {
+		t.Fatalf("failed to call contract: %v", err)
+	}
+	if want := word(0x77); !bytes.Equal(ret, want) {
+		t.Fatalf("return value mismatch: have %x, want %x", ret, want)
+	}
+}
+
+// Tests the EXT*CALL instructions, checking the status codes they push and the
+// return data they make available to RETURNDATALOAD.
+func TestEOFExtCalls(t *testing.T) {
+	var (
+		evm    = newOsakaEVM(t)
+		sender = AccountRef(common.HexToAddress("0x1000"))
+		caller = common.HexToAddress("0xca11e2")
+
+		eofCallee    = common.HexToAddress("0xe0f")
+		legacyCallee = common.HexToAddress("0x1e9a")
+		reverter     = common.HexToAddress("0x2e7e")
+		writer       = common.HexToAddress("0x5704")
+	)
+	// Returns the address the code is executing as
+	addressCode := append([]byte{byte(ADDRESS)}, returnValueCode...)
+	callees := map[common.Address][]byte{
+		eofCallee:    makeContainer([]eofSection{{outputs: nonReturningFunction, maxStackHeight: 2, code: addressCode}}, nil, nil, -1),
+		legacyCallee: addressCode,
+		reverter: makeContainer([]eofSection{{outputs: nonReturningFunction, maxStackHeight: 2, code: []byte{
+			byte(PUSH1), 0xaa, byte(PUSH0), byte(MSTORE), byte(PUSH1), 32, byte(PUSH0), byte(REVERT),
+		}}}, nil, nil, -1),
+		writer: makeContainer([]eofSection{{outputs: nonReturningFunction, maxStackHeight: 2, code: []byte{
+			byte(PUSH1), 1, byte(PUSH0), byte(SSTORE), byte(STOP),
+		}}}, nil, nil, -1),
+	}
+	for addr, code := range callees {
+		evm.StateDB.CreateAccount(addr)
+		evm.StateDB.SetCode(addr, code)
+	}
+	// callCode calls the target with op and returns the first word of the
+	// return data, followed by the status code of the call
+	callCode := func(op OpCode, target common.Address) []byte {
+		code := []byte{byte(PUSH0), byte(PUSH0)}
+		maxStack := uint16(3)
+		if op == EXTCALL {
+			code = append(code, byte(PUSH0))
+			maxStack = 4
+		}
+		code = append(append(append(code, byte(PUSH20)), target.Bytes()...), byte(op))
+		code = append(code,
+			byte(PUSH1), 32, byte(MSTORE),
+			byte(PUSH0), byte(RETURNDATALOAD), byte(PUSH0), byte(MSTORE),
+			byte(PUSH1), 64, byte(PUSH0), byte(RETURN),
+		)
+		return makeContainer([]eofSection{{outputs: nonReturningFunction, maxStackHeight: maxStack, code: code}}, nil, nil, -1)
+	}
+	tests := []struct {
+		name   string
+		op     OpCode
+		target common.Address
+		ret    []byte
+		status byte
+	}{
+		{name: "extcall eof", op: EXTCALL, target: eofCallee, ret: word(eofCallee.Bytes()...)},
+		{name: "extcall legacy", op: EXTCALL, target: legacyCallee, ret: word(legacyCallee.Bytes()...)},
+		{name: "extcall revert", op: EXTCALL, target: reverter, ret: word(0xaa), status: 1},
+		{name: "extdelegatecall eof", op: EXTDELEGATECALL, target: eofCallee, ret: word(caller.Bytes()...)},
+		{name: "extdelegatecall legacy", op: EXTDELEGATECALL, target: legacyCallee, ret: word(), status: 1},
+		{name: "extstaticcall eof", op: EXTSTATICCALL, target: eofCallee, ret: word(eofCallee.Bytes()...)},
+		{name: "extstaticcall write", op: EXTSTATICCALL, target: writer, ret: word(), status: 2},
+	}
+	evm.StateDB.CreateAccount(caller)
+	for _, tt := range tests {
+		code := callCode(tt.op, tt.target)
+		if _, err := ParseAndValidate(code, false); err != nil {
+			t.Fatalf("%s: invalid container: %v", tt.name, err)
+		}
+		evm.StateDB.SetCode(caller, code)
+
+		ret, _, err := evm.Call(sender, caller, nil, 1000000, new(uint256.Int))
+		if err != nil {
+			t.Fatalf("%s: call failed: %v", tt.name, err)
+		}
+		if want := append(tt.ret, word(tt.status)...); !bytes.Equal(ret, want) {
+			t.Errorf("%s: result mismatch: have %x, want %x", tt.name, ret, want)
+		}
+	}
+}
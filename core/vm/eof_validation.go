@@ -0,0 +1,272 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// validateCode validates the code of a single section of the container against
+// the EOF rules, returning the sections it calls or jumps into. Subcontainer
+// references are collected into refs, mapping the container index to the
+// instruction referencing it.
+func validateCode(code []byte, section int, container *Container, jt *JumpTable, isInitcode bool, refs map[int]OpCode) ([]int, error) {
+	var (
+		i         = 0
+		op        OpCode
+		meta      = container.types[section]
+		starts    = make([]bool, len(code)) // instruction boundaries
+		jumps     []int                     // relative jump destinations
+		calls     []int                     // sections called or jumped into
+		returning bool                      // section has RETF or JUMPF into a returning section
+	)
+	for i < len(code) {
+		op = OpCode(code[i])
+		starts[i] = true
+
+		if jt[op].undefined {
+			return nil, fmt.Errorf("%w: op %s, pos %d", ErrUndefinedInstruction, op, i)
+		}
+		size := int(immediates[op])
+		if size != 0 && len(code) <= i+size {
+			return nil, fmt.Errorf("%w: op %s, pos %d", ErrTruncatedImmediate, op, i)
+		}
+		switch op {
+		case RJUMP, RJUMPI:
+			jumps = append(jumps, i+3+int(int16(binary.BigEndian.Uint16(code[i+1:]))))
+
+		case RJUMPV:
+			count := int(code[i+1]) + 1
+			size += 2 * count
+			if len(code) <= i+size {
+				return nil, fmt.Errorf("%w: op %s, pos %d", ErrTruncatedImmediate, op, i)
+			}
+			for j := 0; j < count; j++ {
+				jumps = append(jumps, i+1+size+int(int16(binary.BigEndian.Uint16(code[i+2+2*j:]))))
+			}
+
+		case CALLF:
+			arg := int(binary.BigEndian.Uint16(code[i+1:]))
+			if arg >= len(container.types) {
+				return nil, fmt.Errorf("%w: arg %d, last %d, pos %d", ErrInvalidSectionArgument, arg, len(container.types)-1, i)
+			}
+			if container.types[arg].nonReturning() {
+				return nil, fmt.Errorf("%w: section %d, pos %d", ErrInvalidCallArgument, arg, i)
+			}
+			calls = append(calls, arg)
+
+		case RETF:
+			if meta.nonReturning() {
+				return nil, fmt.Errorf("%w: retf in non-returning section %d, pos %d", ErrInvalidNonReturning, section, i)
+			}
+			returning = true
+
+		case JUMPF:
+			arg := int(binary.BigEndian.Uint16(code[i+1:]))
+			if arg >= len(container.types) {
+				return nil, fmt.Errorf("%w: arg %d, last %d, pos %d", ErrInvalidSectionArgument, arg, len(container.types)-1, i)
+			}
+			if target := container.types[arg]; !target.nonReturning() {
+				if meta.nonReturning() {
+					return nil, fmt.Errorf("%w: jumpf into returning section %d from non-returning section, pos %d", ErrInvalidNonReturning, arg, i)
+				}
+				if target.outputs > meta.outputs {
+					return nil, fmt.Errorf("%w: jumpf target outputs %d exceed %d, pos %d", ErrInvalidOutputs, target.outputs, meta.outputs, i)
+				}
+				returning = true
+			}
+			calls = append(calls, arg)
+
+		case DATALOADN:
+			if arg := int(binary.BigEndian.Uint16(code[i+1:])); arg+32 > container.dataSize {
+				return nil, fmt.Errorf("%w: arg %d, data size %d, pos %d", ErrInvalidDataloadNArgument, arg, container.dataSize, i)
+			}
+
+		case EOFCREATE, RETURNCONTRACT:
+			arg := int(code[i+1])
+			if arg >= len(container.subContainers) {
+				return nil, fmt.Errorf("%w: arg %d, containers %d, pos %d", ErrInvalidContainerSection, arg, len(container.subContainers), i)
+			}
+			if op == RETURNCONTRACT && !isInitcode {
+				return nil, fmt.Errorf("%w: returncontract in runtime code, pos %d", ErrIncompatibleContainer, i)
+			}
+			if prev, ok := refs[arg]; ok && prev != op {
+				return nil, fmt.Errorf("%w: container %d referenced by both %s and %s", ErrIncompatibleContainer, arg, prev, op)
+			}
+			refs[arg] = op
+
+		case STOP, RETURN:
+			if isInitcode {
+				return nil, fmt.Errorf("%w: %s in initcode, pos %d", ErrIncompatibleContainer, op, i)
+			}
+		}
+		i += size + 1
+	}
+	// Code sections may not "fall through" and require proper termination
+	if !terminals[op] && op != RJUMP {
+		return nil, fmt.Errorf("%w: end with %s, pos %d", ErrInvalidCodeTermination, op, i)
+	}
+	for _, dest := range jumps {
+		if dest < 0 || dest >= len(code) || !starts[dest] {
+			return nil, fmt.Errorf("%w: destination %d", ErrInvalidJumpDest, dest)
+		}
+	}
+	if !meta.nonReturning() && !returning {
+		return nil, fmt.Errorf("%w: returning section %d never returns", ErrInvalidNonReturning, section)
+	}
+	if err := validateControlFlow(code, section, container.types, jt); err != nil {
+		return nil, err
+	}
+	return calls, nil
+}
+
+// validateControlFlow performs the EIP-5450 stack validation of a code section,
+// tracking the range of possible stack heights at every instruction, which
+// replaces the legacy JUMPDEST analysis and all runtime stack checks.
+func validateControlFlow(code []byte, section int, metadata []*functionMetadata, jt *JumpTable) error {
+	var (
+		meta       = metadata[section]
+		maxHeight  = int(meta.inputs)
+		boundsMin  = make([]int, len(code))
+		boundsMax  = make([]int, len(code)) // -1 denotes an unvisited instruction
+		stackLimit = int(params.StackLimit)
+	)
+	for i := range boundsMax {
+		boundsMax[i] = -1
+	}
+	boundsMin[0], boundsMax[0] = int(meta.inputs), int(meta.inputs)
+
+	// visit records the stack bounds flowing from pos into target.
+	visit := func(pos, target, low, high int) error {
+		if target > pos {
+			if boundsMax[target] == -1 {
+				boundsMin[target], boundsMax[target] = low, high
+			} else {
+				boundsMin[target] = min(boundsMin[target], low)
+				boundsMax[target] = max(boundsMax[target], high)
+			}
+			return nil
+		}
+		if boundsMin[target] != low || boundsMax[target] != high {
+			return fmt.Errorf("%w: pos %d, target %d, have [%d, %d], want [%d, %d]", ErrInvalidBackwardJump, pos, target, low, high, boundsMin[target], boundsMax[target])
+		}
+		return nil
+	}
+	for pos := 0; pos < len(code); {
+		op := OpCode(code[pos])
+		low, high := boundsMin[pos], boundsMax[pos]
+		if high == -1 {
+			return fmt.Errorf("%w: section %d, pos %d", ErrUnreachableCode, section, pos)
+		}
+		size := int(immediates[op])
+		switch op {
+		case CALLF:
+			callee := metadata[binary.BigEndian.Uint16(code[pos+1:])]
+			if int(callee.inputs) > low {
+				return fmt.Errorf("%w: callf at pos %d, have %d, want %d", ErrEOFStackUnderflow, pos, low, callee.inputs)
+			}
+			if high+int(callee.maxStackHeight)-int(callee.inputs) > stackLimit {
+				return fmt.Errorf("%w: callf at pos %d", ErrEOFStackOverflow, pos)
+			}
+			low += callee.stackDelta()
+			high += callee.stackDelta()
+
+		case RETF:
+			if low != high || low != int(meta.outputs) {
+				return fmt.Errorf("%w: retf at pos %d, have [%d, %d], want %d", ErrInvalidOutputs, pos, low, high, meta.outputs)
+			}
+
+		case JUMPF:
+			callee := metadata[binary.BigEndian.Uint16(code[pos+1:])]
+			if high+int(callee.maxStackHeight)-int(callee.inputs) > stackLimit {
+				return fmt.Errorf("%w: jumpf at pos %d", ErrEOFStackOverflow, pos)
+			}
+			if callee.nonReturning() {
+				if int(callee.inputs) > low {
+					return fmt.Errorf("%w: jumpf at pos %d, have %d, want %d", ErrEOFStackUnderflow, pos, low, callee.inputs)
+				}
+			} else {
+				want := int(meta.outputs) + int(callee.inputs) - int(callee.outputs)
+				if low != high || high != want {
+					return fmt.Errorf("%w: jumpf at pos %d, have [%d, %d], want %d", ErrInvalidOutputs, pos, low, high, want)
+				}
+			}
+
+		case DUPN:
+			if want := int(code[pos+1]) + 1; want > low {
+				return fmt.Errorf("%w: dupn at pos %d, have %d, want %d", ErrEOFStackUnderflow, pos, low, want)
+			}
+			low, high = low+1, high+1
+
+		case SWAPN:
+			if want := int(code[pos+1]) + 2; want > low {
+				return fmt.Errorf("%w: swapn at pos %d, have %d, want %d", ErrEOFStackUnderflow, pos, low, want)
+			}
+
+		case EXCHANGE:
+			n, m := int(code[pos+1]>>4)+1, int(code[pos+1]&0x0f)+1
+			if want := n + m + 1; want > low {
+				return fmt.Errorf("%w: exchange at pos %d, have %d, want %d", ErrEOFStackUnderflow, pos, low, want)
+			}
+
+		default:
+			pops := jt[op].minStack
+			if pops > low {
+				return fmt.Errorf("%w: %s at pos %d, have %d, want %d", ErrEOFStackUnderflow, op, pos, low, pops)
+			}
+			delta := stackLimit - jt[op].maxStack
+			low, high = low+delta, high+delta
+		}
+		maxHeight = max(maxHeight, high)
+
+		// Propagate the stack bounds to the successor instructions
+		switch op {
+		case RJUMP:
+			if err := visit(pos, pos+3+int(int16(binary.BigEndian.Uint16(code[pos+1:]))), low, high); err != nil {
+				return err
+			}
+		case RJUMPI:
+			if err := visit(pos, pos+3+int(int16(binary.BigEndian.Uint16(code[pos+1:]))), low, high); err != nil {
+				return err
+			}
+		case RJUMPV:
+			count := int(code[pos+1]) + 1
+			size += 2 * count
+			for j := 0; j < count; j++ {
+				if err := visit(pos, pos+1+size+int(int16(binary.BigEndian.Uint16(code[pos+2+2*j:]))), low, high); err != nil {
+					return err
+				}
+			}
+		}
+		if next := pos + 1 + size; !terminals[op] && op != RJUMP && next < len(code) {
+			if err := visit(pos, next, low, high); err != nil {
+				return err
+			}
+		}
+		pos += 1 + size
+	}
+	if maxHeight > maxStackHeight {
+		return fmt.Errorf("%w: section %d, have %d, limit %d", ErrInvalidMaxStackHeight, section, maxHeight, maxStackHeight)
+	}
+	if maxHeight != int(meta.maxStackHeight) {
+		return fmt.Errorf("%w: section %d, have %d, want %d", ErrInvalidMaxStackHeight, section, meta.maxStackHeight, maxHeight)
+	}
+	return nil
+}
@@ -38,6 +38,11 @@ var (
 	ErrGasUintOverflow          = errors.New("gas uint64 overflow")
 	ErrInvalidCode              = errors.New("invalid code: must not begin with 0xef")
 	ErrNonceUintOverflow        = errors.New("nonce uint64 overflow")
+	ErrInvalidEOFInitcode       = errors.New("invalid eof initcode")
+	ErrInvalidEOFCode           = errors.New("invalid eof code")
+	ErrInvalidExtCallTarget     = errors.New("invalid extcall target address")
+	ErrReturnStackExceeded      = errors.New("return stack limit reached")
+	ErrInvalidAuxData           = errors.New("invalid aux data size")
 
 	// errStopToken is an internal token indicating interpreter loop termination,
 	// never returned to outside callers.
@@ -18,6 +18,7 @@ package vm
 
 import (
 	"errors"
+	"fmt"
 	"math/big"
 	"sync/atomic"
 
@@ -444,8 +445,10 @@ func (c *codeAndHash) Hash() common.Hash {
 	return c.hash
 }
 
-// create creates a new contract using code as deployment code.
-func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64, value *uint256.Int, address common.Address, typ OpCode) (ret []byte, createAddress common.Address, leftOverGas uint64, err error) {
+// create creates a new contract using code as deployment code. EOF initcode
+// containers are either passed in already validated by EOFCREATE, or decoded
+// from the code of a creation transaction, with the remainder used as input.
+func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64, value *uint256.Int, address common.Address, typ OpCode, input []byte, container *Container) (ret []byte, createAddress common.Address, leftOverGas uint64, err error) {
 	if evm.Config.Tracer != nil {
 		evm.captureBegin(evm.depth, typ, caller.Address(), address, codeAndHash.code, gas, value.ToBig())
 		defer func(startGas uint64) {
@@ -508,8 +511,25 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 	// The contract is a scoped environment for this execution context only.
 	contract := NewContract(caller, AccountRef(address), value, gas)
 	contract.SetCodeOptionalHash(&address, codeAndHash)
+	contract.Container = container
 	contract.IsDeployment = true
 
+	// Validate EOF initcode, which may only be deployed by EOFCREATE or by
+	// creation transactions, never by the legacy create instructions
+	if container == nil && evm.chainRules.IsOsaka && hasEOFMagic(codeAndHash.code) {
+		if typ != CREATE || evm.depth != 0 {
+			err = ErrInvalidEOFInitcode
+		} else {
+			contract.Container = new(Container)
+			if input, err = contract.Container.UnmarshalInitcode(codeAndHash.code); err == nil {
+				err = contract.Container.ValidateCode(evm.interpreter.tableEOF, true)
+			}
+			if err != nil {
+				err = fmt.Errorf("%w: %v", ErrInvalidEOFInitcode, err)
+			}
+		}
+	}
+
 	// Charge the contract creation init gas in verkle mode
 	if evm.chainRules.IsEIP4762 {
 		if !contract.UseGas(evm.AccessEvents.ContractCreateInitGas(address, value.Sign() != 0), evm.Config.Tracer, tracing.GasChangeWitnessContractInit) {
@@ -518,7 +538,7 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 	}
 
 	if err == nil {
-		ret, err = evm.interpreter.Run(contract, nil, false)
+		ret, err = evm.interpreter.Run(contract, input, false)
 	}
 
 	// Check whether the max code size has been exceeded, assign err if the case.
@@ -526,8 +546,9 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 		err = ErrMaxCodeSizeExceeded
 	}
 
-	// Reject code starting with 0xEF if EIP-3541 is enabled.
-	if err == nil && len(ret) >= 1 && ret[0] == 0xEF && evm.chainRules.IsLondon {
+	// Reject code starting with 0xEF if EIP-3541 is enabled. EOF initcode
+	// returns a deploy container which is valid by construction.
+	if err == nil && HasEOFByte(ret) && evm.chainRules.IsLondon && contract.Container == nil {
 		err = ErrInvalidCode
 	}
 
@@ -573,7 +594,7 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 // Create creates a new contract using code as deployment code.
 func (evm *EVM) Create(caller ContractRef, code []byte, gas uint64, value *uint256.Int) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
 	contractAddr = crypto.CreateAddress(caller.Address(), evm.StateDB.GetNonce(caller.Address()))
-	return evm.create(caller, &codeAndHash{code: code}, gas, value, contractAddr, CREATE)
+	return evm.create(caller, &codeAndHash{code: code}, gas, value, contractAddr, CREATE, nil, nil)
 }
 
 // Create2 creates a new contract using code as deployment code.
@@ -583,7 +604,18 @@ func (evm *EVM) Create(caller ContractRef, code []byte, gas uint64, value *uint2
 func (evm *EVM) Create2(caller ContractRef, code []byte, gas uint64, endowment *uint256.Int, salt *uint256.Int) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
 	codeAndHash := &codeAndHash{code: code}
 	contractAddr = crypto.CreateAddress2(caller.Address(), salt.Bytes32(), codeAndHash.Hash().Bytes())
-	return evm.create(caller, codeAndHash, gas, endowment, contractAddr, CREATE2)
+	return evm.create(caller, codeAndHash, gas, endowment, contractAddr, CREATE2, nil, nil)
+}
+
+// EOFCreate creates a new contract from a validated EOF initcode container,
+// passing input as its calldata.
+//
+// The address is derived like in Create2, from the hash of the initcode
+// container: keccak256(0xff ++ msg.sender ++ salt ++ keccak256(initcontainer))[12:].
+func (evm *EVM) EOFCreate(caller ContractRef, container *Container, input []byte, gas uint64, endowment *uint256.Int, salt *uint256.Int) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
+	codeAndHash := &codeAndHash{code: container.raw}
+	contractAddr = crypto.CreateAddress2(caller.Address(), salt.Bytes32(), codeAndHash.Hash().Bytes())
+	return evm.create(caller, codeAndHash, gas, endowment, contractAddr, EOFCREATE, input, container)
 }
 
 // ChainConfig returns the environment's chain configuration
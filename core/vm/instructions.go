@@ -344,6 +344,10 @@ func opExtCodeSize(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext)
 	if witness := interpreter.evm.StateDB.Witness(); witness != nil {
 		witness.AddCode(interpreter.evm.StateDB.GetCode(address))
 	}
+	if interpreter.evm.chainRules.IsOsaka && isEOFVersion1(interpreter.evm.StateDB.GetCode(address)) {
+		slot.SetUint64(uint64(len(eofMagic)))
+		return nil, nil
+	}
 	slot.SetUint64(uint64(interpreter.evm.StateDB.GetCodeSize(slot.Bytes20())))
 	return nil, nil
 }
@@ -386,6 +390,10 @@ func opExtCodeCopy(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext)
 	if witness := interpreter.evm.StateDB.Witness(); witness != nil {
 		witness.AddCode(code)
 	}
+	// Legacy code observes EOF contracts as the bare magic
+	if interpreter.evm.chainRules.IsOsaka && isEOFVersion1(code) {
+		code = eofMagic
+	}
 	codeCopy := getData(code, uint64CodeOffset, length.Uint64())
 	scope.Memory.Set(memOffset.Uint64(), length.Uint64(), codeCopy)
 
@@ -423,6 +431,8 @@ func opExtCodeHash(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext)
 	address := common.Address(slot.Bytes20())
 	if interpreter.evm.StateDB.Empty(address) {
 		slot.Clear()
+	} else if interpreter.evm.chainRules.IsOsaka && isEOFVersion1(interpreter.evm.StateDB.GetCode(address)) {
+		slot.SetBytes(crypto.Keccak256(eofMagic))
 	} else {
 		slot.SetBytes(interpreter.evm.StateDB.GetCodeHash(address).Bytes())
 	}
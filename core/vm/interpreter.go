@@ -85,8 +85,10 @@ func (ctx *ScopeContext) CallInput() []byte {
 
 // EVMInterpreter represents an EVM interpreter
 type EVMInterpreter struct {
-	evm   *EVM
-	table *JumpTable
+	evm      *EVM
+	table    *JumpTable
+	tableID  jumpTableID // Identity of the legacy instruction set across EVMs
+	tableEOF *JumpTable  // Instruction set for EOF containers, nil before Osaka
 
 	hasher    crypto.KeccakState // Keccak256 hasher instance shared across opcodes
 	hasherBuf common.Hash        // Keccak256 hasher result array shared across opcodes
@@ -128,7 +130,10 @@ func NewEVMInterpreter(evm *EVM) *EVMInterpreter {
 	default:
 		table = &frontierInstructionSet
 	}
-	var extraEips []int
+	var (
+		fork      = table
+		extraEips []int
+	)
 	if len(evm.Config.ExtraEips) > 0 {
 		// Deep-copy jumptable to prevent modification of opcodes in other tables
 		table = copyJumpTable(table)
@@ -142,7 +147,21 @@ func NewEVMInterpreter(evm *EVM) *EVMInterpreter {
 		}
 	}
 	evm.Config.ExtraEips = extraEips
-	return &EVMInterpreter{evm: evm, table: table}
+
+	interpreter := &EVMInterpreter{evm: evm, table: table, tableID: jumpTableID{fork: fork}}
+	if len(extraEips) > 0 {
+		interpreter.tableID.eips = fmt.Sprint(extraEips)
+	}
+	if evm.chainRules.IsOsaka {
+		// EOF code runs the active instruction set, extended with the EOF
+		// instructions. Only custom tables need a dedicated EOF variant.
+		if table == &cancunInstructionSet {
+			interpreter.tableEOF = &eofInstructionSet
+		} else {
+			interpreter.tableEOF = newEOFTable(table)
+		}
+	}
+	return interpreter
 }
 
 // Run loops and evaluates the contract's code with the given input data and returns
@@ -172,6 +191,21 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 		return nil, nil
 	}
 
+	// EOF containers execute their code sections with a dedicated instruction
+	// set. The EOF instructions rely on the guarantees of the validation, so
+	// code loaded from the state is validated before running it, rather than
+	// trusting it was deployed through a validating creation. The validated
+	// containers are cached by code hash, like the jump analysis.
+	table := in.table
+	if in.tableEOF != nil && isEOFVersion1(contract.Code) {
+		container, err := contract.eofContainer(in.tableEOF, in.tableID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidEOFCode, err)
+		}
+		contract.Code = container.codeSections[0]
+		table = in.tableEOF
+	}
+
 	var (
 		op          OpCode        // current opcode
 		mem         = NewMemory() // bound memory
@@ -192,7 +226,16 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 		logged  bool   // deferred EVMLogger should ignore already logged steps
 		res     []byte // result of the opcode execution function
 		debug   = in.evm.Config.Tracer != nil
+
+		// Basic block analysis, used to charge and validate whole blocks at once
+		// when no tracer needs the per-instruction accounting.
+		blocks  *blockAnalysis
+		block   = -1 // number of the basic block last entered
+		charged bool // whether the current block has been charged and validated
 	)
+	if !debug && !in.evm.chainRules.IsEIP4762 && contract.Container == nil {
+		blocks = contract.basicBlocks(table, in.tableID)
+	}
 	// Don't move this deferred function, it's placed before the OnOpcode-deferred method,
 	// so that it gets executed _after_: the OnOpcode needs the stacks before
 	// they are returned to the pools
@@ -234,16 +277,27 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 		// Get the operation from the jump table and validate the stack to ensure there are
 		// enough stack items available to perform the operation.
 		op = contract.GetOp(pc)
-		operation := in.table[op]
+		operation := table[op]
 		cost = operation.constantGas // For tracing
-		// Validate stack
-		if sLen := stack.len(); sLen < operation.minStack {
-			return nil, &ErrStackUnderflow{stackLen: sLen, required: operation.minStack}
-		} else if sLen > operation.maxStack {
-			return nil, &ErrStackOverflow{stackLen: sLen, limit: operation.maxStack}
+
+		// Upon entering a basic block, charge and validate it as a whole. If that
+		// fails, fall back to the per-instruction checks to fail at the exact
+		// instruction.
+		if blocks != nil && blocks.isStart(pc) {
+			block = blocks.find(pc, block)
+			b, sLen := &blocks.blocks[block], stack.len()
+			charged = sLen >= b.minStack && sLen <= b.maxStack && contract.UseGas(b.gas, nil, tracing.GasChangeIgnored)
 		}
-		if !contract.UseGas(cost, in.evm.Config.Tracer, tracing.GasChangeIgnored) {
-			return nil, ErrOutOfGas
+		if !charged {
+			// Validate stack
+			if sLen := stack.len(); sLen < operation.minStack {
+				return nil, &ErrStackUnderflow{stackLen: sLen, required: operation.minStack}
+			} else if sLen > operation.maxStack {
+				return nil, &ErrStackOverflow{stackLen: sLen, limit: operation.maxStack}
+			}
+			if !contract.UseGas(cost, in.evm.Config.Tracer, tracing.GasChangeIgnored) {
+				return nil, ErrOutOfGas
+			}
 		}
 
 		if operation.dynamicGas != nil {
@@ -30,6 +30,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/asm"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/eth/tracers"
@@ -913,3 +914,119 @@ func BenchmarkTracerStepVsCallFrame(b *testing.B) {
 	benchmarkNonModifyingCode(10000000, code, "tracer-step-10M", stepTracer, b)
 	benchmarkNonModifyingCode(10000000, code, "tracer-call-frame-10M", callFrameTracer, b)
 }
+
+// TestBasicBlockAccounting checks that charging and validating whole basic blocks
+// yields the same results as the per-instruction accounting used while tracing,
+// for every gas limit around the costs of the executions.
+func TestBasicBlockAccounting(t *testing.T) {
+	codes := map[string][]byte{
+		"loop": {
+			byte(vm.PUSH1), 0,
+			byte(vm.JUMPDEST),
+			byte(vm.PUSH1), 1, byte(vm.ADD),
+			byte(vm.DUP1), byte(vm.PUSH1), 10, byte(vm.GT),
+			byte(vm.PUSH1), 2, byte(vm.JUMPI),
+			byte(vm.STOP),
+		},
+		"underflow": {
+			byte(vm.PUSH1), 1, byte(vm.PUSH1), 2, byte(vm.ADD), byte(vm.ADD), byte(vm.STOP),
+		},
+		"overflow": {
+			byte(vm.JUMPDEST), byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.JUMP),
+		},
+		"storage": {
+			byte(vm.PUSH1), 1, byte(vm.PUSH1), 0, byte(vm.SSTORE),
+			byte(vm.PUSH1), 2, byte(vm.PUSH1), 1, byte(vm.TSTORE),
+			byte(vm.PUSH1), 0, byte(vm.SLOAD), byte(vm.PUSH1), 0, byte(vm.MSTORE),
+			byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.RETURN),
+		},
+		"gas": {
+			byte(vm.GAS), byte(vm.PUSH1), 0, byte(vm.SSTORE),
+			byte(vm.GAS), byte(vm.PUSH1), 0, byte(vm.MSTORE),
+			byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.RETURN),
+		},
+		"revert": {
+			byte(vm.PUSH1), 1, byte(vm.PUSH1), 2, byte(vm.ADD),
+			byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.REVERT),
+		},
+		"truncated": {
+			byte(vm.PUSH1), 1, byte(vm.PUSH2), 1,
+		},
+	}
+	run := func(code []byte, gas uint64, hooks *tracing.Hooks) string {
+		cfg := &Config{GasLimit: gas, EVMConfig: vm.Config{Tracer: hooks}}
+		cfg.State, _ = state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		address := common.BytesToAddress([]byte("contract"))
+		cfg.State.SetCode(address, code)
+
+		ret, left, err := Call(address, nil, cfg)
+		return fmt.Sprintf("ret %x, gas left %d, err %v", ret, left, err)
+	}
+	for name, code := range codes {
+		for gas := uint64(0); gas < 25000; {
+			if have, want := run(code, gas, nil), run(code, gas, &tracing.Hooks{}); have != want {
+				t.Fatalf("%s with %d gas: result mismatch: have %s, want %s", name, gas, have, want)
+			}
+			if gas < 3000 {
+				gas++
+			} else {
+				gas += 7
+			}
+		}
+	}
+}
+
+// BenchmarkBasicBlockAccounting compares the execution of common code patterns
+// with the basic block accounting, and with the per-instruction accounting which
+// an empty tracer enables.
+func BenchmarkBasicBlockAccounting(b *testing.B) {
+	// Sum of the first 10000 integers, stored in memory
+	arithmetic := []byte{
+		byte(vm.PUSH1), 0, byte(vm.PUSH2), 0x27, 0x10, // [sum, n]
+		byte(vm.JUMPDEST),
+		byte(vm.DUP1), byte(vm.SWAP2), byte(vm.ADD), byte(vm.SWAP1), // [sum+n, n]
+		byte(vm.PUSH1), 1, byte(vm.SWAP1), byte(vm.SUB), // [sum+n, n-1]
+		byte(vm.DUP1), byte(vm.PUSH1), 5, byte(vm.JUMPI),
+		byte(vm.POP), byte(vm.PUSH1), 0, byte(vm.MSTORE),
+		byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.RETURN),
+	}
+	// Keccak chain over a memory word
+	hashing := []byte{
+		byte(vm.PUSH2), 0x03, 0xe8, // [n]
+		byte(vm.JUMPDEST),
+		byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.KECCAK256),
+		byte(vm.PUSH1), 0, byte(vm.MSTORE),
+		byte(vm.PUSH1), 1, byte(vm.SWAP1), byte(vm.SUB),
+		byte(vm.DUP1), byte(vm.PUSH1), 3, byte(vm.JUMPI),
+		byte(vm.STOP),
+	}
+	for _, bench := range []struct {
+		name string
+		code []byte
+	}{{"arithmetic", arithmetic}, {"hashing", hashing}} {
+		benchmarkNonModifyingCode(10000000, bench.code, bench.name+"-blocks", "", b)
+		benchmarkPerInstructionCode(10000000, bench.code, bench.name+"-instructions", b)
+	}
+}
+
+// benchmarkPerInstructionCode benchmarks the execution of a code with the
+// per-instruction accounting.
+func benchmarkPerInstructionCode(gas uint64, code []byte, name string, b *testing.B) {
+	cfg := &Config{GasLimit: gas, EVMConfig: vm.Config{Tracer: &tracing.Hooks{}}}
+	setDefaults(cfg)
+	cfg.State, _ = state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+
+	var (
+		destination = common.BytesToAddress([]byte("contract"))
+		vmenv       = NewEnv(cfg)
+		sender      = vm.AccountRef(cfg.Origin)
+	)
+	cfg.State.SetCode(destination, code)
+
+	b.Run(name, func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			vmenv.Call(sender, destination, nil, gas, uint256.MustFromBig(cfg.Value))
+		}
+	})
+}
@@ -26,7 +26,9 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/stateless"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/log"
@@ -35,6 +37,10 @@ import (
 	"github.com/ethereum/go-ethereum/trie"
 )
 
+// executionWitnessReexec is the maximum number of blocks reexecuted to regenerate
+// the pre-state of a block when building its execution witness.
+const executionWitnessReexec = 128
+
 // DebugAPI is the collection of Ethereum full node APIs for debugging the
 // protocol.
 type DebugAPI struct {
@@ -443,3 +449,53 @@ func (api *DebugAPI) GetTrieFlushInterval() (string, error) {
 	}
 	return api.eth.blockchain.GetTrieFlushInterval().String(), nil
 }
+
+// ExecutionWitness re-executes the given block on top of its parent state and
+// returns the witness required to verify it statelessly: the block itself, all
+// accessed trie nodes and contract codes, and the headers needed to serve the
+// BLOCKHASH lookups.
+func (api *DebugAPI) ExecutionWitness(ctx context.Context, blockNr rpc.BlockNumber) (*stateless.Witness, error) {
+	block, err := api.eth.APIBackend.BlockByNumber(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", blockNr)
+	}
+	if block.NumberU64() == 0 {
+		return nil, errors.New("no witness for genesis")
+	}
+	parent := api.eth.blockchain.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, fmt.Errorf("parent %#x not found", block.ParentHash())
+	}
+	statedb, release, err := api.eth.stateAtBlock(ctx, parent, executionWitnessReexec, nil, true, false)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// Reopen the state without the snapshot, so that all accessed accounts and
+	// storage slots are resolved through the tries and recorded in the witness.
+	statedb, err = state.New(parent.Root(), statedb.Database(), nil)
+	if err != nil {
+		return nil, err
+	}
+	witness, err := stateless.NewWitness(api.eth.blockchain, block)
+	if err != nil {
+		return nil, err
+	}
+	statedb.StartPrefetcher("debug", witness)
+	defer statedb.StopPrefetcher()
+
+	receipts, _, usedGas, err := api.eth.blockchain.Processor().Process(block, statedb, vm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to process block %d: %v", block.NumberU64(), err)
+	}
+	// Validating the state also hashes all the tries, pulling in the trie
+	// nodes touched by the state updates.
+	if err := api.eth.blockchain.Validator().ValidateState(block, statedb, receipts, usedGas, false); err != nil {
+		return nil, fmt.Errorf("failed to validate block %d: %v", block.NumberU64(), err)
+	}
+	return witness, nil
+}
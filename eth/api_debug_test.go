@@ -18,7 +18,10 @@ package eth
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"math/big"
 	"reflect"
 	"slices"
 	"strings"
@@ -26,11 +29,18 @@ import (
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/stateless"
 	"github.com/ethereum/go-ethereum/core/tracing"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/ethereum/go-ethereum/triedb"
 	"github.com/holiman/uint256"
 )
@@ -221,3 +231,87 @@ func TestStorageRangeAt(t *testing.T) {
 		}
 	}
 }
+
+// Tests that the witnesses returned by debug_executionWitness survive both the
+// JSON and RLP encodings, and are sufficient to execute the blocks without any
+// other state.
+func TestExecutionWitness(t *testing.T) {
+	t.Parallel()
+
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address  = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.HexToAddress("0xaaaa")
+		gspec    = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				address: {Balance: big.NewInt(params.Ether)},
+				// SSTORE(NUMBER, BLOCKHASH(NUMBER-1))
+				contract: {Code: []byte{0x60, 0x01, 0x43, 0x03, 0x40, 0x43, 0x55}},
+			},
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, blocks, _ := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), 8, func(i int, b *core.BlockGen) {
+		tx, _ := types.SignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   gspec.Config.ChainID,
+			Nonce:     uint64(i),
+			GasTipCap: common.Big0,
+			GasFeeCap: b.BaseFee(),
+			Gas:       100000,
+			To:        &contract,
+		})
+		b.AddTx(tx)
+	})
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), core.DefaultCacheConfigWithScheme(rawdb.HashScheme), gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	eth := &Ethereum{blockchain: chain}
+	eth.APIBackend = &EthAPIBackend{eth: eth}
+	api := NewDebugAPI(eth)
+
+	if _, err := api.ExecutionWitness(context.Background(), 0); err == nil {
+		t.Fatal("expected error for genesis witness")
+	}
+	for _, block := range blocks {
+		witness, err := api.ExecutionWitness(context.Background(), rpc.BlockNumber(block.NumberU64()))
+		if err != nil {
+			t.Fatalf("block %d: failed to create witness: %v", block.NumberU64(), err)
+		}
+		// Round-trip the witness through both encodings and execute it
+		blob, err := json.Marshal(witness)
+		if err != nil {
+			t.Fatalf("block %d: failed to JSON encode witness: %v", block.NumberU64(), err)
+		}
+		var fromJSON stateless.Witness
+		if err := json.Unmarshal(blob, &fromJSON); err != nil {
+			t.Fatalf("block %d: failed to JSON decode witness: %v", block.NumberU64(), err)
+		}
+		blob, err = rlp.EncodeToBytes(witness)
+		if err != nil {
+			t.Fatalf("block %d: failed to RLP encode witness: %v", block.NumberU64(), err)
+		}
+		var fromRLP stateless.Witness
+		if err := rlp.DecodeBytes(blob, &fromRLP); err != nil {
+			t.Fatalf("block %d: failed to RLP decode witness: %v", block.NumberU64(), err)
+		}
+		for name, w := range map[string]*stateless.Witness{"json": &fromJSON, "rlp": &fromRLP} {
+			receiptRoot, stateRoot, err := core.ExecuteStateless(gspec.Config, w)
+			if err != nil {
+				t.Fatalf("block %d (%s): stateless execution failed: %v", block.NumberU64(), name, err)
+			}
+			if stateRoot != block.Root() {
+				t.Errorf("block %d (%s): state root mismatch: have %x, want %x", block.NumberU64(), name, stateRoot, block.Root())
+			}
+			if receiptRoot != block.ReceiptHash() {
+				t.Errorf("block %d (%s): receipt root mismatch: have %x, want %x", block.NumberU64(), name, receiptRoot, block.ReceiptHash())
+			}
+		}
+	}
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/clique"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/tracing"
 	"github.com/ethereum/go-ethereum/core/txpool/blobpool"
 	"github.com/ethereum/go-ethereum/core/txpool/legacypool"
 	"github.com/ethereum/go-ethereum/eth/downloader"
@@ -65,6 +66,7 @@ var Defaults = Config{
 	BlobPool:           blobpool.DefaultConfig,
 	RPCGasCap:          50000000,
 	RPCEVMTimeout:      5 * time.Second,
+	RPCTraceSizeCap:    512 * 1024 * 1024,
 	GPO:                FullNodeGPO,
 	RPCTxFeeCap:        1, // 1 ether
 }
@@ -94,6 +96,7 @@ type Config struct {
 	TxLookupLimit      uint64 `toml:",omitempty"` // The maximum number of blocks from head whose tx indices are reserved.
 	TransactionHistory uint64 `toml:",omitempty"` // The maximum number of blocks from head whose tx indices are reserved.
 	StateHistory       uint64 `toml:",omitempty"` // The maximum number of blocks from head whose state histories are reserved.
+	TraceIndex         bool   `toml:",omitempty"` // Whether to index the call trace addresses of imported blocks for trace_filter.
 
 	// State scheme represents the scheme used to store ethereum states and trie
 	// nodes on top. It can be 'hash', 'path', or none which means use the scheme
@@ -148,6 +151,10 @@ type Config struct {
 	VMTrace           string
 	VMTraceJsonConfig string
 
+	// VMTracer is a tracer instance to use instead of a named VMTrace one,
+	// mostly useful for embedding the node, e.g. in a simulated backend.
+	VMTracer *tracing.Hooks `toml:"-"`
+
 	// Miscellaneous options
 	DocRoot string `toml:"-"`
 
@@ -157,10 +164,19 @@ type Config struct {
 	// RPCEVMTimeout is the global timeout for eth-call.
 	RPCEVMTimeout time.Duration
 
+	// RPCTraceSizeCap is the global cap on the total size of the opcode traces
+	// kept in memory while serving a single debug tracing request, in bytes.
+	// debug_traceChain applies it to every block separately.
+	RPCTraceSizeCap uint64
+
 	// RPCTxFeeCap is the global transaction fee(price * gaslimit) cap for
 	// send-transaction variants. The unit is ether.
 	RPCTxFeeCap float64
 
+	// RPCDatabaseWrites allows modifying the raw chain database through the
+	// debug_db* APIs. It is meant for diagnostics and repairs only.
+	RPCDatabaseWrites bool
+
 	// OverrideCancun (TODO: remove after the fork)
 	OverrideCancun *uint64 `toml:",omitempty"`
 
@@ -18,10 +18,13 @@ package tracers
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"math/big"
 	"os"
 	"runtime"
 	"sync"
@@ -61,6 +64,18 @@ const (
 	// will only be found every ~15K blocks or so.
 	defaultTracechainMemLimit = common.StorageSize(500 * 1024 * 1024)
 
+	// bundleBlockInterval is the number of seconds between the consecutive
+	// simulated blocks of a traceCallMany request.
+	bundleBlockInterval = uint64(12)
+
+	// maxTraceCallManyBundles is the maximum number of bundles that can be
+	// traced by a single traceCallMany request.
+	maxTraceCallManyBundles = 256
+
+	// maxTraceCallManyCalls is the maximum number of calls, summed across all
+	// bundles, that can be traced by a single traceCallMany request.
+	maxTraceCallManyCalls = 1024
+
 	// maximumPendingTraceStates is the maximum number of states allowed waiting
 	// for tracing. The creation of trace state will be paused if the unused
 	// trace states exceed this limit.
@@ -82,6 +97,7 @@ type Backend interface {
 	BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error)
 	GetTransaction(ctx context.Context, txHash common.Hash) (bool, *types.Transaction, common.Hash, uint64, uint64, error)
 	RPCGasCap() uint64
+	RPCTraceSizeCap() uint64
 	ChainConfig() *params.ChainConfig
 	Engine() consensus.Engine
 	ChainDb() ethdb.Database
@@ -264,9 +280,12 @@ func (api *API) traceChain(start, end *types.Block, config *TraceConfig, closed
 
 			// Fetch and execute the block trace taskCh
 			for task := range taskCh {
+				// The results are streamed block by block, so each block has
+				// a size budget of its own
 				var (
 					signer   = types.MakeSigner(api.backend.ChainConfig(), task.block.Number(), task.block.Time())
 					blockCtx = core.NewEVMBlockContext(task.block.Header(), api.chainContext(ctx), nil)
+					budget   = api.newSizeBudget()
 				)
 				// Trace all the transactions contained within
 				for i, tx := range task.block.Transactions() {
@@ -277,7 +296,7 @@ func (api *API) traceChain(start, end *types.Block, config *TraceConfig, closed
 						TxIndex:     i,
 						TxHash:      tx.Hash(),
 					}
-					res, err := api.traceTx(ctx, tx, msg, txctx, blockCtx, task.statedb, config)
+					res, err := api.traceTx(ctx, tx, msg, txctx, blockCtx, task.statedb, config, budget)
 					if err != nil {
 						task.results[i] = &txTraceResult{TxHash: tx.Hash(), Error: err.Error()}
 						log.Warn("Tracing failed", "hash", tx.Hash(), "block", task.block.NumberU64(), "err", err)
@@ -593,12 +612,16 @@ func (api *API) traceBlock(ctx context.Context, block *types.Block, config *Trac
 		return nil, err
 	}
 	defer release()
+
+	// The traces of all the transactions share a single size budget
+	budget := api.newSizeBudget()
+
 	// JS tracers have high overhead. In this case run a parallel
 	// process that generates states in one thread and traces txes
 	// in separate worker threads.
 	if config != nil && config.Tracer != nil && *config.Tracer != "" {
 		if isJS := DefaultDirectory.IsJS(*config.Tracer); isJS {
-			return api.traceBlockParallel(ctx, block, statedb, config)
+			return api.traceBlockParallel(ctx, block, statedb, config, budget)
 		}
 	}
 	// Native tracers have low overhead
@@ -622,7 +645,7 @@ func (api *API) traceBlock(ctx context.Context, block *types.Block, config *Trac
 			TxIndex:     i,
 			TxHash:      tx.Hash(),
 		}
-		res, err := api.traceTx(ctx, tx, msg, txctx, blockCtx, statedb, config)
+		res, err := api.traceTx(ctx, tx, msg, txctx, blockCtx, statedb, config, budget)
 		if err != nil {
 			return nil, err
 		}
@@ -634,7 +657,7 @@ func (api *API) traceBlock(ctx context.Context, block *types.Block, config *Trac
 // traceBlockParallel is for tracers that have a high overhead (read JS tracers). One thread
 // runs along and executes txes without tracing enabled to generate their prestate.
 // Worker threads take the tasks and the prestate and trace them.
-func (api *API) traceBlockParallel(ctx context.Context, block *types.Block, statedb *state.StateDB, config *TraceConfig) ([]*txTraceResult, error) {
+func (api *API) traceBlockParallel(ctx context.Context, block *types.Block, statedb *state.StateDB, config *TraceConfig, budget *logger.SizeBudget) ([]*txTraceResult, error) {
 	// Execute all the transaction contained within the block concurrently
 	var (
 		txs       = block.Transactions()
@@ -666,7 +689,7 @@ func (api *API) traceBlockParallel(ctx context.Context, block *types.Block, stat
 				// concurrent use.
 				// See: https://github.com/ethereum/go-ethereum/issues/29114
 				blockCtx := core.NewEVMBlockContext(block.Header(), api.chainContext(ctx), nil)
-				res, err := api.traceTx(ctx, txs[task.index], msg, txctx, blockCtx, task.statedb, config)
+				res, err := api.traceTx(ctx, txs[task.index], msg, txctx, blockCtx, task.statedb, config, budget)
 				if err != nil {
 					results[task.index] = &txTraceResult{TxHash: txs[task.index].Hash(), Error: err.Error()}
 					continue
@@ -847,17 +870,76 @@ func containsTx(block *types.Block, hash common.Hash) bool {
 // TraceTransaction returns the structured logs created during the execution of EVM
 // and returns them as a JSON object.
 func (api *API) TraceTransaction(ctx context.Context, hash common.Hash, config *TraceConfig) (interface{}, error) {
+	tx, msg, txctx, vmctx, statedb, release, err := api.transactionState(ctx, hash, config)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return api.traceTx(ctx, tx, msg, txctx, vmctx, statedb, config, api.newSizeBudget())
+}
+
+// TraceTransactionStream traces a transaction with the struct logger like
+// TraceTransaction, but delivers the logs as subscription notifications while
+// the transaction is executing, so that the memory usage is bounded regardless
+// of the size of the trace. The logs are sent in chunks, and the last
+// notification carries the result of the execution, or the tracing error.
+func (api *API) TraceTransactionStream(ctx context.Context, hash common.Hash, config *TraceConfig) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	if config == nil {
+		config = &TraceConfig{}
+	}
+	if config.Tracer != nil {
+		return nil, errors.New("only the struct logger supports streaming")
+	}
+	tx, msg, txctx, vmctx, statedb, release, err := api.transactionState(ctx, hash, config)
+	if err != nil {
+		return nil, err
+	}
+	sub := notifier.CreateSubscription()
+
+	go func() {
+		defer release()
+
+		var (
+			writer = &traceStreamWriter{notifier: notifier, sub: sub}
+			logger = logger.NewStreamingStructLogger(config.Config, writer)
+			tracer = &Tracer{
+				Hooks:     logger.Hooks(),
+				GetResult: logger.GetResult,
+				Stop:      logger.Stop,
+			}
+		)
+		res, err := api.runTracer(context.Background(), tracer, tx, msg, txctx, vmctx, statedb, config, nil)
+		if err == nil {
+			err = writer.flush()
+		}
+		if err != nil {
+			notifier.Notify(sub.ID, &TraceStreamChunk{Error: err.Error()})
+			return
+		}
+		notifier.Notify(sub.ID, &TraceStreamChunk{Result: res})
+	}()
+	return sub, nil
+}
+
+// transactionState retrieves a mined transaction along with the state and
+// context it was executed in. The returned state must be released once done.
+func (api *API) transactionState(ctx context.Context, hash common.Hash, config *TraceConfig) (*types.Transaction, *core.Message, *Context, vm.BlockContext, *state.StateDB, StateReleaseFunc, error) {
 	found, _, blockHash, blockNumber, index, err := api.backend.GetTransaction(ctx, hash)
 	if err != nil {
-		return nil, ethapi.NewTxIndexingError()
+		return nil, nil, nil, vm.BlockContext{}, nil, nil, ethapi.NewTxIndexingError()
 	}
 	// Only mined txes are supported
 	if !found {
-		return nil, errTxNotFound
+		return nil, nil, nil, vm.BlockContext{}, nil, nil, errTxNotFound
 	}
 	// It shouldn't happen in practice.
 	if blockNumber == 0 {
-		return nil, errors.New("genesis is not traceable")
+		return nil, nil, nil, vm.BlockContext{}, nil, nil, errors.New("genesis is not traceable")
 	}
 	reexec := defaultTraceReexec
 	if config != nil && config.Reexec != nil {
@@ -865,25 +947,75 @@ func (api *API) TraceTransaction(ctx context.Context, hash common.Hash, config *
 	}
 	block, err := api.blockByNumberAndHash(ctx, rpc.BlockNumber(blockNumber), blockHash)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, vm.BlockContext{}, nil, nil, err
 	}
 	tx, vmctx, statedb, release, err := api.backend.StateAtTransaction(ctx, block, int(index), reexec)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, vm.BlockContext{}, nil, nil, err
 	}
-	defer release()
 	msg, err := core.TransactionToMessage(tx, types.MakeSigner(api.backend.ChainConfig(), block.Number(), block.Time()), block.BaseFee())
 	if err != nil {
-		return nil, err
+		release()
+		return nil, nil, nil, vm.BlockContext{}, nil, nil, err
 	}
-
 	txctx := &Context{
 		BlockHash:   blockHash,
 		BlockNumber: block.Number(),
 		TxIndex:     int(index),
 		TxHash:      hash,
 	}
-	return api.traceTx(ctx, tx, msg, txctx, vmctx, statedb, config)
+	return tx, msg, txctx, vmctx, statedb, release, nil
+}
+
+// traceStreamChunkSize is the number of struct logs delivered per notification
+// by the streaming trace subscriptions.
+const traceStreamChunkSize = 1024
+
+// errTraceStreamClosed is returned when the subscription of a streaming trace
+// is closed before the end of the execution.
+var errTraceStreamClosed = errors.New("trace subscription closed")
+
+// TraceStreamChunk is a notification of a streaming trace subscription.
+type TraceStreamChunk struct {
+	StructLogs []json.RawMessage `json:"structLogs,omitempty"`
+	Result     json.RawMessage   `json:"result,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+// traceStreamWriter batches the entries written by a streaming struct logger
+// into subscription notifications. As notifications are written synchronously
+// to the connection, a slow client throttles the tracing instead of making
+// the pending logs pile up in memory.
+type traceStreamWriter struct {
+	notifier *rpc.Notifier
+	sub      *rpc.Subscription
+	logs     []json.RawMessage
+}
+
+// Write implements io.Writer, receiving a single JSON encoded log per call.
+func (w *traceStreamWriter) Write(entry []byte) (int, error) {
+	select {
+	case <-w.sub.Err():
+		return 0, errTraceStreamClosed
+	default:
+	}
+	w.logs = append(w.logs, bytes.TrimSuffix(common.CopyBytes(entry), []byte{'\n'}))
+	if len(w.logs) >= traceStreamChunkSize {
+		if err := w.flush(); err != nil {
+			return 0, err
+		}
+	}
+	return len(entry), nil
+}
+
+// flush sends the pending logs to the subscriber.
+func (w *traceStreamWriter) flush() error {
+	if len(w.logs) == 0 {
+		return nil
+	}
+	logs := w.logs
+	w.logs = nil
+	return w.notifier.Notify(w.sub.ID, &TraceStreamChunk{StructLogs: logs})
 }
 
 // TraceCall lets you trace a given eth_call. It collects the structured logs
@@ -894,6 +1026,143 @@ func (api *API) TraceTransaction(ctx context.Context, hash common.Hash, config *
 // the trace will be conducted on the state after executing the specified transaction
 // within the specified block.
 func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, config *TraceCallConfig) (interface{}, error) {
+	block, statedb, release, err := api.callState(ctx, blockNrOrHash, config)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	vmctx := core.NewEVMBlockContext(block.Header(), api.chainContext(ctx), nil)
+	// Apply the customization rules if required.
+	if config != nil {
+		if err := config.StateOverrides.Apply(statedb); err != nil {
+			return nil, err
+		}
+		config.BlockOverrides.Apply(&vmctx)
+	}
+	// Execute the trace
+	if err := args.CallDefaults(api.backend.RPCGasCap(), vmctx.BaseFee, api.backend.ChainConfig().ChainID); err != nil {
+		return nil, err
+	}
+	var (
+		msg         = args.ToMessage(vmctx.BaseFee)
+		tx          = args.ToTransaction()
+		traceConfig *TraceConfig
+	)
+	if config != nil {
+		traceConfig = &config.TraceConfig
+	}
+	return api.traceTx(ctx, tx, msg, new(Context), vmctx, statedb, traceConfig, api.newSizeBudget())
+}
+
+// BundleCall is a single call within a traced bundle. The optional overrides
+// are applied right before the call is executed and persist for the remaining
+// calls of the bundle, whereas the block overrides only affect this call.
+type BundleCall struct {
+	ethapi.TransactionArgs
+	StateOverrides *ethapi.StateOverride  `json:"stateOverrides"`
+	BlockOverrides *ethapi.BlockOverrides `json:"blockOverrides"`
+}
+
+// Bundle is an ordered list of calls executed within the same simulated block.
+type Bundle struct {
+	Calls          []BundleCall           `json:"calls"`
+	BlockOverrides *ethapi.BlockOverrides `json:"blockOverrides"`
+}
+
+// TraceCallMany traces a list of bundles on top of the provided block. Every
+// bundle is executed in its own simulated block following the previous one, and
+// every call sees the state changes made by all calls before it. The state and
+// block overrides of the config are applied once before the first bundle. The
+// result holds the traces of the calls, grouped by bundle.
+//
+// The global RPC gas cap applies to all the calls of the request together.
+func (api *API) TraceCallMany(ctx context.Context, bundles []Bundle, blockNrOrHash rpc.BlockNumberOrHash, config *TraceCallConfig) ([][]interface{}, error) {
+	if len(bundles) == 0 {
+		return nil, errors.New("empty bundle list")
+	}
+	if len(bundles) > maxTraceCallManyBundles {
+		return nil, fmt.Errorf("too many bundles: %d > %d", len(bundles), maxTraceCallManyBundles)
+	}
+	var calls int
+	for _, bundle := range bundles {
+		calls += len(bundle.Calls)
+	}
+	if calls > maxTraceCallManyCalls {
+		return nil, fmt.Errorf("too many calls: %d > %d", calls, maxTraceCallManyCalls)
+	}
+	block, statedb, release, err := api.callState(ctx, blockNrOrHash, config)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	base := core.NewEVMBlockContext(block.Header(), api.chainContext(ctx), nil)
+	traceConfig := new(TraceConfig)
+	if config != nil {
+		if err := config.StateOverrides.Apply(statedb); err != nil {
+			return nil, err
+		}
+		config.BlockOverrides.Apply(&base)
+		traceConfig = &config.TraceConfig
+	}
+	gasCap := api.backend.RPCGasCap()
+	if gasCap == 0 {
+		gasCap = math.MaxUint64 / 2
+	}
+	var (
+		results = make([][]interface{}, len(bundles))
+		budget  = api.newSizeBudget()
+		gp      = new(core.GasPool).AddGas(gasCap)
+	)
+	for i, bundle := range bundles {
+		// Subsequent bundles are executed in the blocks following the base one
+		blockctx := base
+		blockctx.BlockNumber = new(big.Int).Add(base.BlockNumber, big.NewInt(int64(i)))
+		blockctx.Time = base.Time + uint64(i)*bundleBlockInterval
+		bundle.BlockOverrides.Apply(&blockctx)
+
+		results[i] = make([]interface{}, len(bundle.Calls))
+		for j, call := range bundle.Calls {
+			if err := call.StateOverrides.Apply(statedb); err != nil {
+				return nil, fmt.Errorf("bundle %d, call %d: %w", i, j, err)
+			}
+			vmctx := blockctx
+			call.BlockOverrides.Apply(&vmctx)
+
+			// Cap the gas of the call to what's left of the request allowance
+			if gp.Gas() == 0 {
+				return nil, fmt.Errorf("bundle %d, call %d: gas cap of %d exhausted", i, j, gasCap)
+			}
+			if err := call.CallDefaults(gp.Gas(), vmctx.BaseFee, api.backend.ChainConfig().ChainID); err != nil {
+				return nil, fmt.Errorf("bundle %d, call %d: %w", i, j, err)
+			}
+			var (
+				msg   = call.ToMessage(vmctx.BaseFee)
+				tx    = call.ToTransaction()
+				txctx = &Context{
+					BlockNumber: vmctx.BlockNumber,
+					TxIndex:     j,
+					TxHash:      tx.Hash(),
+				}
+			)
+			tracer, err := api.newTracer(txctx, traceConfig, budget)
+			if err != nil {
+				return nil, err
+			}
+			res, err := api.runTracer(ctx, tracer, tx, msg, txctx, vmctx, statedb, traceConfig, gp)
+			if err != nil {
+				return nil, fmt.Errorf("bundle %d, call %d: %w", i, j, err)
+			}
+			results[i][j] = res
+		}
+	}
+	return results, nil
+}
+
+// callState retrieves the block referenced by a call tracing request and the
+// state to execute the call(s) on.
+func (api *API) callState(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, config *TraceCallConfig) (*types.Block, *state.StateDB, StateReleaseFunc, error) {
 	// Try to retrieve the specified block
 	var (
 		err     error
@@ -910,14 +1179,14 @@ func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, bloc
 			// more flexibility and stability than trying to trace on 'pending', since
 			// the contents of 'pending' is unstable and probably not a true representation
 			// of what the next actual block is likely to contain.
-			return nil, errors.New("tracing on top of pending is not supported")
+			return nil, nil, nil, errors.New("tracing on top of pending is not supported")
 		}
 		block, err = api.blockByNumber(ctx, number)
 	} else {
-		return nil, errors.New("invalid arguments; neither block nor hash specified")
+		return nil, nil, nil, errors.New("invalid arguments; neither block nor hash specified")
 	}
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 	// try to recompute the state
 	reexec := defaultTraceReexec
@@ -931,66 +1200,53 @@ func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, bloc
 		statedb, release, err = api.backend.StateAtBlock(ctx, block, reexec, nil, true, false)
 	}
 	if err != nil {
-		return nil, err
-	}
-	defer release()
-
-	vmctx := core.NewEVMBlockContext(block.Header(), api.chainContext(ctx), nil)
-	// Apply the customization rules if required.
-	if config != nil {
-		if err := config.StateOverrides.Apply(statedb); err != nil {
-			return nil, err
-		}
-		config.BlockOverrides.Apply(&vmctx)
-	}
-	// Execute the trace
-	if err := args.CallDefaults(api.backend.RPCGasCap(), vmctx.BaseFee, api.backend.ChainConfig().ChainID); err != nil {
-		return nil, err
-	}
-	var (
-		msg         = args.ToMessage(vmctx.BaseFee)
-		tx          = args.ToTransaction()
-		traceConfig *TraceConfig
-	)
-	if config != nil {
-		traceConfig = &config.TraceConfig
+		return nil, nil, nil, err
 	}
-	return api.traceTx(ctx, tx, msg, new(Context), vmctx, statedb, traceConfig)
+	return block, statedb, release, nil
 }
 
 // traceTx configures a new tracer according to the provided configuration, and
 // executes the given message in the provided environment. The return value will
-// be tracer dependent.
-func (api *API) traceTx(ctx context.Context, tx *types.Transaction, message *core.Message, txctx *Context, vmctx vm.BlockContext, statedb *state.StateDB, config *TraceConfig) (interface{}, error) {
-	var (
-		tracer  *Tracer
-		err     error
-		timeout = defaultTraceTimeout
-		usedGas uint64
-	)
+// be tracer dependent. Struct logs are charged to the given size budget, which is
+// shared by all the transactions traced within the same request.
+func (api *API) traceTx(ctx context.Context, tx *types.Transaction, message *core.Message, txctx *Context, vmctx vm.BlockContext, statedb *state.StateDB, config *TraceConfig, budget *logger.SizeBudget) (interface{}, error) {
 	if config == nil {
 		config = &TraceConfig{}
 	}
-	// Default tracer is the struct logger
-	if config.Tracer == nil {
-		logger := logger.NewStructLogger(config.Config)
-		tracer = &Tracer{
-			Hooks:     logger.Hooks(),
-			GetResult: logger.GetResult,
-			Stop:      logger.Stop,
-		}
-	} else {
-		tracer, err = DefaultDirectory.New(*config.Tracer, txctx, config.TracerConfig)
-		if err != nil {
-			return nil, err
-		}
+	tracer, err := api.newTracer(txctx, config, budget)
+	if err != nil {
+		return nil, err
 	}
-	// The actual TxContext will be created as part of ApplyTransactionWithEVM.
-	vmenv := vm.NewEVM(vmctx, vm.TxContext{GasPrice: message.GasPrice, BlobFeeCap: message.BlobGasFeeCap}, statedb, api.backend.ChainConfig(), vm.Config{Tracer: tracer.Hooks, NoBaseFee: true})
-	statedb.SetLogger(tracer.Hooks)
+	return api.runTracer(ctx, tracer, tx, message, txctx, vmctx, statedb, config, nil)
+}
 
-	// Define a meaningful timeout of a single transaction trace
+// newSizeBudget creates the size budget of the struct logs kept while serving a
+// single tracing request.
+func (api *API) newSizeBudget() *logger.SizeBudget {
+	return logger.NewSizeBudget(api.backend.RPCTraceSizeCap())
+}
+
+// newTracer creates the tracer requested by the config, defaulting to the
+// struct logger charging its logs to the given size budget.
+func (api *API) newTracer(txctx *Context, config *TraceConfig, budget *logger.SizeBudget) (*Tracer, error) {
+	if config.Tracer != nil {
+		return DefaultDirectory.New(*config.Tracer, txctx, config.TracerConfig)
+	}
+	logger := logger.NewStructLogger(config.Config)
+	logger.SetSizeBudget(budget)
+	return &Tracer{
+		Hooks:     logger.Hooks(),
+		GetResult: logger.GetResult,
+		Stop:      logger.Stop,
+	}, nil
+}
+
+// traceTimeout interrupts the tracer and the EVM once the tracing timeout of
+// the config elapses. The returned function must be called once done.
+func traceTimeout(ctx context.Context, tracer *Tracer, vmenv *vm.EVM, config *TraceConfig) (context.CancelFunc, error) {
+	timeout := defaultTraceTimeout
 	if config.Timeout != nil {
+		var err error
 		if timeout, err = time.ParseDuration(*config.Timeout); err != nil {
 			return nil, err
 		}
@@ -1004,11 +1260,32 @@ func (api *API) traceTx(ctx context.Context, tx *types.Transaction, message *cor
 			vmenv.Cancel()
 		}
 	}()
+	return cancel, nil
+}
+
+// runTracer executes the transaction with the given tracer and returns the
+// result of the tracer. The gas of the transaction is taken from the given pool,
+// or from a dedicated one if nil.
+func (api *API) runTracer(ctx context.Context, tracer *Tracer, tx *types.Transaction, message *core.Message, txctx *Context, vmctx vm.BlockContext, statedb *state.StateDB, config *TraceConfig, gp *core.GasPool) (json.RawMessage, error) {
+	var usedGas uint64
+	if gp == nil {
+		gp = new(core.GasPool).AddGas(message.GasLimit)
+	}
+
+	// The actual TxContext will be created as part of ApplyTransactionWithEVM.
+	vmenv := vm.NewEVM(vmctx, vm.TxContext{GasPrice: message.GasPrice, BlobFeeCap: message.BlobGasFeeCap}, statedb, api.backend.ChainConfig(), vm.Config{Tracer: tracer.Hooks, NoBaseFee: true})
+	statedb.SetLogger(tracer.Hooks)
+
+	// Define a meaningful timeout of a single transaction trace
+	cancel, err := traceTimeout(ctx, tracer, vmenv, config)
+	if err != nil {
+		return nil, err
+	}
 	defer cancel()
 
 	// Call Prepare to clear out the statedb access list
 	statedb.SetTxContext(txctx.TxHash, txctx.TxIndex)
-	_, err = core.ApplyTransactionWithEVM(message, api.backend.ChainConfig(), new(core.GasPool).AddGas(message.GasLimit), statedb, vmctx.BlockNumber, txctx.BlockHash, tx, &usedGas, vmenv)
+	_, err = core.ApplyTransactionWithEVM(message, api.backend.ChainConfig(), gp, statedb, vmctx.BlockNumber, txctx.BlockHash, tx, &usedGas, vmenv)
 	if err != nil {
 		return nil, fmt.Errorf("tracing failed: %w", err)
 	}
@@ -25,6 +25,7 @@ import (
 	"math/big"
 	"reflect"
 	"slices"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -58,6 +59,8 @@ type testBackend struct {
 	chaindb     ethdb.Database
 	chain       *core.BlockChain
 
+	traceSizeCap uint64 // Size cap of the struct logger traces, zero means unlimited
+
 	refHook func() // Hook is invoked when the requested state is referenced
 	relHook func() // Hook is invoked when the requested state is released
 }
@@ -123,6 +126,10 @@ func (b *testBackend) RPCGasCap() uint64 {
 	return 25000000
 }
 
+func (b *testBackend) RPCTraceSizeCap() uint64 {
+	return b.traceSizeCap
+}
+
 func (b *testBackend) ChainConfig() *params.ChainConfig {
 	return b.chainConfig
 }
@@ -404,6 +411,101 @@ func TestTraceCall(t *testing.T) {
 	}
 }
 
+func TestTraceCallMany(t *testing.T) {
+	t.Parallel()
+
+	// The contract increments the counter in slot 0 and returns the new
+	// counter value together with the current block number.
+	var (
+		accounts = newAccounts(1)
+		contract = common.HexToAddress("0xc0ffee")
+		invalid  = common.HexToAddress("0xbad")
+		code     = common.Hex2Bytes("600054600101806000556000524360205260406000f3")
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+				contract:         {Code: code},
+				invalid:          {Code: []byte{byte(vm.INVALID)}},
+			},
+		}
+		genBlocks = 2
+	)
+	backend := newTestBackend(t, genBlocks, genesis, func(i int, b *core.BlockGen) {})
+	defer backend.teardown()
+	api := NewAPI(backend)
+
+	call := func(overrides *ethapi.StateOverride, blockOverrides *ethapi.BlockOverrides) BundleCall {
+		return BundleCall{
+			TransactionArgs: ethapi.TransactionArgs{From: &accounts[0].addr, To: &contract},
+			StateOverrides:  overrides,
+			BlockOverrides:  blockOverrides,
+		}
+	}
+	bundles := []Bundle{
+		{Calls: []BundleCall{
+			call(nil, nil),
+			call(&ethapi.StateOverride{
+				contract: ethapi.OverrideAccount{StateDiff: newStates([]common.Hash{{}}, []common.Hash{common.BigToHash(big.NewInt(10))})},
+			}, nil),
+		}},
+		{Calls: []BundleCall{
+			call(nil, nil),
+			call(nil, &ethapi.BlockOverrides{Number: (*hexutil.Big)(big.NewInt(100))}),
+		}},
+	}
+	results, err := api.TraceCallMany(context.Background(), bundles, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), nil)
+	if err != nil {
+		t.Fatalf("failed to trace call bundles: %v", err)
+	}
+	want := [][][2]uint64{
+		{{1, uint64(genBlocks)}, {11, uint64(genBlocks)}},
+		{{12, uint64(genBlocks + 1)}, {13, 100}},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("bundle count mismatch: have %d, want %d", len(results), len(want))
+	}
+	for i := range want {
+		if len(results[i]) != len(want[i]) {
+			t.Fatalf("bundle %d: call count mismatch: have %d, want %d", i, len(results[i]), len(want[i]))
+		}
+		for j, exp := range want[i] {
+			var res logger.ExecutionResult
+			if err := json.Unmarshal(results[i][j].(json.RawMessage), &res); err != nil {
+				t.Fatalf("bundle %d, call %d: failed to unmarshal result: %v", i, j, err)
+			}
+			ret := common.FromHex(res.ReturnValue)
+			if len(ret) != 64 {
+				t.Fatalf("bundle %d, call %d: unexpected return value %x", i, j, ret)
+			}
+			have := [2]uint64{new(big.Int).SetBytes(ret[:32]).Uint64(), new(big.Int).SetBytes(ret[32:]).Uint64()}
+			if have != exp {
+				t.Errorf("bundle %d, call %d: result mismatch: have %v, want %v", i, j, have, exp)
+			}
+		}
+	}
+	if _, err := api.TraceCallMany(context.Background(), nil, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), nil); err == nil {
+		t.Fatal("expected error for empty bundle list")
+	}
+	// Check that oversized requests are rejected
+	if _, err := api.TraceCallMany(context.Background(), make([]Bundle, maxTraceCallManyBundles+1), rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), nil); err == nil {
+		t.Fatal("expected error for too many bundles")
+	}
+	oversized := []Bundle{{Calls: make([]BundleCall, maxTraceCallManyCalls/2)}, {Calls: make([]BundleCall, maxTraceCallManyCalls/2+1)}}
+	if _, err := api.TraceCallMany(context.Background(), oversized, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), nil); err == nil {
+		t.Fatal("expected error for too many calls")
+	}
+	// Check that the gas cap applies to the entire request: the first call
+	// burns all the gas it is given, leaving nothing for the second one
+	burner := BundleCall{TransactionArgs: ethapi.TransactionArgs{From: &accounts[0].addr, To: &invalid}}
+	if _, err := api.TraceCallMany(context.Background(), []Bundle{{Calls: []BundleCall{burner}}}, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), nil); err != nil {
+		t.Fatalf("failed to trace single gas burning call: %v", err)
+	}
+	if _, err := api.TraceCallMany(context.Background(), []Bundle{{Calls: []BundleCall{burner}}, {Calls: []BundleCall{call(nil, nil)}}}, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), nil); err == nil {
+		t.Fatal("expected error for exhausted gas cap")
+	}
+}
+
 func TestTraceTransaction(t *testing.T) {
 	t.Parallel()
 
@@ -459,6 +561,310 @@ func TestTraceTransaction(t *testing.T) {
 	}
 }
 
+// Tests that the trace size cap applies to all the transactions traced by a
+// single request together, not to each of them on its own.
+func TestTraceBlockSizeCap(t *testing.T) {
+	t.Parallel()
+
+	// Initialize test accounts and a contract executing a fixed number of opcodes
+	var code []byte
+	for i := 0; i < 10; i++ {
+		code = append(code, byte(vm.PUSH1), 0x00, byte(vm.POP))
+	}
+	var (
+		accounts = newAccounts(1)
+		contract = common.HexToAddress("0x100")
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+				contract:         {Code: append(code, byte(vm.STOP))},
+			},
+		}
+		hashes []common.Hash
+		signer = types.HomesteadSigner{}
+	)
+	backend := newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {
+		for nonce := uint64(0); nonce < 2; nonce++ {
+			tx, _ := types.SignTx(types.NewTx(&types.LegacyTx{
+				Nonce:    nonce,
+				To:       &contract,
+				Gas:      100_000,
+				GasPrice: b.BaseFee(),
+			}), signer, accounts[0].key)
+			b.AddTx(tx)
+			hashes = append(hashes, tx.Hash())
+		}
+	})
+	defer backend.chain.Stop()
+	api := NewAPI(backend)
+
+	// Pick a cap fitting the trace of a single transaction, but not of both
+	backend.traceSizeCap = 6000
+	for _, hash := range hashes {
+		if _, err := api.TraceTransaction(context.Background(), hash, nil); err != nil {
+			t.Fatalf("failed to trace transaction %x: %v", hash, err)
+		}
+	}
+	if _, err := api.TraceBlockByNumber(context.Background(), 1, nil); !errors.Is(err, logger.ErrTraceLimitExceeded) {
+		t.Fatalf("size cap error mismatch: have %v, want %v", err, logger.ErrTraceLimitExceeded)
+	}
+	backend.traceSizeCap = 0
+	if _, err := api.TraceBlockByNumber(context.Background(), 1, nil); err != nil {
+		t.Fatalf("failed to trace block: %v", err)
+	}
+}
+
+func TestTraceTransactionStream(t *testing.T) {
+	t.Parallel()
+
+	// Initialize test accounts and a contract looping until running out of gas
+	var (
+		accounts = newAccounts(1)
+		looper   = common.HexToAddress("0x100")
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+				looper:           {Code: []byte{byte(vm.JUMPDEST), byte(vm.PUSH1), 0x00, byte(vm.JUMP)}},
+			},
+		}
+		target common.Hash
+		signer = types.HomesteadSigner{}
+	)
+	backend := newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTx(&types.LegacyTx{
+			Nonce:    uint64(i),
+			To:       &looper,
+			Gas:      100_000,
+			GasPrice: b.BaseFee(),
+		}), signer, accounts[0].key)
+		b.AddTx(tx)
+		target = tx.Hash()
+	})
+	defer backend.chain.Stop()
+	api := NewAPI(backend)
+
+	// Trace the transaction in one go, which must fail with a low size cap
+	result, err := api.TraceTransaction(context.Background(), target, nil)
+	if err != nil {
+		t.Fatalf("failed to trace transaction: %v", err)
+	}
+	var want logger.ExecutionResult
+	if err := json.Unmarshal(result.(json.RawMessage), &want); err != nil {
+		t.Fatalf("failed to unmarshal result: %v", err)
+	}
+	backend.traceSizeCap = 100_000
+	if _, err := api.TraceTransaction(context.Background(), target, nil); !errors.Is(err, logger.ErrTraceLimitExceeded) {
+		t.Fatalf("size cap error mismatch: have %v, want %v", err, logger.ErrTraceLimitExceeded)
+	}
+	// Stream the trace, which is not subject to the size cap
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("debug", api); err != nil {
+		t.Fatalf("failed to register api: %v", err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	chunks := make(chan *TraceStreamChunk)
+	sub, err := client.Subscribe(context.Background(), "debug", chunks, "traceTransactionStream", target, nil)
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	var (
+		logs     []logger.StructLogRes
+		streamed logger.ExecutionResult
+	)
+	for done := false; !done; {
+		select {
+		case chunk := <-chunks:
+			if chunk.Error != "" {
+				t.Fatalf("streaming failed: %v", chunk.Error)
+			}
+			if len(chunk.StructLogs) > traceStreamChunkSize {
+				t.Fatalf("chunk too large: %d logs", len(chunk.StructLogs))
+			}
+			for _, entry := range chunk.StructLogs {
+				var log logger.StructLogRes
+				if err := json.Unmarshal(entry, &log); err != nil {
+					t.Fatalf("failed to unmarshal log: %v", err)
+				}
+				logs = append(logs, log)
+			}
+			if chunk.Result != nil {
+				if err := json.Unmarshal(chunk.Result, &streamed); err != nil {
+					t.Fatalf("failed to unmarshal result: %v", err)
+				}
+				done = true
+			}
+		case err := <-sub.Err():
+			t.Fatalf("subscription failed: %v", err)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("streaming timed out")
+		}
+	}
+	if len(logs) != len(want.StructLogs) {
+		t.Fatalf("log count mismatch: have %d, want %d", len(logs), len(want.StructLogs))
+	}
+	if !reflect.DeepEqual(logs, want.StructLogs) {
+		t.Errorf("streamed logs mismatch")
+	}
+	if streamed.Gas != want.Gas || streamed.Failed != want.Failed {
+		t.Errorf("result mismatch: have %+v, want gas %d failed %v", streamed, want.Gas, want.Failed)
+	}
+}
+
+func TestReplayBlock(t *testing.T) {
+	t.Parallel()
+
+	// Initialize test accounts, account[2] receiving all the transfers
+	accounts := newAccounts(3)
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: types.GenesisAlloc{
+			accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+			accounts[1].addr: {Balance: big.NewInt(params.Ether)},
+		},
+	}
+	var (
+		signer = types.HomesteadSigner{}
+		hashes []common.Hash
+	)
+	transfer := func(from int, nonce uint64, value int64, baseFee *big.Int) *types.Transaction {
+		tx, _ := types.SignTx(types.NewTx(&types.LegacyTx{
+			Nonce:    nonce,
+			To:       &accounts[2].addr,
+			Value:    big.NewInt(value),
+			Gas:      params.TxGas,
+			GasPrice: baseFee,
+		}), signer, accounts[from].key)
+		return tx
+	}
+	backend := newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {
+		for _, tx := range []*types.Transaction{
+			transfer(0, 0, 1000, b.BaseFee()),
+			transfer(0, 1, 2000, b.BaseFee()),
+			transfer(1, 0, 3000, b.BaseFee()),
+		} {
+			b.AddTx(tx)
+			hashes = append(hashes, tx.Hash())
+		}
+	})
+	defer backend.chain.Stop()
+	api := NewAPI(backend)
+
+	var (
+		block  = rpc.BlockNumberOrHashWithNumber(1)
+		fee    = new(big.Int).Mul(backend.chain.GetBlockByNumber(1).BaseFee(), big.NewInt(int64(params.TxGas)))
+		funded = new(big.Int).SetUint64(params.Ether)
+	)
+	// Remove the transfer of account[1]
+	res, err := api.ReplayBlock(context.Background(), block, &ReplayBlockConfig{
+		Edits: []BlockEdit{{Op: BlockEditRemove, Hash: &hashes[2]}},
+	})
+	if err != nil {
+		t.Fatalf("failed to replay block: %v", err)
+	}
+	if len(res.Transactions) != 2 || uint64(res.GasUsed) != 2*params.TxGas {
+		t.Fatalf("unexpected replay: %d txs, %d gas", len(res.Transactions), res.GasUsed)
+	}
+	for i, tx := range res.Transactions {
+		if tx.Receipt == nil || tx.Receipt.Status != types.ReceiptStatusSuccessful || tx.Receipt.TransactionIndex != uint(i) {
+			t.Errorf("tx %d: unexpected result %+v", i, tx)
+		}
+	}
+	if len(res.StateDiff) != 2 {
+		t.Errorf("state diff mismatch: have %d accounts, want 2", len(res.StateDiff))
+	}
+	diff := res.StateDiff[accounts[1].addr]
+	if diff == nil || diff.Balance == nil || diff.Nonce == nil {
+		t.Fatalf("missing diff of account[1]: %+v", diff)
+	}
+	if want := new(big.Int).Sub(funded, new(big.Int).Add(fee, big.NewInt(3000))); diff.Balance.Canonical.ToInt().Cmp(want) != 0 || diff.Balance.Replay.ToInt().Cmp(funded) != 0 {
+		t.Errorf("balance diff mismatch: have %v -> %v", diff.Balance.Canonical, diff.Balance.Replay)
+	}
+	if diff.Nonce.Canonical != 1 || diff.Nonce.Replay != 0 {
+		t.Errorf("nonce diff mismatch: have %d -> %d", diff.Nonce.Canonical, diff.Nonce.Replay)
+	}
+	if diff := res.StateDiff[accounts[2].addr]; diff == nil || diff.Balance.Canonical.ToInt().Int64() != 6000 || diff.Balance.Replay.ToInt().Int64() != 3000 {
+		t.Errorf("unexpected diff of account[2]: %+v", diff)
+	}
+	// Reorder the transfers of account[0], invalidating the moved one
+	first := 0
+	res, err = api.ReplayBlock(context.Background(), block, &ReplayBlockConfig{
+		Edits: []BlockEdit{{Op: BlockEditMove, Hash: &hashes[1], Index: &first}},
+	})
+	if err != nil {
+		t.Fatalf("failed to replay block: %v", err)
+	}
+	if len(res.Transactions) != 3 || res.Transactions[0].TxHash != hashes[1] || !strings.Contains(res.Transactions[0].Error, "nonce too high") {
+		t.Fatalf("moved transaction not rejected: %+v", res.Transactions[0])
+	}
+	if diff := res.StateDiff[accounts[2].addr]; diff == nil || diff.Balance.Replay.ToInt().Int64() != 4000 {
+		t.Errorf("unexpected diff of account[2]: %+v", diff)
+	}
+	// Insert a new transfer at the end, tracing the block
+	raw, _ := transfer(1, 1, 1, backend.chain.GetBlockByNumber(1).BaseFee()).MarshalBinary()
+	res, err = api.ReplayBlock(context.Background(), block, &ReplayBlockConfig{
+		TraceConfig: TraceConfig{Config: &logger.Config{}},
+		Edits:       []BlockEdit{{Op: BlockEditInsert, Raw: raw}},
+	})
+	if err != nil {
+		t.Fatalf("failed to replay block: %v", err)
+	}
+	if len(res.Transactions) != 4 {
+		t.Fatalf("transaction count mismatch: have %d, want 4", len(res.Transactions))
+	}
+	for i, tx := range res.Transactions {
+		if tx.Receipt == nil || len(tx.Result) == 0 {
+			t.Errorf("tx %d: missing receipt or trace", i)
+		}
+	}
+	if diff := res.StateDiff[accounts[2].addr]; diff == nil || diff.Balance.Replay.ToInt().Int64() != 6001 {
+		t.Errorf("unexpected diff of account[2]: %+v", diff)
+	}
+	// Append a transaction with too little gas, followed by a valid one, to a
+	// block limited to the gas of all the valid transfers, which must all fit
+	lowgas, _ := types.SignTx(types.NewTx(&types.LegacyTx{
+		Nonce:    1,
+		To:       &accounts[2].addr,
+		Gas:      params.TxGas - 1,
+		GasPrice: backend.chain.GetBlockByNumber(1).BaseFee(),
+	}), signer, accounts[1].key)
+	rawLow, _ := lowgas.MarshalBinary()
+	limit := hexutil.Uint64(4 * params.TxGas)
+	res, err = api.ReplayBlock(context.Background(), block, &ReplayBlockConfig{
+		Edits:          []BlockEdit{{Op: BlockEditInsert, Raw: rawLow}, {Op: BlockEditInsert, Raw: raw}},
+		BlockOverrides: &ethapi.BlockOverrides{GasLimit: &limit},
+	})
+	if err != nil {
+		t.Fatalf("failed to replay block: %v", err)
+	}
+	if len(res.Transactions) != 5 || !strings.Contains(res.Transactions[3].Error, "intrinsic gas too low") {
+		t.Fatalf("low gas transaction not rejected: %+v", res.Transactions[3])
+	}
+	for i, tx := range res.Transactions {
+		if i != 3 && (tx.Receipt == nil || tx.Error != "") {
+			t.Errorf("tx %d: unexpected result %+v", i, tx)
+		}
+	}
+	// Check the rejection of invalid edits
+	for i, edits := range [][]BlockEdit{
+		{{Op: "swap"}},
+		{{Op: BlockEditRemove}},
+		{{Op: BlockEditRemove, Hash: &common.Hash{42}}},
+		{{Op: BlockEditInsert, Raw: []byte{0x01}}},
+		{{Op: BlockEditMove, Hash: &hashes[0], Index: new(int)}, {Op: BlockEditInsert, Raw: raw, Index: &[]int{4}[0]}},
+	} {
+		if _, err := api.ReplayBlock(context.Background(), block, &ReplayBlockConfig{Edits: edits}); err == nil {
+			t.Errorf("edits %d: invalid edits accepted", i)
+		}
+	}
+}
+
 func TestTraceBlock(t *testing.T) {
 	t.Parallel()
 
@@ -996,6 +1402,59 @@ func TestTraceChain(t *testing.T) {
 	}
 }
 
+// Tests that the trace size cap applies to each block of a chain trace on its
+// own, rather than to the entire streamed chain.
+func TestTraceChainSizeCap(t *testing.T) {
+	// Initialize test accounts and a contract executing a fixed number of opcodes
+	var code []byte
+	for i := 0; i < 10; i++ {
+		code = append(code, byte(vm.PUSH1), 0x00, byte(vm.POP))
+	}
+	var (
+		accounts = newAccounts(1)
+		contract = common.HexToAddress("0x100")
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+				contract:         {Code: append(code, byte(vm.STOP))},
+			},
+		}
+		genBlocks = 10
+		signer    = types.HomesteadSigner{}
+	)
+	backend := newTestBackend(t, genBlocks, genesis, func(i int, b *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTx(&types.LegacyTx{
+			Nonce:    uint64(i),
+			To:       &contract,
+			Gas:      100_000,
+			GasPrice: b.BaseFee(),
+		}), signer, accounts[0].key)
+		b.AddTx(tx)
+	})
+	defer backend.teardown()
+	api := NewAPI(backend)
+
+	// Pick a cap fitting the trace of a single block, but not of the chain
+	backend.traceSizeCap = 6000
+
+	from, _ := api.blockByNumber(context.Background(), 0)
+	to, _ := api.blockByNumber(context.Background(), rpc.BlockNumber(genBlocks))
+
+	var blocks int
+	for result := range api.traceChain(from, to, nil, nil) {
+		for _, trace := range result.Traces {
+			if trace.Error != "" {
+				t.Fatalf("block %d: tracing failed: %v", result.Block, trace.Error)
+			}
+		}
+		blocks++
+	}
+	if blocks != genBlocks {
+		t.Fatalf("traced block count mismatch: have %d, want %d", blocks, genBlocks)
+	}
+}
+
 // newTestMergedBackend creates a post-merge chain
 func newTestMergedBackend(t *testing.T, n int, gspec *core.Genesis, generator func(i int, b *core.BlockGen)) *testBackend {
 	backend := &testBackend{
@@ -0,0 +1,84 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package coverage
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// LineCoverage is the execution count of a source line.
+type LineCoverage struct {
+	Line  int
+	Count uint64
+}
+
+// FileCoverage is the line coverage of a source file.
+type FileCoverage struct {
+	Path  string
+	Lines []LineCoverage // Instrumented lines, sorted by line number
+}
+
+// Coverage returns the line coverage of the traced executions, for every
+// source file with instrumented lines.
+func (s *Session) Coverage() []*FileCoverage {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	files := make(map[int]*FileCoverage)
+	for key, count := range s.hits {
+		file := files[key.file]
+		if file == nil {
+			file = &FileCoverage{Path: s.sources[key.file].path}
+			files[key.file] = file
+		}
+		file.Lines = append(file.Lines, LineCoverage{Line: key.line, Count: count})
+	}
+	res := make([]*FileCoverage, 0, len(files))
+	for _, file := range files {
+		slices.SortFunc(file.Lines, func(a, b LineCoverage) int {
+			return cmp.Compare(a.Line, b.Line)
+		})
+		res = append(res, file)
+	}
+	slices.SortFunc(res, func(a, b *FileCoverage) int {
+		return cmp.Compare(a.Path, b.Path)
+	})
+	return res
+}
+
+// WriteLCOV writes the line coverage of the traced executions in the lcov
+// tracefile format, with the given test name.
+func (s *Session) WriteLCOV(w io.Writer, test string) error {
+	out := bufio.NewWriter(w)
+	for _, file := range s.Coverage() {
+		fmt.Fprintf(out, "TN:%s\nSF:%s\n", test, file.Path)
+
+		var hit int
+		for _, line := range file.Lines {
+			fmt.Fprintf(out, "DA:%d,%d\n", line.Line, line.Count)
+			if line.Count > 0 {
+				hit++
+			}
+		}
+		fmt.Fprintf(out, "LF:%d\nLH:%d\nend_of_record\n", len(file.Lines), hit)
+	}
+	return out.Flush()
+}
@@ -0,0 +1,134 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package coverage
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common/compiler"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// Location is a position within a source file.
+type Location struct {
+	File   string
+	Line   int // 1-based line number
+	Column int // 1-based column number
+}
+
+// String implements fmt.Stringer.
+func (l *Location) String() string {
+	return fmt.Sprintf("%s:%d:%d", l.File, l.Line, l.Column)
+}
+
+// source is a source file with its line offsets indexed.
+type source struct {
+	path    string
+	content string
+	lines   []int // Byte offsets of the line starts
+}
+
+func newSource(src *compiler.Source) *source {
+	s := &source{path: src.Path, content: src.Content, lines: []int{0}}
+	for i := 0; i < len(src.Content); i++ {
+		if src.Content[i] == '\n' {
+			s.lines = append(s.lines, i+1)
+		}
+	}
+	return s
+}
+
+// position converts a byte offset into a 1-based line and column.
+func (s *source) position(offset int) (int, int, bool) {
+	if s.content == "" || offset < 0 || offset > len(s.content) {
+		return 0, 0, false
+	}
+	line := sort.Search(len(s.lines), func(i int) bool { return s.lines[i] > offset })
+	return line, offset - s.lines[line-1] + 1, true
+}
+
+// text returns the content of the given line, without the line break.
+func (s *source) text(line int) string {
+	if line < 1 || line > len(s.lines) {
+		return ""
+	}
+	end := len(s.content)
+	if line < len(s.lines) {
+		end = s.lines[line] - 1
+	}
+	return s.content[s.lines[line-1]:end]
+}
+
+// program is the creation or runtime code of a contract, mapped to its sources.
+type program struct {
+	name    string
+	code    []byte
+	mask    []bool
+	entries []compiler.SourceMapEntry
+	index   []int // Instruction index of every code offset, -1 within push data
+}
+
+func newProgram(name string, bytecode *compiler.Bytecode) (*program, error) {
+	code, mask, err := bytecode.Code()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	entries, err := compiler.ParseSourceMap(bytecode.SourceMap)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	p := &program{name: name, code: code, mask: mask, entries: entries, index: make([]int, len(code))}
+	for pc, n := 0, 0; pc < len(code); n++ {
+		p.index[pc] = n
+		next := pc + 1
+		if op := vm.OpCode(code[pc]); op.IsPush() {
+			next += int(op - vm.PUSH0)
+		}
+		for i := pc + 1; i < next && i < len(code); i++ {
+			p.index[i] = -1
+		}
+		pc = next
+	}
+	return p, nil
+}
+
+// entry returns the source map entry of the instruction at the given offset.
+func (p *program) entry(pc uint64) *compiler.SourceMapEntry {
+	if pc >= uint64(len(p.index)) {
+		return nil
+	}
+	n := p.index[pc]
+	if n < 0 || n >= len(p.entries) || !p.entries[n].HasSource() {
+		return nil
+	}
+	return &p.entries[n]
+}
+
+// matches reports whether the code was produced by the program. The creation
+// code of a contract is followed by its constructor arguments.
+func (p *program) matches(code []byte, creation bool) bool {
+	if len(code) < len(p.code) || (!creation && len(code) != len(p.code)) {
+		return false
+	}
+	for i, b := range p.code {
+		if b != code[i] && !p.mask[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,283 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package coverage maps the execution of Solidity contracts back to their
+// sources, using the source maps of the compiler output. A Session traces any
+// number of transactions to produce line coverage reports and Solidity level
+// stack traces of reverts.
+package coverage
+
+import (
+	"bytes"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/compiler"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// lineKey identifies a line of a source file.
+type lineKey struct {
+	file int
+	line int
+}
+
+// codeKey identifies a code in the program cache.
+type codeKey struct {
+	hash     common.Hash
+	creation bool
+}
+
+// frame is an active call frame of the session.
+type frame struct {
+	prog  *program // Program of the executed code, nil if unknown
+	addr  common.Address
+	pc    uint64   // Program counter of the last executed instruction
+	calls []uint64 // Program counters of the internal function calls
+	last  lineKey  // Line of the last executed instruction
+}
+
+// Session collects the line coverage and the revert stack traces of the
+// contracts of a compilation, across all the transactions it traces. It is
+// safe to query a session while it is tracing.
+type Session struct {
+	sources  map[int]*source
+	creation []*program
+	runtime  []*program
+	abis     map[string]*abi.ABI // Contract ABIs by artifact name
+
+	lock   sync.Mutex
+	hits   map[lineKey]uint64          // Execution counts of the instrumented lines
+	cache  map[codeKey]*program        // Programs of the seen codes, nil if unknown
+	traces map[common.Hash]*StackTrace // Stack traces of reverted transactions
+	last   *StackTrace                 // Stack trace of the last reverted execution
+
+	env    *tracing.VMContext
+	tx     common.Hash
+	frames []*frame
+	revert *StackTrace // Stack trace of the pending revert
+	depth  int         // Depth of the frame which last propagated the pending revert
+	output []byte      // Output of the pending revert
+}
+
+// NewSession creates a session tracing the contracts of the given compilation.
+func NewSession(build *compiler.Build) (*Session, error) {
+	s := &Session{
+		sources: make(map[int]*source),
+		abis:    make(map[string]*abi.ABI),
+		hits:    make(map[lineKey]uint64),
+		cache:   make(map[codeKey]*program),
+		traces:  make(map[common.Hash]*StackTrace),
+	}
+	for id, src := range build.Sources {
+		s.sources[id] = newSource(src)
+	}
+	for _, artifact := range build.Contracts {
+		if len(artifact.Abi) > 0 {
+			parsed, err := abi.JSON(bytes.NewReader(artifact.Abi))
+			if err != nil {
+				return nil, err
+			}
+			s.abis[artifact.Name] = &parsed
+		}
+		for _, code := range []struct {
+			bytecode *compiler.Bytecode
+			programs *[]*program
+		}{{artifact.Bytecode, &s.creation}, {artifact.DeployedBytecode, &s.runtime}} {
+			prog, err := newProgram(artifact.Name, code.bytecode)
+			if err != nil {
+				return nil, err
+			}
+			// Interfaces and abstract contracts don't have any code
+			if len(prog.code) == 0 {
+				continue
+			}
+			*code.programs = append(*code.programs, prog)
+
+			// Register all the mapped lines, so that unexecuted ones are reported
+			for _, entry := range prog.entries {
+				if key, ok := s.line(&entry); ok {
+					s.hits[key] += 0
+				}
+			}
+		}
+	}
+	return s, nil
+}
+
+// line returns the source line of a source map entry.
+func (s *Session) line(entry *compiler.SourceMapEntry) (lineKey, bool) {
+	if !entry.HasSource() {
+		return lineKey{}, false
+	}
+	src := s.sources[entry.File]
+	if src == nil {
+		return lineKey{}, false
+	}
+	line, _, ok := src.position(entry.Start)
+	return lineKey{entry.File, line}, ok
+}
+
+// Hooks returns the tracing hooks of the session. They can be used both as a
+// live tracer of a chain and to trace individual calls.
+func (s *Session) Hooks() *tracing.Hooks {
+	return &tracing.Hooks{
+		OnTxStart: s.OnTxStart,
+		OnEnter:   s.OnEnter,
+		OnExit:    s.OnExit,
+		OnOpcode:  s.OnOpcode,
+	}
+}
+
+// StackTrace returns the stack trace of a reverted transaction, or nil if the
+// transaction has not been traced or did not revert.
+func (s *Session) StackTrace(tx common.Hash) *StackTrace {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.traces[tx]
+}
+
+// LastStackTrace returns the stack trace of the last reverted execution.
+func (s *Session) LastStackTrace() *StackTrace {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.last
+}
+
+func (s *Session) OnTxStart(env *tracing.VMContext, tx *types.Transaction, from common.Address) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.env, s.tx = env, tx.Hash()
+	s.frames, s.revert = s.frames[:0], nil
+}
+
+func (s *Session) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if depth == 0 {
+		s.frames, s.revert = s.frames[:0], nil
+	}
+	var prog *program
+	switch vm.OpCode(typ) {
+	case vm.CREATE, vm.CREATE2:
+		prog = s.program(input, true)
+	case vm.SELFDESTRUCT:
+		// Selfdestructs don't execute any code
+	default:
+		if s.env != nil {
+			prog = s.program(s.env.StateDB.GetCode(to), false)
+		}
+	}
+	s.frames = append(s.frames, &frame{prog: prog, addr: to})
+}
+
+// program returns the program which produced the code, nil if the code does
+// not belong to the compilation.
+func (s *Session) program(code []byte, creation bool) *program {
+	if len(code) == 0 {
+		return nil
+	}
+	key := codeKey{crypto.Keccak256Hash(code), creation}
+	if prog, ok := s.cache[key]; ok {
+		return prog
+	}
+	var (
+		found    *program
+		programs = s.runtime
+	)
+	if creation {
+		programs = s.creation
+	}
+	for _, prog := range programs {
+		// Prefer the longest match, as the creation code of a contract may be
+		// a prefix of the creation code of another one with arguments appended.
+		if prog.matches(code, creation) && (found == nil || len(prog.code) > len(found.code)) {
+			found = prog
+		}
+	}
+	s.cache[key] = found
+	return found
+}
+
+func (s *Session) OnOpcode(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.frames) == 0 {
+		return
+	}
+	f := s.frames[len(s.frames)-1]
+	f.pc = pc
+	if f.prog == nil {
+		return
+	}
+	entry := f.prog.entry(pc)
+	if entry == nil {
+		return
+	}
+	switch entry.Jump {
+	case compiler.JumpInto:
+		f.calls = append(f.calls, pc)
+	case compiler.JumpOutOf:
+		if len(f.calls) > 0 {
+			f.calls = f.calls[:len(f.calls)-1]
+		}
+	}
+	// Count every line once per consecutive run of its instructions
+	if key, ok := s.line(entry); ok && key != f.last {
+		s.hits[key]++
+		f.last = key
+	}
+}
+
+func (s *Session) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.frames) == 0 {
+		return
+	}
+	if reverted {
+		// Keep the stack trace of the innermost revert if it has been bubbled up
+		// unchanged by the frame, otherwise the frame failed on its own.
+		if s.revert == nil || s.depth != depth+1 || !bytes.Equal(s.output, output) {
+			s.revert = s.stackTrace(output, err)
+		}
+		s.depth, s.output = depth, common.CopyBytes(output)
+	} else {
+		s.revert = nil
+	}
+	s.frames = s.frames[:len(s.frames)-1]
+
+	if depth == 0 && s.revert != nil {
+		s.last = s.revert
+		if s.tx != (common.Hash{}) {
+			s.traces[s.tx] = s.revert
+		}
+	}
+	if depth == 0 {
+		s.tx = common.Hash{}
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package coverage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/compiler"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const testSource = `contract Reverter {
+    function fail() public {
+        require(false, "nope");
+    }
+    function unused() public {}
+}
+`
+
+// testBuild assembles the compilation of a contract which jumps into an
+// internal function reverting with the reason "nope".
+func testBuild(t *testing.T) *compiler.Build {
+	var (
+		call    = strings.Index(testSource, "function fail")
+		require = strings.Index(testSource, "require")
+		unused  = strings.Index(testSource, "function unused")
+	)
+	runtime := "6003" + "56" + "5b" + // PUSH1 3, JUMP, JUMPDEST
+		"6308c379a060e01b600052" + // Error(string) selector
+		"6020600452" + "6004602452" + // offset and length of the reason
+		"636e6f706560e01b604452" + // reason
+		"60646000fd" // REVERT
+	srcmap := fmt.Sprintf("%d:21:0:-;:::i;%d:22:0:-", call, require) + strings.Repeat(";", 19)
+
+	input := fmt.Sprintf(`{"sources":{"Reverter.sol":{"content":%q}}}`, testSource)
+	output := fmt.Sprintf(`{
+		"sources": {"Reverter.sol": {"id": 0}},
+		"contracts": {"Reverter.sol": {"Reverter": {
+			"abi": [],
+			"evm": {
+				"bytecode": {"object": "00", "sourceMap": "%d:27:0:-"},
+				"deployedBytecode": {"object": "%s", "sourceMap": "%s"}
+			}
+		}}}
+	}`, unused, runtime, srcmap)
+
+	build, err := compiler.ParseStandardJSON([]byte(input), []byte(output))
+	if err != nil {
+		t.Fatalf("failed to parse build: %v", err)
+	}
+	return build
+}
+
+func TestSessionSimulated(t *testing.T) {
+	session, err := NewSession(testBuild(t))
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	var (
+		key, _   = crypto.GenerateKey()
+		sender   = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.HexToAddress("0xc0de")
+		code     = session.runtime[0].code
+	)
+	sim := simulated.NewBackend(types.GenesisAlloc{
+		sender:   {Balance: big.NewInt(params.Ether)},
+		contract: {Code: code},
+	}, simulated.WithTracer(session.Hooks()))
+	defer sim.Close()
+
+	client := sim.Client()
+	chainID, _ := client.ChainID(context.Background())
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(chainID), &types.DynamicFeeTx{
+		ChainID:   chainID,
+		To:        &contract,
+		Gas:       100_000,
+		GasFeeCap: big.NewInt(100 * params.GWei),
+		GasTipCap: big.NewInt(params.GWei),
+	})
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	if err := client.SendTransaction(context.Background(), tx); err != nil {
+		t.Fatalf("failed to send transaction: %v", err)
+	}
+	sim.Commit()
+
+	receipt, err := client.TransactionReceipt(context.Background(), tx.Hash())
+	if err != nil {
+		t.Fatalf("failed to retrieve receipt: %v", err)
+	}
+	if receipt.Status != types.ReceiptStatusFailed {
+		t.Fatalf("transaction did not revert")
+	}
+	// Check the stack trace of the revert
+	trace := session.StackTrace(tx.Hash())
+	if trace == nil {
+		t.Fatalf("missing stack trace")
+	}
+	if trace.Reason != "nope" {
+		t.Errorf("reason mismatch: have %q, want %q", trace.Reason, "nope")
+	}
+	if len(trace.Frames) != 2 {
+		t.Fatalf("frame count mismatch: have %d, want 2:\n%s", len(trace.Frames), trace)
+	}
+	for i, want := range []Location{{"Reverter.sol", 3, 9}, {"Reverter.sol", 2, 5}} {
+		if have := trace.Frames[i].Location; have == nil || *have != want {
+			t.Errorf("frame %d location mismatch: have %v, want %v", i, have, &want)
+		}
+	}
+	want := `Error: reverted with reason "nope"
+    at Reverter (Reverter.sol:3:9)
+        require(false, "nope");
+    at Reverter (Reverter.sol:2:5)
+        function fail() public {
+`
+	if have := trace.String(); have != want {
+		t.Errorf("rendered trace mismatch:\nhave:\n%s\nwant:\n%s", have, want)
+	}
+	// Check the coverage of the session
+	var lcov bytes.Buffer
+	if err := session.WriteLCOV(&lcov, "simulated"); err != nil {
+		t.Fatalf("failed to write lcov: %v", err)
+	}
+	wantLCOV := "TN:simulated\nSF:Reverter.sol\nDA:2,1\nDA:3,1\nDA:5,0\nLF:3\nLH:2\nend_of_record\n"
+	if lcov.String() != wantLCOV {
+		t.Errorf("lcov mismatch:\nhave:\n%s\nwant:\n%s", lcov.String(), wantLCOV)
+	}
+}
+
+func TestParseSourceMap(t *testing.T) {
+	entries, err := compiler.ParseSourceMap("1:2:0:-;;3::1:i;:::o:2;-1:0:-1:-")
+	if err != nil {
+		t.Fatalf("failed to parse source map: %v", err)
+	}
+	want := []compiler.SourceMapEntry{
+		{Start: 1, Length: 2, File: 0, Jump: '-'},
+		{Start: 1, Length: 2, File: 0, Jump: '-'},
+		{Start: 3, Length: 2, File: 1, Jump: 'i'},
+		{Start: 3, Length: 2, File: 1, Jump: 'o', ModifierDepth: 2},
+		{Start: -1, Length: 0, File: -1, Jump: '-', ModifierDepth: 2},
+	}
+	have, _ := json.Marshal(entries)
+	exp, _ := json.Marshal(want)
+	if !bytes.Equal(have, exp) {
+		t.Errorf("entries mismatch:\nhave %s\nwant %s", have, exp)
+	}
+	if _, err := compiler.ParseSourceMap("1:2:0:x"); err == nil {
+		t.Errorf("invalid jump type accepted")
+	}
+}
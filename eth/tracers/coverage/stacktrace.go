@@ -0,0 +1,142 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package coverage
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// StackTrace is the Solidity level stack trace of a revert.
+type StackTrace struct {
+	Reason string        // Decoded revert reason or custom error, if any
+	Output []byte        // Raw revert data
+	Err    error         // Execution error of the innermost failing frame
+	Frames []*StackFrame // Stack frames, innermost first
+}
+
+// StackFrame is a single entry of a stack trace. Internal function calls of
+// a contract are reported as separate frames of the same address.
+type StackFrame struct {
+	Contract string         // Fully qualified contract name, empty if unknown
+	Address  common.Address // Address of the executing contract
+	PC       uint64         // Program counter of the instruction
+	Location *Location      // Source location of the instruction, nil if unmapped
+	Code     string         // Source line of the instruction
+}
+
+// stackTrace captures the stack trace of the active frames, the innermost of
+// which is failing with the given output and error.
+func (s *Session) stackTrace(output []byte, err error) *StackTrace {
+	trace := &StackTrace{Output: common.CopyBytes(output), Err: err}
+	for i := len(s.frames) - 1; i >= 0; i-- {
+		f := s.frames[i]
+		if f.prog == nil {
+			trace.Frames = append(trace.Frames, &StackFrame{Address: f.addr, PC: f.pc})
+			continue
+		}
+		pcs := []uint64{f.pc}
+		for j := len(f.calls) - 1; j >= 0; j-- {
+			pcs = append(pcs, f.calls[j])
+		}
+		for _, pc := range pcs {
+			trace.Frames = append(trace.Frames, s.stackFrame(f, pc))
+		}
+	}
+	if reason, err := abi.UnpackRevert(output); err == nil {
+		trace.Reason = reason
+	} else if len(output) >= 4 && len(s.frames) > 0 && s.frames[len(s.frames)-1].prog != nil {
+		trace.Reason = s.customError(s.frames[len(s.frames)-1].prog.name, output)
+	}
+	return trace
+}
+
+// stackFrame resolves the source location of an instruction of a frame.
+func (s *Session) stackFrame(f *frame, pc uint64) *StackFrame {
+	sf := &StackFrame{Contract: f.prog.name, Address: f.addr, PC: pc}
+	entry := f.prog.entry(pc)
+	if entry == nil {
+		return sf
+	}
+	src := s.sources[entry.File]
+	if src == nil {
+		return sf
+	}
+	if line, column, ok := src.position(entry.Start); ok {
+		sf.Location = &Location{File: src.path, Line: line, Column: column}
+		sf.Code = strings.TrimSpace(src.text(line))
+	}
+	return sf
+}
+
+// customError decodes the revert data as a custom error of the contract.
+func (s *Session) customError(contract string, output []byte) string {
+	parsed := s.abis[contract]
+	if parsed == nil {
+		return ""
+	}
+	e, err := parsed.ErrorByID([4]byte(output[:4]))
+	if err != nil {
+		return ""
+	}
+	args, err := e.Inputs.Unpack(output[4:])
+	if err != nil {
+		return e.Name
+	}
+	values := make([]string, len(args))
+	for i, arg := range args {
+		values[i] = fmt.Sprint(arg)
+	}
+	return fmt.Sprintf("%s(%s)", e.Name, strings.Join(values, ", "))
+}
+
+// String renders the stack trace in a format similar to the stack traces of
+// other languages.
+func (t *StackTrace) String() string {
+	var b strings.Builder
+	switch {
+	case t.Reason != "":
+		fmt.Fprintf(&b, "Error: reverted with reason %q\n", t.Reason)
+	case len(t.Output) > 0:
+		fmt.Fprintf(&b, "Error: reverted with data %s\n", hexutil.Encode(t.Output))
+	case t.Err != nil:
+		fmt.Fprintf(&b, "Error: %v\n", t.Err)
+	default:
+		b.WriteString("Error: reverted\n")
+	}
+	for _, f := range t.Frames {
+		name := f.Contract
+		if name == "" {
+			name = "<unknown>"
+		} else if i := strings.LastIndexByte(name, ':'); i >= 0 {
+			name = name[i+1:]
+		}
+		if f.Location == nil {
+			fmt.Fprintf(&b, "    at %s (%s, pc %d)\n", name, f.Address.Hex(), f.PC)
+			continue
+		}
+		fmt.Fprintf(&b, "    at %s (%s)\n", name, f.Location)
+		if f.Code != "" {
+			fmt.Fprintf(&b, "        %s\n", f.Code)
+		}
+	}
+	return b.String()
+}
@@ -19,6 +19,7 @@ package logger
 import (
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
@@ -35,6 +36,10 @@ import (
 	"github.com/holiman/uint256"
 )
 
+// ErrTraceLimitExceeded is returned by a struct logger whose trace grew beyond
+// its size limit.
+var ErrTraceLimitExceeded = errors.New("trace size limit exceeded")
+
 // Storage represents a contract's storage.
 type Storage map[common.Hash]common.Hash
 
@@ -117,10 +122,41 @@ type StructLogger struct {
 	err     error
 	usedGas uint64
 
+	writer     io.Writer   // Destination of the streamed entries, nil if they are kept
+	streamed   int         // Number of entries written to the writer
+	resultSize uint64      // Approximate JSON size of the kept entries
+	budget     *SizeBudget // Cap on the size of the kept entries, nil means unlimited
+
 	interrupt atomic.Bool // Atomic flag to signal execution interruption
 	reason    error       // Textual reason for the interruption
 }
 
+// SizeBudget is a cap on the approximate total size of the entries kept by a
+// group of struct loggers, e.g. all the transaction traces of a single request.
+// It is safe for concurrent use.
+type SizeBudget struct {
+	limit uint64        // Maximum size of the kept entries, zero means unlimited
+	used  atomic.Uint64 // Size of the entries kept so far
+}
+
+// NewSizeBudget creates a budget allowing the given number of bytes to be kept.
+func NewSizeBudget(limit uint64) *SizeBudget {
+	return &SizeBudget{limit: limit}
+}
+
+// consume charges the given size to the budget, returning whether it is still
+// within the limit.
+func (b *SizeBudget) consume(size uint64) bool {
+	return b.used.Add(size) <= b.limit || b.limit == 0
+}
+
+// release returns the given size, previously consumed, to the budget.
+func (b *SizeBudget) release(size uint64) {
+	if b != nil && size != 0 {
+		b.used.Add(^(size - 1))
+	}
+}
+
 // NewStructLogger returns a new logger
 func NewStructLogger(cfg *Config) *StructLogger {
 	logger := &StructLogger{
@@ -132,6 +168,28 @@ func NewStructLogger(cfg *Config) *StructLogger {
 	return logger
 }
 
+// NewStreamingStructLogger returns a new logger which doesn't keep the entries,
+// but writes each of them to the writer as soon as it is captured, as a single
+// line of JSON. Tracing stops if the writer fails.
+func NewStreamingStructLogger(cfg *Config, writer io.Writer) *StructLogger {
+	logger := NewStructLogger(cfg)
+	logger.writer = writer
+	return logger
+}
+
+// SetSizeLimit limits the approximate size of the JSON encoded entries kept by
+// the logger. Tracing is stopped with ErrTraceLimitExceeded once it is reached.
+// The limit doesn't apply to streaming loggers.
+func (l *StructLogger) SetSizeLimit(limit uint64) {
+	l.SetSizeBudget(NewSizeBudget(limit))
+}
+
+// SetSizeBudget is like SetSizeLimit, but charges the kept entries to a budget
+// which may be shared with other loggers.
+func (l *StructLogger) SetSizeBudget(budget *SizeBudget) {
+	l.budget = budget
+}
+
 func (l *StructLogger) Hooks() *tracing.Hooks {
 	return &tracing.Hooks{
 		OnTxStart: l.OnTxStart,
@@ -146,6 +204,9 @@ func (l *StructLogger) Reset() {
 	l.storage = make(map[common.Address]Storage)
 	l.output = make([]byte, 0)
 	l.logs = l.logs[:0]
+	l.streamed = 0
+	l.budget.release(l.resultSize)
+	l.resultSize = 0
 	l.err = nil
 }
 
@@ -158,7 +219,7 @@ func (l *StructLogger) OnOpcode(pc uint64, opcode byte, gas, cost uint64, scope
 		return
 	}
 	// check if already accumulated the specified number of logs
-	if l.cfg.Limit != 0 && l.cfg.Limit <= len(l.logs) {
+	if l.cfg.Limit != 0 && l.cfg.Limit <= len(l.logs)+l.streamed {
 		return
 	}
 
@@ -212,9 +273,43 @@ func (l *StructLogger) OnOpcode(pc uint64, opcode byte, gas, cost uint64, scope
 	}
 	// create a new snapshot of the EVM.
 	log := StructLog{pc, op, gas, cost, mem, len(memory), stck, rdata, storage, depth, l.env.StateDB.GetRefund(), err}
+	if l.writer != nil {
+		l.streamed++
+		l.write(&log)
+		return
+	}
+	if l.budget != nil {
+		size := structLogSize(&log)
+		l.resultSize += size
+		if !l.budget.consume(size) {
+			l.Stop(fmt.Errorf("%w (%d bytes)", ErrTraceLimitExceeded, l.budget.limit))
+			return
+		}
+	}
 	l.logs = append(l.logs, log)
 }
 
+// write streams a single entry to the writer of the logger.
+func (l *StructLogger) write(log *StructLog) {
+	entry, err := json.Marshal(formatLog(log))
+	if err == nil {
+		_, err = l.writer.Write(append(entry, '\n'))
+	}
+	if err != nil {
+		l.Stop(err)
+	}
+}
+
+// structLogSize approximates the size of the JSON encoding of a log entry, as
+// returned by GetResult.
+func structLogSize(log *StructLog) uint64 {
+	size := 160 + len(log.Stack)*70 + len(log.ReturnData)*2 + len(log.Storage)*136
+	if log.Memory != nil {
+		size += len(log.Memory)/32*67 + 2
+	}
+	return uint64(size)
+}
+
 // OnExit is called a call frame finishes processing.
 func (l *StructLogger) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
 	if depth != 0 {
@@ -439,40 +534,46 @@ type StructLogRes struct {
 // formatLogs formats EVM returned structured logs for json output
 func formatLogs(logs []StructLog) []StructLogRes {
 	formatted := make([]StructLogRes, len(logs))
-	for index, trace := range logs {
-		formatted[index] = StructLogRes{
-			Pc:            trace.Pc,
-			Op:            trace.Op.String(),
-			Gas:           trace.Gas,
-			GasCost:       trace.GasCost,
-			Depth:         trace.Depth,
-			Error:         trace.ErrorString(),
-			RefundCounter: trace.RefundCounter,
-		}
-		if trace.Stack != nil {
-			stack := make([]string, len(trace.Stack))
-			for i, stackValue := range trace.Stack {
-				stack[i] = stackValue.Hex()
-			}
-			formatted[index].Stack = &stack
-		}
-		if trace.ReturnData != nil && len(trace.ReturnData) > 0 {
-			formatted[index].ReturnData = hexutil.Bytes(trace.ReturnData).String()
+	for index := range logs {
+		formatted[index] = formatLog(&logs[index])
+	}
+	return formatted
+}
+
+// formatLog formats a single structured log for json output
+func formatLog(trace *StructLog) StructLogRes {
+	formatted := StructLogRes{
+		Pc:            trace.Pc,
+		Op:            trace.Op.String(),
+		Gas:           trace.Gas,
+		GasCost:       trace.GasCost,
+		Depth:         trace.Depth,
+		Error:         trace.ErrorString(),
+		RefundCounter: trace.RefundCounter,
+	}
+	if trace.Stack != nil {
+		stack := make([]string, len(trace.Stack))
+		for i, stackValue := range trace.Stack {
+			stack[i] = stackValue.Hex()
 		}
-		if trace.Memory != nil {
-			memory := make([]string, 0, (len(trace.Memory)+31)/32)
-			for i := 0; i+32 <= len(trace.Memory); i += 32 {
-				memory = append(memory, fmt.Sprintf("%x", trace.Memory[i:i+32]))
-			}
-			formatted[index].Memory = &memory
+		formatted.Stack = &stack
+	}
+	if trace.ReturnData != nil && len(trace.ReturnData) > 0 {
+		formatted.ReturnData = hexutil.Bytes(trace.ReturnData).String()
+	}
+	if trace.Memory != nil {
+		memory := make([]string, 0, (len(trace.Memory)+31)/32)
+		for i := 0; i+32 <= len(trace.Memory); i += 32 {
+			memory = append(memory, fmt.Sprintf("%x", trace.Memory[i:i+32]))
 		}
-		if trace.Storage != nil {
-			storage := make(map[string]string)
-			for i, storageValue := range trace.Storage {
-				storage[fmt.Sprintf("%x", i)] = fmt.Sprintf("%x", storageValue)
-			}
-			formatted[index].Storage = &storage
+		formatted.Memory = &memory
+	}
+	if trace.Storage != nil {
+		storage := make(map[string]string)
+		for i, storageValue := range trace.Storage {
+			storage[fmt.Sprintf("%x", i)] = fmt.Sprintf("%x", storageValue)
 		}
+		formatted.Storage = &storage
 	}
 	return formatted
 }
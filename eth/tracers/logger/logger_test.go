@@ -17,9 +17,11 @@
 package logger
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"math/big"
+	"strings"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -106,3 +108,57 @@ func TestStructLogMarshalingOmitEmpty(t *testing.T) {
 		})
 	}
 }
+
+// runStructLogger executes a small contract with the given logger.
+func runStructLogger(t *testing.T, logger *StructLogger) {
+	var (
+		env      = vm.NewEVM(vm.BlockContext{}, vm.TxContext{}, &dummyStatedb{}, params.TestChainConfig, vm.Config{Tracer: logger.Hooks()})
+		contract = vm.NewContract(&dummyContractRef{}, &dummyContractRef{}, new(uint256.Int), 100000)
+	)
+	contract.Code = []byte{byte(vm.PUSH1), 0x1, byte(vm.PUSH1), 0x0, byte(vm.SSTORE)}
+	logger.OnTxStart(env.GetVMContext(), nil, common.Address{})
+	if _, err := env.Interpreter().Run(contract, []byte{}, false); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Tests that the streaming logger writes the same entries as the ones kept by
+// the regular logger, one per line.
+func TestStreamingStructLogger(t *testing.T) {
+	regular := NewStructLogger(nil)
+	runStructLogger(t, regular)
+
+	var buf bytes.Buffer
+	streaming := NewStreamingStructLogger(nil, &buf)
+	runStructLogger(t, streaming)
+
+	if len(streaming.StructLogs()) != 0 {
+		t.Fatalf("streaming logger kept %d entries", len(streaming.StructLogs()))
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	want := formatLogs(regular.StructLogs())
+	if len(lines) != len(want) {
+		t.Fatalf("entry count mismatch: have %d, want %d", len(lines), len(want))
+	}
+	for i, line := range lines {
+		blob, _ := json.Marshal(want[i])
+		if line != string(blob) {
+			t.Errorf("entry %d mismatch:\n\thave: %s\n\twant: %s", i, line, blob)
+		}
+	}
+}
+
+// Tests that the logger stops with an error once its trace exceeds the size
+// limit.
+func TestStructLoggerSizeLimit(t *testing.T) {
+	logger := NewStructLogger(nil)
+	logger.SetSizeLimit(400)
+	runStructLogger(t, logger)
+
+	if _, err := logger.GetResult(); !errors.Is(err, ErrTraceLimitExceeded) {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrTraceLimitExceeded)
+	}
+	if len(logger.StructLogs()) != 2 {
+		t.Fatalf("entry count mismatch: have %d, want 2", len(logger.StructLogs()))
+	}
+}
@@ -0,0 +1,382 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/google/pprof/profile"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("gasProfiler", newGasProfiler, false)
+}
+
+// gasProfilerConfig is the configuration of the gas profiler.
+type gasProfilerConfig struct {
+	// Format of the result, either "json" (default) or "pprof". The pprof profile
+	// is returned as a base64 encoded string of the gzipped protobuf.
+	Format string `json:"format"`
+}
+
+// gasProfileLoc identifies an instruction of a contract.
+type gasProfileLoc struct {
+	addr common.Address
+	pc   uint64
+}
+
+// gasProfileStat holds the aggregated usage of a single instruction.
+type gasProfileStat struct {
+	op    vm.OpCode
+	gas   uint64
+	count uint64
+}
+
+// gasProfileNode is a node of the call tree. Calls made from the same call
+// site within the same call path are aggregated into the same node.
+type gasProfileNode struct {
+	parent   *gasProfileNode
+	site     gasProfileLoc // Call site within the parent frame
+	addr     common.Address
+	typ      vm.OpCode
+	calls    uint64
+	gas      uint64 // Gas used by the frames, including sub-calls
+	selfGas  uint64 // Gas used by the frames, excluding sub-calls
+	ops      map[uint64]*gasProfileStat
+	children map[gasProfileChildKey]*gasProfileNode
+	order    []*gasProfileNode // Children in the order of the first call
+}
+
+type gasProfileChildKey struct {
+	site gasProfileLoc
+	addr common.Address
+	typ  vm.OpCode
+}
+
+// gasProfileFrame is an active call frame of the profiler.
+type gasProfileFrame struct {
+	node     *gasProfileNode
+	gas      uint64 // Gas available when entering the frame
+	childGas uint64 // Gas used by the sub-calls of the frame
+	pending  *gasProfileStat
+	pc       uint64 // Program counter of the pending instruction
+	opGas    uint64 // Gas available before the pending instruction
+	opChild  uint64 // Gas used by the sub-calls of the pending instruction
+}
+
+// gasProfiler aggregates the gas used and the number of executions per
+// instruction and per call frame. The gas of an instruction is the gas it
+// actually consumed, excluding the gas used by the sub-calls it made.
+type gasProfiler struct {
+	config    gasProfilerConfig
+	root      *gasProfileNode
+	frames    []*gasProfileFrame
+	interrupt atomic.Bool // Atomic flag to signal execution interruption
+	reason    error       // Textual reason for the interruption
+}
+
+func newGasProfiler(ctx *tracers.Context, cfg json.RawMessage) (*tracers.Tracer, error) {
+	var config gasProfilerConfig
+	if cfg != nil {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, err
+		}
+	}
+	switch config.Format {
+	case "", "json", "pprof":
+	default:
+		return nil, fmt.Errorf("unknown gas profile format %q", config.Format)
+	}
+	t := &gasProfiler{config: config}
+	return &tracers.Tracer{
+		Hooks: &tracing.Hooks{
+			OnEnter:  t.OnEnter,
+			OnExit:   t.OnExit,
+			OnOpcode: t.OnOpcode,
+		},
+		GetResult: t.GetResult,
+		Stop:      t.Stop,
+	}, nil
+}
+
+func (t *gasProfiler) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if t.interrupt.Load() {
+		return
+	}
+	var node *gasProfileNode
+	if depth == 0 || len(t.frames) == 0 {
+		if t.root == nil {
+			t.root = newGasProfileNode(nil, gasProfileLoc{}, to, vm.OpCode(typ))
+		}
+		node = t.root
+	} else {
+		parent := t.frames[len(t.frames)-1]
+		key := gasProfileChildKey{site: gasProfileLoc{parent.node.addr, parent.pc}, addr: to, typ: vm.OpCode(typ)}
+		if node = parent.node.children[key]; node == nil {
+			node = newGasProfileNode(parent.node, key.site, to, key.typ)
+			parent.node.children[key] = node
+			parent.node.order = append(parent.node.order, node)
+		}
+	}
+	node.calls++
+	t.frames = append(t.frames, &gasProfileFrame{node: node, gas: gas})
+}
+
+func newGasProfileNode(parent *gasProfileNode, site gasProfileLoc, addr common.Address, typ vm.OpCode) *gasProfileNode {
+	return &gasProfileNode{
+		parent:   parent,
+		site:     site,
+		addr:     addr,
+		typ:      typ,
+		ops:      make(map[uint64]*gasProfileStat),
+		children: make(map[gasProfileChildKey]*gasProfileNode),
+	}
+}
+
+func (t *gasProfiler) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	if t.interrupt.Load() || len(t.frames) == 0 {
+		return
+	}
+	frame := t.frames[len(t.frames)-1]
+	t.frames = t.frames[:len(t.frames)-1]
+
+	if gasUsed <= frame.gas {
+		frame.settle(frame.gas - gasUsed)
+	}
+	frame.node.gas += gasUsed
+	if gasUsed >= frame.childGas {
+		frame.node.selfGas += gasUsed - frame.childGas
+	}
+	if len(t.frames) > 0 {
+		parent := t.frames[len(t.frames)-1]
+		parent.childGas += gasUsed
+		parent.opChild += gasUsed
+	}
+}
+
+func (t *gasProfiler) OnOpcode(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	if t.interrupt.Load() || len(t.frames) == 0 {
+		return
+	}
+	frame := t.frames[len(t.frames)-1]
+	frame.settle(gas)
+
+	stat := frame.node.ops[pc]
+	if stat == nil {
+		stat = &gasProfileStat{op: vm.OpCode(op)}
+		frame.node.ops[pc] = stat
+	}
+	stat.count++
+	frame.pending, frame.pc, frame.opGas, frame.opChild = stat, pc, gas, 0
+}
+
+// settle charges the gas consumed by the pending instruction of the frame,
+// given the gas remaining after its execution.
+func (f *gasProfileFrame) settle(gas uint64) {
+	if f.pending == nil {
+		return
+	}
+	if used := f.opGas - min(f.opGas, gas); used >= f.opChild {
+		f.pending.gas += used - f.opChild
+	}
+	f.pending = nil
+}
+
+// gasProfileOp is the JSON representation of the usage of an instruction.
+type gasProfileOp struct {
+	Address common.Address `json:"address"`
+	Pc      uint64         `json:"pc"`
+	Op      string         `json:"op"`
+	Gas     uint64         `json:"gas"`
+	Count   uint64         `json:"count"`
+}
+
+// gasProfileCall is the JSON representation of the usage of a call path.
+type gasProfileCall struct {
+	Path    []common.Address `json:"path"`
+	Type    string           `json:"type"`
+	Calls   uint64           `json:"calls"`
+	Gas     uint64           `json:"gas"`
+	SelfGas uint64           `json:"selfGas"`
+}
+
+// gasProfileResult is the JSON result of the gas profiler.
+type gasProfileResult struct {
+	Gas    uint64            `json:"gas"`
+	Ops    []*gasProfileOp   `json:"ops"`
+	Frames []*gasProfileCall `json:"frames"`
+}
+
+// GetResult returns the gas profile in the configured format.
+func (t *gasProfiler) GetResult() (json.RawMessage, error) {
+	if t.config.Format == "pprof" {
+		blob, err := t.Profile()
+		if err != nil {
+			return nil, err
+		}
+		res, err := json.Marshal(blob)
+		if err != nil {
+			return nil, err
+		}
+		return res, t.reason
+	}
+	res, err := json.Marshal(t.result())
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// result aggregates the call tree into the JSON result. Instructions are
+// sorted by descending gas usage, frames in call order.
+func (t *gasProfiler) result() *gasProfileResult {
+	res := &gasProfileResult{Ops: []*gasProfileOp{}, Frames: []*gasProfileCall{}}
+	if t.root == nil {
+		return res
+	}
+	res.Gas = t.root.gas
+
+	ops := make(map[gasProfileLoc]*gasProfileOp)
+	t.root.walk(nil, func(node *gasProfileNode, path []common.Address) {
+		res.Frames = append(res.Frames, &gasProfileCall{
+			Path:    slices.Clone(path),
+			Type:    node.typ.String(),
+			Calls:   node.calls,
+			Gas:     node.gas,
+			SelfGas: node.selfGas,
+		})
+		for pc, stat := range node.ops {
+			loc := gasProfileLoc{node.addr, pc}
+			op := ops[loc]
+			if op == nil {
+				op = &gasProfileOp{Address: node.addr, Pc: pc, Op: stat.op.String()}
+				ops[loc] = op
+				res.Ops = append(res.Ops, op)
+			}
+			op.Gas += stat.gas
+			op.Count += stat.count
+		}
+	})
+	slices.SortFunc(res.Ops, func(a, b *gasProfileOp) int {
+		if c := cmp.Compare(b.Gas, a.Gas); c != 0 {
+			return c
+		}
+		if c := bytes.Compare(a.Address[:], b.Address[:]); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Pc, b.Pc)
+	})
+	return res
+}
+
+// walk visits the nodes of the call tree depth first, passing the addresses of
+// the call path leading to each node.
+func (n *gasProfileNode) walk(path []common.Address, visit func(*gasProfileNode, []common.Address)) {
+	path = append(path, n.addr)
+	visit(n, path)
+	for _, child := range n.order {
+		child.walk(path, visit)
+	}
+}
+
+// Profile encodes the gas profile as a gzipped pprof protobuf. Every contract
+// is represented as a function whose line numbers are the program counters,
+// and the samples are attributed to the stacks of call sites.
+func (t *gasProfiler) Profile() ([]byte, error) {
+	p := &profile.Profile{
+		SampleType: []*profile.ValueType{
+			{Type: "gas", Unit: "gas"},
+			{Type: "executions", Unit: "count"},
+		},
+		PeriodType:        &profile.ValueType{Type: "gas", Unit: "gas"},
+		DefaultSampleType: "gas",
+		Period:            1,
+	}
+	var (
+		functions = make(map[common.Address]*profile.Function)
+		locations = make(map[gasProfileLoc]*profile.Location)
+	)
+	location := func(loc gasProfileLoc) *profile.Location {
+		if l, ok := locations[loc]; ok {
+			return l
+		}
+		fn, ok := functions[loc.addr]
+		if !ok {
+			name := strings.ToLower(loc.addr.Hex())
+			fn = &profile.Function{ID: uint64(len(functions) + 1), Name: name, SystemName: name, Filename: name}
+			functions[loc.addr] = fn
+			p.Function = append(p.Function, fn)
+		}
+		l := &profile.Location{ID: uint64(len(locations) + 1), Address: loc.pc, Line: []profile.Line{{Function: fn, Line: int64(loc.pc)}}}
+		locations[loc] = l
+		p.Location = append(p.Location, l)
+		return l
+	}
+	if t.root != nil {
+		t.root.walk(nil, func(node *gasProfileNode, _ []common.Address) {
+			// The call sites leading to the node, innermost first
+			var sites []*profile.Location
+			for n := node; n.parent != nil; n = n.parent {
+				sites = append(sites, location(n.site))
+			}
+			pcs := make([]uint64, 0, len(node.ops))
+			var opGas uint64
+			for pc, stat := range node.ops {
+				pcs = append(pcs, pc)
+				opGas += stat.gas
+			}
+			slices.Sort(pcs)
+			for _, pc := range pcs {
+				stat := node.ops[pc]
+				p.Sample = append(p.Sample, &profile.Sample{
+					Location: append([]*profile.Location{location(gasProfileLoc{node.addr, pc})}, sites...),
+					Value:    []int64{int64(stat.gas), int64(stat.count)},
+					Label:    map[string][]string{"op": {stat.op.String()}},
+				})
+			}
+			// Gas used without executing instructions, e.g. by precompiles
+			if node.selfGas > opGas && len(node.ops) == 0 {
+				p.Sample = append(p.Sample, &profile.Sample{
+					Location: append([]*profile.Location{location(gasProfileLoc{node.addr, 0})}, sites...),
+					Value:    []int64{int64(node.selfGas - opGas), int64(node.calls)},
+				})
+			}
+		})
+	}
+	var buf bytes.Buffer
+	if err := p.Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *gasProfiler) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
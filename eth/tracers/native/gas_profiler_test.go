@@ -0,0 +1,119 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/google/pprof/profile"
+	"github.com/stretchr/testify/require"
+)
+
+// runGasProfile simulates a call from contract A into contract B and returns
+// the result of the gas profiler.
+func runGasProfile(t *testing.T, config string) json.RawMessage {
+	tracer, err := tracers.DefaultDirectory.New("gasProfiler", &tracers.Context{}, json.RawMessage(config))
+	require.NoError(t, err)
+
+	var (
+		a = common.HexToAddress("0xaa")
+		b = common.HexToAddress("0xbb")
+	)
+	tracer.OnEnter(0, byte(vm.CALL), common.Address{}, a, nil, 100, big.NewInt(0))
+	tracer.OnOpcode(0, byte(vm.PUSH1), 100, 3, nil, nil, 1, nil)
+	tracer.OnOpcode(2, byte(vm.CALL), 97, 60, nil, nil, 1, nil)
+	tracer.OnEnter(1, byte(vm.CALL), a, b, nil, 50, big.NewInt(0))
+	tracer.OnOpcode(0, byte(vm.SSTORE), 50, 10, nil, nil, 2, nil)
+	tracer.OnExit(1, nil, 10, nil, false)
+	tracer.OnOpcode(3, byte(vm.STOP), 80, 0, nil, nil, 1, nil)
+	tracer.OnExit(0, nil, 20, nil, false)
+
+	res, err := tracer.GetResult()
+	require.NoError(t, err)
+	return res
+}
+
+func TestGasProfilerJSON(t *testing.T) {
+	var res struct {
+		Gas uint64 `json:"gas"`
+		Ops []struct {
+			Address common.Address `json:"address"`
+			Pc      uint64         `json:"pc"`
+			Op      string         `json:"op"`
+			Gas     uint64         `json:"gas"`
+			Count   uint64         `json:"count"`
+		} `json:"ops"`
+		Frames []struct {
+			Path    []common.Address `json:"path"`
+			Calls   uint64           `json:"calls"`
+			Gas     uint64           `json:"gas"`
+			SelfGas uint64           `json:"selfGas"`
+		} `json:"frames"`
+	}
+	require.NoError(t, json.Unmarshal(runGasProfile(t, `{}`), &res))
+
+	require.Equal(t, uint64(20), res.Gas)
+	require.Len(t, res.Ops, 4)
+	want := []struct {
+		op  string
+		gas uint64
+	}{{"SSTORE", 10}, {"CALL", 7}, {"PUSH1", 3}, {"STOP", 0}}
+	for i, w := range want {
+		require.Equal(t, w.op, res.Ops[i].Op, "op %d", i)
+		require.Equal(t, w.gas, res.Ops[i].Gas, "op %d", i)
+		require.Equal(t, uint64(1), res.Ops[i].Count, "op %d", i)
+	}
+	require.Len(t, res.Frames, 2)
+	require.Equal(t, uint64(20), res.Frames[0].Gas)
+	require.Equal(t, uint64(10), res.Frames[0].SelfGas)
+	require.Equal(t, []common.Address{common.HexToAddress("0xaa"), common.HexToAddress("0xbb")}, res.Frames[1].Path)
+	require.Equal(t, uint64(10), res.Frames[1].Gas)
+}
+
+func TestGasProfilerPprof(t *testing.T) {
+	var blob []byte
+	require.NoError(t, json.Unmarshal(runGasProfile(t, `{"format":"pprof"}`), &blob))
+
+	prof, err := profile.ParseData(blob)
+	require.NoError(t, err)
+	require.NoError(t, prof.CheckValid())
+
+	var total int64
+	for _, sample := range prof.Sample {
+		total += sample.Value[0]
+
+		// The SSTORE of the callee must be attributed to the CALL site of the caller
+		if sample.Label["op"][0] == "SSTORE" {
+			require.Len(t, sample.Location, 2)
+			require.True(t, strings.HasSuffix(sample.Location[0].Line[0].Function.Name, "bb"))
+			require.True(t, strings.HasSuffix(sample.Location[1].Line[0].Function.Name, "aa"))
+			require.Equal(t, int64(2), sample.Location[1].Line[0].Line)
+		}
+	}
+	require.Equal(t, int64(20), total)
+}
+
+func TestGasProfilerInvalidFormat(t *testing.T) {
+	_, err := tracers.DefaultDirectory.New("gasProfiler", &tracers.Context{}, json.RawMessage(`{"format":"svg"}`))
+	require.Error(t, err)
+}
@@ -0,0 +1,260 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("stateDiffTracer", newStateDiffTracer, false)
+}
+
+// diffEntry is a single field of a parity-style state diff. It is marshalled
+// either as "=" for unchanged values, or as an object keyed by "+" (born), "-"
+// (died) or "*" (changed).
+type diffEntry struct {
+	kind byte // one of '=', '+', '-', '*'
+	from interface{}
+	to   interface{}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d diffEntry) MarshalJSON() ([]byte, error) {
+	switch d.kind {
+	case '+':
+		return json.Marshal(map[string]interface{}{"+": d.to})
+	case '-':
+		return json.Marshal(map[string]interface{}{"-": d.from})
+	case '*':
+		return json.Marshal(map[string]interface{}{"*": map[string]interface{}{"from": d.from, "to": d.to}})
+	default:
+		return []byte(`"="`), nil
+	}
+}
+
+// accountDiff is the parity-style state diff of a single account.
+type accountDiff struct {
+	Balance diffEntry                 `json:"balance"`
+	Nonce   diffEntry                 `json:"nonce"`
+	Code    diffEntry                 `json:"code"`
+	Storage map[common.Hash]diffEntry `json:"storage"`
+}
+
+// touchedAccount holds the values of the account fields before the first
+// modification within the transaction.
+type touchedAccount struct {
+	balance *big.Int
+	nonce   *uint64
+	code    []byte
+	hasCode bool
+	storage map[common.Hash]common.Hash
+}
+
+// stateDiffTracer collects the state modifications of a transaction and
+// reports them in the format of the parity trace_replayTransaction stateDiff.
+type stateDiffTracer struct {
+	env       *tracing.VMContext
+	touched   map[common.Address]*touchedAccount
+	created   map[common.Address]bool
+	deleted   map[common.Address]bool
+	result    map[common.Address]*accountDiff
+	interrupt atomic.Bool // Atomic flag to signal execution interruption
+	reason    error       // Textual reason for the interruption
+}
+
+func newStateDiffTracer(ctx *tracers.Context, _ json.RawMessage) (*tracers.Tracer, error) {
+	t := &stateDiffTracer{
+		touched: make(map[common.Address]*touchedAccount),
+		created: make(map[common.Address]bool),
+		deleted: make(map[common.Address]bool),
+	}
+	return &tracers.Tracer{
+		Hooks: &tracing.Hooks{
+			OnTxStart:       t.OnTxStart,
+			OnTxEnd:         t.OnTxEnd,
+			OnEnter:         t.OnEnter,
+			OnBalanceChange: t.OnBalanceChange,
+			OnNonceChange:   t.OnNonceChange,
+			OnCodeChange:    t.OnCodeChange,
+			OnStorageChange: t.OnStorageChange,
+		},
+		GetResult: t.GetResult,
+		Stop:      t.Stop,
+	}, nil
+}
+
+func (t *stateDiffTracer) OnTxStart(env *tracing.VMContext, tx *types.Transaction, from common.Address) {
+	t.env = env
+}
+
+// OnEnter tracks the accounts created and destroyed within the transaction, as
+// a destroyed account remains in the state until the end of the transaction.
+func (t *stateDiffTracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	switch vm.OpCode(typ) {
+	case vm.CREATE, vm.CREATE2:
+		t.created[to] = true
+	case vm.SELFDESTRUCT:
+		// Since EIP-6780, only accounts created in the same transaction are destroyed
+		if t.created[from] || !t.env.ChainConfig.IsCancun(t.env.BlockNumber, t.env.Time) {
+			t.deleted[from] = true
+		}
+	}
+}
+
+func (t *stateDiffTracer) account(addr common.Address) *touchedAccount {
+	acc, ok := t.touched[addr]
+	if !ok {
+		acc = &touchedAccount{storage: make(map[common.Hash]common.Hash)}
+		t.touched[addr] = acc
+	}
+	return acc
+}
+
+func (t *stateDiffTracer) OnBalanceChange(addr common.Address, prev, _ *big.Int, reason tracing.BalanceChangeReason) {
+	if acc := t.account(addr); acc.balance == nil {
+		acc.balance = new(big.Int).Set(prev)
+	}
+}
+
+func (t *stateDiffTracer) OnNonceChange(addr common.Address, prev, new uint64) {
+	if acc := t.account(addr); acc.nonce == nil {
+		acc.nonce = &prev
+	}
+}
+
+func (t *stateDiffTracer) OnCodeChange(addr common.Address, prevCodeHash common.Hash, prevCode []byte, codeHash common.Hash, code []byte) {
+	if acc := t.account(addr); !acc.hasCode {
+		acc.code, acc.hasCode = common.CopyBytes(prevCode), true
+	}
+}
+
+func (t *stateDiffTracer) OnStorageChange(addr common.Address, slot common.Hash, prev, new common.Hash) {
+	acc := t.account(addr)
+	if _, ok := acc.storage[slot]; !ok {
+		acc.storage[slot] = prev
+	}
+}
+
+func (t *stateDiffTracer) OnTxEnd(receipt *types.Receipt, err error) {
+	if err != nil || t.interrupt.Load() {
+		return
+	}
+	t.result = make(map[common.Address]*accountDiff)
+	for addr, acc := range t.touched {
+		// Fields which were never modified still hold their original value
+		var (
+			postBalance = t.env.StateDB.GetBalance(addr).ToBig()
+			postNonce   = t.env.StateDB.GetNonce(addr)
+			postCode    = t.env.StateDB.GetCode(addr)
+			preBalance  = postBalance
+			preNonce    = postNonce
+			preCode     = postCode
+		)
+		if acc.balance != nil {
+			preBalance = acc.balance
+		}
+		if acc.nonce != nil {
+			preNonce = *acc.nonce
+		}
+		if acc.hasCode {
+			preCode = acc.code
+		}
+		var (
+			existed = preBalance.Sign() != 0 || preNonce != 0 || len(preCode) != 0
+			exists  = !t.deleted[addr] && (postBalance.Sign() != 0 || postNonce != 0 || len(postCode) != 0)
+			diff    = &accountDiff{Storage: make(map[common.Hash]diffEntry)}
+		)
+		switch {
+		case !existed && !exists:
+			continue
+
+		case !existed:
+			diff.Balance = diffEntry{kind: '+', to: (*hexutil.Big)(postBalance)}
+			diff.Nonce = diffEntry{kind: '+', to: hexutil.Uint64(postNonce)}
+			diff.Code = diffEntry{kind: '+', to: hexutil.Bytes(postCode)}
+			for slot := range acc.storage {
+				if val := t.env.StateDB.GetState(addr, slot); val != (common.Hash{}) {
+					diff.Storage[slot] = diffEntry{kind: '+', to: val}
+				}
+			}
+
+		case !exists:
+			diff.Balance = diffEntry{kind: '-', from: (*hexutil.Big)(preBalance)}
+			diff.Nonce = diffEntry{kind: '-', from: hexutil.Uint64(preNonce)}
+			diff.Code = diffEntry{kind: '-', from: hexutil.Bytes(preCode)}
+			for slot, val := range acc.storage {
+				if val != (common.Hash{}) {
+					diff.Storage[slot] = diffEntry{kind: '-', from: val}
+				}
+			}
+
+		default:
+			modified := false
+			if preBalance.Cmp(postBalance) != 0 {
+				diff.Balance = diffEntry{kind: '*', from: (*hexutil.Big)(preBalance), to: (*hexutil.Big)(postBalance)}
+				modified = true
+			}
+			if preNonce != postNonce {
+				diff.Nonce = diffEntry{kind: '*', from: hexutil.Uint64(preNonce), to: hexutil.Uint64(postNonce)}
+				modified = true
+			}
+			if !bytes.Equal(preCode, postCode) {
+				diff.Code = diffEntry{kind: '*', from: hexutil.Bytes(preCode), to: hexutil.Bytes(postCode)}
+				modified = true
+			}
+			for slot, val := range acc.storage {
+				if post := t.env.StateDB.GetState(addr, slot); post != val {
+					diff.Storage[slot] = diffEntry{kind: '*', from: val, to: post}
+					modified = true
+				}
+			}
+			if !modified {
+				continue
+			}
+		}
+		t.result[addr] = diff
+	}
+}
+
+// GetResult returns the state diff of the transaction keyed by address.
+func (t *stateDiffTracer) GetResult() (json.RawMessage, error) {
+	if t.result == nil {
+		t.result = make(map[common.Address]*accountDiff)
+	}
+	res, err := json.Marshal(t.result)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *stateDiffTracer) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
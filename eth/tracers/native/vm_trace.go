@@ -0,0 +1,272 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("vmTracer", newVMTracer, false)
+}
+
+// vmTrace is the parity-style trace of the instructions executed by a single
+// call frame.
+type vmTrace struct {
+	Code hexutil.Bytes `json:"code"`
+	Ops  []*vmTraceOp  `json:"ops"`
+}
+
+// vmTraceOp is a single executed instruction. Sub holds the trace of the frame
+// entered by the instruction, if any.
+type vmTraceOp struct {
+	Cost uint64     `json:"cost"`
+	Ex   *vmTraceEx `json:"ex"`
+	Pc   uint64     `json:"pc"`
+	Sub  *vmTrace   `json:"sub"`
+}
+
+// vmTraceEx holds the effects of an instruction. It is nil if the instruction
+// failed.
+type vmTraceEx struct {
+	Mem   *vmTraceMem     `json:"mem"`
+	Push  []*hexutil.U256 `json:"push"`
+	Store *vmTraceStore   `json:"store"`
+	Used  uint64          `json:"used"`
+}
+
+type vmTraceMem struct {
+	Data hexutil.Bytes `json:"data"`
+	Off  uint64        `json:"off"`
+}
+
+type vmTraceStore struct {
+	Key *hexutil.U256 `json:"key"`
+	Val *hexutil.U256 `json:"val"`
+}
+
+// vmTraceFrame is an active call frame of the vmTracer.
+type vmTraceFrame struct {
+	trace   *vmTrace      // Trace of the frame, nil if the frame has no code
+	gas     uint64        // Gas available when entering the frame
+	pending *vmTraceOp    // Last instruction, waiting for its effects
+	op      vm.OpCode     // Opcode of the pending instruction
+	memOff  uint64        // Offset of the memory written by the pending instruction
+	memSize uint64        // Size of the memory written by the pending instruction
+	store   *vmTraceStore // Storage written by the pending instruction
+}
+
+// vmTracer reports the executed instructions of a transaction in the format
+// of the parity trace_replayTransaction vmTrace.
+type vmTracer struct {
+	env       *tracing.VMContext
+	table     vm.JumpTable
+	root      *vmTrace
+	frames    []*vmTraceFrame
+	interrupt atomic.Bool // Atomic flag to signal execution interruption
+	reason    error       // Textual reason for the interruption
+}
+
+func newVMTracer(ctx *tracers.Context, _ json.RawMessage) (*tracers.Tracer, error) {
+	t := new(vmTracer)
+	return &tracers.Tracer{
+		Hooks: &tracing.Hooks{
+			OnTxStart: t.OnTxStart,
+			OnEnter:   t.OnEnter,
+			OnExit:    t.OnExit,
+			OnOpcode:  t.OnOpcode,
+			OnFault:   t.OnFault,
+		},
+		GetResult: t.GetResult,
+		Stop:      t.Stop,
+	}, nil
+}
+
+func (t *vmTracer) OnTxStart(env *tracing.VMContext, tx *types.Transaction, from common.Address) {
+	t.env = env
+
+	// The instruction set is only used for the stack requirements, so an
+	// approximated table of a not yet defined fork is fine.
+	rules := env.ChainConfig.Rules(env.BlockNumber, env.Random != nil, env.Time)
+	t.table, _ = vm.LookupInstructionSet(rules)
+}
+
+func (t *vmTracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if t.interrupt.Load() {
+		return
+	}
+	var code []byte
+	switch vm.OpCode(typ) {
+	case vm.CREATE, vm.CREATE2:
+		code = input
+	case vm.SELFDESTRUCT:
+		// Selfdestructs don't execute any code
+	default:
+		code = t.env.StateDB.GetCode(to)
+	}
+	frame := &vmTraceFrame{gas: gas}
+	if len(code) > 0 || depth == 0 {
+		frame.trace = &vmTrace{Code: common.CopyBytes(code), Ops: []*vmTraceOp{}}
+	}
+	if depth == 0 {
+		t.root = frame.trace
+	} else if parent := t.frames[len(t.frames)-1]; parent.pending != nil {
+		parent.pending.Sub = frame.trace
+	}
+	t.frames = append(t.frames, frame)
+}
+
+func (t *vmTracer) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	if t.interrupt.Load() || len(t.frames) == 0 {
+		return
+	}
+	frame := t.frames[len(t.frames)-1]
+	t.frames = t.frames[:len(t.frames)-1]
+
+	if frame.trace != nil && gasUsed <= frame.gas {
+		t.finish(frame, nil, frame.gas-gasUsed)
+	}
+}
+
+func (t *vmTracer) OnOpcode(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	if t.interrupt.Load() || len(t.frames) == 0 {
+		return
+	}
+	frame := t.frames[len(t.frames)-1]
+	if frame.trace == nil {
+		return
+	}
+	t.finish(frame, scope, gas)
+
+	entry := &vmTraceOp{Cost: cost, Pc: pc}
+	frame.trace.Ops = append(frame.trace.Ops, entry)
+	frame.pending, frame.op = entry, vm.OpCode(op)
+	frame.memOff, frame.memSize, frame.store = 0, 0, nil
+
+	// Record the memory and storage locations written by the instruction, the
+	// values are collected once the instruction has been executed.
+	stack := scope.StackData()
+	switch frame.op {
+	case vm.MSTORE:
+		frame.setMem(stack, 0, 32)
+	case vm.MSTORE8:
+		frame.setMem(stack, 0, 1)
+	case vm.CALLDATACOPY, vm.CODECOPY, vm.RETURNDATACOPY, vm.MCOPY:
+		frame.setMemRange(stack, 0, 2)
+	case vm.EXTCODECOPY:
+		frame.setMemRange(stack, 1, 3)
+	case vm.CALL, vm.CALLCODE:
+		frame.setMemRange(stack, 5, 6)
+	case vm.DELEGATECALL, vm.STATICCALL:
+		frame.setMemRange(stack, 4, 5)
+	case vm.SSTORE:
+		if len(stack) >= 2 {
+			frame.store = &vmTraceStore{
+				Key: (*hexutil.U256)(new(uint256.Int).Set(&stack[len(stack)-1])),
+				Val: (*hexutil.U256)(new(uint256.Int).Set(&stack[len(stack)-2])),
+			}
+		}
+	}
+}
+
+// OnFault discards the effects of the failed instruction.
+func (t *vmTracer) OnFault(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, depth int, err error) {
+	if len(t.frames) == 0 {
+		return
+	}
+	t.frames[len(t.frames)-1].pending = nil
+}
+
+// finish fills in the effects of the pending instruction of the frame. The
+// scope is nil if the frame has already been exited.
+func (t *vmTracer) finish(frame *vmTraceFrame, scope tracing.OpContext, gas uint64) {
+	if frame.pending == nil {
+		return
+	}
+	ex := &vmTraceEx{Push: []*hexutil.U256{}, Store: frame.store, Used: gas}
+	if scope != nil {
+		var (
+			stack       = scope.StackData()
+			pops, limit = t.table[frame.op].Stack()
+			pushes      = int(params.StackLimit) + pops - limit
+		)
+		if pushes > len(stack) {
+			pushes = len(stack)
+		}
+		for i := len(stack) - pushes; i < len(stack); i++ {
+			ex.Push = append(ex.Push, (*hexutil.U256)(new(uint256.Int).Set(&stack[i])))
+		}
+		if frame.memSize > 0 {
+			if mem := scope.MemoryData(); frame.memOff+frame.memSize <= uint64(len(mem)) {
+				ex.Mem = &vmTraceMem{
+					Data: common.CopyBytes(mem[frame.memOff : frame.memOff+frame.memSize]),
+					Off:  frame.memOff,
+				}
+			}
+		}
+	}
+	frame.pending.Ex = ex
+	frame.pending = nil
+}
+
+// setMem records a memory write of a fixed size at the offset found at the
+// given stack position.
+func (f *vmTraceFrame) setMem(stack []uint256.Int, off int, size uint64) {
+	if len(stack) <= off || !stack[len(stack)-1-off].IsUint64() {
+		return
+	}
+	f.memOff, f.memSize = stack[len(stack)-1-off].Uint64(), size
+}
+
+// setMemRange records a memory write with both the offset and the size found
+// on the stack.
+func (f *vmTraceFrame) setMemRange(stack []uint256.Int, off, size int) {
+	if len(stack) <= size || !stack[len(stack)-1-size].IsUint64() {
+		return
+	}
+	f.setMem(stack, off, stack[len(stack)-1-size].Uint64())
+}
+
+// GetResult returns the vmTrace of the transaction.
+func (t *vmTracer) GetResult() (json.RawMessage, error) {
+	root := t.root
+	if root == nil {
+		root = &vmTrace{Code: []byte{}, Ops: []*vmTraceOp{}}
+	}
+	res, err := json.Marshal(root)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *vmTracer) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
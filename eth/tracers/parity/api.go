@@ -0,0 +1,431 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package parity implements the OpenEthereum (formerly Parity) compatible trace
+// RPC namespace on top of the tracers API.
+package parity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// maxFilterBlocks is the maximum number of blocks a single trace_filter
+	// request is allowed to trace.
+	maxFilterBlocks = 1000
+)
+
+// The names of the replay trace types.
+const (
+	traceModeTrace     = "trace"
+	traceModeStateDiff = "stateDiff"
+	traceModeVMTrace   = "vmTrace"
+)
+
+var errNoTraceModes = errors.New("no trace types specified")
+
+// API is the collection of the trace RPC methods.
+type API struct {
+	backend tracers.Backend
+	tracers *tracers.API
+}
+
+// NewAPI creates a new trace API instance.
+func NewAPI(backend tracers.Backend) *API {
+	return &API{backend: backend, tracers: tracers.NewAPI(backend)}
+}
+
+// APIs return the collection of RPC services the parity package offers.
+func APIs(backend tracers.Backend) []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "trace",
+			Service:   NewAPI(backend),
+		},
+	}
+}
+
+// ReplayTrace is a call trace within the result of a transaction replay. It
+// omits the block and transaction fields of the flat call trace.
+type ReplayTrace struct {
+	Action       json.RawMessage `json:"action"`
+	Error        string          `json:"error,omitempty"`
+	Result       json.RawMessage `json:"result,omitempty"`
+	Subtraces    int             `json:"subtraces"`
+	TraceAddress []int           `json:"traceAddress"`
+	Type         string          `json:"type"`
+}
+
+// TraceResults is the result of replaying a transaction. The trace, state diff
+// and vm trace are only filled in if the corresponding trace type is requested.
+type TraceResults struct {
+	Output          hexutil.Bytes   `json:"output"`
+	StateDiff       json.RawMessage `json:"stateDiff"`
+	Trace           []*ReplayTrace  `json:"trace"`
+	VMTrace         json.RawMessage `json:"vmTrace"`
+	TransactionHash *common.Hash    `json:"transactionHash,omitempty"`
+}
+
+// FilterArgs are the criteria of a trace_filter request. Traces match if their
+// sender is within FromAddress and their recipient within ToAddress, an empty
+// list matching any address.
+type FilterArgs struct {
+	FromBlock   *rpc.BlockNumber `json:"fromBlock"`
+	ToBlock     *rpc.BlockNumber `json:"toBlock"`
+	FromAddress []common.Address `json:"fromAddress"`
+	ToAddress   []common.Address `json:"toAddress"`
+	After       *uint64          `json:"after"`
+	Count       *uint64          `json:"count"`
+}
+
+// flatConfig is the trace config producing flat call traces.
+func flatConfig() *tracers.TraceConfig {
+	name := "flatCallTracer"
+	return &tracers.TraceConfig{Tracer: &name, TracerConfig: json.RawMessage(`{"convertParityErrors":true}`)}
+}
+
+// replayConfig returns the trace config producing the results of the given
+// trace types, executing the required tracers at once.
+func replayConfig(modes []string) (*tracers.TraceConfig, error) {
+	if len(modes) == 0 {
+		return nil, errNoTraceModes
+	}
+	config := map[string]json.RawMessage{
+		"flatCallTracer": json.RawMessage(`{"convertParityErrors":true}`),
+		"callTracer":     json.RawMessage(`{"onlyTopCall":true}`),
+	}
+	for _, mode := range modes {
+		switch mode {
+		case traceModeTrace:
+		case traceModeStateDiff:
+			config["stateDiffTracer"] = json.RawMessage(`{}`)
+		case traceModeVMTrace:
+			config["vmTracer"] = json.RawMessage(`{}`)
+		default:
+			return nil, fmt.Errorf("unknown trace type %q", mode)
+		}
+	}
+	blob, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	name := "muxTracer"
+	return &tracers.TraceConfig{Tracer: &name, TracerConfig: blob}, nil
+}
+
+// replayResults assembles the replay result from the output of the tracers
+// configured by replayConfig.
+func replayResults(result interface{}, modes []string) (*TraceResults, error) {
+	blob, ok := result.(json.RawMessage)
+	if !ok {
+		return nil, fmt.Errorf("unexpected trace result %T", result)
+	}
+	var outputs struct {
+		Flat      json.RawMessage `json:"flatCallTracer"`
+		Call      json.RawMessage `json:"callTracer"`
+		StateDiff json.RawMessage `json:"stateDiffTracer"`
+		VMTrace   json.RawMessage `json:"vmTracer"`
+	}
+	if err := json.Unmarshal(blob, &outputs); err != nil {
+		return nil, err
+	}
+	var call struct {
+		Output hexutil.Bytes `json:"output"`
+	}
+	if err := json.Unmarshal(outputs.Call, &call); err != nil {
+		return nil, err
+	}
+	res := &TraceResults{Output: call.Output, Trace: []*ReplayTrace{}}
+	if res.Output == nil {
+		res.Output = hexutil.Bytes{}
+	}
+	if slices.Contains(modes, traceModeTrace) {
+		if err := json.Unmarshal(outputs.Flat, &res.Trace); err != nil {
+			return nil, err
+		}
+	}
+	if slices.Contains(modes, traceModeStateDiff) {
+		res.StateDiff = outputs.StateDiff
+	}
+	if slices.Contains(modes, traceModeVMTrace) {
+		res.VMTrace = outputs.VMTrace
+	}
+	return res, nil
+}
+
+// Block returns the flat call traces of all the transactions in the block.
+func (api *API) Block(ctx context.Context, number rpc.BlockNumber) ([]json.RawMessage, error) {
+	results, err := api.tracers.TraceBlockByNumber(ctx, number, flatConfig())
+	if err != nil {
+		return nil, err
+	}
+	traces := []json.RawMessage{}
+	for _, result := range results {
+		if result.Error != "" {
+			return nil, fmt.Errorf("tracing transaction %x failed: %s", result.TxHash, result.Error)
+		}
+		var frames []json.RawMessage
+		if err := json.Unmarshal(result.Result.(json.RawMessage), &frames); err != nil {
+			return nil, err
+		}
+		traces = append(traces, frames...)
+	}
+	return traces, nil
+}
+
+// Transaction returns the flat call traces of the transaction.
+func (api *API) Transaction(ctx context.Context, hash common.Hash) (interface{}, error) {
+	return api.tracers.TraceTransaction(ctx, hash, flatConfig())
+}
+
+// ReplayTransaction replays the transaction, returning the requested trace
+// types out of trace, stateDiff and vmTrace.
+func (api *API) ReplayTransaction(ctx context.Context, hash common.Hash, modes []string) (*TraceResults, error) {
+	config, err := replayConfig(modes)
+	if err != nil {
+		return nil, err
+	}
+	result, err := api.tracers.TraceTransaction(ctx, hash, config)
+	if err != nil {
+		return nil, err
+	}
+	return replayResults(result, modes)
+}
+
+// ReplayBlockTransactions replays all the transactions in the block, returning
+// the requested trace types out of trace, stateDiff and vmTrace.
+func (api *API) ReplayBlockTransactions(ctx context.Context, number rpc.BlockNumber, modes []string) ([]*TraceResults, error) {
+	config, err := replayConfig(modes)
+	if err != nil {
+		return nil, err
+	}
+	results, err := api.tracers.TraceBlockByNumber(ctx, number, config)
+	if err != nil {
+		return nil, err
+	}
+	replays := make([]*TraceResults, len(results))
+	for i, result := range results {
+		if result.Error != "" {
+			return nil, fmt.Errorf("tracing transaction %x failed: %s", result.TxHash, result.Error)
+		}
+		if replays[i], err = replayResults(result.Result, modes); err != nil {
+			return nil, err
+		}
+		hash := result.TxHash
+		replays[i].TransactionHash = &hash
+	}
+	return replays, nil
+}
+
+// Call executes the call on top of the given block, defaulting to the latest
+// one, returning the requested trace types out of trace, stateDiff and vmTrace.
+func (api *API) Call(ctx context.Context, args ethapi.TransactionArgs, modes []string, blockNrOrHash *rpc.BlockNumberOrHash) (*TraceResults, error) {
+	config, err := replayConfig(modes)
+	if err != nil {
+		return nil, err
+	}
+	if blockNrOrHash == nil {
+		latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		blockNrOrHash = &latest
+	}
+	result, err := api.tracers.TraceCall(ctx, args, *blockNrOrHash, &tracers.TraceCallConfig{TraceConfig: *config})
+	if err != nil {
+		return nil, err
+	}
+	return replayResults(result, modes)
+}
+
+// Filter returns the flat call traces within the block range matching the
+// sender and recipient criteria. The trace address index is used to skip the
+// blocks without any matching call if it is available.
+func (api *API) Filter(ctx context.Context, args FilterArgs) ([]json.RawMessage, error) {
+	from, err := api.resolveNumber(ctx, args.FromBlock)
+	if err != nil {
+		return nil, err
+	}
+	to, err := api.resolveNumber(ctx, args.ToBlock)
+	if err != nil {
+		return nil, err
+	}
+	if from > to {
+		return nil, fmt.Errorf("invalid block range %d-%d", from, to)
+	}
+	// The genesis block is not traceable and holds no calls
+	from = max(from, 1)
+	if from > to {
+		return []json.RawMessage{}, nil
+	}
+	blocks := api.filterBlocks(from, to, args.FromAddress, args.ToAddress)
+	if len(blocks) > maxFilterBlocks {
+		return nil, fmt.Errorf("too many blocks to trace: %d, limit %d", len(blocks), maxFilterBlocks)
+	}
+	var (
+		traces  = []json.RawMessage{}
+		skipped uint64
+	)
+	for _, number := range blocks {
+		frames, err := api.Block(ctx, rpc.BlockNumber(number))
+		if err != nil {
+			return nil, err
+		}
+		for _, frame := range frames {
+			sender, recipient, err := frameAddresses(frame)
+			if err != nil {
+				return nil, err
+			}
+			if !matchAddress(args.FromAddress, sender) || !matchAddress(args.ToAddress, recipient) {
+				continue
+			}
+			if args.After != nil && skipped < *args.After {
+				skipped++
+				continue
+			}
+			traces = append(traces, frame)
+			if args.Count != nil && uint64(len(traces)) >= *args.Count {
+				return traces, nil
+			}
+		}
+	}
+	return traces, nil
+}
+
+// resolveNumber converts the block number of a filter to an absolute number,
+// defaulting to the latest block.
+func (api *API) resolveNumber(ctx context.Context, number *rpc.BlockNumber) (uint64, error) {
+	if number != nil && *number >= 0 {
+		return uint64(*number), nil
+	}
+	n := rpc.LatestBlockNumber
+	if number != nil {
+		n = *number
+	}
+	header, err := api.backend.HeaderByNumber(ctx, n)
+	if err != nil {
+		return 0, err
+	}
+	if header == nil {
+		return 0, fmt.Errorf("block %v not found", n)
+	}
+	return header.Number.Uint64(), nil
+}
+
+// filterBlocks returns the blocks within [from, to] which may hold calls
+// matching the addresses. Blocks outside of the indexed range are always
+// included, as are all blocks if no address is given.
+func (api *API) filterBlocks(from, to uint64, senders, recipients []common.Address) []uint64 {
+	tail, head, ok := rawdb.ReadTraceIndexRange(api.backend.ChainDb())
+	if !ok || (len(senders) == 0 && len(recipients) == 0) || tail > to || head < from {
+		return blockRange(from, to)
+	}
+	var blocks []uint64
+	if from < tail {
+		blocks = append(blocks, blockRange(from, tail-1)...)
+	}
+	var (
+		db      = api.backend.ChainDb()
+		start   = max(from, tail)
+		end     = min(to, head)
+		indexed []uint64
+	)
+	lookup := func(addrs []common.Address, role byte) []uint64 {
+		var numbers []uint64
+		for _, addr := range addrs {
+			numbers = append(numbers, rawdb.ReadTraceAddressBlocks(db, addr, start, end, role)...)
+		}
+		slices.Sort(numbers)
+		return slices.Compact(numbers)
+	}
+	switch {
+	case len(recipients) == 0:
+		indexed = lookup(senders, rawdb.TraceAddressFrom)
+	case len(senders) == 0:
+		indexed = lookup(recipients, rawdb.TraceAddressTo)
+	default:
+		sent := lookup(senders, rawdb.TraceAddressFrom)
+		for _, number := range lookup(recipients, rawdb.TraceAddressTo) {
+			if _, found := slices.BinarySearch(sent, number); found {
+				indexed = append(indexed, number)
+			}
+		}
+	}
+	blocks = append(blocks, indexed...)
+	if to > head {
+		blocks = append(blocks, blockRange(head+1, to)...)
+	}
+	return blocks
+}
+
+// blockRange returns the block numbers within [from, to], truncated to one
+// above the filter limit to avoid allocating huge ranges.
+func blockRange(from, to uint64) []uint64 {
+	var numbers []uint64
+	for n := from; n <= to && len(numbers) <= maxFilterBlocks; n++ {
+		numbers = append(numbers, n)
+	}
+	return numbers
+}
+
+// matchAddress reports whether the address is in the list, an empty list
+// matching any address.
+func matchAddress(list []common.Address, addr *common.Address) bool {
+	if len(list) == 0 {
+		return true
+	}
+	return addr != nil && slices.Contains(list, *addr)
+}
+
+// frameAddresses extracts the sender and recipient of a flat call trace. The
+// recipient of a creation is the created contract, the one of a selfdestruct
+// the beneficiary.
+func frameAddresses(frame json.RawMessage) (*common.Address, *common.Address, error) {
+	var trace struct {
+		Type   string `json:"type"`
+		Action struct {
+			From          *common.Address `json:"from"`
+			To            *common.Address `json:"to"`
+			Address       *common.Address `json:"address"`
+			RefundAddress *common.Address `json:"refundAddress"`
+		} `json:"action"`
+		Result *struct {
+			Address *common.Address `json:"address"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(frame, &trace); err != nil {
+		return nil, nil, err
+	}
+	switch trace.Type {
+	case "create":
+		if trace.Result != nil {
+			return trace.Action.From, trace.Result.Address, nil
+		}
+		return trace.Action.From, nil, nil
+	case "suicide":
+		return trace.Action.Address, trace.Action.RefundAddress, nil
+	default:
+		return trace.Action.From, trace.Action.To, nil
+	}
+}
@@ -0,0 +1,340 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package parity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	_ "github.com/ethereum/go-ethereum/eth/tracers/native"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type testBackend struct {
+	chainConfig *params.ChainConfig
+	engine      consensus.Engine
+	chaindb     ethdb.Database
+	chain       *core.BlockChain
+}
+
+func newTestBackend(t *testing.T, n int, gspec *core.Genesis, generator func(i int, b *core.BlockGen)) *testBackend {
+	backend := &testBackend{
+		chainConfig: gspec.Config,
+		engine:      ethash.NewFaker(),
+		chaindb:     rawdb.NewMemoryDatabase(),
+	}
+	_, blocks, _ := core.GenerateChainWithGenesis(gspec, backend.engine, n, generator)
+
+	cacheConfig := &core.CacheConfig{
+		TrieCleanLimit:    256,
+		TrieDirtyLimit:    256,
+		TrieTimeLimit:     5 * time.Minute,
+		TrieDirtyDisabled: true, // Archive mode
+	}
+	chain, err := core.NewBlockChain(backend.chaindb, cacheConfig, gspec, nil, backend.engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	backend.chain = chain
+	return backend
+}
+
+func (b *testBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	return b.chain.GetHeaderByHash(hash), nil
+}
+
+func (b *testBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	if number == rpc.PendingBlockNumber || number == rpc.LatestBlockNumber {
+		return b.chain.CurrentHeader(), nil
+	}
+	return b.chain.GetHeaderByNumber(uint64(number)), nil
+}
+
+func (b *testBackend) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	return b.chain.GetBlockByHash(hash), nil
+}
+
+func (b *testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	if number == rpc.PendingBlockNumber || number == rpc.LatestBlockNumber {
+		return b.chain.GetBlockByNumber(b.chain.CurrentBlock().Number.Uint64()), nil
+	}
+	return b.chain.GetBlockByNumber(uint64(number)), nil
+}
+
+func (b *testBackend) GetTransaction(ctx context.Context, txHash common.Hash) (bool, *types.Transaction, common.Hash, uint64, uint64, error) {
+	tx, hash, blockNumber, index := rawdb.ReadTransaction(b.chaindb, txHash)
+	return tx != nil, tx, hash, blockNumber, index, nil
+}
+
+func (b *testBackend) RPCGasCap() uint64                { return 25000000 }
+func (b *testBackend) RPCTraceSizeCap() uint64          { return 0 }
+func (b *testBackend) ChainConfig() *params.ChainConfig { return b.chainConfig }
+func (b *testBackend) Engine() consensus.Engine         { return b.engine }
+func (b *testBackend) ChainDb() ethdb.Database          { return b.chaindb }
+func (b *testBackend) teardown()                        { b.chain.Stop() }
+func (b *testBackend) release()                         {}
+
+func (b *testBackend) StateAtBlock(ctx context.Context, block *types.Block, reexec uint64, base *state.StateDB, readOnly bool, preferDisk bool) (*state.StateDB, tracers.StateReleaseFunc, error) {
+	statedb, err := b.chain.StateAt(block.Root())
+	if err != nil {
+		return nil, nil, err
+	}
+	return statedb, b.release, nil
+}
+
+func (b *testBackend) StateAtTransaction(ctx context.Context, block *types.Block, txIndex int, reexec uint64) (*types.Transaction, vm.BlockContext, *state.StateDB, tracers.StateReleaseFunc, error) {
+	parent := b.chain.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, vm.BlockContext{}, nil, nil, errors.New("parent not found")
+	}
+	statedb, err := b.chain.StateAt(parent.Root())
+	if err != nil {
+		return nil, vm.BlockContext{}, nil, nil, err
+	}
+	signer := types.MakeSigner(b.chainConfig, block.Number(), block.Time())
+	for idx, tx := range block.Transactions() {
+		msg, _ := core.TransactionToMessage(tx, signer, block.BaseFee())
+		context := core.NewEVMBlockContext(block.Header(), b.chain, nil)
+		if idx == txIndex {
+			return tx, context, statedb, b.release, nil
+		}
+		vmenv := vm.NewEVM(context, core.NewEVMTxContext(msg), statedb, b.chainConfig, vm.Config{})
+		if _, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(tx.Gas())); err != nil {
+			return nil, vm.BlockContext{}, nil, nil, err
+		}
+		statedb.Finalise(vmenv.ChainConfig().IsEIP158(block.Number()))
+	}
+	return nil, vm.BlockContext{}, nil, nil, errors.New("transaction index out of range")
+}
+
+// newTraceChain creates a chain of three blocks: calls to a contract which sets
+// a storage slot and calls a second account in the first and third blocks, and
+// a plain transfer in the second block.
+func newTraceChain(t *testing.T) (*testBackend, common.Address, common.Address, []common.Hash) {
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender   = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.HexToAddress("0xc0de")
+		callee   = common.HexToAddress("0xca11ee")
+		other    = common.HexToAddress("0x0fe4")
+		code     = append([]byte{
+			byte(vm.PUSH1), 0x01, byte(vm.PUSH1), 0x00, byte(vm.SSTORE),
+			byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00,
+			byte(vm.PUSH20)}, append(callee.Bytes(), byte(vm.GAS), byte(vm.CALL), byte(vm.STOP))...)
+		genesis = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				sender:   {Balance: big.NewInt(params.Ether)},
+				contract: {Code: code},
+			},
+		}
+		signer = types.HomesteadSigner{}
+		hashes []common.Hash
+	)
+	backend := newTestBackend(t, 3, genesis, func(i int, b *core.BlockGen) {
+		to, gas := contract, uint64(100000)
+		if i == 1 {
+			to, gas = other, params.TxGas
+		}
+		tx, _ := types.SignTx(types.NewTx(&types.LegacyTx{
+			Nonce:    uint64(i),
+			To:       &to,
+			Value:    big.NewInt(1),
+			Gas:      gas,
+			GasPrice: b.BaseFee(),
+		}), signer, key)
+		b.AddTx(tx)
+		hashes = append(hashes, tx.Hash())
+	})
+	return backend, contract, callee, hashes
+}
+
+func TestReplayTransaction(t *testing.T) {
+	t.Parallel()
+
+	backend, contract, _, hashes := newTraceChain(t)
+	defer backend.teardown()
+	api := NewAPI(backend)
+
+	res, err := api.ReplayTransaction(context.Background(), hashes[0], []string{"trace", "stateDiff", "vmTrace"})
+	if err != nil {
+		t.Fatalf("failed to replay transaction: %v", err)
+	}
+	if len(res.Trace) != 2 {
+		t.Fatalf("trace count mismatch: have %d, want 2", len(res.Trace))
+	}
+	if res.Trace[0].Subtraces != 1 || !slices.Equal(res.Trace[1].TraceAddress, []int{0}) {
+		t.Errorf("unexpected trace structure: %+v %+v", res.Trace[0], res.Trace[1])
+	}
+	var diff map[common.Address]struct {
+		Balance json.RawMessage                 `json:"balance"`
+		Code    json.RawMessage                 `json:"code"`
+		Storage map[common.Hash]json.RawMessage `json:"storage"`
+	}
+	if err := json.Unmarshal(res.StateDiff, &diff); err != nil {
+		t.Fatalf("failed to decode state diff: %v", err)
+	}
+	want := `{"*":{"from":"0x0000000000000000000000000000000000000000000000000000000000000000","to":"0x0000000000000000000000000000000000000000000000000000000000000001"}}`
+	if have := string(diff[contract].Storage[common.Hash{}]); have != want {
+		t.Errorf("storage diff mismatch: have %s, want %s", have, want)
+	}
+	if have := string(diff[contract].Code); have != `"="` {
+		t.Errorf("code diff mismatch: have %s, want \"=\"", have)
+	}
+	var trace struct {
+		Ops []struct {
+			Pc uint64 `json:"pc"`
+			Ex *struct {
+				Push  []string `json:"push"`
+				Store *struct {
+					Key string `json:"key"`
+					Val string `json:"val"`
+				} `json:"store"`
+			} `json:"ex"`
+			Sub json.RawMessage `json:"sub"`
+		} `json:"ops"`
+	}
+	if err := json.Unmarshal(res.VMTrace, &trace); err != nil {
+		t.Fatalf("failed to decode vm trace: %v", err)
+	}
+	if len(trace.Ops) != 12 {
+		t.Fatalf("op count mismatch: have %d, want 12", len(trace.Ops))
+	}
+	if push := trace.Ops[0].Ex.Push; !slices.Equal(push, []string{"0x1"}) {
+		t.Errorf("push mismatch: have %v, want [0x1]", push)
+	}
+	if store := trace.Ops[2].Ex.Store; store == nil || store.Key != "0x0" || store.Val != "0x1" {
+		t.Errorf("store mismatch: have %+v", store)
+	}
+	if call := trace.Ops[10]; call.Pc != 37 || !slices.Equal(call.Ex.Push, []string{"0x1"}) || string(call.Sub) != "null" {
+		t.Errorf("call mismatch: pc %d, push %v, sub %s", call.Pc, call.Ex.Push, call.Sub)
+	}
+	// Unrequested trace types must be left empty
+	res, err = api.ReplayTransaction(context.Background(), hashes[0], []string{"stateDiff"})
+	if err != nil {
+		t.Fatalf("failed to replay transaction: %v", err)
+	}
+	if len(res.Trace) != 0 || res.VMTrace != nil || res.StateDiff == nil {
+		t.Errorf("unexpected trace types in result: %+v", res)
+	}
+	if _, err := api.ReplayTransaction(context.Background(), hashes[0], []string{"foo"}); err == nil {
+		t.Error("expected error for unknown trace type")
+	}
+}
+
+func TestTraceCall(t *testing.T) {
+	t.Parallel()
+
+	backend, contract, _, _ := newTraceChain(t)
+	defer backend.teardown()
+	api := NewAPI(backend)
+
+	res, err := api.Call(context.Background(), ethapi.TransactionArgs{To: &contract}, []string{"trace"}, nil)
+	if err != nil {
+		t.Fatalf("failed to trace call: %v", err)
+	}
+	if len(res.Trace) != 2 || res.Trace[0].Type != "call" {
+		t.Errorf("unexpected call trace: %+v", res.Trace)
+	}
+}
+
+func TestTraceFilter(t *testing.T) {
+	t.Parallel()
+
+	backend, contract, callee, _ := newTraceChain(t)
+	defer backend.teardown()
+	api := NewAPI(backend)
+
+	block, err := api.Block(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("failed to trace block: %v", err)
+	}
+	if len(block) != 1 {
+		t.Fatalf("block trace count mismatch: have %d, want 1", len(block))
+	}
+	// Index the chain and check the index narrows down the traced blocks
+	indexer := NewIndexer(backend, backend.chain)
+	for _, n := range []uint64{1, 3} { // Block 2 is indexed as a missed block
+		indexer.enqueue(n)
+		for indexer.process() {
+		}
+	}
+	if tail, head, ok := rawdb.ReadTraceIndexRange(backend.chaindb); !ok || tail != 1 || head != 3 {
+		t.Fatalf("index range mismatch: have [%d, %d] %v, want [1, 3]", tail, head, ok)
+	}
+	if blocks := api.filterBlocks(1, 3, nil, []common.Address{callee}); !slices.Equal(blocks, []uint64{1, 3}) {
+		t.Errorf("indexed blocks mismatch: have %v, want [1 3]", blocks)
+	}
+	if blocks := api.filterBlocks(1, 5, []common.Address{contract}, nil); !slices.Equal(blocks, []uint64{1, 3, 4, 5}) {
+		t.Errorf("indexed blocks mismatch: have %v, want [1 3 4 5]", blocks)
+	}
+	var (
+		from  = rpc.BlockNumber(0)
+		to    = rpc.LatestBlockNumber
+		one   = uint64(1)
+		tests = []struct {
+			args   FilterArgs
+			blocks []uint64
+		}{
+			{FilterArgs{FromBlock: &from, ToBlock: &to}, []uint64{1, 1, 2, 3, 3}},
+			{FilterArgs{FromBlock: &from, ToBlock: &to, ToAddress: []common.Address{callee}}, []uint64{1, 3}},
+			{FilterArgs{FromBlock: &from, ToBlock: &to, FromAddress: []common.Address{contract}, ToAddress: []common.Address{callee}}, []uint64{1, 3}},
+			{FilterArgs{FromBlock: &from, ToBlock: &to, ToAddress: []common.Address{callee}, After: &one, Count: &one}, []uint64{3}},
+			{FilterArgs{FromBlock: &from, ToBlock: &to, FromAddress: []common.Address{callee}}, nil},
+		}
+	)
+	for i, tt := range tests {
+		traces, err := api.Filter(context.Background(), tt.args)
+		if err != nil {
+			t.Fatalf("test %d: failed to filter traces: %v", i, err)
+		}
+		var blocks []uint64
+		for _, trace := range traces {
+			var frame struct {
+				BlockNumber uint64 `json:"blockNumber"`
+			}
+			if err := json.Unmarshal(trace, &frame); err != nil {
+				t.Fatalf("test %d: failed to decode trace: %v", i, err)
+			}
+			blocks = append(blocks, frame.BlockNumber)
+		}
+		if !slices.Equal(blocks, tt.blocks) {
+			t.Errorf("test %d: trace blocks mismatch: have %v, want %v", i, blocks, tt.blocks)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package parity
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// chainEvents is the source of the imported blocks to index.
+type chainEvents interface {
+	SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription
+}
+
+// indexBatchSize is the number of blocks indexed before checking for newly
+// imported blocks and termination.
+const indexBatchSize = 64
+
+// Indexer maintains the trace address index used by trace_filter, tracing every
+// imported block and recording the senders and recipients of its calls.
+//
+// Tracing happens in the background, the chain events only record the range of
+// blocks to index, so that block import is never held up by the indexer.
+//
+// The index is keyed by block number, so the entries of blocks reorged out of
+// the canonical chain are kept. They only cause additional blocks to be traced
+// by the filter, which always checks the canonical traces.
+type Indexer struct {
+	api    *API
+	chain  chainEvents
+	wake   chan struct{}
+	closed chan struct{}
+	wg     sync.WaitGroup
+
+	lock    sync.Mutex
+	pending bool   // Whether there are imported blocks left to index
+	rewound bool   // Whether a block below the head was imported during a batch
+	low     uint64 // Lowest imported block not indexed yet
+	high    uint64 // Latest imported block, the head of the chain
+}
+
+// NewIndexer creates the trace address indexer of the chain.
+func NewIndexer(backend tracers.Backend, chain chainEvents) *Indexer {
+	return &Indexer{
+		api:    NewAPI(backend),
+		chain:  chain,
+		wake:   make(chan struct{}, 1),
+		closed: make(chan struct{}),
+	}
+}
+
+// Start implements node.Lifecycle, starting the indexing of new blocks.
+func (idx *Indexer) Start() error {
+	events := make(chan core.ChainEvent, 128)
+	sub := idx.chain.SubscribeChainEvent(events)
+
+	idx.wg.Add(2)
+	go func() {
+		defer idx.wg.Done()
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-events:
+				idx.enqueue(ev.Block.NumberU64())
+			case <-sub.Err():
+				return
+			case <-idx.closed:
+				return
+			}
+		}
+	}()
+	go func() {
+		defer idx.wg.Done()
+
+		for {
+			select {
+			case <-idx.wake:
+				for idx.process() {
+					select {
+					case <-idx.closed:
+						return
+					default:
+					}
+				}
+			case <-idx.closed:
+				return
+			}
+		}
+	}()
+	log.Info("Started trace address indexer")
+	return nil
+}
+
+// Stop implements node.Lifecycle, terminating the indexing.
+func (idx *Indexer) Stop() error {
+	close(idx.closed)
+	idx.wg.Wait()
+	return nil
+}
+
+// enqueue records an imported block as the new head to index up to, waking up
+// the indexing if it's idle.
+func (idx *Indexer) enqueue(number uint64) {
+	idx.lock.Lock()
+	if idx.pending && number <= idx.high {
+		idx.rewound = true
+	}
+	if !idx.pending || number < idx.low {
+		idx.low = number
+	}
+	idx.high, idx.pending = number, true
+	idx.lock.Unlock()
+
+	select {
+	case idx.wake <- struct{}{}:
+	default:
+	}
+}
+
+// process indexes the next batch of imported blocks, returning whether there
+// are more blocks left. Blocks missed since the last indexed one are indexed
+// first, restarting the index from the head if they cannot be traced anymore.
+func (idx *Indexer) process() bool {
+	idx.lock.Lock()
+	low, high, pending := idx.low, idx.high, idx.pending
+	idx.rewound = false
+	idx.lock.Unlock()
+
+	if !pending {
+		return false
+	}
+	var (
+		db   = idx.api.backend.ChainDb()
+		from = low
+	)
+	tail, head, ok := rawdb.ReadTraceIndexRange(db)
+	switch {
+	case !ok:
+		tail, from = high, high
+	case from > head+1:
+		from = head + 1
+	case from < tail:
+		tail = from
+	}
+	last := min(from+indexBatchSize-1, high)
+	for n := from; n <= last; n++ {
+		if err := idx.indexBlock(n); err != nil {
+			if n == high {
+				log.Error("Failed to index block traces", "number", n, "err", err)
+				break
+			}
+			log.Warn("Restarting trace address index", "number", high, "missing", n, "err", err)
+			tail, n, last = high, high-1, high
+			continue
+		}
+		rawdb.WriteTraceIndexRange(db, tail, n)
+	}
+	// Mark the batch done, unless a reorg requested reindexing it
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+
+	if !idx.rewound {
+		idx.low = last + 1
+	}
+	if idx.low > idx.high {
+		idx.pending = false
+	}
+	return idx.pending
+}
+
+// indexBlock traces the canonical block with the given number and records the
+// addresses of its calls.
+func (idx *Indexer) indexBlock(number uint64) error {
+	frames, err := idx.api.Block(context.Background(), rpc.BlockNumber(number))
+	if err != nil {
+		return err
+	}
+	roles := make(map[common.Address]byte)
+	for _, frame := range frames {
+		sender, recipient, err := frameAddresses(frame)
+		if err != nil {
+			return err
+		}
+		if sender != nil {
+			roles[*sender] |= rawdb.TraceAddressFrom
+		}
+		if recipient != nil {
+			roles[*recipient] |= rawdb.TraceAddressTo
+		}
+	}
+	var (
+		db    = idx.api.backend.ChainDb()
+		batch = db.NewBatch()
+	)
+	for addr, role := range roles {
+		// Merge with the entry of a previously indexed block at the same height
+		role |= rawdb.ReadTraceAddressEntry(db, addr, number)
+		rawdb.WriteTraceAddressEntry(batch, addr, number, role)
+	}
+	return batch.Write()
+}
@@ -0,0 +1,497 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Block edit operations supported by ReplayBlock.
+const (
+	BlockEditRemove = "remove" // Remove the transaction with the given hash
+	BlockEditInsert = "insert" // Insert a raw transaction at the given index
+	BlockEditMove   = "move"   // Move the transaction with the given hash to the given index
+)
+
+// BlockEdit is a modification of the transaction list of a replayed block.
+// Edits are applied in order, each on the list resulting from the previous ones.
+type BlockEdit struct {
+	Op    string        `json:"op"`
+	Hash  *common.Hash  `json:"hash,omitempty"`  // Transaction to remove or move
+	Raw   hexutil.Bytes `json:"raw,omitempty"`   // Binary encoded transaction to insert
+	Index *int          `json:"index,omitempty"` // Target position, defaulting to the end of the list
+}
+
+// ReplayBlockConfig holds the modifications of a replayed block, along with the
+// tracer to run on its transactions. No tracer is run if neither a tracer nor
+// a struct logger config is given.
+type ReplayBlockConfig struct {
+	TraceConfig
+	Edits          []BlockEdit
+	BlockOverrides *ethapi.BlockOverrides
+}
+
+// ReplayBlockResult is the outcome of a replayed block.
+type ReplayBlockResult struct {
+	Transactions []*ReplayTxResult                     `json:"transactions"`
+	GasUsed      hexutil.Uint64                        `json:"gasUsed"`
+	StateRoot    common.Hash                           `json:"stateRoot"`
+	StateDiff    map[common.Address]*ReplayAccountDiff `json:"stateDiff"`
+}
+
+// ReplayTxResult is the outcome of a transaction of a replayed block. Invalid
+// transactions are skipped and only report an error.
+type ReplayTxResult struct {
+	TxHash  common.Hash     `json:"txHash"`
+	Receipt *types.Receipt  `json:"receipt,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// ReplayDiff is a value which differs between the canonical and the replayed
+// block.
+type ReplayDiff[T any] struct {
+	Canonical T `json:"canonical"`
+	Replay    T `json:"replay"`
+}
+
+// ReplayAccountDiff holds the fields of an account which differ between the
+// canonical and the replayed block.
+type ReplayAccountDiff struct {
+	Balance  *ReplayDiff[*hexutil.Big]                `json:"balance,omitempty"`
+	Nonce    *ReplayDiff[hexutil.Uint64]              `json:"nonce,omitempty"`
+	CodeHash *ReplayDiff[common.Hash]                 `json:"codeHash,omitempty"`
+	Storage  map[common.Hash]*ReplayDiff[common.Hash] `json:"storage,omitempty"`
+}
+
+// ReplayBlock re-executes a historical block on top of its parent state, with
+// its transaction list and header fields modified as requested. It returns the
+// receipts and traces of the replayed transactions, along with the differences
+// between the resulting state and the one of the canonical block.
+//
+// Note the receipts and logs of the replayed transactions still reference the
+// hash of the canonical block.
+func (api *API) ReplayBlock(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, config *ReplayBlockConfig) (*ReplayBlockResult, error) {
+	if config == nil {
+		config = new(ReplayBlockConfig)
+	}
+	var (
+		block *types.Block
+		err   error
+	)
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		block, err = api.blockByHash(ctx, hash)
+	} else if number, ok := blockNrOrHash.Number(); ok {
+		if number == rpc.PendingBlockNumber {
+			return nil, errors.New("replaying pending is not supported")
+		}
+		block, err = api.blockByNumber(ctx, number)
+	} else {
+		return nil, errors.New("invalid arguments; neither block nor hash specified")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if block.NumberU64() == 0 {
+		return nil, errors.New("genesis is not traceable")
+	}
+	txs, err := applyBlockEdits(block.Transactions(), config.Edits)
+	if err != nil {
+		return nil, err
+	}
+	// Prepare base state
+	parent, err := api.blockByNumberAndHash(ctx, rpc.BlockNumber(block.NumberU64()-1), block.ParentHash())
+	if err != nil {
+		return nil, err
+	}
+	reexec := defaultTraceReexec
+	if config.Reexec != nil {
+		reexec = *config.Reexec
+	}
+	statedb, release, err := api.backend.StateAtBlock(ctx, parent, reexec, nil, true, false)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// Execute the canonical block first to know which state it modifies
+	var (
+		canonical        = statedb.Copy()
+		canonicalTouches = make(stateTouches)
+	)
+	if _, _, err := api.replayTransactions(ctx, block, block.Header(), nil, block.Transactions(), canonical, canonicalTouches, nil); err != nil {
+		return nil, err
+	}
+	// Execute the modified block, tracing it if requested
+	var (
+		header  = overrideHeader(block.Header(), config.BlockOverrides)
+		touches = make(stateTouches)
+		trace   *TraceConfig
+	)
+	if config.Tracer != nil || config.Config != nil {
+		trace = &config.TraceConfig
+	}
+	results, gasUsed, err := api.replayTransactions(ctx, block, header, config.BlockOverrides, txs, statedb, touches, trace)
+	if err != nil {
+		return nil, err
+	}
+	eip158 := api.backend.ChainConfig().IsEIP158(header.Number)
+	return &ReplayBlockResult{
+		Transactions: results,
+		GasUsed:      hexutil.Uint64(gasUsed),
+		StateRoot:    statedb.IntermediateRoot(eip158),
+		StateDiff:    replayStateDiff(canonical, statedb, canonicalTouches, touches),
+	}, nil
+}
+
+// applyBlockEdits returns the transaction list resulting from the edits.
+func applyBlockEdits(txs types.Transactions, edits []BlockEdit) (types.Transactions, error) {
+	txs = append(types.Transactions{}, txs...)
+
+	find := func(i int, edit *BlockEdit) (int, error) {
+		if edit.Hash == nil {
+			return 0, fmt.Errorf("edit %d: missing transaction hash", i)
+		}
+		for j, tx := range txs {
+			if tx.Hash() == *edit.Hash {
+				return j, nil
+			}
+		}
+		return 0, fmt.Errorf("edit %d: transaction %s not found", i, edit.Hash.Hex())
+	}
+	insert := func(i int, edit *BlockEdit, tx *types.Transaction) error {
+		index := len(txs)
+		if edit.Index != nil {
+			index = *edit.Index
+		}
+		if index < 0 || index > len(txs) {
+			return fmt.Errorf("edit %d: index %d out of range", i, index)
+		}
+		txs = append(txs[:index], append(types.Transactions{tx}, txs[index:]...)...)
+		return nil
+	}
+	for i := range edits {
+		edit := &edits[i]
+		switch edit.Op {
+		case BlockEditRemove:
+			j, err := find(i, edit)
+			if err != nil {
+				return nil, err
+			}
+			txs = append(txs[:j], txs[j+1:]...)
+
+		case BlockEditInsert:
+			tx := new(types.Transaction)
+			if err := tx.UnmarshalBinary(edit.Raw); err != nil {
+				return nil, fmt.Errorf("edit %d: invalid transaction: %v", i, err)
+			}
+			if err := insert(i, edit, tx); err != nil {
+				return nil, err
+			}
+
+		case BlockEditMove:
+			j, err := find(i, edit)
+			if err != nil {
+				return nil, err
+			}
+			tx := txs[j]
+			txs = append(txs[:j], txs[j+1:]...)
+			if err := insert(i, edit, tx); err != nil {
+				return nil, err
+			}
+
+		default:
+			return nil, fmt.Errorf("edit %d: unknown operation %q", i, edit.Op)
+		}
+	}
+	return txs, nil
+}
+
+// overrideHeader returns a copy of the header with the overridden fields set.
+func overrideHeader(header *types.Header, overrides *ethapi.BlockOverrides) *types.Header {
+	header = types.CopyHeader(header)
+	if overrides == nil {
+		return header
+	}
+	if overrides.Number != nil {
+		header.Number = overrides.Number.ToInt()
+	}
+	if overrides.Difficulty != nil {
+		header.Difficulty = overrides.Difficulty.ToInt()
+	}
+	if overrides.Time != nil {
+		header.Time = uint64(*overrides.Time)
+	}
+	if overrides.GasLimit != nil {
+		header.GasLimit = uint64(*overrides.GasLimit)
+	}
+	if overrides.Coinbase != nil {
+		header.Coinbase = *overrides.Coinbase
+	}
+	if overrides.Random != nil {
+		header.MixDigest = *overrides.Random
+	}
+	if overrides.BaseFee != nil {
+		header.BaseFee = overrides.BaseFee.ToInt()
+	}
+	return header
+}
+
+// replayTransactions executes the transactions on top of the state as the body
+// of the block with the given header and overrides, recording the modified state in touches.
+// The transactions are traced if a config is given. Invalid transactions are
+// skipped, as a block builder would do.
+func (api *API) replayTransactions(ctx context.Context, block *types.Block, header *types.Header, overrides *ethapi.BlockOverrides, txs types.Transactions, statedb *state.StateDB, touches stateTouches, config *TraceConfig) ([]*ReplayTxResult, uint64, error) {
+	var (
+		chainConfig = api.backend.ChainConfig()
+		blockHash   = block.Hash()
+		blockCtx    = core.NewEVMBlockContext(header, api.chainContext(ctx), nil)
+		signer      = types.MakeSigner(chainConfig, header.Number, header.Time)
+		gp          = new(core.GasPool).AddGas(header.GasLimit)
+		results     = make([]*ReplayTxResult, 0, len(txs))
+		budget      = api.newSizeBudget()
+		usedGas     uint64
+		included    int
+	)
+	overrides.Apply(&blockCtx)
+	statedb.SetLogger(touches.hooks(nil))
+	if beaconRoot := header.ParentBeaconRoot; beaconRoot != nil {
+		vmenv := vm.NewEVM(blockCtx, vm.TxContext{}, statedb, chainConfig, vm.Config{})
+		core.ProcessBeaconBlockRoot(*beaconRoot, vmenv, statedb)
+	}
+	for _, tx := range txs {
+		res := &ReplayTxResult{TxHash: tx.Hash()}
+		results = append(results, res)
+
+		msg, err := core.TransactionToMessage(tx, signer, header.BaseFee)
+		if err != nil {
+			res.Error = err.Error()
+			continue
+		}
+		var tracer *Tracer
+		if config != nil {
+			txctx := &Context{
+				BlockHash:   blockHash,
+				BlockNumber: header.Number,
+				TxIndex:     included,
+				TxHash:      tx.Hash(),
+			}
+			if tracer, err = api.newTracer(txctx, config, budget); err != nil {
+				return nil, 0, err
+			}
+		}
+		var hooks *tracing.Hooks
+		if tracer != nil {
+			hooks = tracer.Hooks
+		}
+		hooks = touches.hooks(hooks)
+		// The actual TxContext will be created as part of ApplyTransactionWithEVM.
+		vmenv := vm.NewEVM(blockCtx, vm.TxContext{GasPrice: msg.GasPrice, BlobFeeCap: msg.BlobGasFeeCap}, statedb, chainConfig, vm.Config{Tracer: hooks})
+		statedb.SetLogger(hooks)
+		statedb.SetTxContext(tx.Hash(), included)
+
+		receipt, err := api.replayTx(ctx, tracer, vmenv, msg, tx, header, blockHash, gp, statedb, &usedGas, config)
+		if err != nil {
+			res.Error = err.Error()
+			continue
+		}
+		included++
+		res.Receipt = receipt
+		if tracer != nil {
+			if res.Result, err = tracer.GetResult(); err != nil {
+				res.Error = err.Error()
+			}
+		}
+	}
+	// Apply the block rewards and withdrawals of the consensus engine
+	statedb.SetLogger(touches.hooks(nil))
+	chain := &replayChain{ctx: ctx, backend: api.backend}
+	api.backend.Engine().Finalize(chain, header, statedb, &types.Body{Uncles: block.Uncles(), Withdrawals: block.Withdrawals()})
+	statedb.SetLogger(nil)
+	return results, usedGas, nil
+}
+
+// replayTx applies a single transaction of a replayed block. The state and the
+// gas pool are reverted if the transaction turns out to be invalid.
+func (api *API) replayTx(ctx context.Context, tracer *Tracer, vmenv *vm.EVM, msg *core.Message, tx *types.Transaction, header *types.Header, blockHash common.Hash, gp *core.GasPool, statedb *state.StateDB, usedGas *uint64, config *TraceConfig) (*types.Receipt, error) {
+	if tracer != nil {
+		cancel, err := traceTimeout(ctx, tracer, vmenv, config)
+		if err != nil {
+			return nil, err
+		}
+		defer cancel()
+	}
+	var (
+		snap = statedb.Snapshot()
+		gas  = gp.Gas()
+	)
+	receipt, err := core.ApplyTransactionWithEVM(msg, api.backend.ChainConfig(), gp, statedb, header.Number, blockHash, tx, usedGas, vmenv)
+	if err != nil {
+		statedb.RevertToSnapshot(snap)
+		gp.SetGas(gas)
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// stateTouches records the accounts and storage slots modified by an execution.
+type stateTouches map[common.Address]map[common.Hash]struct{}
+
+func (t stateTouches) touch(addr common.Address) map[common.Hash]struct{} {
+	slots, ok := t[addr]
+	if !ok {
+		slots = make(map[common.Hash]struct{})
+		t[addr] = slots
+	}
+	return slots
+}
+
+// hooks returns the given tracing hooks, extended to record the state changes.
+func (t stateTouches) hooks(inner *tracing.Hooks) *tracing.Hooks {
+	hooks := new(tracing.Hooks)
+	if inner != nil {
+		*hooks = *inner
+	}
+	onBalance := hooks.OnBalanceChange
+	hooks.OnBalanceChange = func(addr common.Address, prev, new *big.Int, reason tracing.BalanceChangeReason) {
+		t.touch(addr)
+		if onBalance != nil {
+			onBalance(addr, prev, new, reason)
+		}
+	}
+	onNonce := hooks.OnNonceChange
+	hooks.OnNonceChange = func(addr common.Address, prev, new uint64) {
+		t.touch(addr)
+		if onNonce != nil {
+			onNonce(addr, prev, new)
+		}
+	}
+	onCode := hooks.OnCodeChange
+	hooks.OnCodeChange = func(addr common.Address, prevCodeHash common.Hash, prevCode []byte, codeHash common.Hash, code []byte) {
+		t.touch(addr)
+		if onCode != nil {
+			onCode(addr, prevCodeHash, prevCode, codeHash, code)
+		}
+	}
+	onStorage := hooks.OnStorageChange
+	hooks.OnStorageChange = func(addr common.Address, slot common.Hash, prev, new common.Hash) {
+		t.touch(addr)[slot] = struct{}{}
+		if onStorage != nil {
+			onStorage(addr, slot, prev, new)
+		}
+	}
+	return hooks
+}
+
+// replayStateDiff compares the canonical and the replayed states over the
+// accounts and slots modified by either execution.
+func replayStateDiff(canonical, replay *state.StateDB, touches ...stateTouches) map[common.Address]*ReplayAccountDiff {
+	all := make(stateTouches)
+	for _, t := range touches {
+		for addr, slots := range t {
+			merged := all.touch(addr)
+			for slot := range slots {
+				merged[slot] = struct{}{}
+			}
+		}
+	}
+	diffs := make(map[common.Address]*ReplayAccountDiff)
+	for addr, slots := range all {
+		var (
+			diff    = new(ReplayAccountDiff)
+			changed bool
+		)
+		if a, b := canonical.GetBalance(addr), replay.GetBalance(addr); !a.Eq(b) {
+			diff.Balance = &ReplayDiff[*hexutil.Big]{(*hexutil.Big)(a.ToBig()), (*hexutil.Big)(b.ToBig())}
+			changed = true
+		}
+		if a, b := canonical.GetNonce(addr), replay.GetNonce(addr); a != b {
+			diff.Nonce = &ReplayDiff[hexutil.Uint64]{hexutil.Uint64(a), hexutil.Uint64(b)}
+			changed = true
+		}
+		if a, b := canonical.GetCodeHash(addr), replay.GetCodeHash(addr); a != b {
+			diff.CodeHash = &ReplayDiff[common.Hash]{a, b}
+			changed = true
+		}
+		for slot := range slots {
+			if a, b := canonical.GetState(addr, slot), replay.GetState(addr, slot); a != b {
+				if diff.Storage == nil {
+					diff.Storage = make(map[common.Hash]*ReplayDiff[common.Hash])
+				}
+				diff.Storage[slot] = &ReplayDiff[common.Hash]{a, b}
+				changed = true
+			}
+		}
+		if changed {
+			diffs[addr] = diff
+		}
+	}
+	return diffs
+}
+
+// replayChain gives the consensus engine access to the chain while finalizing
+// a replayed block.
+type replayChain struct {
+	ctx     context.Context
+	backend Backend
+}
+
+func (c *replayChain) Config() *params.ChainConfig {
+	return c.backend.ChainConfig()
+}
+
+func (c *replayChain) CurrentHeader() *types.Header {
+	header, _ := c.backend.HeaderByNumber(c.ctx, rpc.LatestBlockNumber)
+	return header
+}
+
+func (c *replayChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	header, _ := c.backend.HeaderByHash(c.ctx, hash)
+	if header == nil || header.Number.Uint64() != number {
+		return nil
+	}
+	return header
+}
+
+func (c *replayChain) GetHeaderByNumber(number uint64) *types.Header {
+	header, _ := c.backend.HeaderByNumber(c.ctx, rpc.BlockNumber(number))
+	return header
+}
+
+func (c *replayChain) GetHeaderByHash(hash common.Hash) *types.Header {
+	header, _ := c.backend.HeaderByHash(c.ctx, hash)
+	return header
+}
+
+func (c *replayChain) GetTd(hash common.Hash, number uint64) *big.Int {
+	return nil
+}
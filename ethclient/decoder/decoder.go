@@ -0,0 +1,135 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package decoder implements ABI-aware decoding of the transactions, logs and
+// reverts returned by an Ethereum client.
+package decoder
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Backend is the chain access needed by the decoder, implemented by
+// ethclient.Client.
+type Backend interface {
+	ethereum.TransactionReader
+	ethereum.ContractCaller
+}
+
+// Transaction is a transaction along with its decoded call, events and revert.
+type Transaction struct {
+	Tx      *types.Transaction
+	From    common.Address // Sender of the transaction
+	Receipt *types.Receipt // Receipt of the transaction, nil if it's pending
+
+	Call      *Call    // Decoded method call, nil for contract creations or if unknown
+	CallErr   error    // Reason the call could not be decoded
+	Events    []*Event // Decoded receipt logs, nil entries for unknown events
+	EventErrs []error  // Reasons the receipt logs could not be decoded
+	Revert    *Revert  // Decoded revert reason of a failed transaction, if it could be reproduced
+}
+
+// Decoder retrieves chain data from a backend and decodes it with the ABIs of
+// a registry.
+type Decoder struct {
+	backend  Backend
+	registry *Registry
+}
+
+// New creates a decoder on top of the given backend.
+func New(backend Backend, registry *Registry) *Decoder {
+	return &Decoder{backend: backend, registry: registry}
+}
+
+// Registry returns the ABI registry used for decoding.
+func (d *Decoder) Registry() *Registry {
+	return d.registry
+}
+
+// Transaction retrieves a transaction along with its receipt and decodes them.
+// Decoding failures of individual parts are reported in the result, only chain
+// access failures are returned as errors.
+//
+// The revert reason of a failed transaction is not part of the receipt, it is
+// reproduced by executing the call on top of the parent block instead. This
+// doesn't account for the transactions preceding it in the same block, so the
+// reason may be missing or differ from the one of the original execution.
+func (d *Decoder) Transaction(ctx context.Context, hash common.Hash) (*Transaction, error) {
+	tx, pending, err := d.backend.TransactionByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return nil, err
+	}
+	res := &Transaction{Tx: tx, From: from}
+	if tx.To() != nil {
+		res.Call, res.CallErr = d.registry.DecodeCall(tx.To(), tx.Data())
+	}
+	if pending {
+		return res, nil
+	}
+	if res.Receipt, err = d.backend.TransactionReceipt(ctx, hash); err != nil {
+		return nil, err
+	}
+	res.Events = make([]*Event, len(res.Receipt.Logs))
+	res.EventErrs = make([]error, len(res.Receipt.Logs))
+	for i, log := range res.Receipt.Logs {
+		res.Events[i], res.EventErrs[i] = d.registry.DecodeLog(log)
+	}
+	if res.Receipt.Status == types.ReceiptStatusFailed && res.Receipt.BlockNumber.Sign() > 0 {
+		msg := ethereum.CallMsg{
+			From:       from,
+			To:         tx.To(),
+			Gas:        tx.Gas(),
+			Value:      tx.Value(),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		}
+		parent := new(big.Int).Sub(res.Receipt.BlockNumber, common.Big1)
+		if _, err := d.CallContract(ctx, msg, parent); err != nil {
+			errors.As(err, &res.Revert)
+		}
+	}
+	return res, nil
+}
+
+// CallContract executes a message call like ethclient.Client.CallContract. If
+// the call reverts with data known to the registry, the error returned is the
+// decoded *Revert, which can be retrieved with errors.As.
+func (d *Decoder) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	out, err := d.backend.CallContract(ctx, msg, blockNumber)
+	if err == nil {
+		return out, nil
+	}
+	data, ok := bind.RevertData(err)
+	if !ok {
+		return nil, err
+	}
+	revert, derr := d.registry.DecodeRevert(msg.To, data)
+	if derr != nil {
+		return nil, err
+	}
+	return nil, revert
+}
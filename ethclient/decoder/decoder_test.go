@@ -0,0 +1,318 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package decoder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	erc20ABI = mustParse(
+		"function transfer(address to, uint256 amount) returns (bool)",
+		"event Transfer(address indexed from, address indexed to, uint256 value)",
+		"error InsufficientBalance(uint256 available, uint256 required)",
+	)
+	erc721ABI = mustParse(
+		"event Transfer(address indexed from, address indexed to, uint256 indexed tokenId)",
+		"event Named(string indexed name, bytes data)",
+	)
+)
+
+func mustParse(signatures ...string) *abi.ABI {
+	parsed, err := abi.ParseHumanReadable(signatures)
+	if err != nil {
+		panic(err)
+	}
+	return &parsed
+}
+
+// selectorDB is a selector database backed by a map.
+type selectorDB map[string]string
+
+func (db selectorDB) Selector(id []byte) (string, error) {
+	if sig, ok := db[fmt.Sprintf("%x", id)]; ok {
+		return sig, nil
+	}
+	return "", errors.New("not found")
+}
+
+func TestDecodeCall(t *testing.T) {
+	t.Parallel()
+
+	var (
+		token = common.HexToAddress("0x1000000000000000000000000000000000000001")
+		other = common.HexToAddress("0x2000000000000000000000000000000000000002")
+		to    = common.HexToAddress("0x3000000000000000000000000000000000000003")
+	)
+	registry := NewRegistry()
+	registry.Register(token, erc20ABI)
+
+	input, _ := erc20ABI.Pack("transfer", to, big.NewInt(100))
+	call, err := registry.DecodeCall(&token, input)
+	if err != nil {
+		t.Fatalf("failed to decode call: %v", err)
+	}
+	if have, want := call.String(), fmt.Sprintf("transfer(to=%s, amount=100)", to); have != want {
+		t.Errorf("call mismatch: have %q, want %q", have, want)
+	}
+	// Calls to other contracts can't be decoded without a global ABI or database
+	if _, err := registry.DecodeCall(&other, input); !errors.Is(err, ErrUnknownSelector) {
+		t.Errorf("error mismatch: have %v, want %v", err, ErrUnknownSelector)
+	}
+	registry.SetSelectorDatabase(selectorDB{"a9059cbb": "transfer(address,uint256)"})
+	if call, err = registry.DecodeCall(&other, input); err != nil {
+		t.Fatalf("failed to decode call via database: %v", err)
+	}
+	if have, want := call.String(), fmt.Sprintf("transfer(arg0=%s, arg1=100)", to); have != want || !call.Guessed {
+		t.Errorf("call mismatch: have %q (guessed %v), want %q", have, call.Guessed, want)
+	}
+	// Guessed selectors must match the data exactly
+	if _, err := registry.DecodeCall(&other, append(input, 0x01)); err == nil {
+		t.Errorf("decoded call with trailing data")
+	}
+	registry.AddABI(erc20ABI)
+	if call, err = registry.DecodeCall(&other, input); err != nil || call.Guessed {
+		t.Fatalf("failed to decode call via global ABI: %v (guessed %v)", err, call.Guessed)
+	}
+}
+
+func TestDecodeLog(t *testing.T) {
+	t.Parallel()
+
+	var (
+		from  = common.HexToAddress("0x1000000000000000000000000000000000000001")
+		to    = common.HexToAddress("0x2000000000000000000000000000000000000002")
+		event = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	)
+	registry := NewRegistry()
+	registry.AddABI(erc20ABI)
+	registry.AddABI(erc721ABI)
+
+	// ERC-20 and ERC-721 transfers share the signature but not the indexing
+	fungible := &types.Log{
+		Topics: []common.Hash{event, common.BytesToHash(from[:]), common.BytesToHash(to[:])},
+		Data:   common.LeftPadBytes([]byte{42}, 32),
+	}
+	nft := &types.Log{
+		Topics: []common.Hash{event, common.BytesToHash(from[:]), common.BytesToHash(to[:]), common.BigToHash(big.NewInt(7))},
+	}
+	named := &types.Log{
+		Topics: []common.Hash{crypto.Keccak256Hash([]byte("Named(string,bytes)")), crypto.Keccak256Hash([]byte("geth"))},
+		Data:   common.FromHex("0x000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000000020102000000000000000000000000000000000000000000000000000000000000"),
+	}
+	tests := []struct {
+		log  *types.Log
+		want string
+	}{
+		{fungible, fmt.Sprintf("Transfer(from=%s, to=%s, value=42)", from, to)},
+		{nft, fmt.Sprintf("Transfer(from=%s, to=%s, tokenId=7)", from, to)},
+		{named, fmt.Sprintf("Named(name=%s, data=0x0102)", crypto.Keccak256Hash([]byte("geth")))},
+	}
+	for i, tt := range tests {
+		event, err := registry.DecodeLog(tt.log)
+		if err != nil {
+			t.Errorf("test %d: failed to decode log: %v", i, err)
+			continue
+		}
+		if have := event.String(); have != tt.want {
+			t.Errorf("test %d: event mismatch: have %q, want %q", i, have, tt.want)
+		}
+	}
+	unknown := &types.Log{Topics: []common.Hash{{0x01}}}
+	if _, err := registry.DecodeLog(unknown); !errors.Is(err, ErrUnknownEvent) {
+		t.Errorf("error mismatch: have %v, want %v", err, ErrUnknownEvent)
+	}
+}
+
+func TestDecodeRevert(t *testing.T) {
+	t.Parallel()
+
+	token := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	registry := NewRegistry()
+	registry.Register(token, erc20ABI)
+
+	custom := append(crypto.Keccak256([]byte("InsufficientBalance(uint256,uint256)"))[:4],
+		append(common.LeftPadBytes([]byte{1}, 32), common.LeftPadBytes([]byte{2}, 32)...)...)
+	reason := common.FromHex("0x08c379a0000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000000046e6f706500000000000000000000000000000000000000000000000000000000")
+	panicked := common.FromHex("0x4e487b710000000000000000000000000000000000000000000000000000000000000011")
+
+	tests := []struct {
+		contract *common.Address
+		data     []byte
+		want     string
+	}{
+		{&token, custom, "execution reverted: InsufficientBalance(available=1, required=2)"},
+		{&token, reason, "execution reverted: nope"},
+		{nil, reason, "execution reverted: nope"},
+		{nil, panicked, "execution reverted: Panic(code=17)"},
+	}
+	for i, tt := range tests {
+		revert, err := registry.DecodeRevert(tt.contract, tt.data)
+		if err != nil {
+			t.Errorf("test %d: failed to decode revert: %v", i, err)
+			continue
+		}
+		if have := revert.Error(); have != tt.want {
+			t.Errorf("test %d: revert mismatch: have %q, want %q", i, have, tt.want)
+		}
+	}
+	if _, err := registry.DecodeRevert(nil, custom); !errors.Is(err, ErrUnknownSelector) {
+		t.Errorf("error mismatch: have %v, want %v", err, ErrUnknownSelector)
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	t.Parallel()
+
+	var (
+		dir   = t.TempDir()
+		token = common.HexToAddress("0x1000000000000000000000000000000000000001")
+		other = common.HexToAddress("0x2000000000000000000000000000000000000002")
+	)
+	files := map[string]string{
+		token.Hex() + ".json": `[{"type":"function","name":"mint","inputs":[{"name":"amount","type":"uint256"}],"outputs":[]}]`,
+		"artifact.json":       `{"contractName":"Burner","abi":[{"type":"function","name":"burn","inputs":[{"name":"amount","type":"uint256"}],"outputs":[]}]}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	registry := NewRegistry()
+	if err := registry.LoadDir(dir); err != nil {
+		t.Fatalf("failed to load ABIs: %v", err)
+	}
+	mint := append(crypto.Keccak256([]byte("mint(uint256)"))[:4], common.LeftPadBytes([]byte{1}, 32)...)
+	burn := append(crypto.Keccak256([]byte("burn(uint256)"))[:4], common.LeftPadBytes([]byte{1}, 32)...)
+
+	if _, err := registry.DecodeCall(&token, mint); err != nil {
+		t.Errorf("failed to decode call to registered contract: %v", err)
+	}
+	if _, err := registry.DecodeCall(&other, mint); err == nil {
+		t.Errorf("decoded call of another contract's ABI")
+	}
+	if _, err := registry.DecodeCall(&other, burn); err != nil {
+		t.Errorf("failed to decode call via artifact ABI: %v", err)
+	}
+}
+
+// storeABI is the interface of a hand assembled contract storing a single value
+// set by the constructor or set(), which emits Stored. fail() always reverts
+// with the Failure custom error.
+var storeABI = mustParse(
+	"function value() view returns (uint256)",
+	"function set(uint256 v)",
+	"function fail(uint256 code) view",
+	"event Stored(address indexed from, uint256 value)",
+	"error Failure(uint256 code, address who)",
+)
+
+// storeBin is the bytecode of the store contract.
+var storeBin = common.FromHex(strings.ReplaceAll("60206020380360003960005160005560e3601b60003960e36000f360003560e01c80633fa4f24514610042578063928012301461004e57806360fe47b11461006c578063a8aa1b311461009f578063132e4f3c146100af5760006000fd5b60005460005260206000f35b73__$PLACEHOLDER$__60005260206000f35b60043580600055600052337febfcf7c0a1b09f6499e519a8d8bb85ce33cd539ec6cbd964e116cd74943ead1a60206000a2005b6000546000523360205260406000f35b7f73f6e924000000000000000000000000000000000000000000000000000000006000526004356004523360245260446000fd", "__$PLACEHOLDER$__", strings.Repeat("00", 20)))
+
+// Tests that transactions are decoded end to end, including the revert reasons
+// of failed ones.
+func TestDecodeTransaction(t *testing.T) {
+	t.Parallel()
+
+	key, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+
+	sim := simulated.NewBackend(types.GenesisAlloc{sender: {Balance: big.NewInt(params.Ether)}})
+	defer sim.Close()
+	client := sim.Client()
+
+	chainID, err := client.ChainID(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := types.LatestSignerForChainID(chainID)
+	send := func(nonce uint64, to *common.Address, data []byte) common.Hash {
+		tx := types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   chainID,
+			Nonce:     nonce,
+			To:        to,
+			Gas:       200000,
+			GasFeeCap: big.NewInt(params.GWei * 10),
+			GasTipCap: big.NewInt(params.GWei),
+			Data:      data,
+		})
+		if err := client.SendTransaction(context.Background(), tx); err != nil {
+			t.Fatalf("failed to send transaction: %v", err)
+		}
+		sim.Commit()
+		return tx.Hash()
+	}
+	initial := common.LeftPadBytes([]byte{7}, 32)
+	send(0, nil, append(common.CopyBytes(storeBin), initial...))
+	store := crypto.CreateAddress(sender, 0)
+
+	registry := NewRegistry()
+	registry.Register(store, storeABI)
+	decoder := New(client, registry)
+
+	// Successful call emitting an event
+	input, _ := storeABI.Pack("set", big.NewInt(42))
+	res, err := decoder.Transaction(context.Background(), send(1, &store, input))
+	if err != nil {
+		t.Fatalf("failed to decode transaction: %v", err)
+	}
+	if res.From != sender || res.Receipt == nil || res.Receipt.Status != types.ReceiptStatusSuccessful {
+		t.Fatalf("transaction mismatch: from %v, receipt %+v", res.From, res.Receipt)
+	}
+	if res.Call == nil || res.Call.String() != "set(v=42)" {
+		t.Errorf("call mismatch: have %v (%v)", res.Call, res.CallErr)
+	}
+	if len(res.Events) != 1 || res.Events[0] == nil || res.Events[0].String() != fmt.Sprintf("Stored(from=%s, value=42)", sender) {
+		t.Errorf("events mismatch: have %v (%v)", res.Events, res.EventErrs)
+	}
+	if res.Revert != nil {
+		t.Errorf("unexpected revert: %v", res.Revert)
+	}
+	// Failed transaction reverting with a custom error
+	input, _ = storeABI.Pack("fail", big.NewInt(3))
+	if res, err = decoder.Transaction(context.Background(), send(2, &store, input)); err != nil {
+		t.Fatalf("failed to decode transaction: %v", err)
+	}
+	if res.Receipt.Status != types.ReceiptStatusFailed {
+		t.Fatalf("transaction didn't fail")
+	}
+	if want := fmt.Sprintf("execution reverted: Failure(code=3, who=%s)", sender); res.Revert == nil || res.Revert.Error() != want {
+		t.Errorf("revert mismatch: have %v, want %v", res.Revert, want)
+	}
+	// Reverting calls
+	_, err = decoder.CallContract(context.Background(), ethereum.CallMsg{From: sender, To: &store, Data: input}, nil)
+	var revert *Revert
+	if !errors.As(err, &revert) || revert.Name != "Failure" {
+		t.Errorf("call error mismatch: have %v", err)
+	}
+}
@@ -0,0 +1,317 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package decoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var (
+	// ErrUnknownSelector is returned if no known method or error matches the
+	// 4 byte selector of the data.
+	ErrUnknownSelector = errors.New("unknown selector")
+
+	// ErrUnknownEvent is returned if no known event matches a log.
+	ErrUnknownEvent = errors.New("unknown event")
+)
+
+// builtinErrors are the errors raised by the Solidity compiler itself, which
+// are decoded for any contract.
+var builtinErrors = func() abi.ABI {
+	parsed, err := abi.ParseHumanReadable([]string{
+		"error Error(string reason)",
+		"error Panic(uint256 code)",
+	})
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+// SelectorDatabase resolves 4 byte selectors into textual signatures, such as
+// "transfer(address,uint256)". It is implemented by the signer/fourbyte database.
+type SelectorDatabase interface {
+	Selector(id []byte) (string, error)
+}
+
+// Registry is a collection of ABIs used to decode contract interactions. ABIs
+// can be registered for specific contract addresses, or globally to decode the
+// interactions with any contract, e.g. for well known token standards. Method
+// and error selectors unknown to all ABIs may be resolved by a selector database.
+//
+// Lookups first consult the ABI of the contract, then the global ABIs and last
+// the selector database.
+type Registry struct {
+	contracts map[common.Address]*abi.ABI // ABIs of specific contracts
+	methods   map[[4]byte]abi.Method      // Methods of the global ABIs
+	events    map[common.Hash][]abi.Event // Events of the global ABIs, the indexing may differ
+	errors    map[[4]byte]abi.Error       // Errors of the global ABIs
+	selectors SelectorDatabase            // Fallback for unknown selectors
+
+	lock sync.RWMutex
+}
+
+// NewRegistry creates an empty ABI registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		contracts: make(map[common.Address]*abi.ABI),
+		methods:   make(map[[4]byte]abi.Method),
+		events:    make(map[common.Hash][]abi.Event),
+		errors:    make(map[[4]byte]abi.Error),
+	}
+}
+
+// Register sets the ABI of the contract at the given address.
+func (r *Registry) Register(addr common.Address, contract *abi.ABI) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.contracts[addr] = contract
+}
+
+// AddABI adds the methods, events and errors of an ABI to the global set used
+// for all contracts.
+func (r *Registry) AddABI(contract *abi.ABI) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for _, method := range contract.Methods {
+		r.methods[[4]byte(method.ID)] = method
+	}
+	for _, event := range contract.Events {
+		if event.Anonymous {
+			continue
+		}
+		// Events with the same signature may differ in their indexed arguments,
+		// e.g. ERC-20 and ERC-721 transfers, so keep all of them
+		var known bool
+		for _, have := range r.events[event.ID] {
+			if sameIndexing(have, event) {
+				known = true
+				break
+			}
+		}
+		if !known {
+			r.events[event.ID] = append(r.events[event.ID], event)
+		}
+	}
+	for _, e := range contract.Errors {
+		r.errors[[4]byte(e.ID[:4])] = e
+	}
+}
+
+// sameIndexing reports whether two events with the same signature index the
+// same arguments.
+func sameIndexing(a, b abi.Event) bool {
+	for i := range a.Inputs {
+		if a.Inputs[i].Indexed != b.Inputs[i].Indexed {
+			return false
+		}
+	}
+	return true
+}
+
+// SetSelectorDatabase sets the database used to resolve the method and error
+// selectors not found in any of the ABIs.
+func (r *Registry) SetSelectorDatabase(db SelectorDatabase) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.selectors = db
+}
+
+// LoadFile loads an ABI from a JSON file, either holding the ABI itself or a
+// compiler artifact with an "abi" field. If the name of the file, without its
+// extension, is a contract address, the ABI is registered for that contract.
+// Otherwise it's added to the global set.
+func (r *Registry) LoadFile(path string) error {
+	blob, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	// Unwrap compiler artifacts holding the ABI in a field
+	var artifact struct {
+		ABI json.RawMessage `json:"abi"`
+	}
+	if trimmed := bytes.TrimSpace(blob); len(trimmed) > 0 && trimmed[0] == '{' {
+		if err := json.Unmarshal(trimmed, &artifact); err != nil {
+			return fmt.Errorf("invalid ABI file %s: %v", path, err)
+		}
+		if artifact.ABI == nil {
+			return fmt.Errorf("invalid ABI file %s: no abi field", path)
+		}
+		blob = artifact.ABI
+	}
+	parsed, err := abi.JSON(bytes.NewReader(blob))
+	if err != nil {
+		return fmt.Errorf("invalid ABI file %s: %v", path, err)
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if common.IsHexAddress(name) {
+		r.Register(common.HexToAddress(name), &parsed)
+	} else {
+		r.AddABI(&parsed)
+	}
+	return nil
+}
+
+// LoadDir loads all the JSON files in the given directory as ABIs, see LoadFile.
+func (r *Registry) LoadDir(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := r.LoadFile(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DecodeCall decodes the input data of a call to the given contract. The
+// contract may be nil if unknown.
+func (r *Registry) DecodeCall(contract *common.Address, input []byte) (*Call, error) {
+	if len(input) < 4 {
+		return nil, fmt.Errorf("%w: call data too short (%d bytes)", ErrUnknownSelector, len(input))
+	}
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	id := [4]byte(input[:4])
+	if parsed := r.contractABI(contract); parsed != nil {
+		if method, err := parsed.MethodById(input); err == nil {
+			return decodeCall(method, input, false)
+		}
+	}
+	if method, ok := r.methods[id]; ok {
+		return decodeCall(&method, input, false)
+	}
+	method, err := r.lookupSelector(id)
+	if err != nil {
+		return nil, err
+	}
+	return decodeCall(method, input, true)
+}
+
+// DecodeLog decodes an event emitted by a contract.
+func (r *Registry) DecodeLog(log *types.Log) (*Event, error) {
+	if len(log.Topics) == 0 {
+		return nil, fmt.Errorf("%w: log without topics", ErrUnknownEvent)
+	}
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	var candidates []abi.Event
+	if parsed := r.contractABI(&log.Address); parsed != nil {
+		if event, err := parsed.EventByID(log.Topics[0]); err == nil && !event.Anonymous {
+			candidates = append(candidates, *event)
+		}
+	}
+	candidates = append(candidates, r.events[log.Topics[0]]...)
+
+	// Pick the first event whose layout matches the log
+	var err error
+	for i := range candidates {
+		var event *Event
+		if event, err = decodeLog(&candidates[i], log); err == nil {
+			return event, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownEvent, log.Topics[0])
+}
+
+// DecodeRevert decodes the revert data returned by a failed call to the given
+// contract. The contract may be nil if unknown.
+func (r *Registry) DecodeRevert(contract *common.Address, data []byte) (*Revert, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("%w: revert data too short (%d bytes)", ErrUnknownSelector, len(data))
+	}
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	id := [4]byte(data[:4])
+	if parsed := r.contractABI(contract); parsed != nil {
+		if e, err := parsed.ErrorByID(id); err == nil {
+			return decodeRevert(e, data, false)
+		}
+	}
+	if e, err := builtinErrors.ErrorByID(id); err == nil {
+		return decodeRevert(e, data, false)
+	}
+	if e, ok := r.errors[id]; ok {
+		return decodeRevert(&e, data, false)
+	}
+	method, err := r.lookupSelector(id)
+	if err != nil {
+		return nil, err
+	}
+	e := abi.NewError(method.RawName, method.Inputs)
+	return decodeRevert(&e, data, true)
+}
+
+// contractABI returns the ABI registered for the contract, if any. The caller
+// must hold the read lock.
+func (r *Registry) contractABI(contract *common.Address) *abi.ABI {
+	if contract == nil {
+		return nil
+	}
+	return r.contracts[*contract]
+}
+
+// lookupSelector resolves a selector via the selector database, returning it
+// as a method. The caller must hold the read lock.
+func (r *Registry) lookupSelector(id [4]byte) (*abi.Method, error) {
+	if r.selectors == nil {
+		return nil, fmt.Errorf("%w: %#x", ErrUnknownSelector, id)
+	}
+	signature, err := r.selectors.Selector(id[:])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %#x", ErrUnknownSelector, id)
+	}
+	parsed, err := abi.ParseSelector(signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature %q for selector %#x: %v", signature, id, err)
+	}
+	args := make(abi.Arguments, len(parsed.Inputs))
+	for i, input := range parsed.Inputs {
+		typ, err := abi.NewType(input.Type, input.InternalType, input.Components)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signature %q for selector %#x: %v", signature, id, err)
+		}
+		args[i] = abi.Argument{Name: fmt.Sprintf("arg%d", i), Type: typ}
+	}
+	method := abi.NewMethod(parsed.Name, parsed.Name, abi.Function, "", false, false, args, nil)
+	if !bytes.Equal(method.ID, id[:]) {
+		return nil, fmt.Errorf("signature %q doesn't match selector %#x", signature, id)
+	}
+	return &method, nil
+}
@@ -0,0 +1,230 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package decoder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Argument is a decoded argument of a method call, event or error.
+type Argument struct {
+	Name    string      // Name of the argument, argN if it's unnamed
+	Type    string      // Canonical ABI type of the argument
+	Value   interface{} // Decoded value, the topic hash for indexed dynamic types
+	Indexed bool        // Whether the argument is an indexed event topic
+}
+
+// String implements fmt.Stringer.
+func (arg Argument) String() string {
+	return fmt.Sprintf("%s %s=%s", arg.Type, arg.Name, formatValue(arg.Value))
+}
+
+// Call is a decoded contract method call.
+type Call struct {
+	Name      string     // Name of the method
+	Signature string     // Canonical signature of the method, e.g. transfer(address,uint256)
+	Args      []Argument // Decoded input arguments
+	Guessed   bool       // Whether the method was resolved via the selector database
+}
+
+// String implements fmt.Stringer.
+func (c *Call) String() string {
+	return c.Name + formatArgs(c.Args)
+}
+
+// Event is a decoded contract event.
+type Event struct {
+	Name      string     // Name of the event
+	Signature string     // Canonical signature of the event
+	Args      []Argument // Decoded arguments, in declaration order
+	Log       *types.Log // Log the event was decoded from
+}
+
+// String implements fmt.Stringer.
+func (e *Event) String() string {
+	return e.Name + formatArgs(e.Args)
+}
+
+// Revert is a decoded revert of a contract execution. It implements the error
+// interface so it can be returned in place of the raw execution error.
+type Revert struct {
+	Name      string     // Name of the error, Error and Panic for builtin reverts
+	Signature string     // Canonical signature of the error
+	Args      []Argument // Decoded arguments of the error
+	Guessed   bool       // Whether the error was resolved via the selector database
+	Data      []byte     // Raw revert data
+}
+
+// Error implements error.
+func (r *Revert) Error() string {
+	if r.Signature == "Error(string)" {
+		return fmt.Sprintf("execution reverted: %v", r.Args[0].Value)
+	}
+	return "execution reverted: " + r.Name + formatArgs(r.Args)
+}
+
+// formatArgs formats a list of arguments as name=value pairs.
+func formatArgs(args []Argument) string {
+	formatted := make([]string, len(args))
+	for i, arg := range args {
+		formatted[i] = arg.Name + "=" + formatValue(arg.Value)
+	}
+	return "(" + strings.Join(formatted, ", ") + ")"
+}
+
+// formatValue formats a decoded ABI value, printing byte arrays as hex.
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case []byte:
+		return hexutil.Encode(v)
+	case common.Address, common.Hash:
+		return fmt.Sprint(v)
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Array && rv.Type().Elem().Kind() == reflect.Uint8 {
+		blob := make([]byte, rv.Len())
+		reflect.Copy(reflect.ValueOf(blob), rv)
+		return hexutil.Encode(blob)
+	}
+	return fmt.Sprint(value)
+}
+
+// newArguments pairs ABI arguments with their decoded values.
+func newArguments(args abi.Arguments, values []interface{}) []Argument {
+	decoded := make([]Argument, len(args))
+	for i, arg := range args {
+		decoded[i] = Argument{
+			Name:    argumentName(arg, i),
+			Type:    arg.Type.String(),
+			Value:   values[i],
+			Indexed: arg.Indexed,
+		}
+	}
+	return decoded
+}
+
+// argumentName returns the name of an argument, argN if unnamed.
+func argumentName(arg abi.Argument, index int) string {
+	if arg.Name == "" {
+		return fmt.Sprintf("arg%d", index)
+	}
+	return arg.Name
+}
+
+// decodeCall decodes the input data of a method call. If the method was guessed
+// from its selector, the data is required to be its exact encoding to rule out
+// selector collisions.
+func decodeCall(method *abi.Method, input []byte, guessed bool) (*Call, error) {
+	values, err := unpackStrict(method.Inputs, input[4:], guessed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode call to %s: %v", method.Sig, err)
+	}
+	return &Call{
+		Name:      method.RawName,
+		Signature: method.Sig,
+		Args:      newArguments(method.Inputs, values),
+		Guessed:   guessed,
+	}, nil
+}
+
+// decodeRevert decodes the revert data of a custom or builtin error.
+func decodeRevert(e *abi.Error, data []byte, guessed bool) (*Revert, error) {
+	values, err := unpackStrict(e.Inputs, data[4:], guessed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode error %s: %v", e.Sig, err)
+	}
+	return &Revert{
+		Name:      e.Name,
+		Signature: e.Sig,
+		Args:      newArguments(e.Inputs, values),
+		Guessed:   guessed,
+		Data:      common.CopyBytes(data),
+	}, nil
+}
+
+// unpackStrict unpacks the arguments from the data, and if strict is set also
+// checks that the data is their exact encoding.
+func unpackStrict(args abi.Arguments, data []byte, strict bool) ([]interface{}, error) {
+	values, err := args.Unpack(data)
+	if err != nil {
+		return nil, err
+	}
+	if strict {
+		packed, err := args.Pack(values...)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(packed, data) {
+			return nil, errors.New("data is not a canonical encoding of the arguments")
+		}
+	}
+	return values, nil
+}
+
+// decodeLog decodes a log as the given event, failing if the layout of the
+// event doesn't match the log.
+func decodeLog(event *abi.Event, log *types.Log) (*Event, error) {
+	var indexed abi.Arguments
+	for _, arg := range event.Inputs {
+		if arg.Indexed {
+			indexed = append(indexed, arg)
+		}
+	}
+	if len(indexed) != len(log.Topics)-1 {
+		return nil, fmt.Errorf("failed to decode event %s: have %d topics, want %d", event.Sig, len(log.Topics)-1, len(indexed))
+	}
+	data, err := event.Inputs.Unpack(log.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode event %s: %v", event.Sig, err)
+	}
+	// Merge the data and topic values back into declaration order
+	values := make([]interface{}, len(event.Inputs))
+	for i, topic := 0, 1; i < len(event.Inputs); i++ {
+		arg := event.Inputs[i]
+		if !arg.Indexed {
+			values[i], data = data[0], data[1:]
+			continue
+		}
+		switch arg.Type.T {
+		case abi.TupleTy, abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy:
+			// Dynamic and composite topics are hashes of the value
+			values[i] = log.Topics[topic]
+		default:
+			out := make(map[string]interface{})
+			if err := abi.ParseTopicsIntoMap(out, abi.Arguments{arg}, log.Topics[topic:topic+1]); err != nil {
+				return nil, fmt.Errorf("failed to decode event %s: %v", event.Sig, err)
+			}
+			values[i] = out[arg.Name]
+		}
+		topic++
+	}
+	return &Event{
+		Name:      event.RawName,
+		Signature: event.Sig,
+		Args:      newArguments(event.Inputs, values),
+		Log:       log,
+	}, nil
+}
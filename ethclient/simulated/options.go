@@ -19,6 +19,7 @@ package simulated
 import (
 	"math/big"
 
+	"github.com/ethereum/go-ethereum/core/tracing"
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
 	"github.com/ethereum/go-ethereum/node"
 )
@@ -53,3 +54,12 @@ func WithMinerMinTip(tip *big.Int) func(nodeConf *node.Config, ethConf *ethconfi
 		ethConf.Miner.GasPrice = tip
 	}
 }
+
+// WithTracer configures the simulated backend to trace the execution of all the
+// transactions included in its blocks with the given hooks, e.g. to collect the
+// coverage of the tested contracts.
+func WithTracer(hooks *tracing.Hooks) func(nodeConf *node.Config, ethConf *ethconfig.Config) {
+	return func(nodeConf *node.Config, ethConf *ethconfig.Config) {
+		ethConf.VMTracer = hooks
+	}
+}
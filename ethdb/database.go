@@ -17,7 +17,14 @@
 // Package ethdb defines the interfaces for an Ethereum data store.
 package ethdb
 
-import "io"
+import (
+	"errors"
+	"io"
+)
+
+// ErrAncientNotLocal is returned by AncientDatadir if the ancient store exists,
+// but has no local directory, e.g. because the database is accessed remotely.
+var ErrAncientNotLocal = errors.New("ancient store is not locally accessible")
 
 // KeyValueReader wraps the Has and Get method of a backing data store.
 type KeyValueReader interface {
@@ -146,6 +153,8 @@ type AncientStater interface {
 	//
 	// If the ancient store is not activated, an error is returned.
 	// If an ephemeral ancient store is used, an empty path is returned.
+	// If the ancient store is not locally accessible, ErrAncientNotLocal is
+	// returned.
 	//
 	// The path returned by AncientDatadir can be used as the root path
 	// of the ancient store to construct paths for other sub ancient stores.
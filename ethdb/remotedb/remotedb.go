@@ -15,19 +15,33 @@
 // along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
 
 // Package remotedb implements the key-value database layer based on a remote geth
-// node. Under the hood, it utilises the `debug_dbGet` method to implement a
-// read-only database.
+// node. Under the hood, it utilises the `debug_db*` methods to implement the
+// database: reads are proxied one by one, iteration is streamed via the
+// `debug_dbIterate` subscription and writes are only accepted if the remote node
+// was started with `--rpc.dbwrite`.
 // There really are no guarantees in this database, since the local geth does not
 // exclusive access, but it can be used for basic diagnostics of a remote node.
 package remotedb
 
 import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
-// Database is a key-value lookup for a remote database via debug_dbGet.
+// errNotSupported is returned for operations which cannot be proxied to the
+// remote node.
+var errNotSupported = errors.New("not supported by remote database")
+
+// errIterationAborted is returned by an iterator if the remote subscription
+// ended before all the entries were streamed.
+var errIterationAborted = errors.New("database iteration ended prematurely")
+
+// Database is a key-value lookup for a remote database via the debug_db* APIs.
 type Database struct {
 	remote *rpc.Client
 }
@@ -65,7 +79,16 @@ func (db *Database) Ancient(kind string, number uint64) ([]byte, error) {
 }
 
 func (db *Database) AncientRange(kind string, start, count, maxBytes uint64) ([][]byte, error) {
-	panic("not supported")
+	var resp []hexutil.Bytes
+	err := db.remote.Call(&resp, "debug_dbAncientRange", kind, start, count, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	items := make([][]byte, len(resp))
+	for i, item := range resp {
+		items[i] = item
+	}
+	return items, nil
 }
 
 func (db *Database) Ancients() (uint64, error) {
@@ -75,11 +98,15 @@ func (db *Database) Ancients() (uint64, error) {
 }
 
 func (db *Database) Tail() (uint64, error) {
-	panic("not supported")
+	var resp uint64
+	err := db.remote.Call(&resp, "debug_dbAncientTail")
+	return resp, err
 }
 
 func (db *Database) AncientSize(kind string) (uint64, error) {
-	panic("not supported")
+	var resp uint64
+	err := db.remote.Call(&resp, "debug_dbAncientSize", kind)
+	return resp, err
 }
 
 func (db *Database) ReadAncients(fn func(op ethdb.AncientReaderOp) error) (err error) {
@@ -87,23 +114,23 @@ func (db *Database) ReadAncients(fn func(op ethdb.AncientReaderOp) error) (err e
 }
 
 func (db *Database) Put(key []byte, value []byte) error {
-	panic("not supported")
+	return db.remote.Call(nil, "debug_dbPut", hexutil.Bytes(key), hexutil.Bytes(value))
 }
 
 func (db *Database) Delete(key []byte) error {
-	panic("not supported")
+	return db.remote.Call(nil, "debug_dbDelete", hexutil.Bytes(key))
 }
 
 func (db *Database) ModifyAncients(f func(ethdb.AncientWriteOp) error) (int64, error) {
-	panic("not supported")
+	return 0, errNotSupported
 }
 
 func (db *Database) TruncateHead(n uint64) (uint64, error) {
-	panic("not supported")
+	return 0, errNotSupported
 }
 
 func (db *Database) TruncateTail(n uint64) (uint64, error) {
-	panic("not supported")
+	return 0, errNotSupported
 }
 
 func (db *Database) Sync() error {
@@ -111,27 +138,37 @@ func (db *Database) Sync() error {
 }
 
 func (db *Database) MigrateTable(s string, f func([]byte) ([]byte, error)) error {
-	panic("not supported")
+	return errNotSupported
 }
 
 func (db *Database) NewBatch() ethdb.Batch {
-	panic("not supported")
+	return &batch{db: db}
 }
 
 func (db *Database) NewBatchWithSize(size int) ethdb.Batch {
-	panic("not supported")
+	return &batch{db: db}
 }
 
 func (db *Database) NewIterator(prefix []byte, start []byte) ethdb.Iterator {
-	panic("not supported")
+	it := &iterator{
+		batches: make(chan *iteratorBatch),
+		index:   -1,
+	}
+	it.sub, it.err = db.remote.Subscribe(context.Background(), "debug", it.batches, "dbIterate", hexutil.Bytes(prefix), hexutil.Bytes(start))
+	if it.err != nil {
+		it.done = true
+	}
+	return it
 }
 
 func (db *Database) Stat() (string, error) {
-	return "", nil
+	var resp string
+	err := db.remote.Call(&resp, "debug_chaindbProperty")
+	return resp, err
 }
 
 func (db *Database) AncientDatadir() (string, error) {
-	panic("not supported")
+	return "", ethdb.ErrAncientNotLocal
 }
 
 func (db *Database) Compact(start []byte, limit []byte) error {
@@ -148,3 +185,153 @@ func New(client *rpc.Client) ethdb.Database {
 		remote: client,
 	}
 }
+
+// batchOp is a single modification queued up in a remote batch. Its encoding
+// matches the debug_dbWriteBatch parameters.
+type batchOp struct {
+	Key    hexutil.Bytes `json:"key"`
+	Value  hexutil.Bytes `json:"value,omitempty"`
+	Delete bool          `json:"delete,omitempty"`
+}
+
+// batch is a write-only batch that commits changes to the remote database
+// in a single debug_dbWriteBatch call when Write is called.
+type batch struct {
+	db   *Database
+	ops  []batchOp
+	size int
+}
+
+// Put inserts the given value into the batch for later committing.
+func (b *batch) Put(key, value []byte) error {
+	b.ops = append(b.ops, batchOp{Key: common.CopyBytes(key), Value: common.CopyBytes(value)})
+	b.size += len(key) + len(value)
+	return nil
+}
+
+// Delete inserts the key removal into the batch for later committing.
+func (b *batch) Delete(key []byte) error {
+	b.ops = append(b.ops, batchOp{Key: common.CopyBytes(key), Delete: true})
+	b.size += len(key)
+	return nil
+}
+
+// ValueSize retrieves the amount of data queued up for writing.
+func (b *batch) ValueSize() int {
+	return b.size
+}
+
+// Write flushes any accumulated data to the remote database.
+func (b *batch) Write() error {
+	if len(b.ops) == 0 {
+		return nil
+	}
+	return b.db.remote.Call(nil, "debug_dbWriteBatch", b.ops)
+}
+
+// Reset resets the batch for reuse.
+func (b *batch) Reset() {
+	b.ops = b.ops[:0]
+	b.size = 0
+}
+
+// Replay replays the batch contents.
+func (b *batch) Replay(w ethdb.KeyValueWriter) error {
+	for _, op := range b.ops {
+		if op.Delete {
+			if err := w.Delete(op.Key); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := w.Put(op.Key, op.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// iteratorBatch is a chunk of entries streamed by the debug_dbIterate
+// subscription.
+type iteratorBatch struct {
+	Keys   []hexutil.Bytes `json:"keys"`
+	Values []hexutil.Bytes `json:"values"`
+	Done   bool            `json:"done"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// iterator walks the remote database, consuming the entries streamed over a
+// debug_dbIterate subscription. The remote node must be connected to via a
+// transport supporting subscriptions (websocket or IPC).
+type iterator struct {
+	sub     *rpc.ClientSubscription
+	batches chan *iteratorBatch
+
+	batch *iteratorBatch // Batch currently being iterated
+	index int            // Position within the current batch
+	done  bool           // Whether the remote side finished streaming
+	err   error
+}
+
+// Next moves the iterator to the next key/value pair. It returns whether the
+// iterator is exhausted.
+func (it *iterator) Next() bool {
+	it.index++
+	for it.batch == nil || it.index >= len(it.batch.Keys) {
+		if it.done {
+			it.batch = nil
+			return false
+		}
+		select {
+		case batch := <-it.batches:
+			it.batch, it.index = batch, 0
+			if batch.Done {
+				it.done = true
+				it.sub.Unsubscribe()
+			}
+			if batch.Error != "" {
+				it.err = errors.New(batch.Error)
+			}
+		case err := <-it.sub.Err():
+			// The subscription ended before the final batch arrived, so the
+			// remaining entries were lost. Never report that as exhaustion.
+			if err == nil {
+				err = errIterationAborted
+			}
+			it.batch, it.done, it.err = nil, true, err
+			return false
+		}
+	}
+	return true
+}
+
+// Error returns any accumulated error. Exhausting all the key/value pairs
+// is not considered to be an error.
+func (it *iterator) Error() error {
+	return it.err
+}
+
+// Key returns the key of the current key/value pair, or nil if done.
+func (it *iterator) Key() []byte {
+	if it.batch == nil || it.index < 0 || it.index >= len(it.batch.Keys) {
+		return nil
+	}
+	return it.batch.Keys[it.index]
+}
+
+// Value returns the value of the current key/value pair, or nil if done.
+func (it *iterator) Value() []byte {
+	if it.batch == nil || it.index < 0 || it.index >= len(it.batch.Values) {
+		return nil
+	}
+	return it.batch.Values[it.index]
+}
+
+// Release releases associated resources. Release should always succeed and can
+// be called multiple times without causing error.
+func (it *iterator) Release() {
+	if it.sub != nil {
+		it.sub.Unsubscribe()
+	}
+	it.batch, it.done = nil, true
+}
@@ -53,6 +53,7 @@ type Backend interface {
 	RPCEVMTimeout() time.Duration // global timeout for eth_call over rpc: DoS protection
 	RPCTxFeeCap() float64         // global tx fee cap for all transaction related APIs
 	UnprotectedAllowed() bool     // allows only for EIP155 transactions.
+	RPCDatabaseWrites() bool      // allows modifying the chain database via debug_db* APIs
 
 	// Blockchain API
 	SetHead(number uint64)
@@ -17,8 +17,13 @@
 package ethapi
 
 import (
+	"context"
+	"errors"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 // DbGet returns the raw value of a key stored in the database.
@@ -41,3 +46,137 @@ func (api *DebugAPI) DbAncient(kind string, number uint64) (hexutil.Bytes, error
 func (api *DebugAPI) DbAncients() (uint64, error) {
 	return api.b.ChainDb().Ancients()
 }
+
+// dbIterateBatchSize is the approximate number of bytes of key-value data that
+// is accumulated before being streamed to a debug_dbIterate subscriber.
+const dbIterateBatchSize = ethdb.IdealBatchSize
+
+// errDatabaseWritesDisabled is returned if a database modification is requested
+// without the node operator explicitly opting in.
+var errDatabaseWritesDisabled = errors.New("database writes are disabled (enable with --rpc.dbwrite)")
+
+// DbAncientRange retrieves multiple sequential ancient items from the given
+// table, limited by both count and total size. It is a mapping to the
+// `AncientReaderOp.AncientRange` method
+func (api *DebugAPI) DbAncientRange(kind string, start, count, maxBytes uint64) ([]hexutil.Bytes, error) {
+	blobs, err := api.b.ChainDb().AncientRange(kind, start, count, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]hexutil.Bytes, len(blobs))
+	for i, blob := range blobs {
+		items[i] = blob
+	}
+	return items, nil
+}
+
+// DbAncientTail returns the number of the first stored item in the ancient store.
+// It is a mapping to the `AncientReaderOp.Tail` method
+func (api *DebugAPI) DbAncientTail() (uint64, error) {
+	return api.b.ChainDb().Tail()
+}
+
+// DbAncientSize returns the storage size of the given ancient table.
+// It is a mapping to the `AncientReaderOp.AncientSize` method
+func (api *DebugAPI) DbAncientSize(kind string) (uint64, error) {
+	return api.b.ChainDb().AncientSize(kind)
+}
+
+// DbIteratorBatch is a chunk of consecutive database entries streamed by the
+// debug_dbIterate subscription. The final batch is flagged as done, carrying
+// any error encountered during iteration.
+type DbIteratorBatch struct {
+	Keys   []hexutil.Bytes `json:"keys"`
+	Values []hexutil.Bytes `json:"values"`
+	Done   bool            `json:"done"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// DbIterate streams all the database entries with the given prefix, starting
+// at the given position, to the subscriber in batches.
+func (api *DebugAPI) DbIterate(ctx context.Context, prefix hexutil.Bytes, start hexutil.Bytes) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	var (
+		sub = notifier.CreateSubscription()
+		it  = api.b.ChainDb().NewIterator(prefix, start)
+	)
+	go func() {
+		defer it.Release()
+
+		var (
+			batch = new(DbIteratorBatch)
+			size  int
+		)
+		for it.Next() {
+			select {
+			case <-sub.Err():
+				return
+			default:
+			}
+			batch.Keys = append(batch.Keys, common.CopyBytes(it.Key()))
+			batch.Values = append(batch.Values, common.CopyBytes(it.Value()))
+
+			if size += len(it.Key()) + len(it.Value()); size >= dbIterateBatchSize {
+				if err := notifier.Notify(sub.ID, batch); err != nil {
+					return
+				}
+				batch, size = new(DbIteratorBatch), 0
+			}
+		}
+		batch.Done = true
+		if err := it.Error(); err != nil {
+			batch.Error = err.Error()
+		}
+		notifier.Notify(sub.ID, batch)
+	}()
+	return sub, nil
+}
+
+// DbPut inserts the given value into the database. It is only available if the
+// node was started with database writes enabled.
+func (api *DebugAPI) DbPut(key hexutil.Bytes, value hexutil.Bytes) error {
+	if !api.b.RPCDatabaseWrites() {
+		return errDatabaseWritesDisabled
+	}
+	return api.b.ChainDb().Put(key, value)
+}
+
+// DbDelete removes the key from the database. It is only available if the node
+// was started with database writes enabled.
+func (api *DebugAPI) DbDelete(key hexutil.Bytes) error {
+	if !api.b.RPCDatabaseWrites() {
+		return errDatabaseWritesDisabled
+	}
+	return api.b.ChainDb().Delete(key)
+}
+
+// DbBatchOp is a single database modification within a debug_dbWriteBatch call.
+type DbBatchOp struct {
+	Key    hexutil.Bytes `json:"key"`
+	Value  hexutil.Bytes `json:"value,omitempty"`
+	Delete bool          `json:"delete,omitempty"`
+}
+
+// DbWriteBatch atomically applies a list of database modifications. It is only
+// available if the node was started with database writes enabled.
+func (api *DebugAPI) DbWriteBatch(ops []DbBatchOp) error {
+	if !api.b.RPCDatabaseWrites() {
+		return errDatabaseWritesDisabled
+	}
+	batch := api.b.ChainDb().NewBatch()
+	for _, op := range ops {
+		var err error
+		if op.Delete {
+			err = batch.Delete(op.Key)
+		} else {
+			err = batch.Put(op.Key, op.Value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return batch.Write()
+}
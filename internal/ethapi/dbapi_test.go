@@ -0,0 +1,139 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/ethdb/remotedb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Tests that a remote database backed by the debug_db* APIs can read, stream
+// and (if allowed) modify the database of the serving node.
+func TestRemoteDatabase(t *testing.T) {
+	db, err := rawdb.NewDatabaseWithFreezer(memorydb.New(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	// Fill the key-value store with enough data to span multiple streamed
+	// batches, and the freezer with a few blocks.
+	value := bytes.Repeat([]byte{0xff}, 1024)
+	for i := 0; i < 300; i++ {
+		db.Put([]byte(fmt.Sprintf("a-%04d", i)), value)
+		db.Put([]byte(fmt.Sprintf("b-%04d", i)), value)
+	}
+	gspec := &core.Genesis{Config: params.TestChainConfig}
+	_, blocks, receipts := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), 4, nil)
+	blocks = append([]*types.Block{gspec.ToBlock()}, blocks...)
+	receipts = append([]types.Receipts{nil}, receipts...)
+	if _, err := rawdb.WriteAncientBlocks(db, blocks, receipts, big.NewInt(0)); err != nil {
+		t.Fatalf("failed to write ancients: %v", err)
+	}
+	backend := &testBackend{db: db}
+	server := rpc.NewServer()
+	if err := server.RegisterName("debug", NewDebugAPI(backend)); err != nil {
+		t.Fatalf("failed to register API: %v", err)
+	}
+	defer server.Stop()
+
+	remote := remotedb.New(rpc.DialInProc(server))
+	defer remote.Close()
+
+	// Check that iteration streams exactly the requested range
+	it := remote.NewIterator([]byte("a-"), []byte("0100"))
+	var count int
+	for it.Next() {
+		if want := fmt.Sprintf("a-%04d", count+100); string(it.Key()) != want {
+			t.Fatalf("key %d mismatch: have %s, want %s", count, it.Key(), want)
+		}
+		if !bytes.Equal(it.Value(), value) {
+			t.Fatalf("value %d mismatch", count)
+		}
+		count++
+	}
+	if err := it.Error(); err != nil {
+		t.Fatalf("iteration failed: %v", err)
+	}
+	it.Release()
+	if count != 200 {
+		t.Fatalf("iterated item count mismatch: have %d, want %d", count, 200)
+	}
+	// Check the ancient store accessors
+	if n, err := remote.Ancients(); err != nil || n != uint64(len(blocks)) {
+		t.Fatalf("ancients mismatch: have %d (%v), want %d", n, err, len(blocks))
+	}
+	if tail, err := remote.Tail(); err != nil || tail != 0 {
+		t.Fatalf("tail mismatch: have %d (%v), want 0", tail, err)
+	}
+	items, err := remote.AncientRange(rawdb.ChainFreezerHashTable, 1, 3, 0)
+	if err != nil {
+		t.Fatalf("failed to read ancient range: %v", err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("ancient range length mismatch: have %d, want 3", len(items))
+	}
+	for i, item := range items {
+		if !bytes.Equal(item, blocks[i+1].Hash().Bytes()) {
+			t.Fatalf("ancient item %d mismatch: have %x, want %x", i, item, blocks[i+1].Hash())
+		}
+	}
+	// Check that writes are rejected unless explicitly enabled
+	if err := remote.Put([]byte("c"), []byte("c")); err == nil {
+		t.Fatal("expected write to be rejected")
+	}
+	backend.dbWrites = true
+
+	if err := remote.Put([]byte("c"), []byte("c")); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	batch := remote.NewBatch()
+	batch.Put([]byte("d"), []byte("d"))
+	batch.Delete([]byte("a-0000"))
+	if err := batch.Write(); err != nil {
+		t.Fatalf("failed to write batch: %v", err)
+	}
+	for key, want := range map[string][]byte{"c": []byte("c"), "d": []byte("d"), "a-0000": nil} {
+		if have, _ := db.Get([]byte(key)); !bytes.Equal(have, want) {
+			t.Errorf("key %s mismatch: have %x, want %x", key, have, want)
+		}
+	}
+	// Check that losing the remote node mid-iteration is reported as a failure
+	// instead of silent exhaustion
+	it = remote.NewIterator([]byte("b-"), nil)
+	defer it.Release()
+	if !it.Next() {
+		t.Fatalf("iteration failed: %v", it.Error())
+	}
+	remote.Close()
+	for it.Next() {
+	}
+	if it.Error() == nil {
+		t.Fatal("expected iteration error after losing the remote node")
+	}
+}
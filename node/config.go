@@ -83,6 +83,15 @@ type Config struct {
 	// scrypt KDF at the expense of security.
 	UseLightweightKDF bool `toml:",omitempty"`
 
+	// KeyStoreKDF selects the key derivation function the key store encrypts new
+	// and updated keys with, either "scrypt" (default) or "argon2id".
+	KeyStoreKDF string `toml:",omitempty"`
+
+	// PasswordMinLength and PasswordMinClasses define the strength policy the
+	// passwords of new keys must satisfy. Zero disables the respective check.
+	PasswordMinLength  int `toml:",omitempty"`
+	PasswordMinClasses int `toml:",omitempty"`
+
 	// InsecureUnlockAllowed allows user to unlock accounts in unsafe http environment.
 	InsecureUnlockAllowed bool `toml:",omitempty"`
 
@@ -96,6 +105,10 @@ type Config struct {
 	// SmartCardDaemonPath is the path to the smartcard daemon's socket.
 	SmartCardDaemonPath string `toml:",omitempty"`
 
+	// PKCS11Module is the path to a PKCS#11 module giving access to secp256k1
+	// keys on hardware security modules.
+	PKCS11Module string `toml:",omitempty"`
+
 	// IPCPath is the requested location to place the IPC endpoint. If the path is
 	// a simple file name, it is placed inside the data directory (or on the root
 	// pipe path on Windows), whereas if it's a resolvable path name (absolute or
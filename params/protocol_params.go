@@ -125,6 +125,10 @@ const (
 	// Introduced in Tangerine Whistle (Eip 150)
 	CreateBySelfdestructGas uint64 = 25000
 
+	// Introduced with the EVM Object Format (EIP-7069)
+	ExtCallMinRetainedGas uint64 = 5000 // Minimum gas retained by the caller of an EXT*CALL.
+	ExtCallMinCalleeGas   uint64 = 2300 // Minimum gas an EXT*CALL must be able to pass on to the callee.
+
 	DefaultBaseFeeChangeDenominator = 8          // Bounds the amount the base fee can change between blocks.
 	DefaultElasticityMultiplier     = 2          // Bounds the maximum gas limit an EIP-1559 block may have.
 	InitialBaseFee                  = 1000000000 // Initial base fee for EIP-1559 blocks.
@@ -146,6 +146,9 @@ func (args *SendTxArgs) ToTransaction() (*types.Transaction, error) {
 	var data types.TxData
 	switch {
 	case args.BlobHashes != nil:
+		if err := args.validateBlobTx(); err != nil {
+			return nil, err
+		}
 		al := types.AccessList{}
 		if args.AccessList != nil {
 			al = *args.AccessList
@@ -212,10 +215,55 @@ func (args *SendTxArgs) ToTransaction() (*types.Transaction, error) {
 	return types.NewTx(data), nil
 }
 
+// validateBlobTx checks that all the fields required by a blob transaction are
+// present and within range, so it can be constructed without panicking.
+func (args *SendTxArgs) validateBlobTx() error {
+	if args.To == nil {
+		return errors.New(`blob transactions cannot create contracts, "to" is required`)
+	}
+	if len(args.BlobHashes) == 0 {
+		return errors.New("blob transaction without blob hashes")
+	}
+	for i, h := range args.BlobHashes {
+		if !kzg4844.IsValidVersionedHash(h[:]) {
+			return fmt.Errorf("blob hash %d has invalid version: %s", i, h)
+		}
+	}
+	fields := []struct {
+		name  string
+		value *hexutil.Big
+	}{
+		{"chainId", args.ChainID},
+		{"maxFeePerGas", args.MaxFeePerGas},
+		{"maxPriorityFeePerGas", args.MaxPriorityFeePerGas},
+		{"maxFeePerBlobGas", args.BlobFeeCap},
+		{"value", &args.Value},
+	}
+	for _, field := range fields {
+		if field.value == nil {
+			return fmt.Errorf("blob transaction missing %q", field.name)
+		}
+		if v := field.value.ToInt(); v.Sign() < 0 || v.BitLen() > 256 {
+			return fmt.Errorf("blob transaction %q out of range", field.name)
+		}
+	}
+	return nil
+}
+
+// ValidateSidecar verifies the blob sidecar of the transaction, if present,
+// against its blob hashes. Missing commitments, proofs and hashes are computed
+// from the blobs.
+func (args *SendTxArgs) ValidateSidecar() error {
+	return args.validateTxSidecar()
+}
+
 // validateTxSidecar validates blob data, if present
 func (args *SendTxArgs) validateTxSidecar() error {
 	// No blobs, we're done.
 	if args.Blobs == nil {
+		if args.Commitments != nil || args.Proofs != nil {
+			return errors.New(`blob commitments or proofs provided without blobs`)
+		}
 		return nil
 	}
 
@@ -19,9 +19,12 @@ package apitypes
 import (
 	"crypto/sha256"
 	"encoding/json"
+	"math/big"
+	"strings"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto/kzg4844"
 	"github.com/holiman/uint256"
@@ -141,3 +144,86 @@ func TestBlobTxs(t *testing.T) {
 	}
 	t.Logf("tx %v", string(data))
 }
+
+func TestBlobTxArgsValidation(t *testing.T) {
+	blob := kzg4844.Blob{0x1}
+	commitment, err := kzg4844.BlobToCommitment(&blob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := kzg4844.ComputeBlobProof(&blob, commitment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := kzg4844.CalcBlobHashV1(sha256.New(), &commitment)
+
+	// newArgs creates a valid blob transaction without sidecar
+	newArgs := func() *SendTxArgs {
+		to := common.NewMixedcaseAddress(common.HexToAddress("0x1b442286e32ddcaa6e2570ce9ed85f4b4fc87425"))
+		return &SendTxArgs{
+			To:                   &to,
+			Gas:                  21000,
+			ChainID:              (*hexutil.Big)(big.NewInt(7)),
+			MaxFeePerGas:         (*hexutil.Big)(big.NewInt(600)),
+			MaxPriorityFeePerGas: (*hexutil.Big)(big.NewInt(500)),
+			BlobFeeCap:           (*hexutil.Big)(big.NewInt(700)),
+			BlobHashes:           []common.Hash{hash},
+		}
+	}
+	for i, tt := range []struct {
+		modify func(args *SendTxArgs)
+		err    string
+	}{
+		{func(args *SendTxArgs) {}, ""},
+		{func(args *SendTxArgs) { args.To = nil }, `blob transactions cannot create contracts, "to" is required`},
+		{func(args *SendTxArgs) { args.BlobHashes = []common.Hash{} }, "blob transaction without blob hashes"},
+		{func(args *SendTxArgs) { args.BlobHashes = []common.Hash{{0x02}} }, "blob hash 0 has invalid version"},
+		{func(args *SendTxArgs) { args.ChainID = nil }, `blob transaction missing "chainId"`},
+		{func(args *SendTxArgs) { args.MaxPriorityFeePerGas = nil }, `blob transaction missing "maxPriorityFeePerGas"`},
+		{func(args *SendTxArgs) { args.BlobFeeCap = nil }, `blob transaction missing "maxFeePerBlobGas"`},
+		{func(args *SendTxArgs) {
+			args.MaxFeePerGas = (*hexutil.Big)(new(big.Int).Lsh(big.NewInt(1), 256))
+		}, `blob transaction "maxFeePerGas" out of range`},
+		{func(args *SendTxArgs) { args.Commitments = []kzg4844.Commitment{commitment} }, "blob commitments or proofs provided without blobs"},
+		// Sidecars with and without commitments and proofs
+		{func(args *SendTxArgs) { args.Blobs = []kzg4844.Blob{blob} }, ""},
+		{func(args *SendTxArgs) { args.Blobs, args.BlobHashes = []kzg4844.Blob{blob}, nil }, ""},
+		{func(args *SendTxArgs) {
+			args.Blobs = []kzg4844.Blob{blob}
+			args.Commitments = []kzg4844.Commitment{commitment}
+			args.Proofs = []kzg4844.Proof{proof}
+		}, ""},
+		{func(args *SendTxArgs) {
+			args.Blobs = []kzg4844.Blob{blob}
+			args.BlobHashes = []common.Hash{{0x01}}
+		}, "blob hash verification failed"},
+		{func(args *SendTxArgs) {
+			args.Blobs = []kzg4844.Blob{{0x2}}
+			args.Commitments = []kzg4844.Commitment{commitment}
+			args.Proofs = []kzg4844.Proof{proof}
+		}, "failed to verify blob proof"},
+	} {
+		args := newArgs()
+		tt.modify(args)
+
+		tx, err := args.ToTransaction()
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("test %d: error mismatch: have %v, want %q", i, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i, err)
+		}
+		if tx.Type() != types.BlobTxType {
+			t.Errorf("test %d: have type %d, want %d", i, tx.Type(), types.BlobTxType)
+		}
+		if hashes := tx.BlobHashes(); len(hashes) != 1 || hashes[0] != hash {
+			t.Errorf("test %d: blob hash mismatch: have %v, want %v", i, hashes, hash)
+		}
+		if (args.Blobs != nil) != (tx.BlobTxSidecar() != nil) {
+			t.Errorf("test %d: sidecar mismatch: have %v", i, tx.BlobTxSidecar())
+		}
+	}
+}
@@ -17,111 +17,393 @@
 package core
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"crypto/ecdsa"
 	"encoding/json"
-	"log/slog"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/signer/core/apitypes"
 )
 
+// auditSignInterval is the number of entries after which the audit log chain is
+// signed again by the signer key.
+const auditSignInterval = 32
+
+// Decisions recorded in the audit log for the outcome of a request.
+const (
+	AuditApproved = "approved" // Request was served
+	AuditDenied   = "denied"   // Request was denied by the user or the rules
+	AuditFailed   = "failed"   // Request failed for any other reason
+)
+
+// AuditStartup is the method recorded for the entry opening a session of clef.
+const AuditStartup = "Startup"
+
+// AuditRecord is the content of an entry in the audit log.
+type AuditRecord struct {
+	Seq      uint64          `json:"seq"`                // Position of the entry in the log
+	Time     string          `json:"time"`               // Time of the response in RFC3339 format
+	Method   string          `json:"method"`             // Name of the external API method invoked
+	Metadata *Metadata       `json:"metadata,omitempty"` // Metadata of the request
+	Request  json.RawMessage `json:"request,omitempty"`  // Parameters of the request
+	Decision string          `json:"decision,omitempty"` // Outcome of the request
+	Result   json.RawMessage `json:"result,omitempty"`   // Result returned to the caller
+	Error    string          `json:"error,omitempty"`    // Error returned to the caller
+	Signer   *common.Address `json:"signer,omitempty"`   // Key signing the log, announced on startup
+	Prev     common.Hash     `json:"prev"`               // Hash of the previous entry
+}
+
+// AuditEntry is a single line of the audit log, committing to all entries
+// before it through the hash of its predecessor.
+type AuditEntry struct {
+	AuditRecord
+	Hash      common.Hash   `json:"hash"`                // Keccak256 hash of the JSON encoded record
+	Signature hexutil.Bytes `json:"signature,omitempty"` // Signature over the hash, on some entries
+}
+
+// hash computes the hash of the record of the entry.
+func (e *AuditEntry) hash() common.Hash {
+	blob, _ := json.Marshal(&e.AuditRecord) // can't fail, all fields are marshallable
+	return crypto.Keccak256Hash(blob)
+}
+
+// AuditLogger is an ExternalAPI wrapper recording every request and its outcome
+// in a tamper-evident log. Entries are chained together by hashes and the chain
+// is periodically signed with the key of the signer, if available.
 type AuditLogger struct {
-	log log.Logger
 	api ExternalAPI
+	key *ecdsa.PrivateKey
+
+	lock     sync.Mutex
+	file     *os.File
+	seq      uint64      // Sequence number of the next entry
+	prev     common.Hash // Hash of the last entry
+	unsigned int         // Entries since the last signature
 }
 
 func (l *AuditLogger) List(ctx context.Context) ([]common.Address, error) {
-	l.log.Info("List", "type", "request", "metadata", MetadataFromContext(ctx).String())
 	res, e := l.api.List(ctx)
-	l.log.Info("List", "type", "response", "data", res)
-
+	l.record(ctx, "List", nil, res, e)
 	return res, e
 }
 
 func (l *AuditLogger) New(ctx context.Context) (common.Address, error) {
-	return l.api.New(ctx)
+	res, e := l.api.New(ctx)
+	l.record(ctx, "New", nil, res, e)
+	return res, e
 }
 
 func (l *AuditLogger) SignTransaction(ctx context.Context, args apitypes.SendTxArgs, methodSelector *string) (*ethapi.SignTransactionResult, error) {
-	sel := "<nil>"
-	if methodSelector != nil {
-		sel = *methodSelector
-	}
-	l.log.Info("SignTransaction", "type", "request", "metadata", MetadataFromContext(ctx).String(),
-		"tx", args.String(),
-		"methodSelector", sel)
-
 	res, e := l.api.SignTransaction(ctx, args, methodSelector)
-	if res != nil {
-		l.log.Info("SignTransaction", "type", "response", "data", common.Bytes2Hex(res.Raw), "error", e)
-	} else {
-		l.log.Info("SignTransaction", "type", "response", "data", res, "error", e)
-	}
+	l.record(ctx, "SignTransaction", map[string]interface{}{
+		"transaction":    args,
+		"methodSelector": methodSelector,
+	}, res, e)
 	return res, e
 }
 
 func (l *AuditLogger) SignData(ctx context.Context, contentType string, addr common.MixedcaseAddress, data interface{}) (hexutil.Bytes, error) {
-	marshalledData, _ := json.Marshal(data) // can ignore error, marshalling what we just unmarshalled
-	l.log.Info("SignData", "type", "request", "metadata", MetadataFromContext(ctx).String(),
-		"addr", addr.String(), "data", marshalledData, "content-type", contentType)
 	b, e := l.api.SignData(ctx, contentType, addr, data)
-	l.log.Info("SignData", "type", "response", "data", common.Bytes2Hex(b), "error", e)
+	l.record(ctx, "SignData", map[string]interface{}{
+		"contentType": contentType,
+		"address":     addr,
+		"data":        data,
+	}, b, e)
 	return b, e
 }
 
 func (l *AuditLogger) SignGnosisSafeTx(ctx context.Context, addr common.MixedcaseAddress, gnosisTx GnosisSafeTx, methodSelector *string) (*GnosisSafeTx, error) {
-	sel := "<nil>"
-	if methodSelector != nil {
-		sel = *methodSelector
-	}
-	data, _ := json.Marshal(gnosisTx) // can ignore error, marshalling what we just unmarshalled
-	l.log.Info("SignGnosisSafeTx", "type", "request", "metadata", MetadataFromContext(ctx).String(),
-		"addr", addr.String(), "data", string(data), "selector", sel)
 	res, e := l.api.SignGnosisSafeTx(ctx, addr, gnosisTx, methodSelector)
-	if res != nil {
-		data, _ := json.Marshal(res) // can ignore error, marshalling what we just unmarshalled
-		l.log.Info("SignGnosisSafeTx", "type", "response", "data", string(data), "error", e)
-	} else {
-		l.log.Info("SignGnosisSafeTx", "type", "response", "data", res, "error", e)
-	}
+	l.record(ctx, "SignGnosisSafeTx", map[string]interface{}{
+		"address":        addr,
+		"gnosisTx":       gnosisTx,
+		"methodSelector": methodSelector,
+	}, res, e)
 	return res, e
 }
 
 func (l *AuditLogger) SignTypedData(ctx context.Context, addr common.MixedcaseAddress, data apitypes.TypedData) (hexutil.Bytes, error) {
-	l.log.Info("SignTypedData", "type", "request", "metadata", MetadataFromContext(ctx).String(),
-		"addr", addr.String(), "data", data)
 	b, e := l.api.SignTypedData(ctx, addr, data)
-	l.log.Info("SignTypedData", "type", "response", "data", common.Bytes2Hex(b), "error", e)
+	l.record(ctx, "SignTypedData", map[string]interface{}{
+		"address": addr,
+		"data":    data,
+	}, b, e)
 	return b, e
 }
 
 func (l *AuditLogger) EcRecover(ctx context.Context, data hexutil.Bytes, sig hexutil.Bytes) (common.Address, error) {
-	l.log.Info("EcRecover", "type", "request", "metadata", MetadataFromContext(ctx).String(),
-		"data", common.Bytes2Hex(data), "sig", common.Bytes2Hex(sig))
 	b, e := l.api.EcRecover(ctx, data, sig)
-	l.log.Info("EcRecover", "type", "response", "address", b.String(), "error", e)
+	l.record(ctx, "EcRecover", map[string]interface{}{
+		"data":      data,
+		"signature": sig,
+	}, b, e)
 	return b, e
 }
 
 func (l *AuditLogger) Version(ctx context.Context) (string, error) {
-	l.log.Info("Version", "type", "request", "metadata", MetadataFromContext(ctx).String())
 	data, err := l.api.Version(ctx)
-	l.log.Info("Version", "type", "response", "data", data, "error", err)
+	l.record(ctx, "Version", nil, data, err)
 	return data, err
 }
 
-func NewAuditLogger(path string, api ExternalAPI) (*AuditLogger, error) {
-	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+// record appends the outcome of a request to the audit log. Failing to do so is
+// only logged, as the request has already been served.
+func (l *AuditLogger) record(ctx context.Context, method string, request interface{}, result interface{}, err error) {
+	meta := MetadataFromContext(ctx)
+	rec := AuditRecord{Method: method, Metadata: &meta, Decision: AuditApproved}
+	if request != nil {
+		rec.Request, _ = json.Marshal(request) // can ignore error, marshalling what we just unmarshalled
+	}
+	if err != nil {
+		rec.Decision, rec.Error = AuditFailed, err.Error()
+		if errors.Is(err, ErrRequestDenied) {
+			rec.Decision = AuditDenied
+		}
+	} else {
+		rec.Result, _ = json.Marshal(result)
+	}
+	if err := l.append(rec); err != nil {
+		log.Error("Failed to write audit log", "method", method, "err", err)
+	}
+}
+
+// append links the record to the chain, signing it if due, and writes it out.
+func (l *AuditLogger) append(rec AuditRecord) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	rec.Seq, rec.Prev = l.seq, l.prev
+	rec.Time = time.Now().UTC().Format(time.RFC3339Nano)
+
+	entry := &AuditEntry{AuditRecord: rec}
+	entry.Hash = entry.hash()
+	if l.key != nil && (rec.Signer != nil || l.unsigned+1 >= auditSignInterval) {
+		sig, err := crypto.Sign(entry.Hash[:], l.key)
+		if err != nil {
+			return err
+		}
+		entry.Signature = sig
+	}
+	blob, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := l.file.Write(append(blob, '\n')); err != nil {
+		return err
+	}
+	if err := l.file.Sync(); err != nil {
+		return err
+	}
+	l.seq, l.prev = rec.Seq+1, entry.Hash
+	if entry.Signature != nil {
+		l.unsigned = 0
+	} else {
+		l.unsigned++
+	}
+	return nil
+}
+
+// NewAuditLogger opens the audit log at the given path, continuing the chain of
+// entries already present, and records the start of a new session. If a key is
+// given, it periodically signs the chain of entries.
+//
+// A plain text log written by earlier versions of clef is moved aside, starting
+// a new chain. An entry left partially written by a crash is truncated.
+func NewAuditLogger(path string, api ExternalAPI, key *ecdsa.PrivateKey) (*AuditLogger, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
 	if err != nil {
 		return nil, err
 	}
+	// Resume the chain from the last entry, refusing to append to a log that
+	// was tampered with
+	last, size, legacy, err := scanAuditLog(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("audit log %s: %v", path, err)
+	}
+	if legacy {
+		f.Close()
+		backup := path + "." + time.Now().UTC().Format("20060102-150405") + ".legacy"
+		if err := os.Rename(path, backup); err != nil {
+			return nil, fmt.Errorf("failed to move legacy audit log %s away: %v", path, err)
+		}
+		log.Warn("Moved legacy audit log away, starting a hash-chained one", "file", path, "backup", backup)
+
+		if f, err = os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_EXCL, 0600); err != nil {
+			return nil, err
+		}
+		last, size = nil, 0
+	}
+	if stat, err := f.Stat(); err != nil {
+		f.Close()
+		return nil, err
+	} else if stat.Size() > size {
+		log.Warn("Truncating partially written audit log entry", "file", path, "size", size, "dropped", stat.Size()-size)
+		if err := f.Truncate(size); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	l := &AuditLogger{api: api, key: key, file: f}
+	if last != nil {
+		l.seq, l.prev = last.Seq+1, last.Hash
+	}
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		f.Close()
+		return nil, err
+	}
+	rec := AuditRecord{Method: AuditStartup, Decision: AuditApproved}
+	if key != nil {
+		signer := crypto.PubkeyToAddress(key.PublicKey)
+		rec.Signer = &signer
+	}
+	if err := l.append(rec); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return l, nil
+}
 
-	handler := slog.NewTextHandler(f, nil)
-	l := log.NewLogger(handler).With("api", "signer")
-	l.Info("Configured", "audit log", path)
-	return &AuditLogger{l, api}, nil
+// scanAuditLog reads an existing audit log, returning its last entry, or nil if
+// it's empty, and the size of its completely written lines. If the log doesn't
+// start with a hash-chained entry, it's reported as a legacy log instead.
+func scanAuditLog(r io.Reader) (last *AuditEntry, size int64, legacy bool, err error) {
+	var (
+		reader = bufio.NewReader(r)
+		line   []byte
+	)
+	for {
+		blob, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			break // Anything without a newline was not fully written
+		}
+		if err != nil {
+			return nil, 0, false, err
+		}
+		if len(blob) > maxAuditEntrySize {
+			return nil, 0, false, fmt.Errorf("line too long at offset %d", size)
+		}
+		size += int64(len(blob))
+		if blob = bytes.TrimSpace(blob); len(blob) == 0 {
+			continue
+		}
+		if line == nil {
+			var entry AuditEntry
+			if json.Unmarshal(blob, &entry) != nil || entry.Hash == (common.Hash{}) {
+				return nil, 0, true, nil
+			}
+		}
+		line = append(line[:0], blob...)
+	}
+	if line == nil {
+		return nil, size, false, nil
+	}
+	entry := new(AuditEntry)
+	if err := json.Unmarshal(line, entry); err != nil {
+		return nil, 0, false, fmt.Errorf("malformed last entry: %v", err)
+	}
+	if entry.hash() != entry.Hash {
+		return nil, 0, false, fmt.Errorf("last entry %d is corrupted", entry.Seq)
+	}
+	return entry, size, false, nil
+}
+
+// maxAuditEntrySize is the maximum size of a single audit log line.
+const maxAuditEntrySize = 16 * 1024 * 1024
+
+// AuditVerifyError is returned when verifying an audit log fails, pinpointing
+// the line of the offending entry.
+type AuditVerifyError struct {
+	Line int
+	Err  error
+}
+
+func (e *AuditVerifyError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *AuditVerifyError) Unwrap() error {
+	return e.Err
+}
+
+// VerifyAuditLog checks the integrity of an audit log: that entries are numbered
+// consecutively, that each commits to its predecessor, and that signatures were
+// made by the key announced at the start of the session. If a signer is given,
+// every session must be signed by it.
+//
+// The verified entries are returned, up to the first one failing verification.
+// Note, the removal of entries from the end of the log can only be detected up
+// to the last signed entry still present.
+func VerifyAuditLog(r io.Reader, signer *common.Address) ([]*AuditEntry, error) {
+	var (
+		entries []*AuditEntry
+		scanner = bufio.NewScanner(r)
+		line    int
+		prev    common.Hash
+		session *common.Address // Signer announced in the current session
+	)
+	scanner.Buffer(nil, maxAuditEntrySize)
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		fail := func(format string, args ...interface{}) ([]*AuditEntry, error) {
+			return entries, &AuditVerifyError{Line: line, Err: fmt.Errorf(format, args...)}
+		}
+		entry := new(AuditEntry)
+		if err := json.Unmarshal(scanner.Bytes(), entry); err != nil {
+			return fail("malformed entry: %v", err)
+		}
+		if entry.Seq != uint64(len(entries)) {
+			return fail("entry %d out of sequence, expected %d", entry.Seq, len(entries))
+		}
+		if entry.Prev != prev {
+			return fail("entry %d does not link to its predecessor", entry.Seq)
+		}
+		if hash := entry.hash(); entry.Hash != hash {
+			return fail("entry %d hash mismatch: have %v, want %v", entry.Seq, entry.Hash, hash)
+		}
+		if entry.Method == AuditStartup {
+			session = entry.Signer
+			if signer != nil && (session == nil || *session != *signer) {
+				return fail("session started at entry %d is not signed by %v", entry.Seq, *signer)
+			}
+			if session != nil && entry.Signature == nil {
+				return fail("session start at entry %d is not signed", entry.Seq)
+			}
+		} else if entry.Signer != nil {
+			return fail("entry %d announces a signer outside of session start", entry.Seq)
+		}
+		if entry.Signature != nil {
+			if session == nil {
+				return fail("entry %d signed without an announced signer", entry.Seq)
+			}
+			pubkey, err := crypto.SigToPub(entry.Hash[:], entry.Signature)
+			if err != nil {
+				return fail("entry %d has invalid signature: %v", entry.Seq, err)
+			}
+			if addr := crypto.PubkeyToAddress(*pubkey); addr != *session {
+				return fail("entry %d signed by %v, expected %v", entry.Seq, addr, *session)
+			}
+		}
+		entries = append(entries, entry)
+		prev = entry.Hash
+	}
+	if err := scanner.Err(); err != nil {
+		return entries, &AuditVerifyError{Line: line, Err: err}
+	}
+	return entries, nil
 }
@@ -0,0 +1,165 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core"
+)
+
+// versionAPI is a signer backend only serving version requests, denying the
+// signing of data.
+type versionAPI struct {
+	core.ExternalAPI
+}
+
+func (versionAPI) Version(context.Context) (string, error) {
+	return "6.0.0", nil
+}
+
+func (versionAPI) SignData(context.Context, string, common.MixedcaseAddress, interface{}) (hexutil.Bytes, error) {
+	return nil, core.ErrRequestDenied
+}
+
+func TestAuditLogVerify(t *testing.T) {
+	var (
+		path   = filepath.Join(t.TempDir(), "audit.log")
+		key, _ = crypto.GenerateKey()
+		signer = crypto.PubkeyToAddress(key.PublicKey)
+		ctx    = context.Background()
+	)
+	// Write two sessions, making sure the second continues the chain
+	for session := 0; session < 2; session++ {
+		logger, err := core.NewAuditLogger(path, versionAPI{}, key)
+		if err != nil {
+			t.Fatalf("session %d: failed to open audit log: %v", session, err)
+		}
+		for i := 0; i < 40; i++ {
+			logger.Version(ctx)
+		}
+		logger.SignData(ctx, "text/plain", common.NewMixedcaseAddress(signer), "0x01")
+	}
+	blob, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := core.VerifyAuditLog(bytes.NewReader(blob), &signer)
+	if err != nil {
+		t.Fatalf("failed to verify audit log: %v", err)
+	}
+	if len(entries) != 84 {
+		t.Fatalf("entry count mismatch: have %d, want 84", len(entries))
+	}
+	var signatures int
+	for _, entry := range entries {
+		if entry.Signature != nil {
+			signatures++
+		}
+	}
+	if signatures != 4 { // Both session starts, and one periodic signature each
+		t.Errorf("signature count mismatch: have %d, want 4", signatures)
+	}
+	if last := entries[len(entries)-1]; last.Decision != core.AuditDenied || last.Error != core.ErrRequestDenied.Error() {
+		t.Errorf("denied request recorded as %s (%s)", last.Decision, last.Error)
+	}
+	// Any other signer must be rejected
+	other := common.Address{0x1}
+	if _, err := core.VerifyAuditLog(bytes.NewReader(blob), &other); err == nil {
+		t.Error("audit log verified against wrong signer")
+	}
+	// Tampering with, dropping or reordering entries must be detected
+	lines := strings.Split(strings.TrimSpace(string(blob)), "\n")
+	tamper := map[string]func([]string) []string{
+		"modify": func(l []string) []string {
+			l[10] = strings.Replace(l[10], `"decision":"approved"`, `"decision":"denied"`, 1)
+			return l
+		},
+		"drop": func(l []string) []string {
+			return append(l[:10], l[11:]...)
+		},
+		"swap": func(l []string) []string {
+			l[10], l[11] = l[11], l[10]
+			return l
+		},
+	}
+	for name, fn := range tamper {
+		mangled := fn(append([]string{}, lines...))
+		entries, err := core.VerifyAuditLog(strings.NewReader(strings.Join(mangled, "\n")), nil)
+		if err == nil {
+			t.Errorf("%s: tampered audit log verified", name)
+		} else if len(entries) != 10 {
+			t.Errorf("%s: verified entry count mismatch: have %d, want 10", name, len(entries))
+		}
+	}
+	// A partially written last entry must be dropped, continuing the chain
+	os.WriteFile(path, append(blob, `{"seq":84,"time":"2025-01-01T0`...), 0600)
+	if _, err := core.NewAuditLogger(path, versionAPI{}, key); err != nil {
+		t.Fatalf("failed to open torn audit log: %v", err)
+	}
+	if blob, err = os.ReadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if entries, err = core.VerifyAuditLog(bytes.NewReader(blob), &signer); err != nil {
+		t.Fatalf("failed to verify truncated audit log: %v", err)
+	} else if len(entries) != 85 {
+		t.Fatalf("entry count mismatch: have %d, want 85", len(entries))
+	}
+	// Appending to a tampered audit log must be refused
+	lines = strings.Split(strings.TrimSpace(string(blob)), "\n")
+	lines[len(lines)-1] = strings.Replace(lines[len(lines)-1], `"method":"Startup"`, `"method":"Version"`, 1)
+	os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600)
+	if _, err := core.NewAuditLogger(path, versionAPI{}, key); err == nil {
+		t.Error("appended to tampered audit log")
+	}
+}
+
+// Tests that a plain text audit log of earlier clef versions is moved aside,
+// starting a new hash-chained log.
+func TestAuditLogLegacy(t *testing.T) {
+	var (
+		dir    = t.TempDir()
+		path   = filepath.Join(dir, "audit.log")
+		legacy = []byte("time=2025-01-01T00:00:00Z level=INFO msg=Configured\n")
+	)
+	os.WriteFile(path, legacy, 0600)
+	if _, err := core.NewAuditLogger(path, versionAPI{}, nil); err != nil {
+		t.Fatalf("failed to open audit log: %v", err)
+	}
+	blob, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries, err := core.VerifyAuditLog(bytes.NewReader(blob), nil); err != nil || len(entries) != 1 {
+		t.Fatalf("new audit log invalid: %d entries, err %v", len(entries), err)
+	}
+	backups, _ := filepath.Glob(filepath.Join(dir, "audit.log.*.legacy"))
+	if len(backups) != 1 {
+		t.Fatalf("legacy audit log not moved aside: %v", backups)
+	}
+	if have, _ := os.ReadFile(backups[0]); !bytes.Equal(have, legacy) {
+		t.Errorf("legacy audit log content mismatch: have %q, want %q", have, legacy)
+	}
+}
@@ -137,10 +137,18 @@ func (ui *CommandlineUI) ApproveTx(request *SignTxRequest) (SignTxResponse, erro
 		}
 	}
 	if len(request.Transaction.BlobHashes) > 0 {
+		if request.Transaction.BlobFeeCap != nil {
+			fmt.Printf("maxFeePerBlobGas: %v wei\n", request.Transaction.BlobFeeCap.ToInt())
+		}
 		fmt.Printf("Blob hashes:\n")
 		for _, bh := range request.Transaction.BlobHashes {
 			fmt.Printf("   %v\n", bh)
 		}
+		if request.Transaction.Blobs != nil {
+			fmt.Printf("Blob sidecar: %d blob(s), commitments verified\n", len(request.Transaction.Blobs))
+		} else {
+			fmt.Printf("Blob sidecar: <none>\n")
+		}
 	}
 	if request.Transaction.Data != nil {
 		d := *request.Transaction.Data
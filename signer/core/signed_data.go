@@ -240,7 +240,7 @@ func (api *SignerAPI) signTypedData(ctx context.Context, addr common.MixedcaseAd
 	req.Address = addr
 	req.Meta = MetadataFromContext(ctx)
 	if validationMessages != nil {
-		req.Callinfo = validationMessages.Messages
+		req.Callinfo = append(req.Callinfo, validationMessages.Messages...)
 	}
 	signature, err := api.sign(req, true)
 	if err != nil {
@@ -286,7 +286,9 @@ func typedDataRequest(data any) (*SignDataRequest, error) {
 		ContentType: apitypes.DataTyped.Mime,
 		Rawdata:     []byte(rawData),
 		Messages:    messages,
-		Hash:        sighash}, nil
+		Callinfo:    typedDataWarnings(&typedData).Messages,
+		Hash:        sighash,
+		TypedData:   &typedData}, nil
 }
 
 // EcRecover recovers the address associated with the given sig.
@@ -0,0 +1,271 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// Names of the approval schemas recognized by AnalyzeTypedData.
+const (
+	SchemaERC2612          = "ERC-2612 permit"
+	SchemaDAIPermit        = "DAI permit"
+	SchemaPermit2Allowance = "Permit2 allowance"
+	SchemaPermit2Transfer  = "Permit2 signature transfer"
+)
+
+// maxDisplayableTimestamp is 9999-12-31T23:59:59Z, deadlines past it are shown
+// as never expiring.
+const maxDisplayableTimestamp = 253402300799
+
+// TypedDataApproval is a token approval granted by signing an EIP-712 message.
+type TypedDataApproval struct {
+	Schema    string         // Name of the recognized approval schema
+	Token     common.Address // Token contract the approval is for
+	TokenName string         // Name of the token from the signing domain, if known
+	Spender   common.Address // Account allowed to spend the tokens
+	Amount    *big.Int       // Amount the spender may move, nil if not limited by the schema or unreadable
+	Unlimited bool           // Whether the amount is the maximum the schema allows, or unreadable
+	Deadline  *big.Int       // Unix timestamp the approval (or signature) expires at, nil if never
+}
+
+// String returns a human-readable summary of the approval.
+func (a *TypedDataApproval) String() string {
+	token := a.Token.Hex()
+	if a.TokenName != "" {
+		token = fmt.Sprintf("%s (%s)", token, a.TokenName)
+	}
+	amount := "an unknown amount"
+	switch {
+	case a.Unlimited && a.Amount == nil:
+		amount = "an UNLIMITED amount (unreadable value)"
+	case a.Unlimited:
+		amount = "an UNLIMITED amount"
+	case a.Amount != nil:
+		amount = a.Amount.String()
+	}
+	return fmt.Sprintf("%s: spender %s may spend %s of token %s, deadline %s",
+		a.Schema, a.Spender.Hex(), amount, token, formatDeadline(a.Deadline))
+}
+
+// formatDeadline formats a unix timestamp deadline.
+func formatDeadline(deadline *big.Int) string {
+	switch {
+	case deadline == nil || !deadline.IsInt64() || deadline.Int64() > maxDisplayableTimestamp:
+		return "never"
+	default:
+		return time.Unix(deadline.Int64(), 0).UTC().Format(time.RFC3339)
+	}
+}
+
+// AnalyzeTypedData inspects an EIP-712 message for well known token approval
+// schemas, ERC-2612 and DAI style permits as well as Permit2 allowances and
+// signature transfers, returning the approvals granted by signing it.
+func AnalyzeTypedData(typedData *apitypes.TypedData) []*TypedDataApproval {
+	var (
+		msg     = typedData.Message
+		primary = typedData.PrimaryType
+	)
+	switch {
+	case primary == "Permit" && hasTypedFields(typedData, "Permit", "owner", "address", "spender", "address", "value", "uint256", "deadline", "uint256"):
+		approval := &TypedDataApproval{
+			Schema:    SchemaERC2612,
+			Token:     common.HexToAddress(typedData.Domain.VerifyingContract),
+			TokenName: typedData.Domain.Name,
+			Spender:   typedDataAddress(msg["spender"]),
+			Deadline:  typedDataInteger(msg["deadline"]),
+		}
+		approval.Amount, approval.Unlimited = typedDataAmount(msg["value"], 256)
+		return []*TypedDataApproval{approval}
+
+	case primary == "Permit" && hasTypedFields(typedData, "Permit", "holder", "address", "spender", "address", "expiry", "uint256", "allowed", "bool"):
+		approval := &TypedDataApproval{
+			Schema:    SchemaDAIPermit,
+			Token:     common.HexToAddress(typedData.Domain.VerifyingContract),
+			TokenName: typedData.Domain.Name,
+			Spender:   typedDataAddress(msg["spender"]),
+			Amount:    new(big.Int),
+			Deadline:  typedDataInteger(msg["expiry"]),
+		}
+		// DAI permits either grant an unlimited allowance or revoke it. An expiry of
+		// zero means the permit never expires.
+		if allowed, _ := msg["allowed"].(bool); allowed {
+			approval.Unlimited = true
+		}
+		if approval.Deadline != nil && approval.Deadline.Sign() == 0 {
+			approval.Deadline = nil
+		}
+		return []*TypedDataApproval{approval}
+
+	case (primary == "PermitSingle" || primary == "PermitBatch") && hasTypedFields(typedData, "PermitDetails", "token", "address", "amount", "uint160", "expiration", "uint48"):
+		// Permit2 allowances, the deadline is the expiry of the allowance itself
+		// rather than of the signature
+		var details []interface{}
+		switch d := msg["details"].(type) {
+		case map[string]interface{}:
+			details = []interface{}{d}
+		case []interface{}:
+			details = d
+		}
+		spender := typedDataAddress(msg["spender"])
+
+		var approvals []*TypedDataApproval
+		for _, detail := range details {
+			fields, ok := detail.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			approval := &TypedDataApproval{
+				Schema:   SchemaPermit2Allowance,
+				Token:    typedDataAddress(fields["token"]),
+				Spender:  spender,
+				Deadline: typedDataInteger(fields["expiration"]),
+			}
+			approval.Amount, approval.Unlimited = typedDataAmount(fields["amount"], 160)
+			approvals = append(approvals, approval)
+		}
+		return approvals
+
+	case hasTypedFields(typedData, primary, "spender", "address", "deadline", "uint256") && hasTypedFields(typedData, "TokenPermissions", "token", "address", "amount", "uint256"):
+		// Permit2 signature transfers, including the witness and batch variants
+		var permitted []interface{}
+		switch p := msg["permitted"].(type) {
+		case map[string]interface{}:
+			permitted = []interface{}{p}
+		case []interface{}:
+			permitted = p
+		}
+		var (
+			spender  = typedDataAddress(msg["spender"])
+			deadline = typedDataInteger(msg["deadline"])
+		)
+		var approvals []*TypedDataApproval
+		for _, permission := range permitted {
+			fields, ok := permission.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			approval := &TypedDataApproval{
+				Schema:   SchemaPermit2Transfer,
+				Token:    typedDataAddress(fields["token"]),
+				Spender:  spender,
+				Deadline: deadline,
+			}
+			approval.Amount, approval.Unlimited = typedDataAmount(fields["amount"], 256)
+			approvals = append(approvals, approval)
+		}
+		return approvals
+	}
+	return nil
+}
+
+// typedDataWarnings returns the validation messages to show for the approvals
+// granted by signing the typed data.
+func typedDataWarnings(typedData *apitypes.TypedData) *apitypes.ValidationMessages {
+	messages := new(apitypes.ValidationMessages)
+	for _, approval := range AnalyzeTypedData(typedData) {
+		messages.Warn(approval.String())
+	}
+	return messages
+}
+
+// hasTypedFields checks whether the given type of the typed data has all the
+// listed fields, given as name and type pairs.
+func hasTypedFields(typedData *apitypes.TypedData, typeName string, fields ...string) bool {
+	typ, ok := typedData.Types[typeName]
+	if !ok {
+		return false
+	}
+	for i := 0; i < len(fields); i += 2 {
+		var found bool
+		for _, field := range typ {
+			if field.Name == fields[i] && field.Type == fields[i+1] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// typedDataAddress interprets a typed data message value as an address.
+func typedDataAddress(value interface{}) common.Address {
+	switch v := value.(type) {
+	case string:
+		return common.HexToAddress(v)
+	case common.Address:
+		return v
+	}
+	return common.Address{}
+}
+
+// typedDataInteger interprets a typed data message value as an integer, returning
+// nil if it is not one.
+func typedDataInteger(value interface{}) *big.Int {
+	switch v := value.(type) {
+	case string:
+		if n, ok := math.ParseBig256(v); ok {
+			return n
+		}
+	case json.Number:
+		if n, ok := math.ParseBig256(v.String()); ok {
+			return n
+		}
+	case float64:
+		// Large JSON numbers lose precision when decoded, only accept exact ones
+		if float64(int64(v)) == v {
+			return big.NewInt(int64(v))
+		}
+	case *big.Int:
+		return v
+	case *math.HexOrDecimal256:
+		return (*big.Int)(v)
+	}
+	return nil
+}
+
+// typedDataAmount interprets a typed data message value as a token amount of
+// the given bit size, returning whether it's unlimited. An amount that cannot be
+// read exactly, like a large JSON number decoded with a loss of precision, is
+// reported as unlimited, being the worst case.
+func typedDataAmount(value interface{}, bits uint) (*big.Int, bool) {
+	amount := typedDataInteger(value)
+	if amount == nil {
+		return nil, true
+	}
+	return amount, isMaxUint(amount, bits)
+}
+
+// isMaxUint checks whether n is the maximum value of an unsigned integer of the
+// given bit size, which token contracts treat as an unlimited allowance.
+func isMaxUint(n *big.Int, bits uint) bool {
+	if n == nil {
+		return false
+	}
+	max := new(big.Int).Sub(new(big.Int).Lsh(common.Big1, bits), common.Big1)
+	return n.Cmp(max) == 0
+}
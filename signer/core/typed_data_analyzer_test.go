@@ -0,0 +1,148 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/signer/core"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+const erc2612Permit = `{
+	"types": {
+		"EIP712Domain": [{"name":"name","type":"string"},{"name":"version","type":"string"},{"name":"chainId","type":"uint256"},{"name":"verifyingContract","type":"address"}],
+		"Permit": [{"name":"owner","type":"address"},{"name":"spender","type":"address"},{"name":"value","type":"uint256"},{"name":"nonce","type":"uint256"},{"name":"deadline","type":"uint256"}]
+	},
+	"primaryType": "Permit",
+	"domain": {"name":"USD Coin","version":"2","chainId":1,"verifyingContract":"0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"},
+	"message": {"owner":"0x1111111111111111111111111111111111111111","spender":"0x2222222222222222222222222222222222222222","value":"1000000","nonce":0,"deadline":1767225600}
+}`
+
+const daiPermit = `{
+	"types": {
+		"EIP712Domain": [{"name":"name","type":"string"},{"name":"version","type":"string"},{"name":"chainId","type":"uint256"},{"name":"verifyingContract","type":"address"}],
+		"Permit": [{"name":"holder","type":"address"},{"name":"spender","type":"address"},{"name":"nonce","type":"uint256"},{"name":"expiry","type":"uint256"},{"name":"allowed","type":"bool"}]
+	},
+	"primaryType": "Permit",
+	"domain": {"name":"Dai Stablecoin","version":"1","chainId":1,"verifyingContract":"0x6B175474E89094C44Da98b954EedeAC495271d0F"},
+	"message": {"holder":"0x1111111111111111111111111111111111111111","spender":"0x2222222222222222222222222222222222222222","nonce":3,"expiry":0,"allowed":true}
+}`
+
+const permit2Batch = `{
+	"types": {
+		"EIP712Domain": [{"name":"name","type":"string"},{"name":"chainId","type":"uint256"},{"name":"verifyingContract","type":"address"}],
+		"PermitBatch": [{"name":"details","type":"PermitDetails[]"},{"name":"spender","type":"address"},{"name":"sigDeadline","type":"uint256"}],
+		"PermitDetails": [{"name":"token","type":"address"},{"name":"amount","type":"uint160"},{"name":"expiration","type":"uint48"},{"name":"nonce","type":"uint48"}]
+	},
+	"primaryType": "PermitBatch",
+	"domain": {"name":"Permit2","chainId":1,"verifyingContract":"0x000000000022D473030F116dDEE9F6B43aC78BA3"},
+	"message": {
+		"details": [
+			{"token":"0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48","amount":"0xffffffffffffffffffffffffffffffffffffffff","expiration":1767225600,"nonce":0},
+			{"token":"0x6B175474E89094C44Da98b954EedeAC495271d0F","amount":"5000","expiration":"1767225600","nonce":1}
+		],
+		"spender":"0x3333333333333333333333333333333333333333",
+		"sigDeadline":1767225600
+	}
+}`
+
+const permit2Transfer = `{
+	"types": {
+		"EIP712Domain": [{"name":"name","type":"string"},{"name":"chainId","type":"uint256"},{"name":"verifyingContract","type":"address"}],
+		"PermitTransferFrom": [{"name":"permitted","type":"TokenPermissions"},{"name":"spender","type":"address"},{"name":"nonce","type":"uint256"},{"name":"deadline","type":"uint256"}],
+		"TokenPermissions": [{"name":"token","type":"address"},{"name":"amount","type":"uint256"}]
+	},
+	"primaryType": "PermitTransferFrom",
+	"domain": {"name":"Permit2","chainId":1,"verifyingContract":"0x000000000022D473030F116dDEE9F6B43aC78BA3"},
+	"message": {
+		"permitted": {"token":"0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48","amount":"0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"},
+		"spender":"0x4444444444444444444444444444444444444444",
+		"nonce":7,
+		"deadline":"0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"
+	}
+}`
+
+func TestAnalyzeTypedData(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		data string
+		want []string
+	}{
+		{erc2612Permit, []string{
+			"ERC-2612 permit: spender 0x2222222222222222222222222222222222222222 may spend 1000000 of token 0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48 (USD Coin), deadline 2026-01-01T00:00:00Z",
+		}},
+		{daiPermit, []string{
+			"DAI permit: spender 0x2222222222222222222222222222222222222222 may spend an UNLIMITED amount of token 0x6B175474E89094C44Da98b954EedeAC495271d0F (Dai Stablecoin), deadline never",
+		}},
+		{permit2Batch, []string{
+			"Permit2 allowance: spender 0x3333333333333333333333333333333333333333 may spend an UNLIMITED amount of token 0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48, deadline 2026-01-01T00:00:00Z",
+			"Permit2 allowance: spender 0x3333333333333333333333333333333333333333 may spend 5000 of token 0x6B175474E89094C44Da98b954EedeAC495271d0F, deadline 2026-01-01T00:00:00Z",
+		}},
+		{permit2Transfer, []string{
+			"Permit2 signature transfer: spender 0x4444444444444444444444444444444444444444 may spend an UNLIMITED amount of token 0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48, deadline never",
+		}},
+		// Regular typed data should not be reported
+		{jsonTypedData, nil},
+		{gnosisTypedData, nil},
+	}
+	for i, tt := range tests {
+		var td apitypes.TypedData
+		if err := json.Unmarshal([]byte(tt.data), &td); err != nil {
+			t.Fatalf("test %d: failed to unmarshal typed data: %v", i, err)
+		}
+		// Make sure the approval fixtures are signable typed data
+		if _, _, err := apitypes.TypedDataAndHash(td); tt.want != nil && err != nil {
+			t.Fatalf("test %d: invalid typed data: %v", i, err)
+		}
+		approvals := core.AnalyzeTypedData(&td)
+		if len(approvals) != len(tt.want) {
+			t.Fatalf("test %d: approval count mismatch: have %d, want %d", i, len(approvals), len(tt.want))
+		}
+		for j, approval := range approvals {
+			if have := approval.String(); have != tt.want[j] {
+				t.Errorf("test %d, approval %d: summary mismatch:\nhave %q\nwant %q", i, j, have, tt.want[j])
+			}
+		}
+	}
+}
+
+// Tests that an amount given as a JSON number too large to be decoded exactly is
+// reported as unlimited rather than unknown.
+func TestAnalyzeTypedDataImpreciseAmount(t *testing.T) {
+	t.Parallel()
+
+	var td apitypes.TypedData
+	data := strings.Replace(erc2612Permit, `"value":"1000000"`, `"value":115792089237316195423570985008687907853269984665640564039457584007913129639935`, 1)
+	if err := json.Unmarshal([]byte(data), &td); err != nil {
+		t.Fatalf("failed to unmarshal typed data: %v", err)
+	}
+	approvals := core.AnalyzeTypedData(&td)
+	if len(approvals) != 1 {
+		t.Fatalf("approval count mismatch: have %d, want 1", len(approvals))
+	}
+	if !approvals[0].Unlimited {
+		t.Errorf("imprecise amount not treated as unlimited: %v", approvals[0])
+	}
+	want := "ERC-2612 permit: spender 0x2222222222222222222222222222222222222222 may spend an UNLIMITED amount (unreadable value) of token 0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48 (USD Coin), deadline 2026-01-01T00:00:00Z"
+	if have := approvals[0].String(); have != want {
+		t.Errorf("summary mismatch:\nhave %q\nwant %q", have, want)
+	}
+}
@@ -134,6 +134,33 @@ func (api *UIServerAPI) ImportRawKey(privkey string, password string) (accounts.
 	return fetchKeystore(api.am).ImportECDSA(key, password)
 }
 
+// ImportMnemonic stores the seed of the given BIP-39 mnemonic into the key directory
+// as a hierarchical deterministic wallet, encrypting it with the password.
+// Example call (should fail on password too short)
+// {"jsonrpc":"2.0","method":"clef_importMnemonic","params":["abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about","","test"], "id":6}
+func (api *UIServerAPI) ImportMnemonic(mnemonic string, bip39Passphrase string, password string) (accounts.Account, error) {
+	if err := ValidatePasswordFormat(password); err != nil {
+		return accounts.Account{}, fmt.Errorf("password requirements not met: %v", err)
+	}
+	ks := fetchKeystore(api.am)
+	if ks == nil {
+		return accounts.Account{}, errors.New("password based accounts not supported")
+	}
+	return ks.ImportMnemonic(mnemonic, bip39Passphrase, password)
+}
+
+// ExportMnemonic decrypts and returns the BIP-39 mnemonic of the hierarchical
+// deterministic wallet containing the given account.
+// Example call
+// {"jsonrpc":"2.0","method":"clef_exportMnemonic","params":["0x9858EfFD232B4033E47d90003D41EC34EcaEda94","yaddayadda"], "id":6}
+func (api *UIServerAPI) ExportMnemonic(addr common.Address, password string) (string, error) {
+	ks := fetchKeystore(api.am)
+	if ks == nil {
+		return "", errors.New("password based accounts not supported")
+	}
+	return ks.ExportMnemonic(accounts.Account{Address: addr}, password)
+}
+
 // OpenWallet initiates a hardware wallet opening procedure, establishing a USB
 // connection and attempting to authenticate via the provided passphrase. Note,
 // the method may return an extra challenge requiring a second open (e.g. the
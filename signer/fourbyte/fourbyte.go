@@ -114,6 +114,27 @@ func (db *Database) Selector(id []byte) (string, error) {
 	return "", fmt.Errorf("signature %v not found", sig)
 }
 
+// Method resolves the method invoked by the given call data against the known
+// ABI methods and verifies that the data is a well-formed call to it, returning
+// the canonical signature of the method.
+func (db *Database) Method(calldata []byte) (string, error) {
+	selector, err := db.Selector(calldata)
+	if err != nil {
+		return "", err
+	}
+	return VerifyMethod(selector, calldata)
+}
+
+// VerifyMethod checks that the given call data is a well-formed call to the
+// provided method signature, returning its canonical form.
+func VerifyMethod(selector string, calldata []byte) (string, error) {
+	decoded, err := verifySelector(selector, calldata)
+	if err != nil {
+		return "", err
+	}
+	return decoded.signature, nil
+}
+
 // AddSelector inserts a new 4byte entry into the database. If custom database
 // saving is enabled, the new dataset is also persisted to disk.
 //
@@ -87,6 +87,12 @@ func (db *Database) ValidateTransaction(selector *string, tx *apitypes.SendTxArg
 	case tx.GasPrice != nil && tx.MaxPriorityFeePerGas != nil:
 		messages.Crit("Both 'gasPrice' and 'maxPriorityFeePerGas' specified.")
 	}
+	if tx.BlobHashes != nil {
+		messages.Info(fmt.Sprintf("Transaction carries %d blob(s), paying up to %v wei per blob gas", len(tx.BlobHashes), tx.BlobFeeCap.ToInt()))
+		if tx.Blobs == nil {
+			messages.Warn("Blob transaction without sidecar, it cannot be submitted to the network without the blobs")
+		}
+	}
 	// Semantic fields validated, try to make heads or tails of the call data
 	db.ValidateCallData(selector, data, messages)
 	return messages, nil
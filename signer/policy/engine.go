@@ -0,0 +1,374 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/signer/core"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/ethereum/go-ethereum/signer/fourbyte"
+	"github.com/ethereum/go-ethereum/signer/storage"
+)
+
+// Decision is the verdict of the policy on a single request.
+type Decision struct {
+	Action Action `json:"action"`
+	Reason string `json:"reason"`
+}
+
+// spend is the value, including fees, and gas an account used on a single day.
+type spend struct {
+	Value *hexutil.Big   `json:"value"`
+	Gas   hexutil.Uint64 `json:"gas"`
+}
+
+// spendKey identifies a transaction of an account by its nonce.
+type spendKey struct {
+	from  common.Address
+	nonce uint64
+}
+
+// Evaluator checks requests against a policy, tracking the daily spend of the
+// accounts in the given storage.
+type Evaluator struct {
+	policy  *Policy
+	db      *fourbyte.Database // Optional, used to name methods in decisions
+	storage storage.Storage
+	now     func() time.Time
+
+	lock     sync.Mutex
+	reserved map[spendKey]bool // Auto-approved transactions already accounted for
+}
+
+// NewEvaluator creates an evaluator of the given policy.
+func NewEvaluator(policy *Policy, db *fourbyte.Database, backend storage.Storage) *Evaluator {
+	return &Evaluator{
+		policy:   policy,
+		db:       db,
+		storage:  backend,
+		now:      time.Now,
+		reserved: make(map[spendKey]bool),
+	}
+}
+
+func (e *Evaluator) reject(format string, args ...interface{}) Decision {
+	return Decision{Action: e.policy.Fallback, Reason: fmt.Sprintf(format, args...)}
+}
+
+// ApproveTx evaluates a transaction signing request. If the transaction is
+// approved, its cost and gas are counted towards the daily limits right away
+// so that concurrent requests cannot exceed them.
+func (e *Evaluator) ApproveTx(req *core.SignTxRequest) Decision {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	var (
+		tx   = &req.Transaction
+		from = tx.From.Address()
+		now  = e.now()
+	)
+	account := e.policy.account(from)
+	if account == nil {
+		return e.reject("account %v not covered by policy", from)
+	}
+	for _, info := range req.Callinfo {
+		if info.Typ == apitypes.WARN || info.Typ == apitypes.CRIT {
+			return e.reject("validation %s: %s", strings.ToLower(info.Typ), info.Message)
+		}
+	}
+	if !account.inWindow(now) {
+		return e.reject("outside of approval windows")
+	}
+	if reason := e.checkDestination(account, tx); reason != "" {
+		return e.reject("%s", reason)
+	}
+	if reason := checkFees(account, tx); reason != "" {
+		return e.reject("%s", reason)
+	}
+	cost := txCost(tx)
+	if account.MaxTxValue != nil && cost.Cmp(account.MaxTxValue.Big()) > 0 {
+		return e.reject("cost %v exceeds per-transaction limit %v", cost, account.MaxTxValue)
+	}
+	gas := uint64(tx.Gas)
+	if account.MaxTxGas != 0 && gas > account.MaxTxGas {
+		return e.reject("gas %d exceeds per-transaction limit %d", gas, account.MaxTxGas)
+	}
+	used := e.spent(from, now)
+	total := new(big.Int).Add(used.Value.ToInt(), cost)
+	if account.DailyValue != nil && total.Cmp(account.DailyValue.Big()) > 0 {
+		return e.reject("cost %v exceeds remaining daily limit %v", cost, new(big.Int).Sub(account.DailyValue.Big(), used.Value.ToInt()))
+	}
+	if account.DailyGas != 0 && uint64(used.Gas)+gas > account.DailyGas {
+		return e.reject("gas %d exceeds remaining daily limit %d", gas, account.DailyGas-min(account.DailyGas, uint64(used.Gas)))
+	}
+	e.record(from, now, cost, gas)
+	e.reserved[spendKey{from, uint64(tx.Nonce)}] = true
+
+	return Decision{Action: Approve, Reason: "transaction permitted"}
+}
+
+// checkFees checks the fee caps of a transaction against the limits of the
+// account, returning the reason of a rejection.
+func checkFees(account *AccountPolicy, tx *apitypes.SendTxArgs) string {
+	if fee := txFeeCap(tx); account.MaxFeePerGas != nil && fee.Cmp(account.MaxFeePerGas.Big()) > 0 {
+		return fmt.Sprintf("fee per gas %v exceeds limit %v", fee, account.MaxFeePerGas)
+	}
+	if tx.BlobFeeCap != nil && account.MaxFeePerBlobGas != nil && tx.BlobFeeCap.ToInt().Cmp(account.MaxFeePerBlobGas.Big()) > 0 {
+		return fmt.Sprintf("fee per blob gas %v exceeds limit %v", tx.BlobFeeCap.ToInt(), account.MaxFeePerBlobGas)
+	}
+	return ""
+}
+
+// txFeeCap returns the maximum fee per gas the transaction may pay.
+func txFeeCap(tx *apitypes.SendTxArgs) *big.Int {
+	switch {
+	case tx.MaxFeePerGas != nil:
+		return tx.MaxFeePerGas.ToInt()
+	case tx.GasPrice != nil:
+		return tx.GasPrice.ToInt()
+	default:
+		return new(big.Int)
+	}
+}
+
+// txCost returns the maximum amount the transaction may take from the sender:
+// its value along with the fees at the fee caps, including blob gas.
+func txCost(tx *apitypes.SendTxArgs) *big.Int {
+	cost := new(big.Int).Mul(txFeeCap(tx), new(big.Int).SetUint64(uint64(tx.Gas)))
+	cost.Add(cost, tx.Value.ToInt())
+	if tx.BlobFeeCap != nil {
+		blobGas := new(big.Int).SetUint64(uint64(len(tx.BlobHashes)) * params.BlobTxBlobGasPerBlob)
+		cost.Add(cost, blobGas.Mul(blobGas, tx.BlobFeeCap.ToInt()))
+	}
+	return cost
+}
+
+// checkDestination checks the recipient and call data of a transaction against
+// the allowlists of the account, returning the reason of a rejection.
+func (e *Evaluator) checkDestination(account *AccountPolicy, tx *apitypes.SendTxArgs) string {
+	if tx.To == nil {
+		return "contract creation not permitted"
+	}
+	var (
+		to   = tx.To.Address()
+		data = txData(tx)
+	)
+	if len(data) == 0 {
+		for _, recipient := range account.Recipients {
+			if recipient == to {
+				return ""
+			}
+		}
+	}
+	for _, contract := range account.Contracts {
+		if contract.Address != to {
+			continue
+		}
+		if len(contract.Methods) == 0 {
+			return ""
+		}
+		if len(data) == 0 {
+			return fmt.Sprintf("plain transfer to contract %v not permitted", to)
+		}
+		for _, method := range contract.Methods {
+			if matchMethod(method, data) {
+				return ""
+			}
+		}
+		return fmt.Sprintf("method %s of contract %v not permitted", e.methodName(data), to)
+	}
+	if len(data) == 0 {
+		return fmt.Sprintf("recipient %v not permitted", to)
+	}
+	return fmt.Sprintf("contract %v not permitted", to)
+}
+
+// matchMethod reports whether the call data invokes the given method. Selectors
+// are matched as is, whereas signatures additionally need the call data to be
+// well-formed for the method.
+func matchMethod(method string, data []byte) bool {
+	if len(data) < 4 {
+		return false
+	}
+	if strings.HasPrefix(method, "0x") {
+		selector, err := hexutil.Decode(method)
+		return err == nil && bytes.Equal(selector, data[:4])
+	}
+	_, err := fourbyte.VerifyMethod(method, data)
+	return err == nil
+}
+
+// methodName returns a human readable identifier of the invoked method.
+func (e *Evaluator) methodName(data []byte) string {
+	if len(data) < 4 {
+		return fmt.Sprintf("%#x", data)
+	}
+	if e.db != nil {
+		if method, err := e.db.Method(data); err == nil {
+			return method
+		}
+	}
+	return hexutil.Encode(data[:4])
+}
+
+func txData(tx *apitypes.SendTxArgs) []byte {
+	if tx.Input != nil {
+		return *tx.Input
+	}
+	if tx.Data != nil {
+		return *tx.Data
+	}
+	return nil
+}
+
+// ApproveSignData evaluates a data signing request. Only EIP-712 typed data of
+// allowlisted domains is ever approved.
+func (e *Evaluator) ApproveSignData(req *core.SignDataRequest) Decision {
+	from := req.Address.Address()
+	account := e.policy.account(from)
+	if account == nil {
+		return e.reject("account %v not covered by policy", from)
+	}
+	if req.ContentType != apitypes.DataTyped.Mime || req.TypedData == nil {
+		return e.reject("content type %s not permitted", req.ContentType)
+	}
+	for _, info := range req.Callinfo {
+		if info.Typ == apitypes.WARN || info.Typ == apitypes.CRIT {
+			return e.reject("validation %s: %s", strings.ToLower(info.Typ), info.Message)
+		}
+	}
+	if !account.inWindow(e.now()) {
+		return e.reject("outside of approval windows")
+	}
+	domain := req.TypedData.Domain
+	for _, allowed := range account.TypedData {
+		if allowed.matches(&domain) {
+			return Decision{Action: Approve, Reason: "typed data permitted"}
+		}
+	}
+	return e.reject("typed data domain %q (chain %v, contract %s) not permitted", domain.Name, domain.ChainId, domain.VerifyingContract)
+}
+
+// ApproveListing evaluates an account listing request, returning the accounts
+// to reveal if approved.
+func (e *Evaluator) ApproveListing(req *core.ListRequest) ([]accounts.Account, Decision) {
+	if e.policy.Listing != Approve {
+		return nil, Decision{Action: e.policy.Listing, Reason: "listing not permitted"}
+	}
+	var listed []accounts.Account
+	for _, account := range req.Accounts {
+		if e.policy.account(account.Address) != nil {
+			listed = append(listed, account)
+		}
+	}
+	return listed, Decision{Action: Approve, Reason: "listing permitted"}
+}
+
+// RecordTx counts a signed transaction towards the daily limits of its sender,
+// unless it was auto-approved and has thus already been accounted for.
+func (e *Evaluator) RecordTx(tx *types.Transaction) {
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		log.Warn("Failed to recover transaction sender", "hash", tx.Hash(), "err", err)
+		return
+	}
+	if e.policy.account(from) == nil {
+		return
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	key := spendKey{from, tx.Nonce()}
+	if e.reserved[key] {
+		delete(e.reserved, key)
+		return
+	}
+	e.record(from, e.now(), tx.Cost(), tx.Gas())
+}
+
+func (e *Evaluator) spendKey(from common.Address, now time.Time) string {
+	return fmt.Sprintf("policy-spend-%s-%s", from.Hex(), now.UTC().Format(time.DateOnly))
+}
+
+// spent returns the value and gas the account used on the day of the given time.
+func (e *Evaluator) spent(from common.Address, now time.Time) spend {
+	used := spend{Value: new(hexutil.Big)}
+	blob, err := e.storage.Get(e.spendKey(from, now))
+	if err != nil {
+		return used
+	}
+	if err := json.Unmarshal([]byte(blob), &used); err != nil || used.Value == nil {
+		log.Warn("Corrupt policy spend record, ignoring", "account", from, "err", err)
+		return spend{Value: new(hexutil.Big)}
+	}
+	return used
+}
+
+// record adds the value and gas to the spend of the account on the given day.
+func (e *Evaluator) record(from common.Address, now time.Time, value *big.Int, gas uint64) {
+	used := e.spent(from, now)
+	used.Value = (*hexutil.Big)(new(big.Int).Add(used.Value.ToInt(), value))
+	used.Gas += hexutil.Uint64(gas)
+
+	blob, _ := json.Marshal(used) // can't fail
+	e.storage.Put(e.spendKey(from, now), string(blob))
+}
+
+func (a *AccountPolicy) inWindow(now time.Time) bool {
+	if len(a.Windows) == 0 {
+		return true
+	}
+	for i := range a.Windows {
+		if a.Windows[i].contains(now) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *DomainPolicy) matches(domain *apitypes.TypedDataDomain) bool {
+	if p.Name != "" && p.Name != domain.Name {
+		return false
+	}
+	if p.Version != "" && p.Version != domain.Version {
+		return false
+	}
+	if p.ChainID != nil {
+		if domain.ChainId == nil || (*big.Int)(domain.ChainId).Cmp(new(big.Int).SetUint64(*p.ChainID)) != 0 {
+			return false
+		}
+	}
+	if p.VerifyingContract != nil {
+		if !common.IsHexAddress(domain.VerifyingContract) || common.HexToAddress(domain.VerifyingContract) != *p.VerifyingContract {
+			return false
+		}
+	}
+	return true
+}
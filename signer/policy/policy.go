@@ -0,0 +1,350 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package policy implements a declarative approval policy for clef, evaluated
+// natively as an auditable alternative to the javascript rule engine.
+//
+// A policy is a YAML (or JSON) document listing the accounts that may be used
+// without manual confirmation, and the constraints under which requests on
+// their behalf are auto-approved:
+//
+//	fallback: manual            # or reject; what to do with requests not approved
+//	listing: approve            # or manual/reject; approve lists policy accounts only
+//	accounts:
+//	  - address: 0x8a8eafb1cf62bfbeb1741769dae1a9dd47996192
+//	    recipients: [0xd9c9cd5f6779558b6e0ed4e6acf6b1947e7fa1f3]
+//	    contracts:
+//	      - address: 0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48
+//	        methods: ["transfer(address,uint256)", "0x095ea7b3"]
+//	    maxTxValue: 1 ether
+//	    dailyValue: 5 ether
+//	    maxTxGas: 200000
+//	    dailyGas: 2000000
+//	    maxFeePerGas: 100 gwei
+//	    windows:
+//	      - days: [mon, tue, wed, thu, fri]
+//	        from: "09:00"
+//	        to: "17:00"
+//	        zone: Europe/Berlin
+//	    typedData:
+//	      - name: Permit2
+//	        chainId: 1
+//	        verifyingContract: 0x000000000022d473030f116ddee9f6b43ac78ba3
+//
+// Every constraint of an account must hold for a request to be approved. The
+// value limits apply to the cost of transactions: their value along with the
+// fees they pay at their fee caps, including blob fees.
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/params"
+	"gopkg.in/yaml.v3"
+)
+
+// Action is the outcome of evaluating a request against a policy.
+type Action string
+
+const (
+	Approve Action = "approve" // Request is signed without user interaction
+	Reject  Action = "reject"  // Request is denied without user interaction
+	Manual  Action = "manual"  // Request is forwarded to the user for a decision
+)
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (a *Action) UnmarshalText(input []byte) error {
+	switch action := Action(strings.ToLower(string(input))); action {
+	case Approve, Reject, Manual:
+		*a = action
+		return nil
+	default:
+		return fmt.Errorf("unknown action %q", input)
+	}
+}
+
+// Policy is the declarative configuration of the policy engine.
+type Policy struct {
+	Fallback Action          `yaml:"fallback"` // Action for requests the policy does not approve, defaults to manual
+	Listing  Action          `yaml:"listing"`  // Action for account listing requests, defaults to manual
+	Accounts []AccountPolicy `yaml:"accounts"` // Accounts eligible for auto-approval
+}
+
+// AccountPolicy defines the requests an account may auto-approve.
+type AccountPolicy struct {
+	Address    common.Address   `yaml:"address"`
+	Recipients []common.Address `yaml:"recipients"` // Allowed recipients of plain value transfers
+	Contracts  []ContractPolicy `yaml:"contracts"`  // Allowed contract interactions
+
+	MaxTxValue       *Amount `yaml:"maxTxValue"`       // Maximum cost (value plus maximum fees) of a single transaction
+	DailyValue       *Amount `yaml:"dailyValue"`       // Maximum cost of transactions per UTC day
+	MaxTxGas         uint64  `yaml:"maxTxGas"`         // Maximum gas limit of a single transaction
+	DailyGas         uint64  `yaml:"dailyGas"`         // Maximum sum of gas limits per UTC day
+	MaxFeePerGas     *Amount `yaml:"maxFeePerGas"`     // Maximum gas price or fee cap per gas
+	MaxFeePerBlobGas *Amount `yaml:"maxFeePerBlobGas"` // Maximum fee cap per blob gas
+
+	Windows   []TimeWindow   `yaml:"windows"`   // Times of approval, any if empty
+	TypedData []DomainPolicy `yaml:"typedData"` // Allowed EIP-712 signing domains
+}
+
+// ContractPolicy allows calls to a contract, optionally restricted to a set of
+// methods given either as signatures or as hex encoded 4byte selectors.
+type ContractPolicy struct {
+	Address common.Address `yaml:"address"`
+	Methods []string       `yaml:"methods"` // Allowed methods, any if empty
+}
+
+// DomainPolicy allows EIP-712 signatures for a matching domain. Fields left
+// empty match any value.
+type DomainPolicy struct {
+	Name              string          `yaml:"name"`
+	Version           string          `yaml:"version"`
+	ChainID           *uint64         `yaml:"chainId"`
+	VerifyingContract *common.Address `yaml:"verifyingContract"`
+}
+
+// TimeWindow is a recurring daily period of time, the end of which is exclusive.
+// A window ending before its start wraps around midnight.
+type TimeWindow struct {
+	Days []string `yaml:"days"` // Weekdays as three letter abbreviations, every day if empty
+	From string   `yaml:"from"` // Start of the window as HH:MM
+	To   string   `yaml:"to"`   // End of the window as HH:MM
+	Zone string   `yaml:"zone"` // IANA time zone of the window, UTC if empty
+
+	days     map[time.Weekday]bool
+	from, to time.Duration
+	loc      *time.Location
+}
+
+// Amount is a wei denominated value, which may be written as a decimal or hex
+// integer or as a decimal followed by one of the units wei, gwei or ether.
+type Amount big.Int
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (a *Amount) UnmarshalText(input []byte) error {
+	value, err := parseAmount(string(input))
+	if err != nil {
+		return err
+	}
+	*a = Amount(*value)
+	return nil
+}
+
+// Big returns the amount as a big integer.
+func (a *Amount) Big() *big.Int {
+	return (*big.Int)(a)
+}
+
+// String implements fmt.Stringer.
+func (a *Amount) String() string {
+	return a.Big().String()
+}
+
+var units = map[string]*big.Int{
+	"wei":   big.NewInt(1),
+	"gwei":  big.NewInt(params.GWei),
+	"ether": big.NewInt(params.Ether),
+}
+
+func parseAmount(input string) (*big.Int, error) {
+	fields := strings.Fields(input)
+	switch len(fields) {
+	case 1:
+		if strings.HasPrefix(fields[0], "0x") {
+			return hexutil.DecodeBig(fields[0])
+		}
+		value, ok := new(big.Int).SetString(fields[0], 10)
+		if !ok || value.Sign() < 0 {
+			return nil, fmt.Errorf("invalid amount %q", input)
+		}
+		return value, nil
+	case 2:
+		unit, ok := units[strings.ToLower(fields[1])]
+		if !ok {
+			return nil, fmt.Errorf("unknown unit %q", fields[1])
+		}
+		value, ok := new(big.Rat).SetString(fields[0])
+		if !ok || value.Sign() < 0 {
+			return nil, fmt.Errorf("invalid amount %q", input)
+		}
+		value.Mul(value, new(big.Rat).SetInt(unit))
+		if !value.IsInt() {
+			return nil, fmt.Errorf("amount %q is not a whole number of wei", input)
+		}
+		return value.Num(), nil
+	default:
+		return nil, fmt.Errorf("invalid amount %q", input)
+	}
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// init validates the window and precomputes its bounds.
+func (w *TimeWindow) init() error {
+	var err error
+	if w.from, err = parseClock(w.From); err != nil {
+		return err
+	}
+	if w.to, err = parseClock(w.To); err != nil {
+		return err
+	}
+	if w.from == w.to {
+		return errors.New("empty time window")
+	}
+	if w.loc, err = time.LoadLocation(w.Zone); err != nil {
+		return err
+	}
+	w.days = make(map[time.Weekday]bool)
+	for _, day := range w.Days {
+		weekday, ok := weekdays[strings.ToLower(day)]
+		if !ok {
+			return fmt.Errorf("unknown weekday %q", day)
+		}
+		w.days[weekday] = true
+	}
+	return nil
+}
+
+// contains reports whether the given time falls into the window. The weekday
+// of a window wrapping around midnight is the one it started on.
+func (w *TimeWindow) contains(t time.Time) bool {
+	t = t.In(w.loc)
+	var (
+		day   = t.Weekday()
+		clock = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	)
+	if w.from < w.to {
+		if clock < w.from || clock >= w.to {
+			return false
+		}
+	} else {
+		if clock >= w.to && clock < w.from {
+			return false
+		}
+		if clock < w.to {
+			day = (day + 6) % 7
+		}
+	}
+	return len(w.days) == 0 || w.days[day]
+}
+
+func parseClock(clock string) (time.Duration, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q", clock)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Load reads and validates a policy from the given file.
+func Load(path string) (*Policy, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse decodes and validates a YAML or JSON policy. Unknown fields are rejected
+// to avoid silently ignoring misspelled constraints.
+func Parse(r io.Reader) (*Policy, error) {
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+
+	var policy Policy
+	if err := dec.Decode(&policy); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if err := policy.validate(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (p *Policy) validate() error {
+	if p.Fallback == "" {
+		p.Fallback = Manual
+	}
+	if p.Fallback == Approve {
+		return errors.New("fallback must be manual or reject")
+	}
+	if p.Listing == "" {
+		p.Listing = Manual
+	}
+	seen := make(map[common.Address]bool)
+	for i := range p.Accounts {
+		account := &p.Accounts[i]
+		if account.Address == (common.Address{}) {
+			return fmt.Errorf("account %d: missing address", i)
+		}
+		if seen[account.Address] {
+			return fmt.Errorf("account %v: duplicate policy", account.Address)
+		}
+		seen[account.Address] = true
+
+		for _, contract := range account.Contracts {
+			for _, method := range contract.Methods {
+				if err := validateMethod(method); err != nil {
+					return fmt.Errorf("account %v: contract %v: %v", account.Address, contract.Address, err)
+				}
+			}
+		}
+		for j := range account.Windows {
+			if err := account.Windows[j].init(); err != nil {
+				return fmt.Errorf("account %v: window %d: %v", account.Address, j, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateMethod checks that a method is either a 4byte selector or a parsable
+// method signature.
+func validateMethod(method string) error {
+	if strings.HasPrefix(method, "0x") {
+		if selector, err := hexutil.Decode(method); err != nil || len(selector) != 4 {
+			return fmt.Errorf("invalid method selector %q", method)
+		}
+		return nil
+	}
+	if _, err := abi.ParseSelector(method); err != nil {
+		return fmt.Errorf("invalid method signature %q: %v", method, err)
+	}
+	return nil
+}
+
+// account returns the policy of the given account, if any.
+func (p *Policy) account(addr common.Address) *AccountPolicy {
+	for i := range p.Accounts {
+		if p.Accounts[i].Address == addr {
+			return &p.Accounts[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,452 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/signer/core"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/ethereum/go-ethereum/signer/storage"
+)
+
+var (
+	owner     = common.HexToAddress("0x8a8eafb1cf62bfbeb1741769dae1a9dd47996192")
+	friend    = common.HexToAddress("0xd9c9cd5f6779558b6e0ed4e6acf6b1947e7fa1f3")
+	token     = common.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48")
+	stranger  = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	permit2   = common.HexToAddress("0x000000000022d473030f116ddee9f6b43ac78ba3")
+	monday    = time.Date(2025, 3, 3, 10, 0, 0, 0, time.UTC)
+	transfer  = common.FromHex("0xa9059cbb000000000000000000000000d9c9cd5f6779558b6e0ed4e6acf6b1947e7fa1f30000000000000000000000000000000000000000000000000000000000000001")
+	approve   = common.FromHex("0x095ea7b3000000000000000000000000d9c9cd5f6779558b6e0ed4e6acf6b1947e7fa1f30000000000000000000000000000000000000000000000000000000000000001")
+	burnFrom  = common.FromHex("0x79cc6790000000000000000000000000d9c9cd5f6779558b6e0ed4e6acf6b1947e7fa1f30000000000000000000000000000000000000000000000000000000000000001")
+	stuffedTx = append(append([]byte{}, transfer...), make([]byte, 32)...)
+)
+
+const testPolicy = `
+fallback: reject
+listing: approve
+accounts:
+  - address: 0x8a8eafb1cf62bfbeb1741769dae1a9dd47996192
+    recipients: [0xd9c9cd5f6779558b6e0ed4e6acf6b1947e7fa1f3]
+    contracts:
+      - address: 0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48
+        methods: ["transfer(address,uint256)", "0x095ea7b3"]
+    maxTxValue: 1 ether
+    dailyValue: 1.5 ether
+    maxTxGas: 100000
+    dailyGas: 150000
+    windows:
+      - days: [mon, tue, wed, thu, fri]
+        from: "09:00"
+        to: "17:00"
+      - days: [sat]
+        from: "22:00"
+        to: "02:00"
+    typedData:
+      - name: Permit2
+        chainId: 1
+        verifyingContract: 0x000000000022d473030f116ddee9f6b43ac78ba3
+`
+
+func mustParse(t *testing.T, input string) *Policy {
+	t.Helper()
+	policy, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("failed to parse policy: %v", err)
+	}
+	return policy
+}
+
+func newTestEvaluator(t *testing.T, backend storage.Storage) (*Evaluator, *time.Time) {
+	now := monday
+	evaluator := NewEvaluator(mustParse(t, testPolicy), nil, backend)
+	evaluator.now = func() time.Time { return now }
+	return evaluator, &now
+}
+
+func txRequest(from common.Address, to *common.Address, value *big.Int, gas uint64, nonce uint64, data []byte) *core.SignTxRequest {
+	args := apitypes.SendTxArgs{
+		From:  common.NewMixedcaseAddress(from),
+		Value: hexutil.Big(*value),
+		Gas:   hexutil.Uint64(gas),
+		Nonce: hexutil.Uint64(nonce),
+	}
+	if to != nil {
+		mixed := common.NewMixedcaseAddress(*to)
+		args.To = &mixed
+	}
+	if data != nil {
+		input := hexutil.Bytes(data)
+		args.Input = &input
+	}
+	return &core.SignTxRequest{Transaction: args}
+}
+
+func ether(n float64) *big.Int {
+	value, _ := parseAmount(strconv.FormatFloat(n, 'f', -1, 64) + " ether")
+	return value
+}
+
+func TestParse(t *testing.T) {
+	policy := mustParse(t, testPolicy)
+	if policy.Fallback != Reject || policy.Listing != Approve {
+		t.Errorf("actions mismatch: have %v/%v", policy.Fallback, policy.Listing)
+	}
+	account := policy.account(owner)
+	if account == nil {
+		t.Fatal("account policy missing")
+	}
+	if have, want := account.DailyValue.Big(), ether(1.5); have.Cmp(want) != 0 {
+		t.Errorf("daily value mismatch: have %v, want %v", have, want)
+	}
+	// JSON policies are accepted just as well
+	policy = mustParse(t, `{"accounts": [{"address": "0x8a8eafb1cf62bfbeb1741769dae1a9dd47996192", "maxTxValue": "0x10"}]}`)
+	if policy.Fallback != Manual || policy.Listing != Manual {
+		t.Errorf("default actions mismatch: have %v/%v", policy.Fallback, policy.Listing)
+	}
+	if have := policy.Accounts[0].MaxTxValue.Big(); have.Int64() != 16 {
+		t.Errorf("max value mismatch: have %v, want 16", have)
+	}
+	for i, input := range []string{
+		`fallback: approve`,
+		`fallback: maybe`,
+		`accounts: [{address: "0x8a8eafb1cf62bfbeb1741769dae1a9dd47996192", maxValue: 1}]`,
+		`accounts: [{address: "0x8a8eafb1cf62bfbeb1741769dae1a9dd47996192", maxTxValue: "1 finney"}]`,
+		`accounts: [{address: "0x8a8eafb1cf62bfbeb1741769dae1a9dd47996192", maxTxValue: "0.5 wei"}]`,
+		`accounts: [{address: "0x8a8eafb1cf62bfbeb1741769dae1a9dd47996192"}, {address: "0x8a8eafb1cf62bfbeb1741769dae1a9dd47996192"}]`,
+		`accounts: [{address: "0x8a8eafb1cf62bfbeb1741769dae1a9dd47996192", contracts: [{address: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", methods: ["0x1234"]}]}]`,
+		`accounts: [{address: "0x8a8eafb1cf62bfbeb1741769dae1a9dd47996192", contracts: [{address: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", methods: ["transfer"]}]}]`,
+		`accounts: [{address: "0x8a8eafb1cf62bfbeb1741769dae1a9dd47996192", windows: [{from: "9:00", to: "25:00"}]}]`,
+		`accounts: [{address: "0x8a8eafb1cf62bfbeb1741769dae1a9dd47996192", windows: [{days: [monday], from: "09:00", to: "17:00"}]}]`,
+	} {
+		if _, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("test %d: expected error for %s", i, input)
+		}
+	}
+}
+
+func TestApproveTx(t *testing.T) {
+	evaluator, now := newTestEvaluator(t, storage.NewEphemeralStorage())
+
+	tests := []struct {
+		req    *core.SignTxRequest
+		at     time.Time
+		action Action
+		reason string
+	}{
+		{txRequest(owner, &friend, ether(0.6), 21000, 0, nil), monday, Approve, ""},
+		{txRequest(stranger, &friend, ether(0.5), 21000, 0, nil), monday, Reject, "not covered by policy"},
+		{txRequest(owner, &stranger, ether(0.5), 21000, 1, nil), monday, Reject, "recipient"},
+		{txRequest(owner, nil, common.Big0, 21000, 1, []byte{0x60}), monday, Reject, "contract creation"},
+		{txRequest(owner, &token, common.Big0, 50000, 1, transfer), monday, Approve, ""},
+		{txRequest(owner, &token, common.Big0, 80000, 2, approve), monday, Reject, "gas 80000 exceeds remaining daily limit 79000"},
+		{txRequest(owner, &token, common.Big0, 30000, 2, approve), monday, Approve, ""},
+		{txRequest(owner, &token, common.Big0, 30000, 3, burnFrom), monday, Reject, "method 0x79cc6790"},
+		{txRequest(owner, &token, common.Big0, 30000, 3, stuffedTx), monday, Reject, "method"},
+		{txRequest(owner, &token, ether(0.1), 21000, 3, nil), monday, Reject, "plain transfer to contract"},
+		{txRequest(owner, &friend, ether(1.1), 21000, 3, nil), monday, Reject, "exceeds per-transaction limit"},
+		{txRequest(owner, &friend, ether(0.5), 210000, 3, nil), monday, Reject, "gas 210000 exceeds per-transaction limit"},
+		{txRequest(owner, &friend, ether(1), 21000, 3, nil), monday, Reject, "exceeds remaining daily limit 900000000000000000"},
+		{txRequest(owner, &friend, ether(1), 21000, 3, nil), monday.Add(-2 * time.Hour), Reject, "outside of approval windows"},
+		{txRequest(owner, &friend, ether(1), 21000, 3, nil), monday.Add(-34 * time.Hour), Approve, ""},       // Saturday night
+		{txRequest(owner, &friend, ether(1), 21000, 4, nil), monday.Add(-10 * time.Hour), Reject, "outside"}, // Sunday night
+		{txRequest(owner, &friend, ether(1), 21000, 4, nil), monday.Add(24 * time.Hour), Approve, ""},
+	}
+	for i, tt := range tests {
+		*now = tt.at
+		decision := evaluator.ApproveTx(tt.req)
+		if decision.Action != tt.action || !strings.Contains(decision.Reason, tt.reason) {
+			t.Errorf("test %d: decision mismatch: have %v (%s), want %v (%s)", i, decision.Action, decision.Reason, tt.action, tt.reason)
+		}
+	}
+	// Transactions with validation warnings are never approved
+	req := txRequest(owner, &friend, ether(0.1), 21000, 4, nil)
+	req.Callinfo = []apitypes.ValidationInfo{{Typ: apitypes.WARN, Message: "suspicious"}}
+	if decision := evaluator.ApproveTx(req); decision.Action != Reject {
+		t.Errorf("warned transaction approved: %v", decision.Reason)
+	}
+}
+
+func TestSpendTracking(t *testing.T) {
+	var (
+		key, _  = crypto.GenerateKey()
+		from    = crypto.PubkeyToAddress(key.PublicKey)
+		signer  = types.LatestSignerForChainID(big.NewInt(1))
+		backend = storage.NewEphemeralStorage()
+	)
+	spec := mustParse(t, `accounts: [{address: "`+from.Hex()+`", recipients: ["`+friend.Hex()+`"], dailyValue: 1 ether}]`)
+	evaluator := NewEvaluator(spec, nil, backend)
+	evaluator.now = func() time.Time { return monday }
+
+	sign := func(nonce uint64, value *big.Int) *types.Transaction {
+		tx, err := types.SignNewTx(key, signer, &types.LegacyTx{Nonce: nonce, To: &friend, Value: value, Gas: 21000, GasPrice: common.Big1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+	// Auto-approved transactions are only accounted for once
+	if decision := evaluator.ApproveTx(txRequest(from, &friend, ether(0.5), 21000, 0, nil)); decision.Action != Approve {
+		t.Fatalf("transaction not approved: %v", decision.Reason)
+	}
+	evaluator.RecordTx(sign(0, ether(0.5)))
+
+	// Manually approved transactions count towards the limits too
+	evaluator.RecordTx(sign(1, ether(0.3)))
+
+	// Spend survives restarts through the storage
+	evaluator = NewEvaluator(spec, nil, backend)
+	evaluator.now = func() time.Time { return monday }
+
+	if decision := evaluator.ApproveTx(txRequest(from, &friend, ether(0.3), 21000, 2, nil)); decision.Action != Manual {
+		t.Fatalf("transaction over daily limit approved")
+	}
+	// Fees of the signed transactions count towards the limit as well
+	if decision := evaluator.ApproveTx(txRequest(from, &friend, ether(0.2), 21000, 2, nil)); decision.Action != Manual {
+		t.Fatalf("transaction over daily limit including fees approved")
+	}
+	if decision := evaluator.ApproveTx(txRequest(from, &friend, ether(0.19), 21000, 2, nil)); decision.Action != Approve {
+		t.Fatalf("transaction within daily limit not approved: %v", decision.Reason)
+	}
+}
+
+func TestFeeLimits(t *testing.T) {
+	spec := mustParse(t, `accounts: [{address: "`+owner.Hex()+`", recipients: ["`+friend.Hex()+`"], maxTxValue: 1 ether, maxFeePerGas: 100 gwei, maxFeePerBlobGas: 10 gwei}]`)
+	evaluator := NewEvaluator(spec, nil, storage.NewEphemeralStorage())
+
+	gwei := func(n int64) *hexutil.Big {
+		return (*hexutil.Big)(new(big.Int).Mul(big.NewInt(n), big.NewInt(params.GWei)))
+	}
+	tests := []struct {
+		value   *big.Int
+		gas     uint64
+		price   *hexutil.Big // legacy gas price
+		feeCap  *hexutil.Big // dynamic fee cap
+		blobCap *hexutil.Big
+		blobs   int
+		action  Action
+	}{
+		{ether(0.5), 21000, gwei(50), nil, nil, 0, Approve},
+		{ether(0.5), 21000, nil, gwei(100), nil, 0, Approve},
+		{ether(0.5), 21000, gwei(101), nil, nil, 0, Manual},
+		{ether(0.5), 21000, nil, gwei(1000), nil, 0, Manual},
+		{ether(0.9), 2000000, nil, gwei(100), nil, 0, Manual},     // 0.9 ether + 0.2 ether fees
+		{ether(0.5), 21000, nil, gwei(10), gwei(10), 1, Approve},  // 0.0013 ether blob fees
+		{ether(0.5), 21000, nil, gwei(10), gwei(11), 1, Manual},   // blob fee cap exceeded
+		{ether(0.999), 21000, nil, gwei(10), gwei(10), 1, Manual}, // value plus blob fees exceeded
+	}
+	for i, tt := range tests {
+		req := txRequest(owner, &friend, tt.value, tt.gas, uint64(i), nil)
+		req.Transaction.GasPrice = tt.price
+		req.Transaction.MaxFeePerGas = tt.feeCap
+		req.Transaction.BlobFeeCap = tt.blobCap
+		req.Transaction.BlobHashes = make([]common.Hash, tt.blobs)
+		if decision := evaluator.ApproveTx(req); decision.Action != tt.action {
+			t.Errorf("test %d: decision mismatch: have %v (%s), want %v", i, decision.Action, decision.Reason, tt.action)
+		}
+	}
+}
+
+func testTypedData(name string, chainID int64, contract common.Address) apitypes.TypedData {
+	return apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+			"Mail": {{Name: "contents", Type: "string"}},
+		},
+		PrimaryType: "Mail",
+		Domain: apitypes.TypedDataDomain{
+			Name:              name,
+			ChainId:           (*math.HexOrDecimal256)(big.NewInt(chainID)),
+			VerifyingContract: contract.Hex(),
+		},
+		Message: apitypes.TypedDataMessage{"contents": "hello"},
+	}
+}
+
+func TestApproveSignData(t *testing.T) {
+	evaluator, _ := newTestEvaluator(t, storage.NewEphemeralStorage())
+
+	tests := []struct {
+		from   common.Address
+		typed  *apitypes.TypedData
+		action Action
+	}{
+		{owner, ptr(testTypedData("Permit2", 1, permit2)), Approve},
+		{owner, ptr(testTypedData("Permit2", 5, permit2)), Reject},
+		{owner, ptr(testTypedData("Permit2", 1, token)), Reject},
+		{owner, ptr(testTypedData("Permit3", 1, permit2)), Reject},
+		{stranger, ptr(testTypedData("Permit2", 1, permit2)), Reject},
+		{owner, nil, Reject},
+	}
+	for i, tt := range tests {
+		req := &core.SignDataRequest{ContentType: apitypes.DataTyped.Mime, Address: common.NewMixedcaseAddress(tt.from), TypedData: tt.typed}
+		if tt.typed == nil {
+			req.ContentType = apitypes.TextPlain.Mime
+		}
+		if decision := evaluator.ApproveSignData(req); decision.Action != tt.action {
+			t.Errorf("test %d: decision mismatch: have %v (%s), want %v", i, decision.Action, decision.Reason, tt.action)
+		}
+	}
+	// Typed data flagged during validation must never be auto-approved
+	req := &core.SignDataRequest{
+		ContentType: apitypes.DataTyped.Mime,
+		Address:     common.NewMixedcaseAddress(owner),
+		TypedData:   ptr(testTypedData("Permit2", 1, permit2)),
+		Callinfo:    []apitypes.ValidationInfo{{Typ: apitypes.WARN, Message: "unlimited approval"}},
+	}
+	if decision := evaluator.ApproveSignData(req); decision.Action != Reject {
+		t.Errorf("warned typed data approved: %v", decision.Reason)
+	}
+}
+
+func TestApproveListing(t *testing.T) {
+	evaluator, _ := newTestEvaluator(t, storage.NewEphemeralStorage())
+
+	listed, decision := evaluator.ApproveListing(&core.ListRequest{Accounts: []accounts.Account{{Address: stranger}, {Address: owner}}})
+	if decision.Action != Approve {
+		t.Fatalf("listing not approved: %v", decision.Reason)
+	}
+	if len(listed) != 1 || listed[0].Address != owner {
+		t.Errorf("listed accounts mismatch: have %v", listed)
+	}
+}
+
+// recordingAPI is a signer backend failing every request, only used to have
+// the audit logger record them.
+type recordingAPI struct {
+	core.ExternalAPI
+}
+
+var errTestDenied = errors.New("denied")
+
+func (recordingAPI) SignTransaction(context.Context, apitypes.SendTxArgs, *string) (*ethapi.SignTransactionResult, error) {
+	return nil, errTestDenied
+}
+
+func (recordingAPI) SignData(context.Context, string, common.MixedcaseAddress, interface{}) (hexutil.Bytes, error) {
+	return nil, errTestDenied
+}
+
+func (recordingAPI) SignTypedData(context.Context, common.MixedcaseAddress, apitypes.TypedData) (hexutil.Bytes, error) {
+	return nil, errTestDenied
+}
+
+func TestReplayAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	logger, err := core.NewAuditLogger(path, recordingAPI{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		ctx      = context.Background()
+		typed    = testTypedData("Permit2", 1, permit2)
+		blob, _  = json.Marshal(typed)
+		selector = "transfer(address,uint256)"
+	)
+	logger.SignTransaction(ctx, txRequest(owner, &friend, ether(1), 21000, 0, nil).Transaction, nil)
+	logger.SignTransaction(ctx, txRequest(owner, &friend, ether(1), 21000, 1, nil).Transaction, nil)
+	logger.SignTransaction(ctx, txRequest(owner, &token, common.Big0, 50000, 1, transfer).Transaction, &selector)
+	logger.SignTransaction(ctx, txRequest(owner, &token, common.Big0, 50000, 1, stuffedTx).Transaction, nil)
+	logger.SignData(ctx, apitypes.DataTyped.Mime, common.NewMixedcaseAddress(owner), hexutil.Encode(blob))
+	logger.SignData(ctx, apitypes.TextPlain.Mime, common.NewMixedcaseAddress(owner), "0x68656c6c6f")
+	logger.SignTypedData(ctx, common.NewMixedcaseAddress(owner), testTypedData("Permit2", 1, token))
+
+	// Pin the recorded times into the approval window
+	blob, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinned := regexp.MustCompile(`"time":"[^"]*"`).ReplaceAllString(string(blob), `"time":"`+monday.Format(time.RFC3339Nano)+`"`)
+
+	records, err := ReadAuditLog(strings.NewReader(pinned))
+	if err != nil {
+		t.Fatalf("failed to read audit log: %v", err)
+	}
+	want := []Action{Approve, Reject, Approve, Reject, Approve, Reject, Reject}
+	if len(records) != len(want) {
+		t.Fatalf("record count mismatch: have %d, want %d", len(records), len(want))
+	}
+	for i, decision := range Replay(mustParse(t, testPolicy), nil, records) {
+		if records[i].Err != nil {
+			t.Errorf("record %d: %v", i, records[i].Err)
+		}
+		if decision.Action != want[i] {
+			t.Errorf("record %d (%s): decision mismatch: have %v (%s), want %v", i, records[i].Method, decision.Action, decision.Reason, want[i])
+		}
+	}
+}
+
+func TestReadLegacyAuditLog(t *testing.T) {
+	log := `time=2025-03-03T10:00:00.000Z level=INFO msg=Configured api=signer "audit log"=audit.log
+time=2025-03-03T10:00:01.000Z level=INFO msg=SignTransaction api=signer type=request metadata="{}" tx="{\"from\":\"0x8A8eAFb1cf62BfBeb1741769DAE1a9dd47996192\",\"to\":\"0xD9C9Cd5f6779558b6e0eD4e6Acf6b1947E7fA1F3\",\"gas\":\"0x5208\",\"gasPrice\":\"0x1\",\"value\":\"0x1\",\"nonce\":\"0x0\"}" methodSelector=<nil>
+time=2025-03-03T10:00:01.000Z level=INFO msg=SignTransaction api=signer type=response data=<nil> error="request denied"
+`
+	records, err := ReadAuditLog(strings.NewReader(log))
+	if err != nil {
+		t.Fatalf("failed to read audit log: %v", err)
+	}
+	if len(records) != 1 || records[0].Err != nil || records[0].Line != 2 {
+		t.Fatalf("records mismatch: %+v", records)
+	}
+	if decision := Replay(mustParse(t, testPolicy), nil, records)[0]; decision.Action != Approve {
+		t.Errorf("decision mismatch: have %v (%s), want %v", decision.Action, decision.Reason, Approve)
+	}
+}
+
+func TestParseLogfmt(t *testing.T) {
+	fields, err := parseLogfmt(`time=2025-03-03T10:00:00Z level=INFO msg=Configured api=signer "audit log"="/tmp/a b.log" sel=<nil> data="\"0x\""`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"time": "2025-03-03T10:00:00Z", "level": "INFO", "msg": "Configured", "api": "signer",
+		"audit log": "/tmp/a b.log", "sel": "<nil>", "data": `"0x"`,
+	}
+	for key, value := range want {
+		if fields[key] != value {
+			t.Errorf("field %q mismatch: have %q, want %q", key, fields[key], value)
+		}
+	}
+	if _, err := parseLogfmt(`msg="unterminated`); err == nil {
+		t.Error("expected error for unterminated quote")
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
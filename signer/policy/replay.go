@@ -0,0 +1,311 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package policy
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/signer/core"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/ethereum/go-ethereum/signer/fourbyte"
+	"github.com/ethereum/go-ethereum/signer/storage"
+)
+
+// Record is a signing request recorded in the clef audit log.
+type Record struct {
+	Line   int       // Line number in the audit log
+	Time   time.Time // Time the request was made
+	Method string    // Name of the external API method invoked
+
+	Tx       *core.SignTxRequest   // Set for transaction signing requests
+	Selector *string               // Method selector provided with a transaction
+	Data     *core.SignDataRequest // Set for data signing requests
+	Err      error                 // Set if the request could not be reconstructed
+}
+
+// ReadAuditLog extracts the signing requests from a clef audit log, either of
+// the hash-chained JSON format or of the legacy plain text one. The integrity of
+// the log is not verified. Requests which cannot be reconstructed are returned
+// with their error set.
+func ReadAuditLog(r io.Reader) ([]*Record, error) {
+	var (
+		records []*Record
+		scanner = bufio.NewScanner(r)
+		line    int
+	)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var (
+			record *Record
+			err    error
+		)
+		if strings.HasPrefix(text, "{") {
+			record, err = readAuditEntry(text)
+		} else {
+			record, err = readLegacyLine(text)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		if record != nil {
+			record.Line = line
+			records = append(records, record)
+		}
+	}
+	return records, scanner.Err()
+}
+
+// readAuditEntry reconstructs the request of a hash-chained audit log entry, or
+// returns nil if it's not a signing request.
+func readAuditEntry(text string) (*Record, error) {
+	var entry core.AuditEntry
+	if err := json.Unmarshal([]byte(text), &entry); err != nil {
+		return nil, err
+	}
+	record := &Record{Method: entry.Method}
+	if !record.signing() {
+		return nil, nil
+	}
+	var err error
+	if record.Time, err = time.Parse(time.RFC3339Nano, entry.Time); err != nil {
+		record.Err = fmt.Errorf("invalid time: %v", err)
+		return record, nil
+	}
+	var req struct {
+		Transaction    *apitypes.SendTxArgs     `json:"transaction"`
+		MethodSelector *string                  `json:"methodSelector"`
+		ContentType    string                   `json:"contentType"`
+		Address        *common.MixedcaseAddress `json:"address"`
+		Data           json.RawMessage          `json:"data"`
+	}
+	if err := json.Unmarshal(entry.Request, &req); err != nil {
+		record.Err = fmt.Errorf("invalid request: %v", err)
+		return record, nil
+	}
+	switch record.Method {
+	case "SignTransaction":
+		if req.Transaction == nil {
+			record.Err = errors.New("missing transaction")
+			break
+		}
+		record.Tx = &core.SignTxRequest{Transaction: *req.Transaction}
+		record.Selector = req.MethodSelector
+
+	case "SignData", "SignTypedData":
+		if req.Address == nil {
+			record.Err = errors.New("missing address")
+			break
+		}
+		contentType := req.ContentType
+		if record.Method == "SignTypedData" {
+			contentType = apitypes.DataTyped.Mime
+		}
+		record.Data = &core.SignDataRequest{ContentType: contentType, Address: *req.Address}
+		if contentType == apitypes.DataTyped.Mime {
+			record.Data.TypedData, record.Err = decodeTypedData(req.Data)
+		}
+	}
+	return record, nil
+}
+
+// readLegacyLine reconstructs the request of a plain text audit log line, or
+// returns nil if it's not a signing request.
+func readLegacyLine(text string) (*Record, error) {
+	fields, err := parseLogfmt(text)
+	if err != nil {
+		return nil, err
+	}
+	if fields["type"] != "request" {
+		return nil, nil
+	}
+	record := &Record{Method: fields["msg"]}
+	if !record.signing() {
+		return nil, nil
+	}
+	if record.Time, err = time.Parse(time.RFC3339Nano, fields["time"]); err != nil {
+		record.Err = fmt.Errorf("invalid time: %v", err)
+	} else {
+		record.Err = record.decodeLegacy(fields)
+	}
+	return record, nil
+}
+
+// signing reports whether the record is of a request the policy evaluates.
+func (r *Record) signing() bool {
+	switch r.Method {
+	case "SignTransaction", "SignData", "SignTypedData":
+		return true
+	default:
+		return false
+	}
+}
+
+// decodeLegacy reconstructs the request of the record from the logged fields.
+func (r *Record) decodeLegacy(fields map[string]string) error {
+	switch r.Method {
+	case "SignTransaction":
+		var args apitypes.SendTxArgs
+		if err := json.Unmarshal([]byte(fields["tx"]), &args); err != nil {
+			return fmt.Errorf("invalid transaction: %v", err)
+		}
+		if sel := fields["methodSelector"]; sel != "<nil>" && sel != "" {
+			r.Selector = &sel
+		}
+		r.Tx = &core.SignTxRequest{Transaction: args}
+
+	case "SignData":
+		addr, err := parseAddress(fields["addr"])
+		if err != nil {
+			return fmt.Errorf("invalid address: %v", err)
+		}
+		r.Data = &core.SignDataRequest{ContentType: fields["content-type"], Address: *addr}
+		if r.Data.ContentType == apitypes.DataTyped.Mime {
+			r.Data.TypedData, err = decodeTypedData([]byte(fields["data"]))
+		}
+		return err
+
+	case "SignTypedData":
+		addr, err := parseAddress(fields["addr"])
+		if err != nil {
+			return fmt.Errorf("invalid address: %v", err)
+		}
+		typed, err := decodeTypedData([]byte(fields["data"]))
+		if err != nil {
+			return err
+		}
+		r.Data = &core.SignDataRequest{ContentType: apitypes.DataTyped.Mime, Address: *addr, TypedData: typed}
+	}
+	return nil
+}
+
+// decodeTypedData decodes typed data submitted either hex encoded or as a plain
+// JSON object.
+func decodeTypedData(data json.RawMessage) (*apitypes.TypedData, error) {
+	var hexdata string
+	if err := json.Unmarshal(data, &hexdata); err == nil {
+		blob, err := hexutil.Decode(hexdata)
+		if err != nil {
+			return nil, fmt.Errorf("invalid data: %v", err)
+		}
+		data = blob
+	}
+	var typed apitypes.TypedData
+	if err := json.Unmarshal(data, &typed); err != nil {
+		return nil, fmt.Errorf("invalid typed data: %v", err)
+	}
+	return &typed, nil
+}
+
+// Replay evaluates recorded requests in order, as if they were made at their
+// recorded time. Daily spend is tracked from scratch, counting the transactions
+// the policy approves.
+func Replay(policy *Policy, db *fourbyte.Database, records []*Record) []Decision {
+	var (
+		evaluator = NewEvaluator(policy, db, storage.NewEphemeralStorage())
+		decisions = make([]Decision, len(records))
+	)
+	for i, record := range records {
+		evaluator.now = func() time.Time { return record.Time }
+
+		switch {
+		case record.Err != nil:
+			decisions[i] = Decision{Action: policy.Fallback, Reason: record.Err.Error()}
+
+		case record.Tx != nil:
+			// Transactions are validated by clef before reaching the policy
+			if db != nil {
+				msgs, err := db.ValidateTransaction(record.Selector, &record.Tx.Transaction)
+				if err != nil {
+					decisions[i] = Decision{Action: Reject, Reason: err.Error()}
+					continue
+				}
+				record.Tx.Callinfo = msgs.Messages
+			}
+			decisions[i] = evaluator.ApproveTx(record.Tx)
+
+		default:
+			// Typed data is analyzed by clef before reaching the policy
+			if typed := record.Data.TypedData; typed != nil {
+				for _, approval := range core.AnalyzeTypedData(typed) {
+					record.Data.Callinfo = append(record.Data.Callinfo, apitypes.ValidationInfo{Typ: apitypes.WARN, Message: approval.String()})
+				}
+			}
+			decisions[i] = evaluator.ApproveSignData(record.Data)
+		}
+	}
+	return decisions
+}
+
+// parseAddress parses an address as logged, with its checksum annotation.
+func parseAddress(logged string) (*common.MixedcaseAddress, error) {
+	addr, _, _ := strings.Cut(logged, " ")
+	return common.NewMixedcaseAddressFromString(addr)
+}
+
+// parseLogfmt splits a line of the audit log into its key-value pairs.
+func parseLogfmt(line string) (map[string]string, error) {
+	fields := make(map[string]string)
+	for line = strings.TrimSpace(line); line != ""; line = strings.TrimLeft(line, " ") {
+		key, rest, err := logfmtToken(line, "=")
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(rest, "=") {
+			return nil, fmt.Errorf("missing value of %q", key)
+		}
+		value, rest, err := logfmtToken(rest[1:], " ")
+		if err != nil {
+			return nil, err
+		}
+		fields[key] = value
+		line = rest
+	}
+	return fields, nil
+}
+
+// logfmtToken reads a quoted token, or an unquoted one up to the separator.
+func logfmtToken(input string, sep string) (string, string, error) {
+	if !strings.HasPrefix(input, `"`) {
+		if idx := strings.Index(input, sep); idx >= 0 {
+			return input[:idx], input[idx:], nil
+		}
+		return input, "", nil
+	}
+	quoted, err := strconv.QuotedPrefix(input)
+	if err != nil {
+		return "", "", errors.New("unterminated quoted string")
+	}
+	token, err := strconv.Unquote(quoted)
+	if err != nil {
+		return "", "", err
+	}
+	return token, input[len(quoted):], nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package policy
+
+import (
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/signer/core"
+)
+
+// policyUI provides an implementation of UIClientAPI that decides requests
+// according to a policy, forwarding the ones requiring manual approval.
+type policyUI struct {
+	next      core.UIClientAPI // The next handler, for manual processing
+	evaluator *Evaluator
+}
+
+// NewPolicyUI creates a UI that evaluates requests with the given evaluator
+// before deferring to the next handler.
+func NewPolicyUI(next core.UIClientAPI, evaluator *Evaluator) *policyUI {
+	return &policyUI{next: next, evaluator: evaluator}
+}
+
+func (p *policyUI) RegisterUIServer(api *core.UIServerAPI) {
+	p.next.RegisterUIServer(api)
+}
+
+func (p *policyUI) ApproveTx(request *core.SignTxRequest) (core.SignTxResponse, error) {
+	decision := p.evaluator.ApproveTx(request)
+	log.Info("Policy evaluated transaction", "from", request.Transaction.From, "action", decision.Action, "reason", decision.Reason)
+
+	switch decision.Action {
+	case Approve:
+		return core.SignTxResponse{Transaction: request.Transaction, Approved: true}, nil
+	case Reject:
+		return core.SignTxResponse{Approved: false}, nil
+	default:
+		return p.next.ApproveTx(request)
+	}
+}
+
+func (p *policyUI) ApproveSignData(request *core.SignDataRequest) (core.SignDataResponse, error) {
+	decision := p.evaluator.ApproveSignData(request)
+	log.Info("Policy evaluated data signing", "address", request.Address, "action", decision.Action, "reason", decision.Reason)
+
+	switch decision.Action {
+	case Approve:
+		return core.SignDataResponse{Approved: true}, nil
+	case Reject:
+		return core.SignDataResponse{Approved: false}, nil
+	default:
+		return p.next.ApproveSignData(request)
+	}
+}
+
+func (p *policyUI) ApproveListing(request *core.ListRequest) (core.ListResponse, error) {
+	listed, decision := p.evaluator.ApproveListing(request)
+	log.Info("Policy evaluated listing", "action", decision.Action, "reason", decision.Reason)
+
+	switch decision.Action {
+	case Approve:
+		return core.ListResponse{Accounts: listed}, nil
+	case Reject:
+		return core.ListResponse{}, nil
+	default:
+		return p.next.ApproveListing(request)
+	}
+}
+
+// ApproveNewAccount is not handled by policies, it requires setting a password.
+func (p *policyUI) ApproveNewAccount(request *core.NewAccountRequest) (core.NewAccountResponse, error) {
+	return p.next.ApproveNewAccount(request)
+}
+
+// OnInputRequired is not handled by policies.
+func (p *policyUI) OnInputRequired(info core.UserInputRequest) (core.UserInputResponse, error) {
+	return p.next.OnInputRequired(info)
+}
+
+func (p *policyUI) ShowError(message string) {
+	log.Error(message)
+	p.next.ShowError(message)
+}
+
+func (p *policyUI) ShowInfo(message string) {
+	log.Info(message)
+	p.next.ShowInfo(message)
+}
+
+func (p *policyUI) OnSignerStartup(info core.StartupInfo) {
+	p.next.OnSignerStartup(info)
+}
+
+// OnApprovedTx counts every signed transaction, manually approved ones included,
+// towards the daily limits of the policy.
+func (p *policyUI) OnApprovedTx(tx ethapi.SignTransactionResult) {
+	if tx.Tx != nil {
+		p.evaluator.RecordTx(tx.Tx)
+	}
+	p.next.OnApprovedTx(tx)
+}
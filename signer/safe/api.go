@@ -0,0 +1,238 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package safe
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/signer/core"
+)
+
+// Signer signs Safe transactions on behalf of their owners, implemented by
+// clef's core.ExternalAPI.
+type Signer interface {
+	SignGnosisSafeTx(ctx context.Context, signerAddress common.MixedcaseAddress, gnosisTx core.GnosisSafeTx, methodSelector *string) (*core.GnosisSafeTx, error)
+}
+
+// Status is a proposal along with the on-chain state of its Safe.
+type Status struct {
+	*Proposal
+	SafeNonce  *hexutil.Big     `json:"safeNonce"`  // Nonce of the next transaction executed by the Safe
+	Threshold  hexutil.Uint64   `json:"threshold"`  // Number of owner signatures required for execution
+	Owners     []common.Address `json:"owners"`     // Current owners of the Safe
+	Confirmed  []common.Address `json:"confirmed"`  // Current owners that signed the proposal
+	Executable bool             `json:"executable"` // Whether the proposal can be executed now
+}
+
+// ExecArgs is the call executing a Safe transaction, which may be sent by any
+// account.
+type ExecArgs struct {
+	To   common.Address `json:"to"`
+	Data hexutil.Bytes  `json:"data"`
+}
+
+// API is the "safe" namespace of clef's external API, managing the multisig
+// workflow of Safe contracts.
+type API struct {
+	signer  Signer
+	backend Backend
+	store   *Store
+}
+
+// NewAPI creates the Safe API, signing with the given signer, reading Safes
+// from the backend and persisting proposals in the store.
+func NewAPI(signer Signer, backend Backend, store *Store) *API {
+	return &API{signer: signer, backend: backend, store: store}
+}
+
+// Propose registers a Safe transaction to be signed by the owners of the Safe.
+// If no nonce is given, the next nonce of the Safe is used. The safeTxHash is
+// computed by the Safe contract itself and checked against the local hash, so
+// the owners sign exactly what the Safe will verify.
+//
+// Proposing the same transaction again returns the existing proposal, along with
+// the signatures collected for it.
+func (api *API) Propose(ctx context.Context, tx Transaction) (*Proposal, error) {
+	if tx.Operation != Call && tx.Operation != DelegateCall {
+		return nil, fmt.Errorf("invalid operation %d", tx.Operation)
+	}
+	tx.setDefaults()
+
+	safe := newContract(tx.Safe, api.backend)
+	st, err := safe.state(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tx.Nonce == nil {
+		tx.Nonce = (*hexutil.Big)(st.nonce)
+	} else if tx.Nonce.ToInt().Cmp(st.nonce) < 0 {
+		return nil, fmt.Errorf("nonce %v already used, Safe %s is at nonce %v", tx.Nonce.ToInt(), tx.Safe, st.nonce)
+	}
+	chainID, err := api.backend.ChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tx.ChainID != nil && tx.ChainID.ToInt().Cmp(chainID) != 0 {
+		return nil, fmt.Errorf("chain id mismatch: have %v, connected to %v", tx.ChainID.ToInt(), chainID)
+	}
+	want, err := safe.transactionHash(ctx, &tx)
+	if err != nil {
+		return nil, err
+	}
+	// Safe v1.3.0 and later commit to the chain id, earlier versions don't
+	tx.ChainID = (*hexutil.Big)(chainID)
+	hash, err := tx.Hash()
+	if err != nil {
+		return nil, err
+	}
+	if hash != want {
+		tx.ChainID = nil
+		if hash, err = tx.Hash(); err != nil {
+			return nil, err
+		}
+		if hash != want {
+			return nil, fmt.Errorf("safeTxHash mismatch: Safe %s computed %s, unsupported Safe version", tx.Safe, want)
+		}
+	}
+	return api.store.Add(&Proposal{SafeTxHash: hash, Tx: &tx})
+}
+
+// Sign signs a proposed transaction with a local account owning its Safe, and
+// persists the signature. The request goes through the usual approval of clef.
+func (api *API) Sign(ctx context.Context, safeTxHash common.Hash, owner common.MixedcaseAddress) (*Proposal, error) {
+	p, err := api.store.Get(safeTxHash)
+	if err != nil {
+		return nil, err
+	}
+	st, err := newContract(p.Tx.Safe, api.backend).state(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPending(p, st); err != nil {
+		return nil, err
+	}
+	if !st.isOwner(owner.Address()) {
+		return nil, fmt.Errorf("%s is not an owner of Safe %s", owner.Address(), p.Tx.Safe)
+	}
+	if _, ok := p.Signatures[owner.Address()]; ok {
+		return p, nil
+	}
+	signed, err := api.signer.SignGnosisSafeTx(ctx, owner, p.Tx.GnosisSafeTx(safeTxHash), nil)
+	if err != nil {
+		return nil, err
+	}
+	// Make sure the signature will be accepted by the Safe before storing it
+	if signer, err := recoverSigner(safeTxHash, signed.Signature); err != nil {
+		return nil, fmt.Errorf("invalid signature: %v", err)
+	} else if signer != owner.Address() {
+		return nil, fmt.Errorf("signature of %s recovers to %s", owner.Address(), signer)
+	}
+	return api.store.AddSignature(safeTxHash, owner.Address(), signed.Signature)
+}
+
+// Status returns a proposed transaction along with the owners of its Safe that
+// signed it, and whether it can be executed.
+func (api *API) Status(ctx context.Context, safeTxHash common.Hash) (*Status, error) {
+	p, err := api.store.Get(safeTxHash)
+	if err != nil {
+		return nil, err
+	}
+	st, err := newContract(p.Tx.Safe, api.backend).state(ctx)
+	if err != nil {
+		return nil, err
+	}
+	confirmed := confirmations(p, st)
+	owners := make([]common.Address, 0, len(confirmed))
+	for owner := range confirmed {
+		owners = append(owners, owner)
+	}
+	sort.Slice(owners, func(i, j int) bool {
+		return owners[i].Cmp(owners[j]) < 0
+	})
+	return &Status{
+		Proposal:   p,
+		SafeNonce:  (*hexutil.Big)(st.nonce),
+		Threshold:  hexutil.Uint64(st.threshold),
+		Owners:     st.owners,
+		Confirmed:  owners,
+		Executable: p.Tx.Nonce.ToInt().Cmp(st.nonce) == 0 && uint64(len(confirmed)) >= st.threshold,
+	}, nil
+}
+
+// List returns all proposed transactions.
+func (api *API) List(ctx context.Context) ([]*Proposal, error) {
+	return api.store.List()
+}
+
+// ExecTransaction assembles the execTransaction call of a proposed transaction
+// from the collected signatures. It fails unless the transaction is the next one
+// of its Safe, and signed by enough current owners to reach the threshold.
+func (api *API) ExecTransaction(ctx context.Context, safeTxHash common.Hash) (*ExecArgs, error) {
+	p, err := api.store.Get(safeTxHash)
+	if err != nil {
+		return nil, err
+	}
+	st, err := newContract(p.Tx.Safe, api.backend).state(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPending(p, st); err != nil {
+		return nil, err
+	}
+	if p.Tx.Nonce.ToInt().Cmp(st.nonce) != 0 {
+		return nil, fmt.Errorf("transaction nonce %v is not the next nonce %v of Safe %s", p.Tx.Nonce.ToInt(), st.nonce, p.Tx.Safe)
+	}
+	confirmed := confirmations(p, st)
+	if uint64(len(confirmed)) < st.threshold {
+		return nil, fmt.Errorf("transaction signed by %d owners, Safe %s requires %d", len(confirmed), p.Tx.Safe, st.threshold)
+	}
+	tx := p.Tx
+	data, err := safeABI.Pack("execTransaction", tx.To, tx.Value.ToInt(), []byte(tx.Data), uint8(tx.Operation),
+		tx.SafeTxGas.ToInt(), tx.BaseGas.ToInt(), tx.GasPrice.ToInt(), tx.GasToken, tx.RefundReceiver, packSignatures(confirmed))
+	if err != nil {
+		return nil, err
+	}
+	return &ExecArgs{To: tx.Safe, Data: data}, nil
+}
+
+// checkPending fails if the nonce of the proposal was already used by the Safe.
+func checkPending(p *Proposal, st *state) error {
+	if p.Tx.Nonce.ToInt().Cmp(st.nonce) < 0 {
+		return fmt.Errorf("transaction nonce %v is below the nonce %v of Safe %s, it was executed or replaced", p.Tx.Nonce.ToInt(), st.nonce, p.Tx.Safe)
+	}
+	return nil
+}
+
+// confirmations returns the valid signatures of the proposal by current owners
+// of the Safe. Signatures of removed owners, or tampered with on disk, are dropped.
+func confirmations(p *Proposal, st *state) map[common.Address]hexutil.Bytes {
+	confirmed := make(map[common.Address]hexutil.Bytes)
+	for owner, signature := range p.Signatures {
+		if !st.isOwner(owner) {
+			continue
+		}
+		if signer, err := recoverSigner(p.SafeTxHash, signature); err != nil || signer != owner {
+			continue
+		}
+		confirmed[owner] = signature
+	}
+	return confirmed
+}
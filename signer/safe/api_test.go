@@ -0,0 +1,348 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package safe
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+var (
+	safeTxTypeHash       = crypto.Keccak256Hash([]byte("SafeTx(address to,uint256 value,bytes data,uint8 operation,uint256 safeTxGas,uint256 baseGas,uint256 gasPrice,address gasToken,address refundReceiver,uint256 nonce)"))
+	domainTypeHash       = crypto.Keccak256Hash([]byte("EIP712Domain(uint256 chainId,address verifyingContract)"))
+	legacyDomainTypeHash = crypto.Keccak256Hash([]byte("EIP712Domain(address verifyingContract)"))
+)
+
+// testSafe is a Safe contract emulated by a backend, computing the safeTxHash
+// and verifying signatures the way the Solidity contract does.
+type testSafe struct {
+	address   common.Address
+	chainID   *big.Int
+	legacy    bool // Whether the Safe predates v1.3.0, not committing to the chain id
+	nonce     uint64
+	threshold uint64
+	owners    []common.Address
+}
+
+func abiArgs(types ...string) abi.Arguments {
+	args := make(abi.Arguments, len(types))
+	for i, typ := range types {
+		t, err := abi.NewType(typ, "", nil)
+		if err != nil {
+			panic(err)
+		}
+		args[i] = abi.Argument{Type: t}
+	}
+	return args
+}
+
+// transactionHash mirrors getTransactionHash of the Safe contract.
+func (s *testSafe) transactionHash(args []interface{}) common.Hash {
+	data := args[2].([]byte)
+	structArgs := abiArgs("bytes32", "address", "uint256", "bytes32", "uint8", "uint256", "uint256", "uint256", "address", "address", "uint256")
+	encoded, err := structArgs.Pack(safeTxTypeHash, args[0], args[1], crypto.Keccak256Hash(data), args[3], args[4], args[5], args[6], args[7], args[8], args[9])
+	if err != nil {
+		panic(err)
+	}
+	var domain []byte
+	if s.legacy {
+		domain, err = abiArgs("bytes32", "address").Pack(legacyDomainTypeHash, s.address)
+	} else {
+		domain, err = abiArgs("bytes32", "uint256", "address").Pack(domainTypeHash, s.chainID, s.address)
+	}
+	if err != nil {
+		panic(err)
+	}
+	return crypto.Keccak256Hash([]byte{0x19, 0x01}, crypto.Keccak256(domain), crypto.Keccak256(encoded))
+}
+
+// testBackend is a chain holding a single Safe.
+type testBackend struct {
+	safe *testSafe
+}
+
+func (b *testBackend) ChainID(ctx context.Context) (*big.Int, error) {
+	return b.safe.chainID, nil
+}
+
+func (b *testBackend) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	if contract != b.safe.address {
+		return nil, nil
+	}
+	return []byte{0x60}, nil
+}
+
+func (b *testBackend) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if *call.To != b.safe.address {
+		return nil, nil
+	}
+	method, err := safeABI.MethodById(call.Data)
+	if err != nil {
+		return nil, err
+	}
+	args, err := method.Inputs.Unpack(call.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+	switch method.Name {
+	case "nonce":
+		return method.Outputs.Pack(new(big.Int).SetUint64(b.safe.nonce))
+	case "getThreshold":
+		return method.Outputs.Pack(new(big.Int).SetUint64(b.safe.threshold))
+	case "getOwners":
+		return method.Outputs.Pack(b.safe.owners)
+	case "getTransactionHash":
+		return method.Outputs.Pack([32]byte(b.safe.transactionHash(args)))
+	}
+	return nil, errors.New("execution reverted")
+}
+
+// testSigner signs Safe transactions with in-memory keys, like clef does.
+type testSigner struct {
+	keys map[common.Address]*ecdsa.PrivateKey
+}
+
+func (s *testSigner) SignGnosisSafeTx(ctx context.Context, addr common.MixedcaseAddress, tx core.GnosisSafeTx, methodSelector *string) (*core.GnosisSafeTx, error) {
+	key, ok := s.keys[addr.Address()]
+	if !ok {
+		return nil, errors.New("unknown account")
+	}
+	hash, _, err := apitypes.TypedDataAndHash(tx.ToTypedData())
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(hash, tx.InputExpHash[:]) {
+		return nil, errors.New("mismatched safeTxHash")
+	}
+	sig, err := crypto.Sign(hash, key)
+	if err != nil {
+		return nil, err
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	tx.Signature = sig
+	return &tx, nil
+}
+
+// newTestAPI creates a Safe API over a 2-of-3 Safe, of which the first two owners
+// are held by the signer.
+func newTestAPI(t *testing.T, legacy bool) (*API, *testSafe, []common.Address) {
+	signer := &testSigner{keys: make(map[common.Address]*ecdsa.PrivateKey)}
+	safe := &testSafe{
+		address:   common.HexToAddress("0x5afe5afe5afe5afe5afe5afe5afe5afe5afe5afe"),
+		chainID:   big.NewInt(1),
+		legacy:    legacy,
+		nonce:     7,
+		threshold: 2,
+	}
+	for i := 0; i < 3; i++ {
+		key, _ := crypto.GenerateKey()
+		addr := crypto.PubkeyToAddress(key.PublicKey)
+		safe.owners = append(safe.owners, addr)
+		signer.keys[addr] = key
+	}
+	owners := append([]common.Address{}, safe.owners...)
+	delete(signer.keys, owners[2])
+
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewAPI(signer, &testBackend{safe: safe}, store), safe, owners
+}
+
+// verifySignatures mirrors checkNSignatures of the Safe contract.
+func verifySignatures(safe *testSafe, hash common.Hash, signatures []byte) error {
+	if uint64(len(signatures)) < safe.threshold*65 {
+		return errors.New("signatures too short")
+	}
+	var last common.Address
+	for i := uint64(0); i < safe.threshold; i++ {
+		sig := common.CopyBytes(signatures[i*65 : (i+1)*65])
+		sig[64] -= 27
+		pubkey, err := crypto.SigToPub(hash[:], sig)
+		if err != nil {
+			return err
+		}
+		owner := crypto.PubkeyToAddress(*pubkey)
+		if owner.Cmp(last) <= 0 {
+			return errors.New("signatures not sorted by owner")
+		}
+		var known bool
+		for _, have := range safe.owners {
+			known = known || have == owner
+		}
+		if !known {
+			return errors.New("signature of non-owner")
+		}
+		last = owner
+	}
+	return nil
+}
+
+func TestSafeWorkflow(t *testing.T) {
+	t.Parallel()
+	for _, legacy := range []bool{false, true} {
+		api, safe, owners := newTestAPI(t, legacy)
+		ctx := context.Background()
+
+		proposal, err := api.Propose(ctx, Transaction{
+			Safe:  safe.address,
+			To:    common.HexToAddress("0x1111111111111111111111111111111111111111"),
+			Value: (*hexutil.Big)(big.NewInt(1e18)),
+			Data:  hexutil.Bytes{0xde, 0xad, 0xbe, 0xef},
+		})
+		if err != nil {
+			t.Fatalf("legacy %v: failed to propose: %v", legacy, err)
+		}
+		if proposal.Tx.Nonce.ToInt().Uint64() != safe.nonce {
+			t.Errorf("legacy %v: nonce mismatch: have %v, want %d", legacy, proposal.Tx.Nonce, safe.nonce)
+		}
+		if (proposal.Tx.ChainID == nil) != legacy {
+			t.Errorf("legacy %v: chain id mismatch: have %v", legacy, proposal.Tx.ChainID)
+		}
+		hash := proposal.SafeTxHash
+
+		// Signatures may only be collected from owners held by the signer
+		if _, err := api.Sign(ctx, hash, common.NewMixedcaseAddress(common.Address{0x01})); err == nil || !strings.Contains(err.Error(), "not an owner") {
+			t.Errorf("legacy %v: non-owner signature error mismatch: %v", legacy, err)
+		}
+		if _, err := api.Sign(ctx, hash, common.NewMixedcaseAddress(owners[2])); err == nil {
+			t.Errorf("legacy %v: signed with unknown account", legacy)
+		}
+		if _, err := api.Sign(ctx, hash, common.NewMixedcaseAddress(owners[1])); err != nil {
+			t.Fatalf("legacy %v: failed to sign: %v", legacy, err)
+		}
+		if _, err := api.ExecTransaction(ctx, hash); err == nil {
+			t.Errorf("legacy %v: assembled execution below threshold", legacy)
+		}
+		if _, err := api.Sign(ctx, hash, common.NewMixedcaseAddress(owners[0])); err != nil {
+			t.Fatalf("legacy %v: failed to sign: %v", legacy, err)
+		}
+		// Proposing again must return the persisted signatures
+		again, err := api.Propose(ctx, *proposal.Tx)
+		if err != nil {
+			t.Fatalf("legacy %v: failed to propose again: %v", legacy, err)
+		}
+		if len(again.Signatures) != 2 {
+			t.Errorf("legacy %v: signature count mismatch: have %d, want 2", legacy, len(again.Signatures))
+		}
+		status, err := api.Status(ctx, hash)
+		if err != nil {
+			t.Fatalf("legacy %v: failed to get status: %v", legacy, err)
+		}
+		if !status.Executable || len(status.Confirmed) != 2 {
+			t.Errorf("legacy %v: status mismatch: executable %v, confirmed %v", legacy, status.Executable, status.Confirmed)
+		}
+		// The execution call must pass the signature checks of the Safe
+		exec, err := api.ExecTransaction(ctx, hash)
+		if err != nil {
+			t.Fatalf("legacy %v: failed to assemble execution: %v", legacy, err)
+		}
+		if exec.To != safe.address {
+			t.Errorf("legacy %v: execution target mismatch: have %v, want %v", legacy, exec.To, safe.address)
+		}
+		args, err := safeABI.Methods["execTransaction"].Inputs.Unpack(exec.Data[4:])
+		if err != nil {
+			t.Fatalf("legacy %v: failed to unpack execution: %v", legacy, err)
+		}
+		// The Safe hashes the call parameters along with its current nonce
+		if have := safe.transactionHash(append(args[:9:9], new(big.Int).SetUint64(safe.nonce))); have != hash {
+			t.Errorf("legacy %v: executed transaction hash mismatch: have %v, want %v", legacy, have, hash)
+		}
+		if err := verifySignatures(safe, hash, args[9].([]byte)); err != nil {
+			t.Errorf("legacy %v: invalid signatures: %v", legacy, err)
+		}
+		// Once executed, the proposal is stale
+		safe.nonce++
+		if _, err := api.ExecTransaction(ctx, hash); err == nil {
+			t.Errorf("legacy %v: assembled execution of executed transaction", legacy)
+		}
+		if _, err := api.Sign(ctx, hash, common.NewMixedcaseAddress(owners[0])); err == nil {
+			t.Errorf("legacy %v: signed executed transaction", legacy)
+		}
+	}
+}
+
+// Tests that signatures of removed owners don't count towards the threshold.
+func TestSafeOwnerRemoved(t *testing.T) {
+	t.Parallel()
+	api, safe, owners := newTestAPI(t, false)
+	ctx := context.Background()
+
+	proposal, err := api.Propose(ctx, Transaction{Safe: safe.address, To: owners[2]})
+	if err != nil {
+		t.Fatalf("failed to propose: %v", err)
+	}
+	for _, owner := range owners[:2] {
+		if _, err := api.Sign(ctx, proposal.SafeTxHash, common.NewMixedcaseAddress(owner)); err != nil {
+			t.Fatalf("failed to sign: %v", err)
+		}
+	}
+	safe.owners = []common.Address{owners[1], owners[2]}
+	if _, err := api.ExecTransaction(ctx, proposal.SafeTxHash); err == nil {
+		t.Error("assembled execution with signature of removed owner")
+	}
+	status, err := api.Status(ctx, proposal.SafeTxHash)
+	if err != nil {
+		t.Fatalf("failed to get status: %v", err)
+	}
+	if status.Executable || len(status.Confirmed) != 1 || status.Confirmed[0] != owners[1] {
+		t.Errorf("status mismatch: executable %v, confirmed %v", status.Executable, status.Confirmed)
+	}
+}
+
+// Tests that proposals tampered with on disk are rejected.
+func TestSafeStoreTampered(t *testing.T) {
+	t.Parallel()
+	api, safe, owners := newTestAPI(t, false)
+	ctx := context.Background()
+
+	proposal, err := api.Propose(ctx, Transaction{Safe: safe.address, To: owners[2]})
+	if err != nil {
+		t.Fatalf("failed to propose: %v", err)
+	}
+	path := filepath.Join(api.store.dir, proposal.SafeTxHash.Hex()+".json")
+	blob, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob = bytes.Replace(blob, []byte(strings.ToLower(owners[2].Hex()[2:])), []byte(strings.Repeat("1", 40)), 1)
+	if err := os.WriteFile(path, blob, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.Sign(ctx, proposal.SafeTxHash, common.NewMixedcaseAddress(owners[0])); err == nil || !strings.Contains(err.Error(), "safeTxHash mismatch") {
+		t.Errorf("tampered proposal error mismatch: %v", err)
+	}
+	if _, err := api.Status(ctx, common.Hash{0x01}); err != ErrUnknownProposal {
+		t.Errorf("unknown proposal error mismatch: have %v, want %v", err, ErrUnknownProposal)
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package safe implements the multisig workflow of Safe (formerly Gnosis Safe)
+// contracts for clef.
+//
+// A transaction is proposed against the on-chain state of a Safe, which fixes
+// its nonce and safeTxHash. The owners of the Safe held by clef then sign it one
+// by one, their signatures being persisted until enough of them are collected
+// to reach the threshold of the Safe. Finally the signatures are assembled into
+// the execTransaction call, which any account may submit to the Safe.
+package safe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// safeABI is the subset of the Safe contract interface used by the workflow,
+// common to all Safe versions.
+var safeABI = func() abi.ABI {
+	parsed, err := abi.ParseHumanReadable([]string{
+		"function nonce() view returns (uint256)",
+		"function getThreshold() view returns (uint256)",
+		"function getOwners() view returns (address[])",
+		"function getTransactionHash(address to, uint256 value, bytes data, uint8 operation, uint256 safeTxGas, uint256 baseGas, uint256 gasPrice, address gasToken, address refundReceiver, uint256 _nonce) view returns (bytes32)",
+		"function execTransaction(address to, uint256 value, bytes data, uint8 operation, uint256 safeTxGas, uint256 baseGas, uint256 gasPrice, address gasToken, address refundReceiver, bytes signatures) payable returns (bool success)",
+	})
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+// Backend is the chain access needed to read the state of Safe contracts,
+// implemented by ethclient.Client.
+type Backend interface {
+	bind.ContractCaller
+	ChainID(ctx context.Context) (*big.Int, error)
+}
+
+// Operation is the kind of call a Safe executes.
+type Operation uint8
+
+const (
+	Call         Operation = 0 // Regular call from the Safe
+	DelegateCall Operation = 1 // Delegate call, executing foreign code in the context of the Safe
+)
+
+// Transaction is a Safe transaction, consisting of the parameters of the
+// execTransaction call apart from the signatures.
+type Transaction struct {
+	Safe           common.Address `json:"safe"`
+	To             common.Address `json:"to"`
+	Value          *hexutil.Big   `json:"value"`
+	Data           hexutil.Bytes  `json:"data"`
+	Operation      Operation      `json:"operation"`
+	SafeTxGas      *hexutil.Big   `json:"safeTxGas"`
+	BaseGas        *hexutil.Big   `json:"baseGas"`
+	GasPrice       *hexutil.Big   `json:"gasPrice"`
+	GasToken       common.Address `json:"gasToken"`
+	RefundReceiver common.Address `json:"refundReceiver"`
+	Nonce          *hexutil.Big   `json:"nonce"`
+
+	// ChainID is the chain the safeTxHash commits to, nil for Safes predating
+	// v1.3.0 which don't include it in their EIP-712 domain.
+	ChainID *hexutil.Big `json:"chainId,omitempty"`
+}
+
+// setDefaults zeroes the unset numeric fields of the transaction, except for
+// the nonce and chain id which are taken from the chain.
+func (tx *Transaction) setDefaults() {
+	for _, field := range []**hexutil.Big{&tx.Value, &tx.SafeTxGas, &tx.BaseGas, &tx.GasPrice} {
+		if *field == nil {
+			*field = new(hexutil.Big)
+		}
+	}
+}
+
+// GnosisSafeTx converts the transaction into the format signed by clef's
+// account_signGnosisSafeTx, expected to hash to safeTxHash.
+func (tx *Transaction) GnosisSafeTx(safeTxHash common.Hash) core.GnosisSafeTx {
+	data := hexutil.Bytes(common.CopyBytes(tx.Data))
+	return core.GnosisSafeTx{
+		Safe:           common.NewMixedcaseAddress(tx.Safe),
+		To:             common.NewMixedcaseAddress(tx.To),
+		Value:          math.Decimal256(*tx.Value.ToInt()),
+		GasPrice:       math.Decimal256(*tx.GasPrice.ToInt()),
+		Data:           &data,
+		Operation:      uint8(tx.Operation),
+		GasToken:       tx.GasToken,
+		RefundReceiver: tx.RefundReceiver,
+		BaseGas:        *new(big.Int).Set(tx.BaseGas.ToInt()),
+		SafeTxGas:      *new(big.Int).Set(tx.SafeTxGas.ToInt()),
+		Nonce:          *new(big.Int).Set(tx.Nonce.ToInt()),
+		InputExpHash:   safeTxHash,
+		ChainId:        (*math.HexOrDecimal256)(tx.ChainID),
+	}
+}
+
+// Hash computes the safeTxHash of the transaction, the EIP-712 hash signed by
+// the owners of the Safe.
+func (tx *Transaction) Hash() (common.Hash, error) {
+	gnosisTx := tx.GnosisSafeTx(common.Hash{})
+	hash, _, err := apitypes.TypedDataAndHash(gnosisTx.ToTypedData())
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(hash), nil
+}
+
+// state is the on-chain configuration of a Safe.
+type state struct {
+	nonce     *big.Int
+	threshold uint64
+	owners    []common.Address
+}
+
+// isOwner reports whether the address is an owner of the Safe.
+func (s *state) isOwner(addr common.Address) bool {
+	for _, owner := range s.owners {
+		if owner == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// contract is a Safe contract deployed on the chain.
+type contract struct {
+	address common.Address
+	bound   *bind.BoundContract
+}
+
+func newContract(address common.Address, backend Backend) *contract {
+	return &contract{address: address, bound: bind.NewBoundContract(address, safeABI, backend, nil, nil)}
+}
+
+// call invokes a view method of the Safe, returning its only result.
+func (c *contract) call(ctx context.Context, method string, args ...interface{}) (interface{}, error) {
+	var out []interface{}
+	if err := c.bound.Call(&bind.CallOpts{Context: ctx}, &out, method, args...); err != nil {
+		return nil, fmt.Errorf("failed to call %s of Safe %s: %w", method, c.address, err)
+	}
+	return out[0], nil
+}
+
+// state reads the nonce, threshold and owners of the Safe.
+func (c *contract) state(ctx context.Context) (*state, error) {
+	nonce, err := c.call(ctx, "nonce")
+	if err != nil {
+		return nil, err
+	}
+	threshold, err := c.call(ctx, "getThreshold")
+	if err != nil {
+		return nil, err
+	}
+	owners, err := c.call(ctx, "getOwners")
+	if err != nil {
+		return nil, err
+	}
+	s := &state{
+		nonce:  nonce.(*big.Int),
+		owners: owners.([]common.Address),
+	}
+	if !threshold.(*big.Int).IsUint64() || threshold.(*big.Int).Sign() == 0 {
+		return nil, fmt.Errorf("invalid threshold %v of Safe %s", threshold, c.address)
+	}
+	s.threshold = threshold.(*big.Int).Uint64()
+	return s, nil
+}
+
+// transactionHash computes the safeTxHash of a transaction with the Safe itself.
+func (c *contract) transactionHash(ctx context.Context, tx *Transaction) (common.Hash, error) {
+	hash, err := c.call(ctx, "getTransactionHash", tx.To, tx.Value.ToInt(), []byte(tx.Data), uint8(tx.Operation),
+		tx.SafeTxGas.ToInt(), tx.BaseGas.ToInt(), tx.GasPrice.ToInt(), tx.GasToken, tx.RefundReceiver, tx.Nonce.ToInt())
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.Hash(hash.([32]byte)), nil
+}
+
+// recoverSigner returns the address that produced an owner signature of the
+// safeTxHash, as created by clef with a V of 27 or 28.
+func recoverSigner(safeTxHash common.Hash, signature []byte) (common.Address, error) {
+	if len(signature) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("invalid signature length %d", len(signature))
+	}
+	if signature[crypto.RecoveryIDOffset] != 27 && signature[crypto.RecoveryIDOffset] != 28 {
+		return common.Address{}, errors.New("invalid signature recovery id")
+	}
+	sig := common.CopyBytes(signature)
+	sig[crypto.RecoveryIDOffset] -= 27
+
+	pubkey, err := crypto.SigToPub(safeTxHash[:], sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pubkey), nil
+}
+
+// packSignatures concatenates the owner signatures in ascending order of the
+// owner addresses, as required by the Safe.
+func packSignatures(signatures map[common.Address]hexutil.Bytes) []byte {
+	owners := make([]common.Address, 0, len(signatures))
+	for owner := range signatures {
+		owners = append(owners, owner)
+	}
+	sort.Slice(owners, func(i, j int) bool {
+		return owners[i].Cmp(owners[j]) < 0
+	})
+	packed := make([]byte, 0, len(owners)*crypto.SignatureLength)
+	for _, owner := range owners {
+		packed = append(packed, signatures[owner]...)
+	}
+	return packed
+}
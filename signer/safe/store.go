@@ -0,0 +1,180 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package safe
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// ErrUnknownProposal is returned if no transaction was proposed with a given
+// safeTxHash.
+var ErrUnknownProposal = errors.New("unknown Safe transaction")
+
+// Proposal is a proposed Safe transaction along with the owner signatures
+// collected so far.
+type Proposal struct {
+	SafeTxHash common.Hash                      `json:"safeTxHash"`
+	Tx         *Transaction                     `json:"tx"`
+	Signatures map[common.Address]hexutil.Bytes `json:"signatures"`
+}
+
+// Store persists proposals as JSON files in a directory, named after their
+// safeTxHash.
+type Store struct {
+	dir  string
+	lock sync.Mutex
+}
+
+// NewStore creates a proposal store in the given directory, creating it if
+// needed.
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir}, nil
+}
+
+// path returns the file holding the proposal with the given safeTxHash.
+func (s *Store) path(safeTxHash common.Hash) string {
+	return filepath.Join(s.dir, safeTxHash.Hex()+".json")
+}
+
+// Get retrieves the proposal with the given safeTxHash.
+func (s *Store) Get(safeTxHash common.Hash) (*Proposal, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.read(s.path(safeTxHash))
+}
+
+// read loads a proposal from disk. The caller must hold the lock.
+func (s *Store) read(path string) (*Proposal, error) {
+	blob, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrUnknownProposal
+	}
+	if err != nil {
+		return nil, err
+	}
+	p := new(Proposal)
+	if err := json.Unmarshal(blob, p); err != nil {
+		return nil, fmt.Errorf("invalid Safe transaction file %s: %v", path, err)
+	}
+	if p.Tx == nil || p.Tx.Value == nil || p.Tx.SafeTxGas == nil || p.Tx.BaseGas == nil || p.Tx.GasPrice == nil || p.Tx.Nonce == nil {
+		return nil, fmt.Errorf("invalid Safe transaction file %s: incomplete transaction", path)
+	}
+	// Never sign a hash that doesn't match the transaction
+	if hash, err := p.Tx.Hash(); err != nil || hash != p.SafeTxHash {
+		return nil, fmt.Errorf("invalid Safe transaction file %s: safeTxHash mismatch", path)
+	}
+	if p.Signatures == nil {
+		p.Signatures = make(map[common.Address]hexutil.Bytes)
+	}
+	return p, nil
+}
+
+// write atomically stores a proposal on disk. The caller must hold the lock.
+func (s *Store) write(p *Proposal) error {
+	blob, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(s.dir, ".tmp-*.json")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(blob); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	f.Close()
+	return os.Rename(f.Name(), s.path(p.SafeTxHash))
+}
+
+// Add stores a new proposal, returning the existing one if the transaction was
+// already proposed.
+func (s *Store) Add(p *Proposal) (*Proposal, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if have, err := s.read(s.path(p.SafeTxHash)); err == nil {
+		return have, nil
+	} else if err != ErrUnknownProposal {
+		return nil, err
+	}
+	if p.Signatures == nil {
+		p.Signatures = make(map[common.Address]hexutil.Bytes)
+	}
+	if err := s.write(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// AddSignature adds an owner signature to a stored proposal, returning the
+// updated proposal.
+func (s *Store) AddSignature(safeTxHash common.Hash, owner common.Address, signature []byte) (*Proposal, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	p, err := s.read(s.path(safeTxHash))
+	if err != nil {
+		return nil, err
+	}
+	p.Signatures[owner] = common.CopyBytes(signature)
+	if err := s.write(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// List returns all stored proposals, ordered by Safe and nonce.
+func (s *Store) List() ([]*Proposal, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(s.dir, "0x*.json"))
+	if err != nil {
+		return nil, err
+	}
+	proposals := make([]*Proposal, 0, len(files))
+	for _, file := range files {
+		p, err := s.read(file)
+		if err != nil {
+			return nil, err
+		}
+		proposals = append(proposals, p)
+	}
+	sort.SliceStable(proposals, func(i, j int) bool {
+		a, b := proposals[i].Tx, proposals[j].Tx
+		if a.Safe != b.Safe {
+			return a.Safe.Cmp(b.Safe) < 0
+		}
+		return a.Nonce.ToInt().Cmp(b.Nonce.ToInt()) < 0
+	})
+	return proposals, nil
+}
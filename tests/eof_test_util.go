@@ -0,0 +1,73 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tests
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// EOFTest checks the validation of EOF containers.
+type EOFTest struct {
+	Vectors map[string]eofVector `json:"vectors"`
+}
+
+type eofVector struct {
+	Code          hexutil.Bytes        `json:"code"`
+	ContainerKind string               `json:"containerKind"`
+	Results       map[string]eofResult `json:"results"`
+}
+
+type eofResult struct {
+	Result    bool   `json:"result"`
+	Exception string `json:"exception,omitempty"`
+}
+
+// Run validates all vectors of the test against the expectations of the forks
+// which support EOF.
+func (t *EOFTest) Run() error {
+	names := make([]string, 0, len(t.Vectors))
+	for name := range t.Vectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		vector := t.Vectors[name]
+		for fork, result := range vector.Results {
+			config, _, err := GetChainConfig(fork)
+			if err != nil {
+				return UnsupportedForkError{fork}
+			}
+			if !config.IsOsaka(new(big.Int), 0) {
+				continue
+			}
+			_, err = vm.ParseAndValidate(vector.Code, vector.ContainerKind == "INITCODE")
+			if result.Result && err != nil {
+				return fmt.Errorf("vector %s (%s): unexpected validation error: %v", name, fork, err)
+			}
+			if !result.Result && err == nil {
+				return fmt.Errorf("vector %s (%s): expected validation error %s", name, fork, result.Exception)
+			}
+		}
+	}
+	return nil
+}
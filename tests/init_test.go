@@ -43,6 +43,8 @@ var (
 	difficultyTestDir              = filepath.Join(baseDir, "BasicTests")
 	executionSpecBlockchainTestDir = filepath.Join(".", "spec-tests", "fixtures", "blockchain_tests")
 	executionSpecStateTestDir      = filepath.Join(".", "spec-tests", "fixtures", "state_tests")
+	executionSpecEOFTestDir        = filepath.Join(".", "spec-tests", "fixtures", "eof_tests")
+	eofVectorDir                   = filepath.Join(".", "eof-vectors")
 	benchmarksDir                  = filepath.Join(".", "evm-benchmarks", "benchmarks")
 )
 
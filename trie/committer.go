@@ -18,6 +18,7 @@ package trie
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/trie/trienode"
@@ -41,13 +42,14 @@ func newCommitter(nodeset *trienode.NodeSet, tracer *tracer, collectLeaf bool) *
 	}
 }
 
-// Commit collapses a node down into a hash node.
-func (c *committer) Commit(n node) hashNode {
-	return c.commit(nil, n).(hashNode)
+// Commit collapses a node down into a hash node. If parallel is set, the
+// subtrees of the topmost full node are committed concurrently.
+func (c *committer) Commit(n node, parallel bool) hashNode {
+	return c.commit(nil, n, parallel).(hashNode)
 }
 
 // commit collapses a node down into a hash node and returns it.
-func (c *committer) commit(path []byte, n node) node {
+func (c *committer) commit(path []byte, n node, parallel bool) node {
 	// if this path is clean, use available cached data
 	hash, dirty := n.cache()
 	if hash != nil && !dirty {
@@ -62,7 +64,7 @@ func (c *committer) commit(path []byte, n node) node {
 		// If the child is fullNode, recursively commit,
 		// otherwise it can only be hashNode or valueNode.
 		if _, ok := cn.Val.(*fullNode); ok {
-			collapsed.Val = c.commit(append(path, cn.Key...), cn.Val)
+			collapsed.Val = c.commit(append(path, cn.Key...), cn.Val, parallel)
 		}
 		// The key needs to be copied, since we're adding it to the
 		// modified nodeset.
@@ -73,7 +75,7 @@ func (c *committer) commit(path []byte, n node) node {
 		}
 		return collapsed
 	case *fullNode:
-		hashedKids := c.commitChildren(path, cn)
+		hashedKids := c.commitChildren(path, cn, parallel)
 		collapsed := cn.copy()
 		collapsed.Children = hashedKids
 
@@ -90,9 +92,16 @@ func (c *committer) commit(path []byte, n node) node {
 	}
 }
 
-// commitChildren commits the children of the given fullnode
-func (c *committer) commitChildren(path []byte, n *fullNode) [17]node {
-	var children [17]node
+// commitChildren commits the children of the given fullnode. If parallel is
+// set, each dirty child is committed in its own thread into a dedicated node
+// set, which is merged into the shared one afterwards.
+func (c *committer) commitChildren(path []byte, n *fullNode, parallel bool) [17]node {
+	var (
+		wg       sync.WaitGroup
+		lock     sync.Mutex
+		mergeErr error
+		children [17]node
+	)
 	for i := 0; i < 16; i++ {
 		child := n.Children[i]
 		if child == nil {
@@ -108,8 +117,35 @@ func (c *committer) commitChildren(path []byte, n *fullNode) [17]node {
 		// Commit the child recursively and store the "hashed" value.
 		// Note the returned node can be some embedded nodes, so it's
 		// possible the type is not hashNode.
-		children[i] = c.commit(append(path, byte(i)), child)
+		if !parallel {
+			children[i] = c.commit(append(path, byte(i)), child, false)
+			continue
+		}
+		wg.Add(1)
+		go func(index int, child node) {
+			defer wg.Done()
+
+			// Cap the path to force a reallocation on append, the backing
+			// array must not be shared across threads.
+			path := append(path[:len(path):len(path)], byte(index))
+			nodes := trienode.NewNodeSet(c.nodes.Owner)
+			children[index] = newCommitter(nodes, c.tracer, c.collectLeaf).commit(path, child, false)
+
+			lock.Lock()
+			if err := c.nodes.MergeSet(nodes); err != nil && mergeErr == nil {
+				mergeErr = err
+			}
+			lock.Unlock()
+		}(i, child)
+	}
+	wg.Wait()
+
+	// The node sets of the children share the owner of the parent set, so a
+	// failing merge can only be caused by a bug, like the invalid nodes above.
+	if mergeErr != nil {
+		panic(fmt.Sprintf("failed to merge committed children: %v", mergeErr))
 	}
+
 	// For the 17th child, it's possible the type is valuenode.
 	if n.Children[16] != nil {
 		children[16] = n.Children[16]
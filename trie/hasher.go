@@ -111,15 +111,26 @@ func (h *hasher) hashFullNodeChildren(n *fullNode) (collapsed *fullNode, cached
 	collapsed = n.copy()
 	if h.parallel {
 		var wg sync.WaitGroup
-		wg.Add(16)
 		for i := 0; i < 16; i++ {
+			child := n.Children[i]
+			if child == nil {
+				collapsed.Children[i] = nilValueNode
+				continue
+			}
+			// Subtrees which are already hashed don't need a thread of their
+			// own, only spin up workers for the dirty ones.
+			if hn, ok := child.(hashNode); ok {
+				collapsed.Children[i], cached.Children[i] = hn, hn
+				continue
+			}
+			if hash, _ := child.cache(); hash != nil {
+				collapsed.Children[i], cached.Children[i] = hash, child
+				continue
+			}
+			wg.Add(1)
 			go func(i int) {
 				hasher := newHasher(false)
-				if child := n.Children[i]; child != nil {
-					collapsed.Children[i], cached.Children[i] = hasher.hash(child, false)
-				} else {
-					collapsed.Children[i] = nilValueNode
-				}
+				collapsed.Children[i], cached.Children[i] = hasher.hash(n.Children[i], false)
 				returnHasherToPool(hasher)
 				wg.Done()
 			}(i)
@@ -774,6 +774,47 @@ func TestCommitAfterHash(t *testing.T) {
 	}
 }
 
+// Tests that committing the subtries concurrently produces exactly the same
+// node set and root as committing them in a single thread.
+func TestCommitParallel(t *testing.T) {
+	addresses, accounts := makeAccounts(1000)
+	newTrie := func() *Trie {
+		trie := NewEmpty(newTestDatabase(rawdb.NewMemoryDatabase(), rawdb.HashScheme))
+		for i := 0; i < len(addresses); i++ {
+			trie.MustUpdate(crypto.Keccak256(addresses[i][:]), accounts[i])
+		}
+		trie.Hash()
+		return trie
+	}
+	var (
+		seqTrie = newTrie()
+		parTrie = newTrie()
+		seqSet  = trienode.NewNodeSet(common.Hash{})
+		parSet  = trienode.NewNodeSet(common.Hash{})
+	)
+	seqRoot := newCommitter(seqSet, seqTrie.tracer, true).Commit(seqTrie.root, false)
+	parRoot := newCommitter(parSet, parTrie.tracer, true).Commit(parTrie.root, true)
+	if !bytes.Equal(seqRoot, parRoot) {
+		t.Fatalf("root mismatch: sequential %x, parallel %x", seqRoot, parRoot)
+	}
+	if len(seqSet.Nodes) != len(parSet.Nodes) {
+		t.Fatalf("node count mismatch: sequential %d, parallel %d", len(seqSet.Nodes), len(parSet.Nodes))
+	}
+	for path, n := range seqSet.Nodes {
+		if have := parSet.Nodes[path]; have == nil || have.Hash != n.Hash || !bytes.Equal(have.Blob, n.Blob) {
+			t.Fatalf("node mismatch at path %x", path)
+		}
+	}
+	if len(seqSet.Leaves) != len(parSet.Leaves) {
+		t.Fatalf("leaf count mismatch: sequential %d, parallel %d", len(seqSet.Leaves), len(parSet.Leaves))
+	}
+	seqUpdates, seqDeletes := seqSet.Size()
+	parUpdates, parDeletes := parSet.Size()
+	if seqUpdates != parUpdates || seqDeletes != parDeletes {
+		t.Fatalf("size mismatch: sequential %d/%d, parallel %d/%d", seqUpdates, seqDeletes, parUpdates, parDeletes)
+	}
+}
+
 func makeAccounts(size int) (addresses [][20]byte, accounts [][]byte) {
 	// Make the random benchmark deterministic
 	random := rand.New(rand.NewSource(0))
@@ -124,6 +124,16 @@ func (set *NodeSet) Merge(owner common.Hash, nodes map[string]*Node) error {
 	return nil
 }
 
+// MergeSet merges the nodes and leaves of another set belonging to the same
+// owner into this set.
+func (set *NodeSet) MergeSet(other *NodeSet) error {
+	if err := set.Merge(other.Owner, other.Nodes); err != nil {
+		return err
+	}
+	set.Leaves = append(set.Leaves, other.Leaves...)
+	return nil
+}
+
 // AddLeaf adds the provided leaf node into set. TODO(rjl493456442) how can
 // we get rid of it?
 func (set *NodeSet) AddLeaf(parent common.Hash, blob []byte) {